@@ -2,12 +2,19 @@
 """
 Python adapter glue for cyborg execution.
 This exposes a simple JSON RPC interface that can be called by the Go daemon.
+
+By default it answers one request read from stdin and exits. With
+--persistent it keeps running, answering newline-delimited JSON-RPC 2.0
+requests as they arrive, one response line each, in whatever order they
+finish; the Go adapter pool keeps a few of these processes alive.
 """
 
 import json
 import sys
 import subprocess
 import argparse
+import threading
+from concurrent.futures import ThreadPoolExecutor
 from typing import Dict, Any, Tuple
 
 class CyborgExecutor:
@@ -16,13 +23,14 @@ class CyborgExecutor:
     def __init__(self):
         pass
     
-    def run_script(self, script: str, args: list) -> Dict[str, Any]:
+    def run_script(self, script: str, args: list, input_text: str = None) -> Dict[str, Any]:
         """
         Run a script with given arguments.
         
         Args:
             script: Path to the script to execute
             args: List of arguments to pass to the script
+            input_text: Text written to the script's stdin, if any
             
         Returns:
             Dictionary with result containing stdout, stderr, and exit code
@@ -31,6 +39,7 @@ class CyborgExecutor:
             # Execute the script with timeout (3 seconds as per requirements)
             result = subprocess.run(
                 [script] + args,
+                input=input_text,
                 capture_output=True,
                 text=True,
                 timeout=3  # 3 second timeout
@@ -67,10 +76,17 @@ class CyborgExecutor:
         method = request.get("method")
         params = request.get("params", {})
         
-        if method == "run":
+        if method == "ping":
+            return {
+                "id": request.get("id", 0),
+                "jsonrpc": "2.0",
+                "result": "pong"
+            }
+        elif method == "run":
             result = self.run_script(
                 params.get("script", ""),
-                params.get("args", [])
+                params.get("args", []),
+                params.get("input")
             )
             
             return {
@@ -88,10 +104,78 @@ class CyborgExecutor:
                 }
             }
 
+def serve(executor: CyborgExecutor, workers: int):
+    """
+    Answer newline-delimited requests from stdin until it closes.
+    
+    Requests run concurrently, so a slow script does not hold up the rest;
+    the id on each response tells the caller which request it answers.
+    """
+    write_lock = threading.Lock()
+    
+    def respond(response: Dict[str, Any]):
+        line = json.dumps(response)
+        with write_lock:
+            sys.stdout.write(line + "\n")
+            sys.stdout.flush()
+    
+    def handle(request: Dict[str, Any]):
+        try:
+            respond(executor.handle_request(request))
+        except Exception as e:
+            respond({
+                "id": request.get("id"),
+                "jsonrpc": "2.0",
+                "error": {
+                    "code": -32603,
+                    "message": str(e)
+                }
+            })
+    
+    with ThreadPoolExecutor(max_workers=workers) as pool:
+        for line in sys.stdin:
+            line = line.strip()
+            if not line:
+                continue
+            try:
+                request = json.loads(line)
+            except json.JSONDecodeError:
+                respond({
+                    "id": None,
+                    "jsonrpc": "2.0",
+                    "error": {
+                        "code": -32700,
+                        "message": "Parse error"
+                    }
+                })
+                continue
+            if not isinstance(request, dict):
+                respond({
+                    "id": None,
+                    "jsonrpc": "2.0",
+                    "error": {
+                        "code": -32600,
+                        "message": "Invalid Request"
+                    }
+                })
+                continue
+            pool.submit(handle, request)
+
 def main():
     """Main entry point for the Python adapter."""
+    parser = argparse.ArgumentParser(description=__doc__.strip().splitlines()[0])
+    parser.add_argument("--persistent", action="store_true",
+                        help="keep answering newline-delimited requests until stdin closes")
+    parser.add_argument("--workers", type=int, default=4,
+                        help="requests run at once in persistent mode")
+    options = parser.parse_args()
+    
     executor = CyborgExecutor()
     
+    if options.persistent:
+        serve(executor, options.workers)
+        return
+    
     # Read request from stdin
     input_data = sys.stdin.read().strip()
     
@@ -0,0 +1,233 @@
+// Command cyborgctl is the operator CLI for a running conductor. It talks to
+// the conductor's HTTP API.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `usage: cyborgctl [-server URL] <command> [arguments]
+
+Commands:
+  deadletter list [-namespace NS] [-reason R] [-before RFC3339]
+  deadletter get ID
+  deadletter edit ID -f JOB.json
+  deadletter requeue ID
+  deadletter delete ID
+  deadletter purge [-namespace NS] [-reason R] [-before RFC3339] [-all]
+`
+
+// client calls the conductor HTTP API
+type client struct {
+	server string
+	http   *http.Client
+}
+
+func main() {
+	server := flag.String("server", envOr("CYBORGCTL_SERVER", "http://localhost:8080"), "conductor HTTP address")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	c := &client{server: *server, http: &http.Client{Timeout: 30 * time.Second}}
+	if err := run(c, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "cyborgctl:", err)
+		os.Exit(1)
+	}
+}
+
+// run dispatches a command line to its handler
+func run(c *client, args []string) error {
+	if len(args) < 2 || args[0] != "deadletter" {
+		flag.Usage()
+		return fmt.Errorf("unknown command")
+	}
+	return runDeadLetter(c, args[1], args[2:])
+}
+
+// runDeadLetter handles the deadletter subcommands
+func runDeadLetter(c *client, cmd string, args []string) error {
+	switch cmd {
+	case "list":
+		query, _, err := parseFilter("list", args)
+		if err != nil {
+			return err
+		}
+		var resp struct {
+			DeadLetters []deadLetter `json:"dead_letters"`
+		}
+		if err := c.do(http.MethodGet, "/api/v1/deadletters?"+query.Encode(), nil, &resp); err != nil {
+			return err
+		}
+		printDeadLetters(resp.DeadLetters)
+		return nil
+
+	case "get":
+		id, err := oneID(cmd, args)
+		if err != nil {
+			return err
+		}
+		return c.print(http.MethodGet, "/api/v1/deadletters/"+url.PathEscape(id), nil)
+
+	case "edit":
+		fs := flag.NewFlagSet("edit", flag.ContinueOnError)
+		file := fs.String("f", "", "file holding the corrected job as JSON")
+		if len(args) < 1 {
+			return fmt.Errorf("edit needs a job ID")
+		}
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *file == "" {
+			return fmt.Errorf("edit needs -f with the corrected job")
+		}
+		body, err := os.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		return c.print(http.MethodPut, "/api/v1/deadletters/"+url.PathEscape(args[0]), body)
+
+	case "requeue":
+		id, err := oneID(cmd, args)
+		if err != nil {
+			return err
+		}
+		return c.print(http.MethodPost, "/api/v1/deadletters/"+url.PathEscape(id)+"/requeue", nil)
+
+	case "delete":
+		id, err := oneID(cmd, args)
+		if err != nil {
+			return err
+		}
+		if err := c.do(http.MethodDelete, "/api/v1/deadletters/"+url.PathEscape(id), nil, nil); err != nil {
+			return err
+		}
+		fmt.Println("deleted", id)
+		return nil
+
+	case "purge":
+		query, all, err := parseFilter("purge", args)
+		if err != nil {
+			return err
+		}
+		if all {
+			query.Set("all", "true")
+		}
+		return c.print(http.MethodDelete, "/api/v1/deadletters?"+query.Encode(), nil)
+	}
+	return fmt.Errorf("unknown deadletter command %q", cmd)
+}
+
+// deadLetter is the part of a dead-letter entry shown by list
+type deadLetter struct {
+	ID        string     `json:"id"`
+	Namespace string     `json:"namespace"`
+	Reason    string     `json:"reason"`
+	Attempts  []struct{} `json:"attempts"`
+	Error     string     `json:"error"`
+	DeadAt    time.Time  `json:"dead_at"`
+}
+
+// printDeadLetters writes entries as an aligned table
+func printDeadLetters(entries []deadLetter) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAMESPACE\tREASON\tATTEMPTS\tDEAD AT\tERROR")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
+			e.ID, e.Namespace, e.Reason, len(e.Attempts), e.DeadAt.Format(time.RFC3339), e.Error)
+	}
+	tw.Flush()
+}
+
+// parseFilter reads the dead-letter filter flags into query parameters
+func parseFilter(name string, args []string) (url.Values, bool, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	namespace := fs.String("namespace", "", "only entries in this namespace")
+	reason := fs.String("reason", "", "only entries with this reason")
+	before := fs.String("before", "", "only entries dead-lettered before this RFC 3339 time")
+	all := fs.Bool("all", false, "match every entry")
+	if err := fs.Parse(args); err != nil {
+		return nil, false, err
+	}
+
+	query := url.Values{}
+	for key, value := range map[string]string{"namespace": *namespace, "reason": *reason, "before": *before} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	return query, *all, nil
+}
+
+// oneID returns the single job ID argument of a command
+func oneID(cmd string, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("%s needs exactly one job ID", cmd)
+	}
+	return args[0], nil
+}
+
+// print calls the API and pretty-prints the JSON response
+func (c *client) print(method, path string, body []byte) error {
+	var resp json.RawMessage
+	if err := c.do(method, path, body, &resp); err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, resp, "", "  "); err != nil {
+		return err
+	}
+	fmt.Println(out.String())
+	return nil
+}
+
+// do calls the API and decodes a JSON response into v when v is not nil
+func (c *client) do(method, path string, body []byte, v interface{}) error {
+	req, err := http.NewRequest(method, c.server+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("%s (HTTP %d)", apiErr.Error, resp.StatusCode)
+		}
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if v == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// envOr returns the environment variable key, or fallback when it is unset
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
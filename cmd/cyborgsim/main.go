@@ -0,0 +1,116 @@
+// Command cyborgsim replays a job trace against a registry snapshot on a
+// virtual clock and prints the scheduling report as JSON.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/policy"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/simulator"
+)
+
+const usage = `usage: cyborgsim -registry CYBORGS.json -trace TRACE.jsonl [-queue-capacity N] [-policy NAME] [-load-aware | -compare]
+
+The registry is a JSON array of {"id", "capabilities", "slots", "speed"}.
+The trace is a JSON array or one JSON object per line of
+{"id", "capabilities", "arrival_ms", "duration_ms", "sla_ms"}.
+The policy is first-fit, least-loaded, weighted-score, round-robin or
+cost-optimized.
+`
+
+func main() {
+	registryPath := flag.String("registry", "", "registry snapshot file")
+	tracePath := flag.String("trace", "", "job trace file")
+	queueCapacity := flag.Int("queue-capacity", 0, "tasks that may wait for a slot (default 100)")
+	loadAware := flag.Bool("load-aware", false, "place tasks when a capable cyborg has a free slot")
+	compare := flag.Bool("compare", false, "report bind-on-arrival and load-aware placement side by side")
+	policyName := flag.String("policy", policy.FirstFit, "scheduling policy that picks among capable cyborgs")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if err := run(*registryPath, *tracePath, *queueCapacity, *policyName, *loadAware, *compare); err != nil {
+		fmt.Fprintln(os.Stderr, "cyborgsim:", err)
+		os.Exit(1)
+	}
+}
+
+// run loads the inputs, simulates and prints the report
+func run(registryPath, tracePath string, queueCapacity int, policyName string, loadAware, compare bool) error {
+	if registryPath == "" || tracePath == "" {
+		flag.Usage()
+		return fmt.Errorf("-registry and -trace are required")
+	}
+	if _, err := policy.New(policyName, policy.DefaultWeights); err != nil {
+		return err
+	}
+	newPolicy := func() policy.SchedulingPolicy {
+		p, _ := policy.New(policyName, policy.DefaultWeights)
+		return p
+	}
+
+	var snapshot []simulator.Cyborg
+	data, err := os.ReadFile(registryPath)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("invalid registry %s: %w", registryPath, err)
+	}
+	trace, err := readTrace(tracePath)
+	if err != nil {
+		return err
+	}
+
+	// Each run gets a fresh policy, so a round-robin rotation does not
+	// carry over between them
+	cfg := simulator.Config{QueueCapacity: queueCapacity, LoadAware: loadAware, Policy: newPolicy()}
+	var out interface{} = simulator.Run(snapshot, trace, cfg)
+	if compare {
+		cfg.LoadAware, cfg.Policy = false, newPolicy()
+		bound := simulator.Run(snapshot, trace, cfg)
+		cfg.LoadAware, cfg.Policy = true, newPolicy()
+		out = map[string]*simulator.Report{
+			"bind_on_arrival": bound,
+			"load_aware":      simulator.Run(snapshot, trace, cfg),
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// readTrace reads a trace given as a JSON array or as JSON lines
+func readTrace(path string) ([]simulator.Task, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var trace []simulator.Task
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &trace); err != nil {
+			return nil, fmt.Errorf("invalid trace %s: %w", path, err)
+		}
+		return trace, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var task simulator.Task
+		if err := json.Unmarshal(scanner.Bytes(), &task); err != nil {
+			return nil, fmt.Errorf("invalid trace %s line %d: %w", path, line, err)
+		}
+		trace = append(trace, task)
+	}
+	return trace, scanner.Err()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/conductor"
+)
+
+// setSecurityHeaders adds the headers every API response carries
+func setSecurityHeaders(w http.ResponseWriter) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("X-XSS-Protection", "1; mode=block")
+}
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	setSecurityHeaders(w)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Error("Failed to encode API response", zap.Error(err))
+	}
+}
+
+// writeError writes a JSON error body with the given status code
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// readJSON decodes a JSON request body into v, rejecting unknown fields
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// writeQueueFull refuses a submission with 429 and a Retry-After estimate,
+// along with the queue state so clients can throttle themselves
+func writeQueueFull(w http.ResponseWriter, err *conductor.JobQueueFullError) {
+	retryAfter := int(math.Ceil(err.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	writeJSON(w, http.StatusTooManyRequests, map[string]interface{}{
+		"error":               err.Error(),
+		"depth":               err.Depth,
+		"capacity":            err.Capacity,
+		"shed":                err.Shed,
+		"retry_after_seconds": retryAfter,
+	})
+}
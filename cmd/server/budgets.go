@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/budget"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/conductor"
+)
+
+// initBudgets creates the budget tracker from BUDGETS, accounting usage in
+// the store matching the queue backend; nil when no budgets are set
+func initBudgets() (*budget.Tracker, error) {
+	budgets, err := budget.ParseBudgets(cfg.Budget.Limits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BUDGETS: %w", err)
+	}
+	if len(budgets) == 0 {
+		return nil, nil
+	}
+
+	var store budget.Store = budget.NewMemoryStore()
+	if cfg.Queue.Backend != "memory" {
+		pgStore := budget.NewPostgresStore(db)
+		if err := pgStore.EnsureSchema(context.Background()); err != nil {
+			return nil, err
+		}
+		store = pgStore
+	}
+
+	tracker := budget.NewTracker(store, budgets)
+	tracker.OnAlert(func(s budget.Status) {
+		logger.Warn("Budget limit reached",
+			zap.String("scope", string(s.Scope)),
+			zap.String("key", s.Key),
+			zap.String("window", string(s.Window)),
+			zap.String("level", s.Level),
+			zap.String("action", string(s.Action)),
+			zap.Int64("tokens", s.Used.Tokens),
+			zap.Float64("cost", s.Used.Cost))
+	})
+	logger.Info("LLM budgets enabled", zap.Int("budgets", len(budgets)))
+	return tracker, nil
+}
+
+// registerBudgetRoutes adds the budget status endpoint
+func registerBudgetRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/budgets", listBudgets)
+}
+
+// listBudgets returns where each budgeted namespace and cyborg stands in
+// the current window, optionally filtered by scope and key
+func listBudgets(w http.ResponseWriter, r *http.Request) {
+	statuses, err := jobConductor.Budgets().Statuses(r.Context())
+	if err != nil {
+		logger.Error("Failed to read budgets", zap.Error(err))
+		writeError(w, http.StatusServiceUnavailable, "budget store unavailable")
+		return
+	}
+
+	scope, key := r.URL.Query().Get("scope"), r.URL.Query().Get("key")
+	filtered := statuses[:0]
+	for _, s := range statuses {
+		if (scope == "" || string(s.Scope) == scope) && (key == "" || s.Key == key) {
+			filtered = append(filtered, s)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"budgets": filtered})
+}
+
+// writeBudgetExceeded refuses a submission with 429 and a Retry-After of
+// when the exhausted budget resets
+func writeBudgetExceeded(w http.ResponseWriter, err *conductor.BudgetExceededError) {
+	retryAfter := int(math.Ceil(time.Until(err.Status.ResetAt).Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	writeJSON(w, http.StatusTooManyRequests, map[string]interface{}{
+		"error":  err.Error(),
+		"budget": err.Status,
+	})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/cluster"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/queue"
+)
+
+// clusterReplica is this instance's cluster membership; nil when running
+// alone, in which case it leads every singleton duty
+var clusterReplica *cluster.Replica
+
+// initCluster joins the replicas sharing the database, so the queue is
+// split between them and the jobs of dead replicas are taken over
+func initCluster(jobQueue queue.Queue) error {
+	if !cfg.Cluster.Enabled {
+		return nil
+	}
+	pgQueue, ok := jobQueue.(*queue.PostgresQueue)
+	if !ok {
+		return fmt.Errorf("cluster mode requires the postgres queue")
+	}
+
+	store := cluster.NewPostgresStore(db)
+	if err := store.EnsureSchema(context.Background()); err != nil {
+		return err
+	}
+
+	clusterReplica = cluster.NewReplica(store, cluster.Config{
+		ID:                cfg.InstanceID,
+		HeartbeatInterval: time.Duration(cfg.Cluster.HeartbeatInterval) * time.Second,
+		MemberTTL:         time.Duration(cfg.Cluster.MemberTTL) * time.Second,
+		LeaseTTL:          time.Duration(cfg.Cluster.LeaseTTL) * time.Second,
+		Partitions:        queue.Partitions,
+		OnPartitions: func(partitions []int) {
+			pgQueue.SetPartitions(partitions)
+			logger.Info("Queue partitions rebalanced", zap.Int("owned", len(partitions)))
+		},
+		OnOrphaned: func(ctx context.Context, replicaID string) (int, error) {
+			n, err := pgQueue.RecoverOwner(ctx, replicaID)
+			if err == nil {
+				logger.Warn("Took over jobs of dead replica",
+					zap.String("replica_id", replicaID), zap.Int("jobs", n))
+			}
+			return n, err
+		},
+	})
+	if err := clusterReplica.Start(context.Background()); err != nil {
+		return err
+	}
+	logger.Info("Joined conductor cluster", zap.String("instance_id", cfg.InstanceID))
+	return nil
+}
+
+// registerClusterRoutes adds the cluster status endpoint
+func registerClusterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/cluster", getClusterStatus)
+}
+
+// getClusterStatus returns this replica's view of the members, its queue
+// partitions and the duties it leads
+func getClusterStatus(w http.ResponseWriter, r *http.Request) {
+	if clusterReplica == nil {
+		writeError(w, http.StatusNotFound, "cluster mode is not enabled")
+		return
+	}
+	writeJSON(w, http.StatusOK, clusterReplica.Status())
+}
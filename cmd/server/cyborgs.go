@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/breaker"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/metrics"
+)
+
+// initBreakers creates the circuit breakers shared by the schedulers.
+// Transitions are exported as metrics and reflected in registry status.
+// It returns nil when breakers are disabled, which lets all work through.
+func initBreakers() *breaker.Set {
+	if !cfg.Breaker.Enabled {
+		return nil
+	}
+
+	breakerCfg := breaker.Config{
+		WindowSize:     cfg.Breaker.WindowSize,
+		MinRequests:    cfg.Breaker.MinRequests,
+		FailureRate:    cfg.Breaker.FailureRate,
+		OpenTimeout:    time.Duration(cfg.Breaker.OpenTimeout) * time.Second,
+		HalfOpenProbes: cfg.Breaker.HalfOpenProbes,
+	}
+	return breaker.NewSet(breakerCfg, cfg.Breaker.PerCapability, func(t breaker.Transition) {
+		metrics.BreakerState.WithLabelValues(t.CyborgID, t.Capability).Set(float64(t.To))
+		metrics.BreakerTransitions.WithLabelValues(t.CyborgID, t.Capability, t.To.String()).Inc()
+		registry.SetBreakerState(t.CyborgID, t.Capability, t.To.String())
+
+		logger.Warn("Circuit breaker changed state",
+			zap.String("cyborg_id", t.CyborgID),
+			zap.String("capability", t.Capability),
+			zap.String("from", t.From.String()),
+			zap.String("to", t.To.String()))
+	})
+}
+
+// registerCyborgRoutes adds read-only endpoints for registered cyborgs
+func registerCyborgRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/cyborgs/{id}/status", getCyborgStatus)
+}
+
+func getCyborgStatus(w http.ResponseWriter, r *http.Request) {
+	status, exists := registry.Status(r.PathValue("id"))
+	if !exists {
+		writeError(w, http.StatusNotFound, "cyborg not registered")
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
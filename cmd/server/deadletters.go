@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/conductor"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/deadletter"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/queue"
+)
+
+// initDeadLetters creates the dead-letter store matching the queue backend
+func initDeadLetters() (deadletter.Store, error) {
+	if cfg.Queue.Backend == "memory" {
+		return deadletter.NewMemoryStore(), nil
+	}
+
+	store := deadletter.NewPostgresStore(db)
+	if err := store.EnsureSchema(context.Background()); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// registerDeadLetterRoutes adds endpoints to inspect, edit, requeue and
+// purge dead-lettered jobs
+func registerDeadLetterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/deadletters", listDeadLetters)
+	mux.HandleFunc("DELETE /api/v1/deadletters", purgeDeadLetters)
+	mux.HandleFunc("GET /api/v1/deadletters/{id}", getDeadLetter)
+	mux.HandleFunc("PUT /api/v1/deadletters/{id}", editDeadLetter)
+	mux.HandleFunc("DELETE /api/v1/deadletters/{id}", deleteDeadLetter)
+	mux.HandleFunc("POST /api/v1/deadletters/{id}/requeue", requeueDeadLetter)
+}
+
+func listDeadLetters(w http.ResponseWriter, r *http.Request) {
+	filter, err := deadLetterFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, err := jobConductor.DeadLetters().List(r.Context(), filter)
+	if err != nil {
+		writeDeadLetterError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"dead_letters": entries})
+}
+
+func getDeadLetter(w http.ResponseWriter, r *http.Request) {
+	entry, err := jobConductor.DeadLetters().Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeDeadLetterError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entry)
+}
+
+func editDeadLetter(w http.ResponseWriter, r *http.Request) {
+	var job conductor.Job
+	if err := readJSON(w, r, &job); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid job: %v", err))
+		return
+	}
+
+	entry, err := jobConductor.EditDeadLetter(r.Context(), r.PathValue("id"), &job)
+	if err != nil {
+		writeDeadLetterError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entry)
+}
+
+func requeueDeadLetter(w http.ResponseWriter, r *http.Request) {
+	job, err := jobConductor.RequeueDeadLetter(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeDeadLetterError(w, err)
+		return
+	}
+	logger.Info("Requeued dead-lettered job", zap.String("job_id", job.ID))
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+func deleteDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if err := jobConductor.DeadLetters().Delete(r.Context(), r.PathValue("id")); err != nil {
+		writeDeadLetterError(w, err)
+		return
+	}
+	setSecurityHeaders(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func purgeDeadLetters(w http.ResponseWriter, r *http.Request) {
+	filter, err := deadLetterFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Guard against wiping the whole store with a bare DELETE
+	if filter == (deadletter.Filter{}) && r.URL.Query().Get("all") != "true" {
+		writeError(w, http.StatusBadRequest, "purge needs a namespace, reason or before filter, or all=true")
+		return
+	}
+
+	purged, err := jobConductor.DeadLetters().Purge(r.Context(), filter)
+	if err != nil {
+		writeDeadLetterError(w, err)
+		return
+	}
+	logger.Info("Purged dead-lettered jobs", zap.Int("count", purged))
+	writeJSON(w, http.StatusOK, map[string]int{"purged": purged})
+}
+
+// deadLetterFilter reads the namespace, reason and before query parameters
+func deadLetterFilter(r *http.Request) (deadletter.Filter, error) {
+	query := r.URL.Query()
+	filter := deadletter.Filter{
+		Namespace: query.Get("namespace"),
+		Reason:    query.Get("reason"),
+	}
+	if before := query.Get("before"); before != "" {
+		t, err := time.Parse(time.RFC3339, before)
+		if err != nil {
+			return filter, fmt.Errorf("before must be an RFC 3339 time: %v", err)
+		}
+		filter.Before = t
+	}
+	return filter, nil
+}
+
+// writeDeadLetterError maps dead-letter and requeue errors onto HTTP status
+// codes
+func writeDeadLetterError(w http.ResponseWriter, err error) {
+	var queueFull *conductor.JobQueueFullError
+	switch {
+	case errors.Is(err, deadletter.ErrNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, conductor.ErrInvalidJob):
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, queue.ErrDuplicate):
+		writeError(w, http.StatusConflict, "job is already queued")
+	case errors.As(err, &queueFull):
+		writeQueueFull(w, queueFull)
+	default:
+		logger.Error("Dead-letter store failure", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "dead-letter store unavailable")
+	}
+}
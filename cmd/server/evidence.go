@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/evidence"
+)
+
+// initEvidence creates the evidence log in the store matching the queue
+// backend
+func initEvidence() (evidence.Store, error) {
+	if cfg.Queue.Backend == "memory" {
+		return evidence.NewMemoryStore(), nil
+	}
+	store := evidence.NewPostgresStore(db)
+	if err := store.EnsureSchema(context.Background()); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// registerEvidenceRoutes adds the evidence log endpoint
+func registerEvidenceRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/jobs/{id}/evidence", getEvidence)
+}
+
+// getEvidence returns a job's evidence log and whether its hash chain
+// verifies
+func getEvidence(w http.ResponseWriter, r *http.Request) {
+	entries, err := jobConductor.Evidence().List(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"entries":  entries,
+		"verified": evidence.Verify(entries) == nil,
+	})
+}
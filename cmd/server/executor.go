@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/toxicoder/cyborg-conductor-core/internal/runner"
+	"github.com/toxicoder/cyborg-conductor-core/internal/runner/adapter"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/executor"
+)
+
+// adapterPool runs the adapter backend's processes; nil unless the adapter
+// is enabled
+var adapterPool *adapter.Pool
+
+// initExecutor creates the router the conductor runs jobs through, with a
+// subprocess backend under the configured execution policies, the adapter
+// backend when it is enabled and the remote backend when workers are. It
+// returns nil when no backend is configured, leaving the conductor to
+// simulate jobs.
+func initExecutor() (*executor.Router, error) {
+	jobTypes, err := executor.ParseJobTypes(cfg.Executor.JobTypes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EXECUTOR_JOB_TYPES: %w", err)
+	}
+	var policies *runner.PolicySet
+	if cfg.Executor.Policies != "" {
+		if policies, err = runner.LoadPolicies(cfg.Executor.Policies); err != nil {
+			return nil, fmt.Errorf("invalid EXECUTOR_POLICIES: %w", err)
+		}
+	}
+	if cfg.Executor.Default == "" && len(jobTypes) == 0 && policies == nil && !cfg.Adapter.Enabled && workerBroker == nil {
+		return nil, nil
+	}
+
+	fallback := cfg.Executor.Default
+	if fallback == "" {
+		fallback = executor.BackendSubprocess
+		if workerBroker != nil {
+			fallback = executor.BackendRemote
+		}
+	}
+	router := executor.NewRouter(fallback)
+	handled := map[string]bool{executor.BackendSubprocess: true}
+	router.Handle(executor.BackendSubprocess, executor.NewSubprocess(runner.NewSubprocessRunner(cfg), policies))
+	if cfg.Adapter.Enabled {
+		adapterPool = adapter.NewPool(adapter.Config{
+			Name:        filepath.Base(cfg.Adapter.Command),
+			Command:     cfg.Adapter.Command,
+			Args:        strings.Fields(cfg.Adapter.Args),
+			Stderr:      os.Stderr,
+			Size:        cfg.Adapter.PoolSize,
+			MaxRequests: cfg.Adapter.MaxRequests,
+		})
+		router.Handle(executor.BackendAdapter, executor.NewAdapter(adapterPool))
+		handled[executor.BackendAdapter] = true
+	}
+	if workerBroker != nil {
+		router.Handle(executor.BackendRemote, workerBroker.Executor())
+		handled[executor.BackendRemote] = true
+	}
+
+	if !handled[fallback] {
+		return nil, fmt.Errorf("invalid EXECUTOR_DEFAULT: %w %q", executor.ErrUnknownBackend, fallback)
+	}
+	for jobType, backend := range jobTypes {
+		if !handled[backend] {
+			return nil, fmt.Errorf("invalid EXECUTOR_JOB_TYPES: %w %q for %s", executor.ErrUnknownBackend, backend, jobType)
+		}
+		router.RouteJobType(jobType, backend)
+	}
+	logger.Info("Executor backends configured",
+		zap.String("default", fallback),
+		zap.Bool("adapter", cfg.Adapter.Enabled),
+		zap.Bool("remote", workerBroker != nil),
+		zap.Int("job_types", len(jobTypes)),
+		zap.Bool("policies", policies != nil))
+	return router, nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/config"
+)
+
+// TestInitExecutorPolicies tests that execution policies are loaded at
+// startup and that a bad policy file stops it
+func TestInitExecutorPolicies(t *testing.T) {
+	cfg = &config.Config{}
+	logger = zap.NewNop()
+	workerBroker = nil
+
+	path := filepath.Join(t.TempDir(), "policies.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"cyborgs": {"SWEN1001": {"executables": [{"name": "git"}]}}}`), 0o644))
+	cfg.Executor.Policies = path
+	router, err := initExecutor()
+	require.NoError(t, err)
+	assert.NotNil(t, router)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"cyborgs": {"SWEN1001": {"executables": [{"name": "git", "args": "("}]}}}`), 0o644))
+	_, err = initExecutor()
+	assert.ErrorContains(t, err, "EXECUTOR_POLICIES")
+
+	cfg.Executor.Policies = filepath.Join(t.TempDir(), "missing.json")
+	_, err = initExecutor()
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/conductor"
+)
+
+// fanOutRetention is how long a finished fan-out can still be fetched
+const fanOutRetention = time.Hour
+
+// fanOutRecord is a fan-out started through the API
+type fanOutRecord struct {
+	ID         string                  `json:"id"`
+	Status     string                  `json:"status"`
+	Error      string                  `json:"error,omitempty"`
+	Result     *conductor.FanOutResult `json:"result,omitempty"`
+	StartedAt  time.Time               `json:"started_at"`
+	FinishedAt *time.Time              `json:"finished_at,omitempty"`
+}
+
+// fanOuts keeps the fan-outs of this instance by ID
+var fanOuts = struct {
+	sync.Mutex
+	records map[string]*fanOutRecord
+}{records: make(map[string]*fanOutRecord)}
+
+// registerFanOutRoutes adds endpoints to start and inspect fan-out jobs
+func registerFanOutRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /api/v1/fanouts", startFanOut)
+	mux.HandleFunc("GET /api/v1/fanouts/{id}", getFanOut)
+}
+
+// startFanOut runs a fan-out in the background and returns its ID
+func startFanOut(w http.ResponseWriter, r *http.Request) {
+	var f conductor.FanOut
+	if err := readJSON(w, r, &f); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid fan-out: %v", err))
+		return
+	}
+	if f.ID == "" {
+		f.ID = "fan-" + newJobID()
+	}
+	if f.Job.Namespace == "" {
+		f.Job.Namespace = cfg.Cyborg.DefaultNamespace
+	}
+	targets, err := jobConductor.FanOutTargets(&f)
+	if err != nil {
+		writeFanOutError(w, err)
+		return
+	}
+
+	record := &fanOutRecord{ID: f.ID, Status: "running", StartedAt: time.Now()}
+	fanOuts.Lock()
+	for id, old := range fanOuts.records {
+		if old.FinishedAt != nil && time.Since(*old.FinishedAt) > fanOutRetention {
+			delete(fanOuts.records, id)
+		}
+	}
+	if _, exists := fanOuts.records[f.ID]; exists {
+		fanOuts.Unlock()
+		writeError(w, http.StatusConflict, "a fan-out with this ID already exists")
+		return
+	}
+	fanOuts.records[f.ID] = record
+	fanOuts.Unlock()
+
+	go func() {
+		result, err := jobConductor.RunFanOut(context.Background(), &f)
+		now := time.Now()
+
+		fanOuts.Lock()
+		defer fanOuts.Unlock()
+		record.FinishedAt = &now
+		if err != nil {
+			record.Status, record.Error = conductor.FanOutFailed, err.Error()
+			logger.Warn("Fan-out failed", zap.String("fanout_id", f.ID), zap.Error(err))
+			return
+		}
+		record.Status, record.Result = result.Status, result
+		logger.Info("Fan-out finished",
+			zap.String("fanout_id", f.ID),
+			zap.String("status", result.Status),
+			zap.Int("succeeded", result.Succeeded),
+			zap.Int("failed", result.Failed))
+	}()
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"fanout_id": f.ID,
+		"status":    "running",
+		"cyborgs":   targets,
+	})
+}
+
+// getFanOut returns a fan-out's status and, once finished, its results
+func getFanOut(w http.ResponseWriter, r *http.Request) {
+	fanOuts.Lock()
+	defer fanOuts.Unlock()
+
+	record, exists := fanOuts.records[r.PathValue("id")]
+	if !exists {
+		writeError(w, http.StatusNotFound, "fan-out not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, record)
+}
+
+// writeFanOutError maps fan-out validation errors onto HTTP status codes
+func writeFanOutError(w http.ResponseWriter, err error) {
+	var noCyborg *conductor.NoSuitableCyborgError
+	switch {
+	case errors.Is(err, conductor.ErrInvalidJob):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.As(err, &noCyborg):
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+	default:
+		logger.Error("Failed to start fan-out", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to start fan-out")
+	}
+}
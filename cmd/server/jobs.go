@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/cluster"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/conductor"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/idempotency"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/queue"
+)
+
+// initIdempotency creates the idempotency store matching the queue backend
+// and starts purging expired keys
+func initIdempotency() (idempotency.Store, error) {
+	var store idempotency.Store = idempotency.NewMemoryStore()
+	if cfg.Queue.Backend != "memory" {
+		pgStore := idempotency.NewPostgresStore(db)
+		if err := pgStore.EnsureSchema(context.Background()); err != nil {
+			return nil, err
+		}
+		store = pgStore
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			if !clusterReplica.IsLeader(cluster.DutyReaper) {
+				// Another replica purges the shared table
+				continue
+			}
+			if n, err := store.Purge(context.Background(), time.Now()); err != nil {
+				logger.Warn("Failed to purge expired idempotency keys", zap.Error(err))
+			} else if n > 0 {
+				logger.Info("Purged expired idempotency keys", zap.Int("count", n))
+			}
+		}
+	}()
+	return store, nil
+}
+
+// registerJobRoutes adds the job submission and queue status endpoints
+func registerJobRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /api/v1/jobs", submitJob)
+	mux.HandleFunc("GET /api/v1/queue", getQueueStats)
+}
+
+// getQueueStats returns the queue depth, capacity, drain rate and
+// load-shedding rules so clients can throttle themselves
+func getQueueStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := jobConductor.QueueStats(r.Context())
+	if err != nil {
+		logger.Error("Failed to read queue stats", zap.Error(err))
+		writeError(w, http.StatusServiceUnavailable, "queue unavailable")
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// submitJob queues a job. A request that repeats an idempotency key, given
+// in the Idempotency-Key header or the job body, gets the original job back.
+func submitJob(w http.ResponseWriter, r *http.Request) {
+	var job conductor.Job
+	if err := readJSON(w, r, &job); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid job: %v", err))
+		return
+	}
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		job.IdempotencyKey = key
+	}
+	if job.ID == "" {
+		job.ID = newJobID()
+	}
+	if job.Namespace == "" {
+		job.Namespace = cfg.Cyborg.DefaultNamespace
+	}
+	// Results, history and spend are produced by the conductor, not the
+	// client
+	job.Attempts, job.Result = nil, nil
+	job.Usage, job.Escalations, job.Preemptions, job.OutputExcerpts = nil, nil, 0, nil
+
+	err := jobConductor.SubmitJob(&job)
+	var duplicate *conductor.DuplicateJobError
+	var queueFull *conductor.JobQueueFullError
+	var overBudget *conductor.BudgetExceededError
+	switch {
+	case errors.As(err, &duplicate):
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"job_id":    duplicate.Record.JobID,
+			"status":    duplicate.Record.Status,
+			"result":    duplicate.Record.Result,
+			"error":     duplicate.Record.Error,
+			"duplicate": true,
+		})
+	case errors.Is(err, conductor.ErrInvalidJob):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.As(err, &queueFull):
+		writeQueueFull(w, queueFull)
+	case errors.As(err, &overBudget):
+		writeBudgetExceeded(w, overBudget)
+	case errors.Is(err, queue.ErrDuplicate):
+		writeError(w, http.StatusConflict, "a job with this ID is already queued")
+	case err != nil:
+		logger.Error("Failed to submit job", zap.String("job_id", job.ID), zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to submit job")
+	default:
+		writeJSON(w, http.StatusAccepted, map[string]string{
+			"job_id": job.ID,
+			"status": idempotency.StatusPending,
+		})
+	}
+}
+
+// newJobID returns a random job ID for submissions that do not name one
+func newJobID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic("failed to generate job ID: " + err.Error())
+	}
+	return "job-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/config"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/budget"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/conductor"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/pb"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/types"
+)
+
+// TestSubmitJobIgnoresForgedUsage tests that a submitted job cannot bring
+// its own usage, escalations or preemptions, so it cannot charge or refund
+// a budget
+func TestSubmitJobIgnoresForgedUsage(t *testing.T) {
+	cfg = &config.Config{}
+	cfg.Cyborg.DefaultNamespace = "default"
+	logger = zap.NewNop()
+
+	budgets, err := budget.ParseBudgets("namespace.finance.daily=1000")
+	require.NoError(t, err)
+	tracker := budget.NewTracker(budget.NewMemoryStore(), budgets)
+	testRegistry := pb.NewRegistry()
+	require.NoError(t, testRegistry.Register(&types.CyborgDescriptor{
+		CyborgID:     "FINC0001",
+		Capabilities: []types.CapabilitySpec{{Name: "reporting"}},
+	}))
+
+	received := make(chan conductor.Job, 1)
+	handler := func(ctx context.Context, job *conductor.Job) error {
+		received <- *job
+		return nil
+	}
+	jobConductor = conductor.NewConductor(testRegistry, conductor.WithJobHandler(handler),
+		conductor.WithBudgets(tracker, 0), conductor.WithPollInterval(time.Millisecond))
+	done := make(chan error, 1)
+	jobConductor.OnJobDone(func(job *conductor.Job, err error) {
+		done <- err
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, jobConductor.Start(ctx))
+	defer jobConductor.Stop()
+
+	body := `{
+		"id": "forged", "namespace": "finance", "capabilities": ["reporting"],
+		"usage": {"tokens": -1000000, "cost": -50},
+		"escalations": [{"from": "FINC0002", "to": "FINC0001"}],
+		"preemptions": 3
+	}`
+	w := httptest.NewRecorder()
+	submitJob(w, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(body)))
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	select {
+	case job := <-received:
+		assert.Nil(t, job.Usage)
+		assert.Empty(t, job.Escalations)
+		assert.Zero(t, job.Preemptions)
+	case <-time.After(2 * time.Second):
+		t.Fatal("job did not run")
+	}
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("job did not finish")
+	}
+
+	statuses, err := tracker.Statuses(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.True(t, statuses[0].Used.IsZero())
+}
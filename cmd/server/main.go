@@ -24,14 +24,23 @@ import (
 	"github.com/toxicoder/cyborg-conductor-core/pkg/config"
 	"github.com/toxicoder/cyborg-conductor-core/pkg/context/pb"
 	"github.com/toxicoder/cyborg-conductor-core/pkg/core/conductor"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/cron"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/orgchart"
 	"github.com/toxicoder/cyborg-conductor-core/pkg/core/pb"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/queue"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/ratelimit"
 	"github.com/toxicoder/cyborg-conductor-core/pkg/core/types"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/proto/submissionv1"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/proto/workerv1"
 )
 
 var logger *zap.Logger
 var cfg *config.Config
 var db *sql.DB
 var scheduler *conductor.Scheduler
+var registry *pb.Registry
+var jobConductor *conductor.Conductor
+var cronRunner *cron.Cron
 
 func initLogger() *zap.Logger {
 	// Create a logger configuration
@@ -75,6 +84,21 @@ func initDB() error {
 	return nil
 }
 
+// initQueue creates the job queue selected by configuration
+func initQueue() (queue.Queue, error) {
+	if cfg.Queue.Backend == "memory" {
+		logger.Warn("Using in-memory job queue; queued jobs are lost on restart")
+		return queue.NewMemoryQueue(cfg.Queue.Capacity), nil
+	}
+	
+	pgQueue := queue.NewPostgresQueue(db, cfg.InstanceID, cfg.Queue.Capacity)
+	if err := pgQueue.EnsureSchema(context.Background()); err != nil {
+		return nil, err
+	}
+	logger.Info("Using durable PostgreSQL job queue", zap.String("instance_id", cfg.InstanceID))
+	return pgQueue, nil
+}
+
 func main() {
 	// Initialize logger
 	logger = initLogger()
@@ -104,6 +128,18 @@ func main() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
 	logger.Info("Server shutting down...")
+	if clusterReplica != nil {
+		// Hand singleton duties to another replica without waiting for
+		// the leases to expire
+		if err := clusterReplica.Stop(context.Background()); err != nil {
+			logger.Warn("Failed to leave cluster cleanly", zap.Error(err))
+		}
+	}
+	if adapterPool != nil {
+		if err := adapterPool.Close(); err != nil {
+			logger.Warn("Failed to stop adapter processes", zap.Error(err))
+		}
+	}
 }
 
 func initializeServer() error {
@@ -132,6 +168,116 @@ func initializeServer() error {
 	// Initialize scheduler
 	scheduler = conductor.NewScheduler(manager, execManager)
 	
+	// Initialize the job conductor over the loaded registry
+	jobQueue, err := initQueue()
+	if err != nil {
+		return fmt.Errorf("failed to initialize job queue: %w", err)
+	}
+	if err := initCluster(jobQueue); err != nil {
+		return fmt.Errorf("failed to join cluster: %w", err)
+	}
+	deadLetters, err := initDeadLetters()
+	if err != nil {
+		return fmt.Errorf("failed to initialize dead-letter store: %w", err)
+	}
+	idempotencyKeys, err := initIdempotency()
+	if err != nil {
+		return fmt.Errorf("failed to initialize idempotency store: %w", err)
+	}
+	rateLimits, err := ratelimit.ParseConfig(cfg.RateLimit.Limits)
+	if err != nil {
+		return fmt.Errorf("invalid RATE_LIMITS: %w", err)
+	}
+	shedRules, err := conductor.ParseShedRules(cfg.Backpressure.Shedding)
+	if err != nil {
+		return fmt.Errorf("invalid LOAD_SHEDDING: %w", err)
+	}
+	budgets, err := initBudgets()
+	if err != nil {
+		return fmt.Errorf("failed to initialize budgets: %w", err)
+	}
+	policies, err := initPolicies()
+	if err != nil {
+		return fmt.Errorf("failed to initialize scheduling policies: %w", err)
+	}
+	evidenceLog, err := initEvidence()
+	if err != nil {
+		return fmt.Errorf("failed to initialize evidence log: %w", err)
+	}
+	outputSpool, err := initOutputSpool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize output spool: %w", err)
+	}
+	conductorOpts := []conductor.Option{
+		conductor.WithQueue(jobQueue),
+		conductor.WithDeadLetterStore(deadLetters),
+		conductor.WithBreakers(initBreakers()),
+		conductor.WithIdempotency(idempotencyKeys, time.Duration(cfg.Idempotency.Window)*time.Second),
+		conductor.WithRateLimiter(ratelimit.NewLimiter(rateLimits)),
+		conductor.WithAffinityWindow(time.Duration(cfg.Affinity.Window) * time.Second),
+		conductor.WithLoadShedding(shedRules),
+		conductor.WithBudgets(budgets, int32(cfg.Budget.DowngradePriority)),
+		conductor.WithPolicies(policies),
+		conductor.WithEvidence(evidenceLog),
+		conductor.WithOutputSpool(outputSpool),
+		conductor.WithVisibilityTimeout(time.Duration(cfg.Queue.VisibilityTimeout) * time.Second),
+		conductor.WithPollInterval(time.Duration(cfg.Queue.PollInterval) * time.Millisecond),
+	}
+	if cfg.Hedge.Enabled {
+		conductorOpts = append(conductorOpts, conductor.WithHedging(conductor.HedgePolicy{
+			Delay:      time.Duration(cfg.Hedge.Delay) * time.Millisecond,
+			Percentile: cfg.Hedge.Percentile,
+			MinSamples: cfg.Hedge.MinSamples,
+		}))
+	}
+	if cfg.Preemption.Enabled {
+		conductorOpts = append(conductorOpts, conductor.WithPreemption(conductor.PreemptionPolicy{
+			MaxPriority:    int32(cfg.Preemption.MaxPriority),
+			MinGap:         int32(cfg.Preemption.MinGap),
+			MaxPreemptions: int32(cfg.Preemption.MaxPerJob),
+		}))
+	}
+	if cfg.Sandbox.Enabled {
+		// The level is validated with the rest of the configuration
+		level, _ := runner.ParseSecurityLevel(cfg.Sandbox.Level)
+		conductorOpts = append(conductorOpts, conductor.WithSandbox(conductor.SandboxPolicy{
+			Level:        level,
+			Timeout:      time.Duration(cfg.Runtime.Timeout.TaskTimeout) * time.Second,
+			CgroupParent: cfg.Sandbox.CgroupParent,
+		}))
+	}
+	if cfg.Escalation.Enabled {
+		chart, err := orgchart.Load(cfg.Escalation.OrgChart)
+		if err != nil {
+			return fmt.Errorf("failed to load org chart: %w", err)
+		}
+		logger.Info("Escalation enabled",
+			zap.Int("reporting_lines", chart.Size()),
+			zap.Int("max_hops", cfg.Escalation.MaxHops))
+		conductorOpts = append(conductorOpts, conductor.WithEscalation(chart, cfg.Escalation.MaxHops))
+	}
+	if cfg.Workers.Enabled {
+		// Jobs may run on remote worker processes instead of in the conductor
+		workerBroker = initWorkerBroker()
+		go workerBroker.Run(context.Background())
+	}
+	jobExecutor, err := initExecutor()
+	if err != nil {
+		return fmt.Errorf("failed to initialize executor: %w", err)
+	}
+	if jobExecutor != nil {
+		conductorOpts = append(conductorOpts, conductor.WithExecutor(jobExecutor))
+	}
+	jobConductor = conductor.NewConductor(registry, conductorOpts...)
+	if err := jobConductor.Start(context.Background()); err != nil {
+		return fmt.Errorf("failed to start conductor: %w", err)
+	}
+	
+	// Initialize recurring job schedules
+	if err := initCron(); err != nil {
+		return fmt.Errorf("failed to initialize cron: %w", err)
+	}
+	
 	// Register components with global state (or context)
 	// This would typically be done through dependency injection or global registry
 	
@@ -235,6 +381,36 @@ func startServer() error {
 		w.Write([]byte(`{"status": "running", "version": "1.0.0"}`))
 	})
 	
+	// Add job submission and queue status endpoints
+	registerJobRoutes(mux)
+	
+	// Add recurring schedule CRUD endpoints
+	registerScheduleRoutes(mux)
+	
+	// Add dead-letter inspection and requeue endpoints
+	registerDeadLetterRoutes(mux)
+	
+	// Add cyborg status endpoints
+	registerCyborgRoutes(mux)
+	
+	// Add fan-out endpoints
+	registerFanOutRoutes(mux)
+	
+	// Add cluster status endpoint
+	registerClusterRoutes(mux)
+	
+	// Add LLM budget status endpoint
+	registerBudgetRoutes(mux)
+	
+	// Add scheduling policy admin endpoints
+	registerSchedulingRoutes(mux)
+	
+	// Add job evidence log endpoint
+	registerEvidenceRoutes(mux)
+	
+	// Add job output streaming endpoint
+	registerOutputRoutes(mux)
+	
 	// Add Prometheus metrics endpoint
 	mux.Handle("/metrics", promhttp.Handler())
 	
@@ -253,6 +429,10 @@ func startServer() error {
 	// Start gRPC server
 	grpcServer := grpc.NewServer()
 	pb.RegisterCyborgRegistrationServiceServer(grpcServer, &CyborgRegistrationServiceServer{})
+	submissionv1.RegisterJobSubmissionServiceServer(grpcServer, &JobSubmissionServiceServer{})
+	if workerBroker != nil {
+		workerv1.RegisterCyborgWorkerServiceServer(grpcServer, &CyborgWorkerServiceServer{broker: workerBroker})
+	}
 	
 	// Listen on gRPC port
 	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.GrpcPort))
@@ -290,7 +470,7 @@ func registerAllCyborgs() error {
 	logger.Info("Registering all cyborgs from txtpb files...")
 	
 	// Create and load registry from txtpb files
-	registry := pb.NewRegistry()
+	registry = pb.NewRegistry()
 	if err := registry.LoadFromTxtpb("cyborgs"); err != nil {
 		return fmt.Errorf("failed to load cyborgs from txtpb files: %w", err)
 	}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/output"
+)
+
+// sseKeepAlive is how often an idle output stream sends a comment so
+// proxies do not close it
+const sseKeepAlive = 15 * time.Second
+
+// outputEvent is the data of an output server-sent event
+type outputEvent struct {
+	Seq    uint64        `json:"seq"`
+	Stream output.Stream `json:"stream"`
+	// Data is the chunk as text; bytes that are not valid UTF-8 are
+	// replaced
+	Data string    `json:"data"`
+	At   time.Time `json:"at"`
+}
+
+// initOutputSpool creates the spool jobs' output spills to; nil when no
+// spill directory is configured
+func initOutputSpool() (*output.Spool, error) {
+	if cfg.Output.SpillDir == "" {
+		return nil, nil
+	}
+	limits := output.Limits{
+		Memory: cfg.Output.MemoryLimit,
+		Head:   cfg.Output.ExcerptBytes,
+		Tail:   cfg.Output.ExcerptBytes,
+	}
+	return output.NewSpool(cfg.Output.SpillDir, limits, time.Duration(cfg.Output.SpillRetention)*time.Hour)
+}
+
+// registerOutputRoutes adds the job output streaming and spilled output
+// endpoints
+func registerOutputRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/jobs/{id}/output", streamOutput)
+	mux.HandleFunc("GET /api/v1/jobs/{id}/output/{stream}", getSpilledOutput)
+}
+
+// getSpilledOutput serves the whole of a job's stdout or stderr once it
+// has spilled to disk, with support for range requests
+func getSpilledOutput(w http.ResponseWriter, r *http.Request) {
+	stream := output.Stream(r.PathValue("stream"))
+	if stream != output.Stdout && stream != output.Stderr {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown output stream %q", stream))
+		return
+	}
+	f, err := jobConductor.Output().Spool().Open(r.PathValue("id"), stream)
+	if errors.Is(err, output.ErrNotSpilled) {
+		writeError(w, http.StatusNotFound, "output was not spilled to disk")
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to open spilled output", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to open output")
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to open output")
+		return
+	}
+
+	setSecurityHeaders(w)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	http.ServeContent(w, r, string(stream)+".log", info.ModTime(), f)
+}
+
+// streamOutput follows a job's output as server-sent events: one stdout or
+// stderr event per chunk, with the chunk's sequence number as its ID, and
+// a done event once the job finishes. A reconnecting client resumes after
+// its Last-Event-ID, or after the ?after= sequence number.
+func streamOutput(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+	after, err := resumeAfter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logger.Debug("Failed to clear write deadline", zap.Error(err))
+	}
+
+	hub := jobConductor.Output()
+	chunks, stop := hub.Watch(jobID, after)
+	defer stop()
+
+	setSecurityHeaders(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				if hub.Finished(jobID) {
+					fmt.Fprint(w, "event: done\ndata: {}\n\n")
+					_ = rc.Flush()
+				}
+				// Otherwise the client fell behind; it reconnects from
+				// its last event
+				return
+			}
+			data, _ := json.Marshal(outputEvent{
+				Seq:    chunk.Seq,
+				Stream: chunk.Stream,
+				Data:   string(chunk.Data),
+				At:     chunk.At,
+			})
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", chunk.Seq, chunk.Stream, data)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// resumeAfter reads the sequence number a watch resumes after
+func resumeAfter(r *http.Request) (uint64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("after")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	after, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid resume sequence number %q", value)
+	}
+	return after, nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/cluster"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/conductor"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/cron"
+)
+
+// initCron creates the Postgres-backed schedule store and starts the cron
+// runner that submits scheduled jobs to the conductor
+func initCron() error {
+	store := cron.NewPostgresStore(db)
+	if err := store.EnsureSchema(context.Background()); err != nil {
+		return err
+	}
+
+	cronRunner = cron.NewCron(store, submitScheduledJob,
+		time.Duration(cfg.Cron.TickInterval)*time.Second,
+		time.Duration(cfg.Cron.MisfireThreshold)*time.Second)
+
+	// Release overlap locks as scheduled jobs finish
+	jobConductor.OnJobDone(func(job *conductor.Job, err error) {
+		cronRunner.JobFinished(job.ID)
+	})
+
+	if cfg.Cron.Enabled {
+		// Only the cron leader fires schedules; without a cluster this
+		// instance always leads
+		clusterReplica.OnLeadership(cluster.DutyCron, func(leader bool) {
+			if !leader {
+				cronRunner.Stop()
+				logger.Info("Cron scheduler stopped; another replica leads")
+				return
+			}
+			cronRunner.Start(context.Background())
+			logger.Info("Cron scheduler started", zap.Int64("tick_interval", cfg.Cron.TickInterval))
+		})
+	}
+	return nil
+}
+
+// submitScheduledJob turns one schedule activation into a conductor job
+func submitScheduledJob(ctx context.Context, s *cron.Schedule, scheduledFor time.Time) (string, error) {
+	namespace := s.Namespace
+	if namespace == "" {
+		namespace = cfg.Cyborg.DefaultNamespace
+	}
+
+	job := &conductor.Job{
+		// One ID per activation keeps resubmissions of the same run traceable
+		ID:           fmt.Sprintf("cron-%s-%d", s.ID, scheduledFor.Unix()),
+		Capabilities: s.Job.Capabilities,
+		Payload:      s.Job.Payload,
+		TimeoutMs:    s.Job.TimeoutMs,
+		CyborgID:     s.Job.CyborgID,
+		Namespace:    namespace,
+		Priority:     s.Job.Priority,
+	}
+
+	if err := jobConductor.SubmitJob(job); err != nil {
+		return "", err
+	}
+	logger.Info("Submitted scheduled job",
+		zap.String("schedule_id", s.ID),
+		zap.String("job_id", job.ID),
+		zap.Time("scheduled_for", scheduledFor))
+	return job.ID, nil
+}
+
+// registerScheduleRoutes adds CRUD endpoints for recurring schedules
+func registerScheduleRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/schedules", listSchedules)
+	mux.HandleFunc("POST /api/v1/schedules", createSchedule)
+	mux.HandleFunc("GET /api/v1/schedules/{id}", getSchedule)
+	mux.HandleFunc("PUT /api/v1/schedules/{id}", updateSchedule)
+	mux.HandleFunc("DELETE /api/v1/schedules/{id}", deleteSchedule)
+}
+
+func listSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := cronRunner.List(r.Context())
+	if err != nil {
+		logger.Error("Failed to list schedules", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to list schedules")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"schedules": schedules})
+}
+
+func createSchedule(w http.ResponseWriter, r *http.Request) {
+	var s cron.Schedule
+	if err := readJSON(w, r, &s); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid schedule: %v", err))
+		return
+	}
+
+	created, err := cronRunner.Create(r.Context(), &s)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func getSchedule(w http.ResponseWriter, r *http.Request) {
+	s, err := cronRunner.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s)
+}
+
+func updateSchedule(w http.ResponseWriter, r *http.Request) {
+	var s cron.Schedule
+	if err := readJSON(w, r, &s); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid schedule: %v", err))
+		return
+	}
+	s.ID = r.PathValue("id")
+
+	updated, err := cronRunner.Update(r.Context(), &s)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func deleteSchedule(w http.ResponseWriter, r *http.Request) {
+	if err := cronRunner.Delete(r.Context(), r.PathValue("id")); err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+	setSecurityHeaders(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeScheduleError maps cron errors onto HTTP status codes
+func writeScheduleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, cron.ErrScheduleNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, cron.ErrInvalidSchedule):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		logger.Error("Schedule store failure", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "schedule store unavailable")
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/policy"
+)
+
+// schedulingConfig is the scheduling admin API's view of the policy
+// assignments
+type schedulingConfig struct {
+	policy.Assignments
+	// Available lists the policies that can be assigned
+	Available []string `json:"available,omitempty"`
+}
+
+// initPolicies creates the scheduling policies and assigns them from
+// SCHEDULING_POLICY and SCHEDULING_POLICIES
+func initPolicies() (*policy.Set, error) {
+	weights, err := policy.ParseWeights(cfg.Scheduling.Weights)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SCHEDULING_WEIGHTS: %w", err)
+	}
+	assignments, err := policy.ParseAssignments(cfg.Scheduling.Policy, cfg.Scheduling.Policies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SCHEDULING_POLICIES: %w", err)
+	}
+	policies := policy.NewSet(weights)
+	if err := policies.Assign(assignments); err != nil {
+		return nil, fmt.Errorf("invalid scheduling policy: %w", err)
+	}
+	logger.Info("Scheduling policies assigned",
+		zap.String("default", assignments.Default),
+		zap.Int("namespaces", len(assignments.Namespaces)),
+		zap.Int("classes", len(assignments.Classes)))
+	return policies, nil
+}
+
+// registerSchedulingRoutes adds the scheduling policy admin endpoints
+func registerSchedulingRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/admin/scheduling", getScheduling)
+	mux.HandleFunc("PUT /api/v1/admin/scheduling", updateScheduling)
+}
+
+// getScheduling returns the policy assignments in force and the policies
+// available
+func getScheduling(w http.ResponseWriter, r *http.Request) {
+	policies := jobConductor.Policies()
+	writeJSON(w, http.StatusOK, schedulingConfig{
+		Assignments: policies.Assignments(),
+		Available:   policies.Names(),
+	})
+}
+
+// updateScheduling replaces the policy assignments. Jobs placed from then
+// on use the new policies; the change is not persisted and applies to
+// this replica only.
+func updateScheduling(w http.ResponseWriter, r *http.Request) {
+	var update schedulingConfig
+	if err := readJSON(w, r, &update); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid scheduling config: %v", err))
+		return
+	}
+
+	policies := jobConductor.Policies()
+	if err := policies.Assign(update.Assignments); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	assignments := policies.Assignments()
+	logger.Info("Scheduling policies reassigned",
+		zap.String("default", assignments.Default),
+		zap.Any("namespaces", assignments.Namespaces),
+		zap.Any("classes", assignments.Classes))
+	writeJSON(w, http.StatusOK, schedulingConfig{
+		Assignments: assignments,
+		Available:   policies.Names(),
+	})
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/conductor"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/idempotency"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/queue"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/proto/submissionv1"
+)
+
+// JobSubmissionServiceServer implements the client-facing gRPC job
+// submission service
+type JobSubmissionServiceServer struct {
+	// Embed the generated service interface
+	submissionv1.UnimplementedJobSubmissionServiceServer
+}
+
+// SubmitJob queues a job, failing with RESOURCE_EXHAUSTED and a retry hint
+// when the conductor refuses it for load
+func (s *JobSubmissionServiceServer) SubmitJob(ctx context.Context, req *submissionv1.SubmitJobRequest) (*submissionv1.SubmitJobResponse, error) {
+	msg := req.GetJob()
+	if msg == nil {
+		return nil, status.Error(codes.InvalidArgument, "job is required")
+	}
+	job := &conductor.Job{
+		ID:             msg.GetJobId(),
+		CyborgID:       msg.GetCyborgId(),
+		Payload:        msg.GetPayload(),
+		Capabilities:   req.GetCapabilities(),
+		Namespace:      req.GetNamespace(),
+		Priority:       req.GetPriority(),
+		IdempotencyKey: req.GetIdempotencyKey(),
+		ContextID:      req.GetContextId(),
+		Class:          req.GetClass(),
+		Owner:          req.GetOwner(),
+	}
+	if job.ID == "" {
+		job.ID = newJobID()
+	}
+	if job.Namespace == "" {
+		job.Namespace = cfg.Cyborg.DefaultNamespace
+	}
+	if deadline := msg.GetDeadlineMs(); deadline > 0 {
+		remaining := time.Until(time.UnixMilli(deadline)).Milliseconds()
+		if remaining <= 0 {
+			return nil, status.Error(codes.DeadlineExceeded, "job deadline has already passed")
+		}
+		if remaining > math.MaxInt32 {
+			remaining = math.MaxInt32
+		}
+		job.TimeoutMs = int32(remaining)
+	}
+
+	err := jobConductor.SubmitJob(job)
+	var duplicate *conductor.DuplicateJobError
+	var queueFull *conductor.JobQueueFullError
+	var overBudget *conductor.BudgetExceededError
+	switch {
+	case errors.As(err, &duplicate):
+		return &submissionv1.SubmitJobResponse{
+			JobId:     duplicate.Record.JobID,
+			Status:    duplicate.Record.Status,
+			Duplicate: true,
+			Result:    duplicate.Record.Result,
+			Error:     duplicate.Record.Error,
+		}, nil
+	case errors.Is(err, conductor.ErrInvalidJob):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &queueFull):
+		return nil, resourceExhausted(ctx, queueFull.Error(), queueFull.RetryAfter)
+	case errors.As(err, &overBudget):
+		return nil, resourceExhausted(ctx, overBudget.Error(), time.Until(overBudget.Status.ResetAt))
+	case errors.Is(err, queue.ErrDuplicate):
+		return nil, status.Error(codes.AlreadyExists, "a job with this ID is already queued")
+	case err != nil:
+		logger.Error("Failed to submit job", zap.String("job_id", job.ID), zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to submit job")
+	}
+	return &submissionv1.SubmitJobResponse{JobId: job.ID, Status: idempotency.StatusPending}, nil
+}
+
+// GetQueueStatus reports the queue depth, capacity and drain rate
+func (s *JobSubmissionServiceServer) GetQueueStatus(ctx context.Context, req *submissionv1.GetQueueStatusRequest) (*submissionv1.QueueStatus, error) {
+	stats, err := jobConductor.QueueStats(ctx)
+	if err != nil {
+		logger.Error("Failed to read queue stats", zap.Error(err))
+		return nil, status.Error(codes.Unavailable, "queue unavailable")
+	}
+	return &submissionv1.QueueStatus{
+		Depth:     int64(stats.Depth),
+		Capacity:  int64(stats.Capacity),
+		DrainRate: stats.DrainRate,
+	}, nil
+}
+
+// WatchJob streams a job's output until the job finishes. A watcher that
+// falls behind is disconnected with UNAVAILABLE and can resume from the
+// last sequence number it received.
+func (s *JobSubmissionServiceServer) WatchJob(req *submissionv1.WatchJobRequest, stream submissionv1.JobSubmissionService_WatchJobServer) error {
+	if req.GetJobId() == "" {
+		return status.Error(codes.InvalidArgument, "job_id is required")
+	}
+	hub := jobConductor.Output()
+	chunks, stop := hub.Watch(req.GetJobId(), req.GetAfterSeq())
+	defer stop()
+
+	for {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				if hub.Finished(req.GetJobId()) {
+					return nil
+				}
+				return status.Error(codes.Unavailable, "watcher fell behind the job's output; resume from the last seq")
+			}
+			if err := stream.Send(&submissionv1.JobOutputChunk{
+				JobId:       chunk.JobID,
+				Seq:         chunk.Seq,
+				Stream:      string(chunk.Stream),
+				Data:        chunk.Data,
+				TimestampMs: chunk.At.UnixMilli(),
+			}); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+// resourceExhausted builds the RESOURCE_EXHAUSTED status for a refused
+// submission with a RetryInfo detail, and sets a retry-after header for
+// clients that do not decode details
+func resourceExhausted(ctx context.Context, message string, wait time.Duration) error {
+	retryAfter := time.Duration(math.Ceil(wait.Seconds())) * time.Second
+	header := metadata.Pairs("retry-after", strconv.Itoa(int(retryAfter.Seconds())))
+	if setErr := grpc.SetHeader(ctx, header); setErr != nil {
+		logger.Debug("Failed to set retry-after header", zap.Error(setErr))
+	}
+
+	st := status.New(codes.ResourceExhausted, message)
+	detailed, detailErr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	if detailErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/budget"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/output"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/remote"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/proto/workerv1"
+	envelopev1 "github.com/toxicoder/cyborg-conductor-core/proto/v1"
+)
+
+const (
+	// maxPollWait caps how long a PollJob call is held open
+	maxPollWait = 60 * time.Second
+	// streamPollWait is how long StreamJobs waits between liveness checks
+	streamPollWait = 30 * time.Second
+)
+
+// workerBroker hands jobs to remote workers; nil unless workers are enabled
+var workerBroker *remote.Broker
+
+// initWorkerBroker creates the remote worker broker from the configuration
+func initWorkerBroker() *remote.Broker {
+	return remote.NewBroker(remote.Config{
+		AckTimeout:    time.Duration(cfg.Workers.AckTimeout) * time.Second,
+		LeaseTimeout:  time.Duration(cfg.Workers.LeaseTimeout) * time.Second,
+		MaxDeliveries: cfg.Workers.MaxDeliveries,
+		OnProgress: func(p remote.Progress) {
+			logger.Debug("Worker progress",
+				zap.String("job_id", p.JobID),
+				zap.String("cyborg_id", p.CyborgID),
+				zap.String("worker_id", p.WorkerID),
+				zap.Float32("percent", p.Percent),
+				zap.String("message", p.Message))
+		},
+		OnOutput: func(chunks []output.Chunk) {
+			for _, chunk := range chunks {
+				jobConductor.Output().Publish(chunk.JobID, chunk.Stream, chunk.Data, chunk.At)
+			}
+		},
+	})
+}
+
+// CyborgWorkerServiceServer implements the worker-facing gRPC service
+type CyborgWorkerServiceServer struct {
+	// Embed the generated service interface
+	workerv1.UnimplementedCyborgWorkerServiceServer
+
+	broker *remote.Broker
+}
+
+// PollJob waits for the next job assigned to the worker's cyborg
+func (s *CyborgWorkerServiceServer) PollJob(ctx context.Context, req *workerv1.PollJobRequest) (*workerv1.PollJobResponse, error) {
+	worker, err := workerIdentity(req.GetWorker())
+	if err != nil {
+		return nil, err
+	}
+
+	wait := time.Duration(req.GetWaitMs()) * time.Millisecond
+	if wait <= 0 || wait > maxPollWait {
+		wait = maxPollWait
+	}
+	delivery, err := s.broker.Poll(ctx, worker.GetCyborgId(), worker.GetWorkerId(), wait)
+	if err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	return &workerv1.PollJobResponse{Delivery: toJobDelivery(delivery)}, nil
+}
+
+// StreamJobs sends jobs to the worker as they are assigned. Jobs the worker
+// has not finished are redelivered when the stream ends.
+func (s *CyborgWorkerServiceServer) StreamJobs(req *workerv1.StreamJobsRequest, stream workerv1.CyborgWorkerService_StreamJobsServer) error {
+	worker, err := workerIdentity(req.GetWorker())
+	if err != nil {
+		return err
+	}
+	defer s.broker.Disconnect(worker.GetCyborgId(), worker.GetWorkerId())
+
+	logger.Info("Worker connected",
+		zap.String("cyborg_id", worker.GetCyborgId()),
+		zap.String("worker_id", worker.GetWorkerId()))
+	for {
+		delivery, err := s.broker.Poll(stream.Context(), worker.GetCyborgId(), worker.GetWorkerId(), streamPollWait)
+		if err != nil {
+			logger.Info("Worker disconnected",
+				zap.String("cyborg_id", worker.GetCyborgId()),
+				zap.String("worker_id", worker.GetWorkerId()))
+			return status.FromContextError(err).Err()
+		}
+		if delivery == nil {
+			continue
+		}
+		if err := stream.Send(toJobDelivery(delivery)); err != nil {
+			return err
+		}
+	}
+}
+
+// AckJob confirms that a delivered job has started
+func (s *CyborgWorkerServiceServer) AckJob(ctx context.Context, req *workerv1.AckJobRequest) (*workerv1.AckJobResponse, error) {
+	worker, err := workerIdentity(req.GetWorker())
+	if err != nil {
+		return nil, err
+	}
+	leaseDeadline, err := s.broker.Ack(worker.GetWorkerId(), req.GetDeliveryId())
+	if err != nil {
+		return nil, deliveryError(err)
+	}
+	return &workerv1.AckJobResponse{LeaseDeadlineMs: leaseDeadline.UnixMilli()}, nil
+}
+
+// ReportProgress records progress on a running job and extends its lease
+func (s *CyborgWorkerServiceServer) ReportProgress(ctx context.Context, req *workerv1.ReportProgressRequest) (*workerv1.ReportProgressResponse, error) {
+	worker, err := workerIdentity(req.GetWorker())
+	if err != nil {
+		return nil, err
+	}
+	leaseDeadline, err := s.broker.ReportProgress(worker.GetWorkerId(), req.GetDeliveryId(), req.GetPercent(), req.GetMessage())
+	if err != nil {
+		return nil, deliveryError(err)
+	}
+	return &workerv1.ReportProgressResponse{LeaseDeadlineMs: leaseDeadline.UnixMilli()}, nil
+}
+
+// ReportOutput relays a running job's output to the clients watching it
+// and extends its lease
+func (s *CyborgWorkerServiceServer) ReportOutput(ctx context.Context, req *workerv1.ReportOutputRequest) (*workerv1.ReportOutputResponse, error) {
+	worker, err := workerIdentity(req.GetWorker())
+	if err != nil {
+		return nil, err
+	}
+	chunks := make([]output.Chunk, 0, len(req.GetChunks()))
+	for _, chunk := range req.GetChunks() {
+		stream := output.Stream(chunk.GetStream())
+		if stream != output.Stdout && stream != output.Stderr {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown output stream %q", chunk.GetStream())
+		}
+		chunks = append(chunks, output.Chunk{
+			Stream: stream,
+			Data:   chunk.GetData(),
+			At:     time.UnixMilli(chunk.GetTimestampMs()),
+		})
+	}
+	leaseDeadline, err := s.broker.ReportOutput(worker.GetWorkerId(), req.GetDeliveryId(), chunks)
+	if err != nil {
+		return nil, deliveryError(err)
+	}
+	return &workerv1.ReportOutputResponse{LeaseDeadlineMs: leaseDeadline.UnixMilli()}, nil
+}
+
+// ReportResult records the final status of a job
+func (s *CyborgWorkerServiceServer) ReportResult(ctx context.Context, req *workerv1.ReportResultRequest) (*workerv1.ReportResultResponse, error) {
+	worker, err := workerIdentity(req.GetWorker())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetStatus() == nil {
+		return nil, status.Error(codes.InvalidArgument, "result status is required")
+	}
+
+	result := remote.Result{
+		Code:         req.GetStatus().GetCode().String(),
+		Message:      req.GetStatus().GetMessage(),
+		ErrorDetails: req.GetStatus().GetErrorDetails(),
+		Payload:      req.GetPayload(),
+		Usage: budget.Usage{
+			Tokens: req.GetUsage().GetTokens(),
+			Cost:   req.GetUsage().GetCost(),
+		},
+	}
+	if err := s.broker.Complete(worker.GetWorkerId(), req.GetDeliveryId(), result); err != nil {
+		return nil, deliveryError(err)
+	}
+	return &workerv1.ReportResultResponse{}, nil
+}
+
+// workerIdentity validates the identity a worker sends with every call
+func workerIdentity(worker *workerv1.WorkerIdentity) (*workerv1.WorkerIdentity, error) {
+	if worker.GetCyborgId() == "" || worker.GetWorkerId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "worker cyborg_id and worker_id are required")
+	}
+	return worker, nil
+}
+
+// deliveryError maps broker errors to gRPC status errors
+func deliveryError(err error) error {
+	if errors.Is(err, remote.ErrUnknownDelivery) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// toJobDelivery converts a broker delivery to its wire form
+func toJobDelivery(delivery *remote.Delivery) *workerv1.JobDelivery {
+	if delivery == nil {
+		return nil
+	}
+	job := &envelopev1.SubmitJobMessage{
+		JobId:             delivery.Job.ID,
+		CyborgId:          delivery.Job.CyborgID,
+		Payload:           delivery.Job.Payload,
+		SubmitTimestampMs: delivery.Job.SubmittedAt.UnixMilli(),
+	}
+	if !delivery.Job.Deadline.IsZero() {
+		job.DeadlineMs = delivery.Job.Deadline.UnixMilli()
+	}
+	return &workerv1.JobDelivery{
+		DeliveryId:      delivery.ID,
+		Job:             job,
+		AckDeadlineMs:   delivery.AckDeadline.UnixMilli(),
+		DeliveryAttempt: int32(delivery.Attempt),
+	}
+}
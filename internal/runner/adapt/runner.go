@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/executor"
 )
 
 // Runner represents a cyborg runner that adapts to different tasks
@@ -13,6 +15,8 @@ type Runner struct {
 	Health      string
 	LastSeen    time.Time
 	ActiveTasks int
+	// Executor runs the runner's tasks; nil simulates them
+	Executor executor.Executor
 }
 
 // NewRunner creates a new runner instance
@@ -29,6 +33,15 @@ func NewRunner(id, name string) *Runner {
 func (r *Runner) Run(ctx context.Context, taskID string) error {
 	fmt.Printf("Runner %s starting task %s\n", r.ID, taskID)
 	
+	if r.Executor != nil {
+		if _, err := r.Executor.Execute(ctx, &executor.Request{JobID: taskID, CyborgID: r.ID}); err != nil {
+			fmt.Printf("Runner %s failed task %s: %v\n", r.ID, taskID, err)
+			return err
+		}
+		fmt.Printf("Runner %s completed task %s\n", r.ID, taskID)
+		return nil
+	}
+	
 	// Simulate task execution
 	select {
 	case <-ctx.Done():
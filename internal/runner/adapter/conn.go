@@ -0,0 +1,210 @@
+package adapter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrAdapterExited is returned for a call whose adapter process exited
+// before answering; the call may be retried on another process
+var ErrAdapterExited = errors.New("adapter process exited")
+
+// errNotSent is returned for a call whose process had exited before the
+// request reached it, so it can be retried safely
+var errNotSent = fmt.Errorf("%w before the request was sent", ErrAdapterExited)
+
+// RPCError is an error response from an adapter
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("adapter error %d: %s", e.Code, e.Message)
+}
+
+// request is a JSON-RPC 2.0 request
+type request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      uint64      `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// response is a JSON-RPC 2.0 response
+type response struct {
+	ID     *uint64         `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// conn is one adapter process, answering newline-delimited JSON-RPC 2.0
+// requests on its stdin with responses on its stdout, in any order
+type conn struct {
+	cmd    *exec.Cmd
+	nextID atomic.Uint64
+
+	// writeMu keeps requests whole on stdin
+	writeMu sync.Mutex
+	stdin   io.WriteCloser
+
+	mu      sync.Mutex
+	pending map[uint64]chan *response
+	exited  bool
+	// done is closed once the process has exited and every pending call
+	// has failed
+	done chan struct{}
+
+	// Tracked by the pool under its lock
+	inFlight int
+	served   int
+	draining bool
+}
+
+// startConn starts an adapter process
+func startConn(cfg Config) (*conn, error) {
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Env = cfg.Env
+	cmd.Stderr = cfg.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start adapter: %w", err)
+	}
+
+	c := &conn{
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[uint64]chan *response),
+		done:    make(chan struct{}),
+	}
+	go c.read(stdout)
+	return c, nil
+}
+
+// read routes responses to their calls until the process's output ends,
+// then reaps the process and fails the calls still pending
+func (c *conn) read(stdout io.Reader) {
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			c.deliver(line)
+		}
+		if err != nil {
+			break
+		}
+	}
+	_ = c.cmd.Wait()
+
+	c.mu.Lock()
+	c.exited = true
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+	close(c.done)
+}
+
+// deliver hands a response line to the call waiting for it. Lines that are
+// not responses to a pending call, such as stray prints, are ignored.
+func (c *conn) deliver(line []byte) {
+	var resp response
+	if err := json.Unmarshal(line, &resp); err != nil || resp.ID == nil {
+		return
+	}
+	c.mu.Lock()
+	ch, ok := c.pending[*resp.ID]
+	delete(c.pending, *resp.ID)
+	c.mu.Unlock()
+	if ok {
+		ch <- &resp
+	}
+}
+
+// call sends a request and waits for its response, decoding its result
+// into result unless that is nil
+func (c *conn) call(ctx context.Context, method string, params, result interface{}) error {
+	id := c.nextID.Add(1)
+	data, err := json.Marshal(request{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+
+	ch := make(chan *response, 1)
+	c.mu.Lock()
+	if c.exited {
+		c.mu.Unlock()
+		return errNotSent
+	}
+	c.pending[id] = ch
+	c.mu.Unlock()
+	forget := func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}
+
+	c.writeMu.Lock()
+	_, err = c.stdin.Write(append(data, '\n'))
+	c.writeMu.Unlock()
+	if err != nil {
+		forget()
+		return fmt.Errorf("%w: %v", errNotSent, err)
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return ErrAdapterExited
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("failed to decode %s result: %w", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		// A late response is dropped
+		forget()
+		return ctx.Err()
+	}
+}
+
+// stop closes the process's stdin so it exits once it has answered what it
+// has read, killing it if it has not exited after timeout
+func (c *conn) stop(timeout time.Duration) {
+	c.writeMu.Lock()
+	_ = c.stdin.Close()
+	c.writeMu.Unlock()
+	select {
+	case <-c.done:
+	case <-time.After(timeout):
+		c.kill()
+	}
+}
+
+// kill ends the process at once
+func (c *conn) kill() {
+	_ = c.cmd.Process.Kill()
+}
@@ -0,0 +1,316 @@
+// Package adapter keeps a pool of long-lived adapter processes, such as
+// adapters/python/exec_wrapper.py --persistent, and calls them with
+// newline-delimited JSON-RPC 2.0 over stdio. Calls are multiplexed by
+// request ID, so one process can serve several at once.
+package adapter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/metrics"
+)
+
+// Defaults for a pool
+const (
+	DefaultSize         = 2
+	DefaultPingInterval = 10 * time.Second
+	DefaultPingTimeout  = 2 * time.Second
+	// DefaultStopTimeout is how long a retiring process has to finish its
+	// calls and exit before it is killed
+	DefaultStopTimeout = 5 * time.Second
+	// minRestartBackoff and maxRestartBackoff bound the delay before a
+	// crashed process is replaced; the delay doubles while processes keep
+	// crashing soon after they start
+	minRestartBackoff = 100 * time.Millisecond
+	maxRestartBackoff = 10 * time.Second
+	// stableUptime is how long a process must run for a crash not to count
+	// toward the backoff
+	stableUptime = 10 * time.Second
+)
+
+// Reasons a process is replaced
+const (
+	RestartCrashed   = "crashed"
+	RestartUnhealthy = "unhealthy"
+	RestartRecycled  = "recycled"
+)
+
+// ErrClosed is returned for calls on a closed pool
+var ErrClosed = errors.New("adapter pool is closed")
+
+// Config describes a pool's adapter processes
+type Config struct {
+	// Name labels the pool's metrics
+	Name string
+	// Command and Args start one adapter process
+	Command string
+	Args    []string
+	// Env is the processes' environment; nil inherits the conductor's
+	Env []string
+	// Stderr receives the processes' standard error; nil discards it
+	Stderr io.Writer
+
+	// Size is how many processes the pool keeps running
+	Size int
+	// MaxRequests recycles a process once it has served this many calls;
+	// zero never recycles
+	MaxRequests int
+	// PingInterval is how often each process is pinged, and PingTimeout
+	// how long it has to answer before it is replaced
+	PingInterval time.Duration
+	PingTimeout  time.Duration
+	// StopTimeout is how long a retiring process has to exit
+	StopTimeout time.Duration
+}
+
+// Pool runs Size adapter processes and spreads calls across them. A
+// process that crashes or stops answering pings is replaced, and calls in
+// flight on it fail with ErrAdapterExited.
+type Pool struct {
+	cfg Config
+
+	mu    sync.Mutex
+	slots []*conn
+	// changed is closed and replaced whenever a slot changes, waking calls
+	// waiting for a process
+	changed chan struct{}
+	closed  bool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewPool starts a pool; zero config values use the defaults. Processes
+// start in the background, and calls wait for one to be ready.
+func NewPool(cfg Config) *Pool {
+	if cfg.Size <= 0 {
+		cfg.Size = DefaultSize
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = DefaultPingInterval
+	}
+	if cfg.PingTimeout <= 0 {
+		cfg.PingTimeout = DefaultPingTimeout
+	}
+	if cfg.StopTimeout <= 0 {
+		cfg.StopTimeout = DefaultStopTimeout
+	}
+
+	p := &Pool{
+		cfg:     cfg,
+		slots:   make([]*conn, cfg.Size),
+		changed: make(chan struct{}),
+		stop:    make(chan struct{}),
+	}
+	for slot := range p.slots {
+		p.wg.Add(1)
+		go p.supervise(slot)
+	}
+	return p
+}
+
+// Call invokes method on the least busy process and decodes its result
+// into result unless that is nil. An error response is returned as a
+// *RPCError. A call that never reached its process, because the process
+// had just exited, is retried on its replacement.
+func (p *Pool) Call(ctx context.Context, method string, params, result interface{}) error {
+	for {
+		c, err := p.acquire(ctx)
+		if err != nil {
+			return err
+		}
+		err = c.call(ctx, method, params, result)
+		p.release(c)
+		if !errors.Is(err, errNotSent) {
+			return err
+		}
+		// Wait for the process to leave its slot
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Ready returns how many processes are taking calls
+func (p *Pool) Ready() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ready := 0
+	for _, c := range p.slots {
+		if c != nil && !c.draining {
+			ready++
+		}
+	}
+	return ready
+}
+
+// Close stops the pool's processes, letting them finish the calls in
+// flight
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.stop)
+	p.notify()
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	return nil
+}
+
+// acquire picks the process with the fewest calls in flight, waiting for
+// one if none is ready
+func (p *Pool) acquire(ctx context.Context) (*conn, error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, ErrClosed
+		}
+		var best *conn
+		for _, c := range p.slots {
+			if c != nil && !c.draining && (best == nil || c.inFlight < best.inFlight) {
+				best = c
+			}
+		}
+		if best != nil {
+			best.inFlight++
+			best.served++
+			if p.cfg.MaxRequests > 0 && best.served >= p.cfg.MaxRequests {
+				// This is its last call; it retires once the call is done
+				best.draining = true
+			}
+			p.mu.Unlock()
+			return best, nil
+		}
+		wait := p.changed
+		p.mu.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// release ends a call on a process, retiring it if it is draining
+func (p *Pool) release(c *conn) {
+	p.mu.Lock()
+	c.inFlight--
+	retire := c.draining && c.inFlight == 0
+	p.mu.Unlock()
+	if retire {
+		go c.stop(p.cfg.StopTimeout)
+	}
+}
+
+// supervise keeps one slot's process running until the pool closes
+func (p *Pool) supervise(slot int) {
+	defer p.wg.Done()
+	backoff := minRestartBackoff
+	for {
+		c, err := startConn(p.cfg)
+		if err != nil {
+			if !p.sleep(backoff) {
+				return
+			}
+			backoff = min(backoff*2, maxRestartBackoff)
+			continue
+		}
+		started := time.Now()
+		p.setSlot(slot, c)
+
+		reason := p.watch(c)
+		p.setSlot(slot, nil)
+		if reason == "" {
+			// The pool closed
+			return
+		}
+		metrics.AdapterRestarts.WithLabelValues(p.cfg.Name, reason).Inc()
+
+		switch {
+		case reason == RestartRecycled || time.Since(started) >= stableUptime:
+			backoff = minRestartBackoff
+		default:
+			if !p.sleep(backoff) {
+				return
+			}
+			backoff = min(backoff*2, maxRestartBackoff)
+		}
+	}
+}
+
+// watch pings a process until it exits, returning why it is being
+// replaced; empty when the pool closed
+func (p *Pool) watch(c *conn) string {
+	ticker := time.NewTicker(p.cfg.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			p.mu.Lock()
+			recycled := c.draining
+			p.mu.Unlock()
+			if recycled {
+				return RestartRecycled
+			}
+			return RestartCrashed
+		case <-ticker.C:
+			p.mu.Lock()
+			draining := c.draining
+			p.mu.Unlock()
+			if draining {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), p.cfg.PingTimeout)
+			err := c.call(ctx, "ping", nil, nil)
+			cancel()
+			if err != nil && !errors.Is(err, ErrAdapterExited) {
+				// Calls in flight on it fail with ErrAdapterExited
+				c.kill()
+				<-c.done
+				return RestartUnhealthy
+			}
+		case <-p.stop:
+			p.mu.Lock()
+			c.draining = true
+			p.mu.Unlock()
+			c.stop(p.cfg.StopTimeout)
+			return ""
+		}
+	}
+}
+
+// setSlot records a slot's process and wakes waiting calls
+func (p *Pool) setSlot(slot int, c *conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.slots[slot] = c
+	p.notify()
+}
+
+// notify wakes calls waiting for a process; callers hold mu
+func (p *Pool) notify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// sleep waits out a restart backoff, reporting false if the pool closed
+func (p *Pool) sleep(d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-p.stop:
+		return false
+	}
+}
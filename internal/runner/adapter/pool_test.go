@@ -0,0 +1,245 @@
+package adapter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// helperEnv runs the test binary as a fake adapter; its value is the
+// adapter's mode
+const helperEnv = "ADAPTER_HELPER"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(helperEnv); mode != "" {
+		fakeAdapter(mode)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeAdapter answers requests on stdin concurrently. Its methods are echo,
+// sleep, pid and crash; in "hang" mode it never answers pings.
+func fakeAdapter(mode string) {
+	var mu sync.Mutex
+	out := json.NewEncoder(os.Stdout)
+	respond := func(resp map[string]interface{}) {
+		resp["jsonrpc"] = "2.0"
+		mu.Lock()
+		defer mu.Unlock()
+		_ = out.Encode(resp)
+	}
+
+	var wg sync.WaitGroup
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     uint64          `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+		// Stray output must not confuse the client
+		mu.Lock()
+		os.Stdout.WriteString("not a response\n")
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			switch req.Method {
+			case "ping":
+				if mode != "hang" {
+					respond(map[string]interface{}{"id": req.ID, "result": "pong"})
+				}
+			case "echo":
+				respond(map[string]interface{}{"id": req.ID, "result": req.Params})
+			case "sleep":
+				var params struct {
+					Ms    int    `json:"ms"`
+					Value string `json:"value"`
+				}
+				_ = json.Unmarshal(req.Params, &params)
+				time.Sleep(time.Duration(params.Ms) * time.Millisecond)
+				respond(map[string]interface{}{"id": req.ID, "result": params.Value})
+			case "pid":
+				respond(map[string]interface{}{"id": req.ID, "result": os.Getpid()})
+			case "crash":
+				os.Exit(3)
+			default:
+				respond(map[string]interface{}{"id": req.ID, "error": map[string]interface{}{"code": -32601, "message": "Method not found"}})
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// testPool starts a pool of fake adapters in mode
+func testPool(t *testing.T, mode string, cfg Config) *Pool {
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	cfg.Name = "test"
+	cfg.Command = exe
+	cfg.Env = append(os.Environ(), helperEnv+"="+mode)
+	if cfg.StopTimeout == 0 {
+		cfg.StopTimeout = time.Second
+	}
+	pool := NewPool(cfg)
+	t.Cleanup(func() { pool.Close() })
+	return pool
+}
+
+// pid asks an adapter in the pool for its process ID
+func pid(t *testing.T, pool *Pool) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var id int
+	require.NoError(t, pool.Call(ctx, "pid", nil, &id))
+	return id
+}
+
+func TestPoolMultiplexesCalls(t *testing.T) {
+	pool := testPool(t, "ok", Config{Size: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The slower call is sent first and answered last
+	var wg sync.WaitGroup
+	results := make([]string, 2)
+	order := make(chan int, 2)
+	for i, ms := range []int{300, 10} {
+		wg.Add(1)
+		go func(i, ms int) {
+			defer wg.Done()
+			params := map[string]interface{}{"ms": ms, "value": []string{"slow", "fast"}[i]}
+			assert.NoError(t, pool.Call(ctx, "sleep", params, &results[i]))
+			order <- i
+		}(i, ms)
+		time.Sleep(50 * time.Millisecond)
+	}
+	wg.Wait()
+	close(order)
+
+	assert.Equal(t, []string{"slow", "fast"}, results)
+	assert.Equal(t, 1, <-order)
+
+	var echoed map[string]string
+	require.NoError(t, pool.Call(ctx, "echo", map[string]string{"a": "b"}, &echoed))
+	assert.Equal(t, map[string]string{"a": "b"}, echoed)
+}
+
+func TestPoolReturnsAdapterErrors(t *testing.T) {
+	pool := testPool(t, "ok", Config{Size: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := pool.Call(ctx, "missing", nil, nil)
+	var rpcErr *RPCError
+	require.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, -32601, rpcErr.Code)
+	assert.Equal(t, "Method not found", rpcErr.Message)
+}
+
+func TestPoolRestartsCrashedAdapter(t *testing.T) {
+	pool := testPool(t, "ok", Config{Size: 1})
+	before := pid(t, pool)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// A call in flight on the crashed process fails
+	slow := make(chan error, 1)
+	go func() { slow <- pool.Call(ctx, "sleep", map[string]int{"ms": 2000}, nil) }()
+	time.Sleep(50 * time.Millisecond)
+	assert.ErrorIs(t, pool.Call(ctx, "crash", nil, nil), ErrAdapterExited)
+	assert.ErrorIs(t, <-slow, ErrAdapterExited)
+
+	assert.NotEqual(t, before, pid(t, pool))
+}
+
+func TestPoolRecyclesAdapter(t *testing.T) {
+	pool := testPool(t, "ok", Config{Size: 1, MaxRequests: 3})
+
+	first := pid(t, pool)
+	assert.Equal(t, first, pid(t, pool))
+	assert.Equal(t, first, pid(t, pool))
+	// The third call was the first process's last
+	second := pid(t, pool)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, second, pid(t, pool))
+}
+
+func TestPoolReplacesUnhealthyAdapter(t *testing.T) {
+	pool := testPool(t, "hang", Config{Size: 1, PingInterval: 100 * time.Millisecond, PingTimeout: 100 * time.Millisecond})
+	before := pid(t, pool)
+
+	assert.Eventually(t, func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		var id int
+		return pool.Call(ctx, "pid", nil, &id) == nil && id != before
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestPoolSpreadsCalls(t *testing.T) {
+	pool := testPool(t, "ok", Config{Size: 2})
+	require.Eventually(t, func() bool { return pool.Ready() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// A busy process is passed over for an idle one
+	slow := make(chan error, 1)
+	go func() { slow <- pool.Call(ctx, "sleep", map[string]int{"ms": 300}, nil) }()
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	require.NoError(t, pool.Call(ctx, "sleep", map[string]int{"ms": 10}, nil))
+	assert.Less(t, time.Since(start), 200*time.Millisecond)
+	require.NoError(t, <-slow)
+}
+
+func TestPoolClose(t *testing.T) {
+	pool := testPool(t, "ok", Config{Size: 1})
+	pid(t, pool)
+	require.NoError(t, pool.Close())
+
+	err := pool.Call(context.Background(), "pid", nil, nil)
+	assert.True(t, errors.Is(err, ErrClosed))
+	assert.Equal(t, 0, pool.Ready())
+}
+
+func TestPoolPythonAdapter(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not installed")
+	}
+	script, err := filepath.Abs(filepath.Join("..", "..", "..", "adapters", "python", "exec_wrapper.py"))
+	require.NoError(t, err)
+	if _, err := os.Stat(script); err != nil {
+		t.Skip("python adapter not found")
+	}
+
+	pool := NewPool(Config{Name: "python", Command: python, Args: []string{script, "--persistent"}, Size: 1})
+	defer pool.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	require.NoError(t, pool.Call(ctx, "ping", nil, nil))
+	var result struct {
+		Stdout     string `json:"stdout"`
+		Returncode int    `json:"returncode"`
+	}
+	require.NoError(t, pool.Call(ctx, "run", map[string]interface{}{"script": "/bin/echo", "args": []string{"hello"}}, &result))
+	assert.Equal(t, "hello\n", result.Stdout)
+	assert.Equal(t, 0, result.Returncode)
+}
@@ -1,21 +1,32 @@
 package runner
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"io"
-	"log/slog"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"sync"
 	"time"
 
-	"github.com/toxicoder/cyborg-conductor-core/pkg/proto/generated"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/config"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/output"
 )
 
 // SubprocessRunner executes external scripts with timeout enforcement
 type SubprocessRunner struct {
 	// Add any necessary configuration fields
 	config *config.Config
+	// sandbox isolates each command; nil runs them unisolated
+	sandbox *Sandbox
+	// policies each restrict what the runner's commands may do
+	policies []*ExecPolicy
+	// capture bounds the output Run keeps
+	capture CaptureLimits
+	// input is written to each command's stdin; nil gives it none
+	input []byte
 }
 
 // RunResult contains the result of a subprocess execution
@@ -23,95 +34,256 @@ type RunResult struct {
 	Stdout []byte
 	Stderr []byte
 	Err    error
+	// StdoutExcerpt and StderrExcerpt are set for a stream that passed its
+	// capture's memory limit; Stdout or Stderr then hold the stream's head,
+	// a truncation marker and its tail
+	StdoutExcerpt *output.Excerpt
+	StderrExcerpt *output.Excerpt
+	// Result is the structured result the command reported in a result
+	// frame; nil for a legacy script that wrote none
+	Result *ScriptResult
 }
 
-// NewSubprocessRunner creates a new subprocess runner
+// CaptureLimits bounds the output a run keeps in memory
+type CaptureLimits struct {
+	output.Limits
+	// SpillDir is where a stream past the memory limit is written whole,
+	// as stdout.log or stderr.log; empty keeps only its excerpts
+	SpillDir string
+}
+
+// ChunkFunc receives a streaming run's output as it is read. Chunks are
+// numbered from 1 across both streams and delivered one at a time, in
+// order.
+type ChunkFunc func(output.Chunk)
+
+// NewSubprocessRunner creates a new subprocess runner. With sandboxing
+// enabled its commands run in the sandbox for the configured level.
 func NewSubprocessRunner(cfg *config.Config) *SubprocessRunner {
-	return &SubprocessRunner{
+	r := &SubprocessRunner{
 		config: cfg,
+		capture: CaptureLimits{Limits: output.Limits{
+			Memory: cfg.Output.MemoryLimit,
+			Head:   cfg.Output.ExcerptBytes,
+			Tail:   cfg.Output.ExcerptBytes,
+		}},
 	}
+	if cfg.Sandbox.Enabled {
+		// The level is validated with the rest of the configuration
+		level, _ := ParseSecurityLevel(cfg.Sandbox.Level)
+		timeout := time.Duration(cfg.Runtime.Timeout.TaskTimeout) * time.Second
+		r.sandbox = SandboxFor(Resources{}, level, timeout)
+		r.sandbox.CgroupParent = cfg.Sandbox.CgroupParent
+	}
+	return r
+}
+
+// WithInput returns a runner whose commands read input on their stdin
+func (r *SubprocessRunner) WithInput(input []byte) *SubprocessRunner {
+	fed := *r
+	fed.input = input
+	return &fed
 }
 
 // Run executes a script with the given arguments
-// It uses exec.CommandContext with a global timeout
+// It uses exec.CommandContext with a global timeout, and keeps its output
+// within the configured capture limits
 func (r *SubprocessRunner) Run(ctx context.Context, script string, args []string) (*RunResult, error) {
-	// Create context with timeout using the configured task timeout
+	return r.RunCaptured(ctx, script, args, r.capture)
+}
+
+// RunCaptured executes a script like Run, keeping its output within
+// limits. A stream past the memory limit spills to limits.SpillDir, and
+// the result carries its excerpt.
+func (r *SubprocessRunner) RunCaptured(ctx context.Context, script string, args []string, limits CaptureLimits) (*RunResult, error) {
+	// Collect the streamed output
+	captures := make(map[output.Stream]*output.Capture)
+	for _, stream := range []output.Stream{output.Stdout, output.Stderr} {
+		var spill output.SpillFunc
+		if limits.SpillDir != "" {
+			path := filepath.Join(limits.SpillDir, string(stream)+".log")
+			spill = func() (string, error) { return path, nil }
+		}
+		captures[stream] = output.NewCapture(limits.Limits, spill)
+	}
+	result, err := r.RunStreaming(ctx, script, args, func(chunk output.Chunk) {
+		captures[chunk.Stream].Write(chunk.Data)
+	})
+	for _, capture := range captures {
+		capture.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Stdout, result.StdoutExcerpt = captures[output.Stdout].Result()
+	result.Stderr, result.StderrExcerpt = captures[output.Stderr].Result()
+	return result, nil
+}
+
+// RunStreaming executes a script like Run but hands its output to onChunk
+// as it is read instead of buffering it; the result carries no output
+func (r *SubprocessRunner) RunStreaming(ctx context.Context, script string, args []string, onChunk ChunkFunc) (*RunResult, error) {
+	// Refuse the command before anything starts if a policy forbids it
+	rules, err := r.rules(script, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create context with timeout using the configured task timeout, unless
+	// a policy overrides it
 	timeout := time.Duration(r.config.Runtime.Timeout.TaskTimeout) * time.Second
+	if rules != nil && rules.timeout > 0 {
+		timeout = rules.timeout
+	}
 	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	// Create command
 	cmd := exec.CommandContext(timeoutCtx, script, args...)
-
-	// Capture output
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	sandboxEnv := []string(nil)
+	if r.sandbox != nil {
+		sandboxEnv = append(append([]string{}, r.sandbox.Env...), resultEnv()...)
+	} else if env := resultEnv(); env != nil {
+		cmd.Env = append(os.Environ(), env...)
 	}
-
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+	if rules != nil {
+		if r.sandbox != nil {
+			sandboxEnv = rules.env
+		} else {
+			cmd.Dir = rules.dir
+			// A nil Env would inherit the conductor's whole environment
+			cmd.Env = append([]string{}, rules.env...)
+		}
 	}
 
-	// Start the command
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start command: %w", err)
+	// Stream both outputs, numbering chunks in the order they are read, and
+	// kill the command if it writes more than its policies allow. Stdout is
+	// scanned for a result frame on the way.
+	emit := &chunkEmitter{onChunk: onChunk, kill: cancel}
+	stdout := &chunkWriter{stream: output.Stdout, emit: emit, results: newResultScanner(ResultFromStdout, false)}
+	stderr := &chunkWriter{stream: output.Stderr, emit: emit}
+	if rules != nil {
+		stdout.limit, stderr.limit = rules.maxStdout, rules.maxStderr
+	}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if r.input != nil {
+		cmd.Stdin = bytes.NewReader(r.input)
 	}
 
-	// Read output asynchronously to avoid deadlocks
-	stdoutBytes, stderrBytes := make([]byte, 0), make([]byte, 0)
-	done := make(chan struct{})
+	// Give the command a descriptor for its result frame
+	results := &resultFile{}
+	if resultEnv() != nil {
+		if results, err = newResultFile(); err != nil {
+			return nil, err
+		}
+		defer results.Close()
+		cmd.ExtraFiles = []*os.File{results.file}
+	}
 
-	go func() {
-		defer close(done)
-		stdoutBytes, _ = io.ReadAll(stdout)
-	}()
+	// Hold a sandboxed command until its limits are set
+	var sandbox *sandboxRun
+	if r.sandbox != nil && cmd.Err == nil {
+		if sandbox, err = r.sandbox.wrap(cmd, sandboxEnv); err != nil {
+			return nil, fmt.Errorf("failed to set up sandbox: %w", err)
+		}
+		defer sandbox.cleanup()
+	}
 
-	go func() {
-		defer close(done)
-		stderrBytes, _ = io.ReadAll(stderr)
-	}()
+	// On timeout or cancellation terminate everything the command started,
+	// giving it the grace period to exit before it is killed. Reading stops
+	// soon after even if something that escaped the group still holds the
+	// output open.
+	group := inProcessGroup(cmd, time.Duration(r.config.Runtime.Timeout.KillGrace)*time.Second)
 
-	// Wait for command to complete or timeout
+	// Run the command to completion
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start command: %w", err)
+	}
+	// Nothing the command started outlives the run
+	defer group.reap()
+	if sandbox != nil {
+		if err := sandbox.release(cmd.Process.Pid); err != nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			return nil, fmt.Errorf("failed to apply sandbox limits: %w", err)
+		}
+	}
 	err = cmd.Wait()
 
-	// Wait for all reads to complete
-	<-done
-	<-done
-
 	// Check if command timed out
-	if timeoutCtx.Err() == context.DeadlineExceeded {
-		return nil, fmt.Errorf("command timed out after %d seconds", r.config.Runtime.Timeout.TaskTimeout)
+	if errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("command timeout after %s: %w", time.Since(start).Round(time.Millisecond), context.DeadlineExceeded)
+	}
+	if emit.exceeded != nil {
+		err = emit.exceeded
+	}
+	result, resultErr := collectResult(stdout.results, results)
+	if resultErr != nil {
+		err = errors.Join(err, resultErr)
 	}
 
-	// Handle command execution error
-	if err != nil {
-		// Decode stdout/stderr into protobuf-wrapped messages if needed
-		// For now, we'll just return the raw output and error
-		return &RunResult{
-			Stdout: stdoutBytes,
-			Stderr: stderrBytes,
-			Err:    err,
-		}, nil
-	}
-
-	// Success case
-	return &RunResult{
-		Stdout: stdoutBytes,
-		Stderr: stderrBytes,
-		Err:    nil,
-	}, nil
+	// A failed command still reports its output; the error is kept with
+	// the result
+	return &RunResult{Err: err, Result: result}, nil
+}
+
+// chunkEmitter numbers chunks from both streams and delivers them one at
+// a time
+type chunkEmitter struct {
+	mu      sync.Mutex
+	seq     uint64
+	onChunk ChunkFunc
+	// kill stops the command once a stream passes its limit, recorded in
+	// exceeded
+	kill     context.CancelFunc
+	exceeded error
+}
+
+// chunkWriter turns each write of a command's output into a chunk
+type chunkWriter struct {
+	stream output.Stream
+	emit   *chunkEmitter
+	// limit caps the bytes passed on from the stream; zero is no limit
+	limit   int64
+	written int64
+	// results, if set, scans the stream for result frames
+	results *resultScanner
 }
 
-// RunWithPolicy executes a script with the given policy
-func (r *SubprocessRunner) RunWithPolicy(ctx context.Context, script string, args []string, policy interface{}) (*RunResult, error) {
-	// Apply policy if needed
-	if policy != nil {
-		// Enforce policy constraints
-		// For example, check resource limits, etc.
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.emit.mu.Lock()
+	defer w.emit.mu.Unlock()
+	n := len(p)
+	if w.emit.exceeded != nil {
+		// The command is being killed; what it still writes is dropped
+		return n, nil
+	}
+	if w.limit > 0 && w.written+int64(len(p)) > w.limit {
+		p = p[:w.limit-w.written]
+		w.emit.exceeded = fmt.Errorf("%w: %s passed %d bytes", ErrOutputLimit, w.stream, w.limit)
+		w.emit.kill()
+		if len(p) == 0 {
+			return n, nil
+		}
+	}
+	w.written += int64(len(p))
+	if w.results != nil {
+		w.results.Write(p)
 	}
+	w.emit.seq++
+	w.emit.onChunk(output.Chunk{
+		Seq:    w.emit.seq,
+		Stream: w.stream,
+		Data:   append([]byte(nil), p...),
+		At:     time.Now(),
+	})
+	return n, nil
+}
 
-	// Call the main Run method
-	return r.Run(ctx, script, args)
-}
\ No newline at end of file
+// RunWithPolicy executes a script under the given policy, on top of any
+// the runner already has. A command the policy forbids is refused with a
+// *PolicyViolation before it starts.
+func (r *SubprocessRunner) RunWithPolicy(ctx context.Context, script string, args []string, policy *ExecPolicy) (*RunResult, error) {
+	return r.WithPolicies(policy).Run(ctx, script, args)
+}
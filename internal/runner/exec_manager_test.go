@@ -2,16 +2,31 @@ package runner
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/config"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/output"
 )
 
+// testConfig returns a configuration with a task timeout long enough for
+// the tests' commands
+func testConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Runtime.Timeout.TaskTimeout = 10
+	return cfg
+}
+
 // TestSubprocessRunner tests the subprocess runner implementation
 func TestSubprocessRunner(t *testing.T) {
 	// Create subprocess runner
-	runner := NewSubprocessRunner()
+	runner := NewSubprocessRunner(testConfig())
 	
 	// Test that runner can be created
 	assert.NotNil(t, runner)
@@ -20,7 +35,7 @@ func TestSubprocessRunner(t *testing.T) {
 // TestSubprocessRunnerRun tests running a simple command
 func TestSubprocessRunnerRun(t *testing.T) {
 	// Create subprocess runner
-	runner := NewSubprocessRunner()
+	runner := NewSubprocessRunner(testConfig())
 	
 	// Test with a simple command that should succeed
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -38,7 +53,7 @@ func TestSubprocessRunnerRun(t *testing.T) {
 // TestSubprocessRunnerTimeout tests timeout handling
 func TestSubprocessRunnerTimeout(t *testing.T) {
 	// Create subprocess runner
-	runner := NewSubprocessRunner()
+	runner := NewSubprocessRunner(testConfig())
 	
 	// Test with a command that will timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
@@ -56,7 +71,7 @@ func TestSubprocessRunnerTimeout(t *testing.T) {
 // TestSubprocessRunnerError tests error handling
 func TestSubprocessRunnerError(t *testing.T) {
 	// Create subprocess runner
-	runner := NewSubprocessRunner()
+	runner := NewSubprocessRunner(testConfig())
 	
 	// Test with a command that should fail
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -72,7 +87,7 @@ func TestSubprocessRunnerError(t *testing.T) {
 // TestSubprocessRunnerWithPolicy tests running with policy
 func TestSubprocessRunnerWithPolicy(t *testing.T) {
 	// Create subprocess runner
-	runner := NewSubprocessRunner()
+	runner := NewSubprocessRunner(testConfig())
 	
 	// Test with a simple command
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -83,4 +98,77 @@ func TestSubprocessRunnerWithPolicy(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.NotEmpty(t, result.Stdout)
-}
\ No newline at end of file
+}
+
+// TestSubprocessRunnerStreaming tests that output is delivered while the
+// command runs, in order and numbered across both streams
+func TestSubprocessRunnerStreaming(t *testing.T) {
+	runner := NewSubprocessRunner(testConfig())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The script waits for each line to be delivered before writing the
+	// next, which keeps the two streams, read separately, in a known order
+	// and shows output arrives while the command runs
+	acks := t.TempDir()
+	script := `echo one; until [ -e "$0/1" ]; do sleep 0.01; done
+		echo two >&2; until [ -e "$0/2" ]; do sleep 0.01; done
+		echo three`
+	var chunks []output.Chunk
+	result, err := runner.RunStreaming(ctx, "sh", []string{"-c", script, acks}, func(chunk output.Chunk) {
+		chunks = append(chunks, chunk)
+		_ = os.WriteFile(filepath.Join(acks, strconv.FormatUint(chunk.Seq, 10)), nil, 0o644)
+	})
+
+	require.NoError(t, err)
+	assert.NoError(t, result.Err)
+	assert.Nil(t, result.Stdout)
+	require.Len(t, chunks, 3)
+	assert.Equal(t, []byte("one\n"), chunks[0].Data)
+	assert.Equal(t, output.Stderr, chunks[1].Stream)
+	assert.Equal(t, []byte("three\n"), chunks[2].Data)
+	for i, chunk := range chunks {
+		assert.Equal(t, uint64(i+1), chunk.Seq)
+	}
+	assert.False(t, chunks[1].At.Before(chunks[0].At))
+}
+
+// TestSubprocessRunnerCapture tests that output past the capture limit
+// spills to disk, leaving excerpts and a truncation marker in the result
+func TestSubprocessRunnerCapture(t *testing.T) {
+	runner := NewSubprocessRunner(testConfig())
+	dir := filepath.Join(t.TempDir(), "job-1")
+	limits := CaptureLimits{Limits: output.Limits{Memory: 64, Head: 6, Tail: 5}, SpillDir: dir}
+
+	script := "echo start; i=0; while [ $i -lt 100 ]; do echo line $i; i=$((i+1)); done; echo end; echo oops >&2"
+	result, err := runner.RunCaptured(context.Background(), "sh", []string{"-c", script}, limits)
+	require.NoError(t, err)
+	require.NoError(t, result.Err)
+
+	require.NotNil(t, result.StdoutExcerpt)
+	assert.Equal(t, "start\n", string(result.StdoutExcerpt.Head))
+	assert.Equal(t, "\nend\n", string(result.StdoutExcerpt.Tail))
+	assert.Contains(t, string(result.Stdout), "bytes truncated")
+	assert.True(t, strings.HasPrefix(string(result.Stdout), "start\n"))
+
+	full, err := os.ReadFile(result.StdoutExcerpt.SpillPath)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(full)), result.StdoutExcerpt.Size)
+	assert.Contains(t, string(full), "line 99\nend\n")
+
+	// Stderr stayed within the limit
+	assert.Nil(t, result.StderrExcerpt)
+	assert.Equal(t, "oops\n", string(result.Stderr))
+	assert.NoFileExists(t, filepath.Join(dir, "stderr.log"))
+}
+
+// TestSubprocessRunnerInput tests that a command reads the runner's input
+// on its stdin
+func TestSubprocessRunnerInput(t *testing.T) {
+	runner := NewSubprocessRunner(testConfig()).WithInput([]byte("from stdin"))
+	result, err := runner.Run(context.Background(), "cat", nil)
+	require.NoError(t, err)
+	require.NoError(t, result.Err)
+	assert.Equal(t, "from stdin", string(result.Stdout))
+}
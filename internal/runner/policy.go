@@ -0,0 +1,338 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ExecPolicy restricts what a command may run and how. Commands are
+// checked before they start; the zero policy allows anything.
+type ExecPolicy struct {
+	// Executables allowlists what may run; empty allows any executable
+	Executables []AllowedExecutable `json:"executables"`
+	// AllowShell lets a shell run, even one allowlisted by Executables
+	AllowShell bool `json:"allow_shell"`
+
+	// AllowEnv and DenyEnv filter the environment the command gets: the
+	// conductor's, or a sandboxed command's extra Env. A name ending in *
+	// matches every variable it prefixes. Empty AllowEnv allows every
+	// variable DenyEnv does not deny.
+	AllowEnv []string `json:"allow_env"`
+	DenyEnv  []string `json:"deny_env"`
+
+	// WorkDir is the directory the command runs in; empty runs it in the
+	// conductor's. A sandboxed command always runs in its sandbox.
+	WorkDir string `json:"work_dir"`
+	// WorkDirs lists the directories, with everything under them, the
+	// command may run in; empty allows any
+	WorkDirs []string `json:"work_dirs"`
+
+	// MaxStdout and MaxStderr cap the bytes read from each stream; a
+	// command that writes more is killed
+	MaxStdout int64 `json:"max_stdout"`
+	MaxStderr int64 `json:"max_stderr"`
+	// Timeout, in seconds, replaces the configured task timeout
+	Timeout int `json:"timeout"`
+}
+
+// AllowedExecutable is an executable a policy lets run
+type AllowedExecutable struct {
+	// Name is a base name, matched against the executable's, or an
+	// absolute path, matched against where the executable was found
+	Name string `json:"name"`
+	// Args must match the whole argument list joined by spaces; nil allows
+	// any arguments
+	Args *regexp.Regexp `json:"args"`
+
+	// anchored is Args bound to the whole argument list; LoadPolicies
+	// compiles it once for each policy it reads
+	anchored *regexp.Regexp
+}
+
+// argsPattern returns Args bound to the whole argument list
+func (e *AllowedExecutable) argsPattern() *regexp.Regexp {
+	if e.anchored != nil {
+		return e.anchored
+	}
+	return anchor(e.Args)
+}
+
+// anchor binds a pattern to the whole of the text it matches
+func anchor(re *regexp.Regexp) *regexp.Regexp {
+	return regexp.MustCompile(`^(?:` + re.String() + `)$`)
+}
+
+// PolicyRule names the rule a command broke
+type PolicyRule string
+
+const (
+	RuleExecutable PolicyRule = "executable"
+	RuleArguments  PolicyRule = "arguments"
+	RuleShell      PolicyRule = "shell"
+	RuleWorkDir    PolicyRule = "work_dir"
+)
+
+// ErrPolicyViolation is wrapped by every PolicyViolation
+var ErrPolicyViolation = errors.New("execution policy violation")
+
+// PolicyViolation is returned for a command an execution policy refused
+// to start
+type PolicyViolation struct {
+	Rule PolicyRule
+	// Command is the executable as it was given
+	Command string
+	Reason  string
+}
+
+func (e *PolicyViolation) Error() string {
+	return fmt.Sprintf("%s refused by execution policy (%s): %s", e.Command, e.Rule, e.Reason)
+}
+
+func (e *PolicyViolation) Unwrap() error {
+	return ErrPolicyViolation
+}
+
+// ErrOutputLimit is returned for a command killed for writing more output
+// than its policy allows
+var ErrOutputLimit = errors.New("output limit exceeded")
+
+// shells are the executables AllowShell governs
+var shells = map[string]bool{
+	"sh": true, "bash": true, "dash": true, "ash": true, "zsh": true, "ksh": true,
+	"mksh": true, "csh": true, "tcsh": true, "fish": true, "busybox": true,
+}
+
+// WithPolicies returns a runner whose commands must satisfy every one of
+// policies, on top of any the runner already has. The tightest output caps
+// and timeout apply, and the environment passes every policy's filter.
+func (r *SubprocessRunner) WithPolicies(policies ...*ExecPolicy) *SubprocessRunner {
+	restricted := *r
+	restricted.policies = append([]*ExecPolicy(nil), r.policies...)
+	for _, p := range policies {
+		if p != nil {
+			restricted.policies = append(restricted.policies, p)
+		}
+	}
+	return &restricted
+}
+
+// check refuses a command the policy does not allow to run in dir
+func (p *ExecPolicy) check(script string, args []string, dir string) error {
+	violation := func(rule PolicyRule, format string, a ...interface{}) error {
+		return &PolicyViolation{Rule: rule, Command: script, Reason: fmt.Sprintf(format, a...)}
+	}
+
+	// Judge the executable by where it would be found and what it links to
+	path, err := exec.LookPath(script)
+	if err != nil {
+		path = script
+	}
+	names := []string{filepath.Base(path)}
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		names = append(names, filepath.Base(target))
+	}
+	if !p.AllowShell {
+		for _, name := range names {
+			if shells[name] {
+				return violation(RuleShell, "shells are not allowed")
+			}
+		}
+	}
+
+	if len(p.Executables) > 0 {
+		allowed := p.allowed(path)
+		if allowed == nil {
+			return violation(RuleExecutable, "%s is not allowlisted", path)
+		}
+		joined := strings.Join(args, " ")
+		if allowed.Args != nil && !allowed.argsPattern().MatchString(joined) {
+			return violation(RuleArguments, "arguments %q do not match %q", joined, allowed.Args)
+		}
+	}
+
+	if len(p.WorkDirs) > 0 && dir != "" {
+		if !within(dir, p.WorkDirs) {
+			return violation(RuleWorkDir, "%s is outside the allowed directories", dir)
+		}
+	}
+	return nil
+}
+
+// allowed returns the entry allowlisting the executable at path
+func (p *ExecPolicy) allowed(path string) *AllowedExecutable {
+	for i, e := range p.Executables {
+		if filepath.IsAbs(e.Name) {
+			if filepath.Clean(e.Name) == filepath.Clean(path) {
+				return &p.Executables[i]
+			}
+		} else if e.Name == filepath.Base(path) {
+			return &p.Executables[i]
+		}
+	}
+	return nil
+}
+
+// environ filters env, a list of NAME=VALUE entries, through the policy's
+// allow and deny lists
+func (p *ExecPolicy) environ(env []string) []string {
+	var kept []string
+	for _, entry := range env {
+		name, _, _ := strings.Cut(entry, "=")
+		if len(p.AllowEnv) > 0 && !matchesName(name, p.AllowEnv) {
+			continue
+		}
+		if matchesName(name, p.DenyEnv) {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// matchesName reports whether an environment variable is named by
+// patterns, where a trailing * matches any suffix
+func matchesName(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether dir is one of roots or under one, after
+// resolving symlinks
+func within(dir string, roots []string) bool {
+	dir = resolveDir(dir)
+	for _, root := range roots {
+		rel, err := filepath.Rel(resolveDir(root), dir)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveDir makes dir absolute and resolves its symlinks where it can
+func resolveDir(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	return dir
+}
+
+// runRules is what a runner's policies add up to for one command
+type runRules struct {
+	dir                  string
+	env                  []string
+	maxStdout, maxStderr int64
+	timeout              time.Duration
+}
+
+// rules checks a command against the runner's policies and combines what
+// they require of it; nil when the runner has no policies
+func (r *SubprocessRunner) rules(script string, args []string) (*runRules, error) {
+	if len(r.policies) == 0 {
+		return nil, nil
+	}
+	rules := &runRules{env: append(os.Environ(), resultEnv()...)}
+	if r.sandbox != nil {
+		rules.env = append(append([]string{}, r.sandbox.Env...), resultEnv()...)
+	}
+	for _, p := range r.policies {
+		if rules.dir == "" && r.sandbox == nil {
+			rules.dir = p.WorkDir
+		}
+		rules.maxStdout = tightest(rules.maxStdout, p.MaxStdout)
+		rules.maxStderr = tightest(rules.maxStderr, p.MaxStderr)
+		timeout := time.Duration(p.Timeout) * time.Second
+		if timeout > 0 && (rules.timeout == 0 || timeout < rules.timeout) {
+			rules.timeout = timeout
+		}
+		rules.env = p.environ(rules.env)
+	}
+
+	// A sandboxed command runs in its own private directory
+	dir := rules.dir
+	if dir == "" && r.sandbox == nil {
+		dir, _ = os.Getwd()
+	}
+	for _, p := range r.policies {
+		if err := p.check(script, args, dir); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// tightest returns the smaller of two limits, where zero is no limit
+func tightest(a, b int64) int64 {
+	if a == 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// PolicySet holds the execution policies attached to cyborgs and to
+// capabilities. A nil *PolicySet attaches none.
+type PolicySet struct {
+	Cyborgs      map[string]*ExecPolicy `json:"cyborgs"`
+	Capabilities map[string]*ExecPolicy `json:"capabilities"`
+}
+
+// LoadPolicies reads a PolicySet from a JSON file
+func LoadPolicies(path string) (*PolicySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read execution policies: %w", err)
+	}
+	var set PolicySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("execution policies %s: %w", path, err)
+	}
+	for _, policies := range []map[string]*ExecPolicy{set.Cyborgs, set.Capabilities} {
+		for _, p := range policies {
+			if p == nil {
+				continue
+			}
+			for i := range p.Executables {
+				if e := &p.Executables[i]; e.Args != nil {
+					e.anchored = anchor(e.Args)
+				}
+			}
+		}
+	}
+	return &set, nil
+}
+
+// For returns the policies a command run on a cyborg for capabilities must
+// satisfy: the cyborg's and each capability's
+func (s *PolicySet) For(cyborgID string, capabilities []string) []*ExecPolicy {
+	if s == nil {
+		return nil
+	}
+	var policies []*ExecPolicy
+	if p := s.Cyborgs[cyborgID]; p != nil {
+		policies = append(policies, p)
+	}
+	for _, capability := range capabilities {
+		if p := s.Capabilities[capability]; p != nil {
+			policies = append(policies, p)
+		}
+	}
+	return policies
+}
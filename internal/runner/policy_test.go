@@ -0,0 +1,144 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecPolicyRejectsBeforeStart tests that each rule refuses a command
+// with a structured violation before it starts
+func TestExecPolicyRejectsBeforeStart(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "ran")
+	policy := &ExecPolicy{
+		Executables: []AllowedExecutable{
+			{Name: "echo", Args: regexp.MustCompile(`[a-z]+`)},
+			{Name: "touch"},
+			{Name: "sh"},
+		},
+		WorkDirs: []string{dir},
+		WorkDir:  dir,
+	}
+	runner := NewSubprocessRunner(testConfig())
+
+	tests := []struct {
+		name   string
+		script string
+		args   []string
+		policy *ExecPolicy
+		rule   PolicyRule
+	}{
+		{"executable", "cat", []string{marker}, policy, RuleExecutable},
+		{"arguments", "echo", []string{"hello", "world"}, policy, RuleArguments},
+		{"shell", "sh", []string{"-c", "touch " + marker}, policy, RuleShell},
+		{"work dir", "touch", []string{marker}, &ExecPolicy{WorkDirs: []string{dir}, WorkDir: os.TempDir()}, RuleWorkDir},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := runner.RunWithPolicy(context.Background(), tt.script, tt.args, tt.policy)
+			assert.Nil(t, result)
+			require.ErrorIs(t, err, ErrPolicyViolation)
+
+			var violation *PolicyViolation
+			require.True(t, errors.As(err, &violation))
+			assert.Equal(t, tt.rule, violation.Rule)
+			assert.Equal(t, tt.script, violation.Command)
+			assert.NoFileExists(t, marker)
+		})
+	}
+
+	// Allowed commands run in the policy's directory
+	result, err := runner.RunWithPolicy(context.Background(), "echo", []string{"hello"}, policy)
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", string(result.Stdout))
+	_, err = runner.RunWithPolicy(context.Background(), "touch", []string{"ran"}, policy)
+	require.NoError(t, err)
+	assert.FileExists(t, marker)
+}
+
+// TestExecPolicyFiltersEnvironment tests that only allowed and undenied
+// variables reach the command
+func TestExecPolicyFiltersEnvironment(t *testing.T) {
+	t.Setenv("CYBORG_KEEP", "kept")
+	t.Setenv("CYBORG_SECRET", "hidden")
+	t.Setenv("OTHER_VAR", "dropped")
+	policy := &ExecPolicy{AllowEnv: []string{"CYBORG_*", "PATH"}, DenyEnv: []string{"CYBORG_SECRET"}}
+
+	result, err := NewSubprocessRunner(testConfig()).RunWithPolicy(context.Background(), "env", nil, policy)
+	require.NoError(t, err)
+	env := string(result.Stdout)
+	assert.Contains(t, env, "CYBORG_KEEP=kept")
+	assert.NotContains(t, env, "CYBORG_SECRET")
+	assert.NotContains(t, env, "OTHER_VAR")
+
+	// Denying everything leaves an empty environment, not the conductor's
+	result, err = NewSubprocessRunner(testConfig()).RunWithPolicy(context.Background(), "/usr/bin/env", nil, &ExecPolicy{DenyEnv: []string{"*"}})
+	require.NoError(t, err)
+	assert.Empty(t, strings.TrimSpace(string(result.Stdout)))
+}
+
+// TestExecPolicyLimitsOutput tests that a command writing past its cap is
+// killed with what it wrote up to the cap
+func TestExecPolicyLimitsOutput(t *testing.T) {
+	policy := &ExecPolicy{AllowShell: true, MaxStdout: 10}
+	start := time.Now()
+	result, err := NewSubprocessRunner(testConfig()).RunWithPolicy(context.Background(), "sh", []string{"-c", "while :; do echo 0123456789; done"}, policy)
+	require.NoError(t, err)
+	assert.ErrorIs(t, result.Err, ErrOutputLimit)
+	assert.Equal(t, "0123456789", string(result.Stdout))
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// Output within the cap is untouched
+	result, err = NewSubprocessRunner(testConfig()).RunWithPolicy(context.Background(), "echo", []string{"short"}, policy)
+	require.NoError(t, err)
+	assert.NoError(t, result.Err)
+}
+
+// TestExecPolicyTimeout tests that a policy's timeout replaces the
+// configured one, and that the tightest of several applies
+func TestExecPolicyTimeout(t *testing.T) {
+	runner := NewSubprocessRunner(testConfig()).WithPolicies(&ExecPolicy{Timeout: 5})
+	start := time.Now()
+	_, err := runner.RunWithPolicy(context.Background(), "sleep", []string{"3"}, &ExecPolicy{Timeout: 1})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+// TestPolicySet tests that a command gets its cyborg's policy and those of
+// its capabilities
+func TestPolicySet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"cyborgs": {"SWEN1001": {"executables": [{"name": "git", "args": "(status|diff)( .*)?"}]}},
+		"capabilities": {"deploy": {"allow_shell": true, "timeout": 60}}
+	}`), 0o644))
+
+	set, err := LoadPolicies(path)
+	require.NoError(t, err)
+	policies := set.For("SWEN1001", []string{"deploy", "review"})
+	require.Len(t, policies, 2)
+	assert.True(t, policies[0].Executables[0].Args.MatchString("status"))
+	assert.Equal(t, 60, policies[1].Timeout)
+	assert.Empty(t, set.For("SALE0001", nil))
+
+	// Argument patterns are compiled once, bound to the whole list
+	require.NotNil(t, policies[0].Executables[0].anchored)
+	assert.NoError(t, policies[0].check("git", []string{"diff", "HEAD"}, ""))
+	assert.ErrorIs(t, policies[0].check("git", []string{"push", "--force", "status"}, ""), ErrPolicyViolation)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"cyborgs": {"SWEN1001": {"executables": [{"name": "git", "args": "("}]}}}`), 0o644))
+	_, err = LoadPolicies(path)
+	assert.Error(t, err)
+
+	var none *PolicySet
+	assert.Nil(t, none.For("SWEN1001", []string{"deploy"}))
+}
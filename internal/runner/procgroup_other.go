@@ -0,0 +1,20 @@
+//go:build !unix
+
+package runner
+
+import (
+	"os/exec"
+	"time"
+)
+
+// processGroup stands in for process groups where there are none; the
+// command alone is killed
+type processGroup struct{}
+
+// inProcessGroup leaves cmd to be killed when its context is done
+func inProcessGroup(cmd *exec.Cmd, grace time.Duration) *processGroup {
+	cmd.WaitDelay = grace + time.Second
+	return &processGroup{}
+}
+
+func (g *processGroup) reap() {}
@@ -0,0 +1,71 @@
+//go:build unix
+
+package runner
+
+import (
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// processGroup terminates everything a command started, not just the
+// command itself
+type processGroup struct {
+	cmd *exec.Cmd
+
+	mu   sync.Mutex
+	kill *time.Timer
+	done bool
+}
+
+// inProcessGroup starts cmd in a process group of its own. Once cmd's
+// context is done the group gets SIGTERM, and SIGKILL grace later.
+func inProcessGroup(cmd *exec.Cmd, grace time.Duration) *processGroup {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+
+	g := &processGroup{cmd: cmd}
+	cmd.Cancel = func() error {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.done || g.kill != nil {
+			return nil
+		}
+		g.signal(syscall.SIGTERM)
+		g.kill = time.AfterFunc(grace, func() {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			if !g.done {
+				g.signal(syscall.SIGKILL)
+			}
+		})
+		return nil
+	}
+	// Go kills only the command, so it must not step in before the group
+	// has been killed; the extra second lets output still in flight be read
+	cmd.WaitDelay = grace + time.Second
+	return g
+}
+
+// reap kills whatever the command left running in its group once it has
+// been waited for
+func (g *processGroup) reap() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.kill != nil {
+		g.kill.Stop()
+	}
+	if !g.done && g.cmd.Process != nil {
+		g.signal(syscall.SIGKILL)
+	}
+	g.done = true
+}
+
+// signal sends sig to the whole group; callers hold mu
+func (g *processGroup) signal(sig syscall.Signal) {
+	// The group is named by the command's pid, which it leads
+	_ = syscall.Kill(-g.cmd.Process.Pid, sig)
+}
@@ -0,0 +1,130 @@
+//go:build unix
+
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/output"
+)
+
+// alive reports whether a process exists and has not exited; an exited
+// orphan may linger as a zombie until init reaps it
+func alive(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return false
+	}
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if os.IsNotExist(err) && runtime.GOOS == "linux" {
+		// Reaped since kill looked
+		return false
+	}
+	if err != nil {
+		// Without /proc, kill's answer stands
+		return true
+	}
+	// The state follows the parenthesised command name
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
+
+// readPID waits for a script to write a pid to path
+func readPID(t *testing.T, path string) int {
+	var pid int
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return false
+		}
+		pid, err = strconv.Atoi(strings.TrimSpace(string(data)))
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	return pid
+}
+
+// runUntilTimeout runs a script with a short deadline and returns its
+// error, its output and how long the run took
+func runUntilTimeout(t *testing.T, grace int64, script string) (error, string, time.Duration) {
+	cfg := testConfig()
+	cfg.Runtime.Timeout.KillGrace = grace
+	runner := NewSubprocessRunner(cfg)
+
+	// Timed from before the deadline is set, so the run cannot seem to
+	// take less than the deadline plus the grace period
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	var out strings.Builder
+	_, err := runner.RunStreaming(ctx, "sh", []string{"-c", script}, func(chunk output.Chunk) {
+		out.Write(chunk.Data)
+	})
+	return err, out.String(), time.Since(start)
+}
+
+// TestTimeoutKillsGrandchildren tests that processes a script forks are
+// killed with it, and that they cannot hold the run open through its
+// output
+func TestTimeoutKillsGrandchildren(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	err, _, took := runUntilTimeout(t, 1, "sleep 30 & echo $! > "+pidFile+"; sleep 30")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, took, 3*time.Second)
+	pid := readPID(t, pidFile)
+	assert.Eventually(t, func() bool { return !alive(pid) }, time.Second, 10*time.Millisecond)
+}
+
+// TestTimeoutKillsDoubleForked tests that a process orphaned by a double
+// fork, and so no longer the command's descendant, is still killed
+func TestTimeoutKillsDoubleForked(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	err, _, took := runUntilTimeout(t, 1, "(sleep 30 & echo $! > "+pidFile+"); sleep 30")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, took, 3*time.Second)
+	pid := readPID(t, pidFile)
+	assert.Eventually(t, func() bool { return !alive(pid) }, time.Second, 10*time.Millisecond)
+}
+
+// TestTimeoutTerminatesGracefully tests that a command is asked to stop
+// with SIGTERM and may clean up within the grace period
+func TestTimeoutTerminatesGracefully(t *testing.T) {
+	err, out, took := runUntilTimeout(t, 5, "trap 'echo cleaned up; exit 0' TERM; while :; do sleep 0.05; done")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, out, "cleaned up")
+	assert.Less(t, took, 2*time.Second)
+}
+
+// TestTimeoutKillsAfterGrace tests that a command ignoring SIGTERM is
+// killed once the grace period is over
+func TestTimeoutKillsAfterGrace(t *testing.T) {
+	err, _, took := runUntilTimeout(t, 1, "trap '' TERM; sleep 30")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, took, 1300*time.Millisecond)
+	assert.Less(t, took, 3*time.Second)
+}
+
+// TestExitKillsBackgroundProcesses tests that a command that exits leaves
+// nothing running behind it
+func TestExitKillsBackgroundProcesses(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	result, err := NewSubprocessRunner(testConfig()).Run(context.Background(), "sh",
+		[]string{"-c", "sleep 30 >/dev/null 2>&1 & echo $! > " + pidFile})
+	require.NoError(t, err)
+	require.NoError(t, result.Err)
+
+	pid := readPID(t, pidFile)
+	assert.Eventually(t, func() bool { return !alive(pid) }, time.Second, 10*time.Millisecond)
+}
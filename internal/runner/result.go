@@ -0,0 +1,535 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// A command may report a structured result by writing a result frame to
+// stdout, or to the descriptor named by CYBORG_RESULT_FD. A frame starts a
+// line with ResultMarker and is either a JSON line
+//
+//	::cyborg-result::json {"status": {"code": "SUCCESS", "message": "done"}, "payload": {...}}
+//
+// or a length-delimited envelope.v1.ScriptResult message after a header
+// line
+//
+//	::cyborg-result::proto
+//	<varint length><ScriptResult>
+//
+// Commands that write no frame are legacy scripts, whose output is kept
+// as raw bytes alone.
+const (
+	// ResultMarker starts a result frame
+	ResultMarker = "::cyborg-result::"
+	// ResultFD is the descriptor a command may write its result frame to,
+	// which ResultFDEnv also names
+	ResultFD    = 3
+	ResultFDEnv = "CYBORG_RESULT_FD"
+	// MaxResultFrame caps the size of a result frame
+	MaxResultFrame = 1 << 20
+)
+
+// StatusCode is a reported result's code, matching
+// envelope.v1.ResultStatus.StatusCode
+type StatusCode int32
+
+const (
+	StatusUnknown StatusCode = iota
+	StatusSuccess
+	StatusFailure
+	StatusTimeout
+	StatusRejected
+	StatusRetryableFailure
+)
+
+var statusNames = map[StatusCode]string{
+	StatusUnknown:          "UNKNOWN",
+	StatusSuccess:          "SUCCESS",
+	StatusFailure:          "FAILURE",
+	StatusTimeout:          "TIMEOUT",
+	StatusRejected:         "REJECTED",
+	StatusRetryableFailure: "RETRYABLE_FAILURE",
+}
+
+func (c StatusCode) String() string {
+	if name, ok := statusNames[c]; ok {
+		return name
+	}
+	return strconv.Itoa(int(c))
+}
+
+// MarshalJSON encodes the code by name
+func (c StatusCode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+// UnmarshalJSON accepts a code's name or number
+func (c *StatusCode) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var number int32
+		if err := json.Unmarshal(data, &number); err != nil {
+			return fmt.Errorf("status code must be a name or number, got %s", data)
+		}
+		name = strconv.Itoa(int(number))
+	}
+	code, err := ParseStatusCode(name)
+	if err != nil {
+		return err
+	}
+	*c = code
+	return nil
+}
+
+// ParseStatusCode returns the code with a name, such as "REJECTED", or
+// number
+func ParseStatusCode(name string) (StatusCode, error) {
+	for code, known := range statusNames {
+		if name == known || name == strconv.Itoa(int(code)) {
+			return code, nil
+		}
+	}
+	return StatusUnknown, fmt.Errorf("unknown status code %q", name)
+}
+
+// ResultStatus is the status a command reported, matching
+// envelope.v1.ResultStatus
+type ResultStatus struct {
+	Code         StatusCode `json:"code"`
+	Message      string     `json:"message,omitempty"`
+	TimestampMs  int64      `json:"timestamp_ms,omitempty"`
+	ErrorDetails string     `json:"error_details,omitempty"`
+}
+
+// ResultSource is where a command's result frame was read from
+type ResultSource string
+
+const (
+	ResultFromStdout ResultSource = "stdout"
+	ResultFromFD     ResultSource = "result fd"
+)
+
+// ScriptResult is the structured result a command reported
+type ScriptResult struct {
+	Status ResultStatus `json:"status"`
+	// Payload is the frame's payload: the bytes of a protobuf frame, or
+	// the JSON of a JSON frame's payload, unquoted if it is a string
+	Payload []byte       `json:"payload,omitempty"`
+	Source  ResultSource `json:"source"`
+}
+
+// ErrReportedFailure is wrapped by every ReportedError
+var ErrReportedFailure = errors.New("command reported failure")
+
+// ReportedError is returned for a command that reported a status other
+// than success
+type ReportedError struct {
+	Status ResultStatus
+}
+
+func (e *ReportedError) Error() string {
+	msg := fmt.Sprintf("command reported %s", e.Status.Code)
+	if e.Status.Message != "" {
+		msg += ": " + e.Status.Message
+	}
+	if e.Status.ErrorDetails != "" {
+		msg += " (" + e.Status.ErrorDetails + ")"
+	}
+	return msg
+}
+
+func (e *ReportedError) Unwrap() error {
+	return ErrReportedFailure
+}
+
+// Err returns nil if the command reported success, and a *ReportedError
+// otherwise
+func (r *ScriptResult) Err() error {
+	if r.Status.Code == StatusSuccess {
+		return nil
+	}
+	return &ReportedError{Status: r.Status}
+}
+
+// ErrMalformedResult is wrapped by every ResultFrameError
+var ErrMalformedResult = errors.New("malformed result frame")
+
+// ResultFrameError is returned for a result frame that could not be read
+type ResultFrameError struct {
+	Source ResultSource
+	Reason string
+}
+
+func (e *ResultFrameError) Error() string {
+	return fmt.Sprintf("malformed result frame on %s: %s", e.Source, e.Reason)
+}
+
+func (e *ResultFrameError) Unwrap() error {
+	return ErrMalformedResult
+}
+
+// resultScanner picks result frames out of a stream as it is written
+type resultScanner struct {
+	source ResultSource
+	// strict refuses anything but frames and whitespace, as on the result
+	// descriptor
+	strict bool
+	// lineStart is set while the next byte starts a line; marker holds the
+	// start of a line that matches the marker so far
+	lineStart bool
+	marker    []byte
+	// frame holds the frame being read, after its marker
+	reading bool
+	frame   []byte
+
+	results []*ScriptResult
+	err     error
+}
+
+func newResultScanner(source ResultSource, strict bool) *resultScanner {
+	return &resultScanner{source: source, strict: strict, lineStart: true}
+}
+
+// Write scans more of the stream; once a frame is malformed the rest is
+// ignored
+func (s *resultScanner) Write(p []byte) {
+	for len(p) > 0 && s.err == nil {
+		switch {
+		case s.reading:
+			p = s.readFrame(p)
+		case s.lineStart:
+			p = s.readMarker(p)
+		default:
+			// The rest of the line is plain output
+			line := p
+			if i := bytes.IndexByte(p, '\n'); i >= 0 {
+				line, p, s.lineStart = p[:i], p[i+1:], true
+			} else {
+				p = nil
+			}
+			if s.strict && len(bytes.TrimSpace(line)) > 0 {
+				s.fail("unexpected output outside a frame")
+			}
+		}
+	}
+}
+
+// readMarker matches the start of a line against the marker
+func (s *resultScanner) readMarker(p []byte) []byte {
+	for len(p) > 0 && len(s.marker) < len(ResultMarker) {
+		if p[0] != ResultMarker[len(s.marker)] {
+			if s.strict && len(s.marker) > 0 {
+				s.fail("unexpected output outside a frame")
+			}
+			s.marker = s.marker[:0]
+			if p[0] == '\n' {
+				return p[1:]
+			}
+			s.lineStart = false
+			return p
+		}
+		s.marker = append(s.marker, p[0])
+		p = p[1:]
+	}
+	if len(s.marker) == len(ResultMarker) {
+		s.marker = s.marker[:0]
+		s.reading = true
+		s.frame = s.frame[:0]
+	}
+	return p
+}
+
+// readFrame adds to the frame being read, decoding it once it is whole
+func (s *resultScanner) readFrame(p []byte) []byte {
+	held := len(s.frame)
+	s.frame = append(s.frame, p...)
+	n, err := frameLength(s.frame)
+	switch {
+	case err != nil:
+		s.fail(err.Error())
+		return nil
+	case n == 0:
+		if len(s.frame) > MaxResultFrame+64 {
+			s.fail(fmt.Sprintf("frame passed the %d byte limit", MaxResultFrame))
+		}
+		return nil
+	}
+
+	s.reading = false
+	s.lineStart = true
+	result, err := decodeFrame(s.frame[:n])
+	if err != nil {
+		s.fail(err.Error())
+		return nil
+	}
+	result.Source = s.source
+	s.results = append(s.results, result)
+	return p[n-held:]
+}
+
+// Close ends the stream, returning the frames it held
+func (s *resultScanner) Close() ([]*ScriptResult, error) {
+	if s.err == nil && s.reading {
+		if kind, _, _ := bytes.Cut(s.frame, []byte(" ")); string(kind) == "json" && !bytes.Contains(s.frame, []byte("\n")) {
+			// The last line need not end in a newline
+			s.Write([]byte("\n"))
+		} else {
+			s.fail("stream ended inside a frame")
+		}
+	}
+	if s.err == nil && s.strict && len(s.marker) > 0 {
+		s.fail("unexpected output outside a frame")
+	}
+	return s.results, s.err
+}
+
+func (s *resultScanner) fail(reason string) {
+	s.err = &ResultFrameError{Source: s.source, Reason: reason}
+}
+
+// frameLength returns the length of the whole frame at the start of frame,
+// which begins after the marker; zero if more of it is still to come
+func frameLength(frame []byte) (int, error) {
+	header := bytes.IndexByte(frame, '\n')
+	if header < 0 {
+		return 0, nil
+	}
+	kind, _, _ := bytes.Cut(bytes.TrimRight(frame[:header], "\r"), []byte(" "))
+	switch string(kind) {
+	case "json":
+		return header + 1, nil
+	case "proto":
+		body := frame[header+1:]
+		size, n := protowire.ConsumeVarint(body)
+		if n < 0 {
+			if err := protowire.ParseError(n); !errors.Is(err, io.ErrUnexpectedEOF) {
+				return 0, fmt.Errorf("invalid length prefix: %v", err)
+			}
+			return 0, nil
+		}
+		if size > MaxResultFrame {
+			return 0, fmt.Errorf("frame of %d bytes passed the %d byte limit", size, MaxResultFrame)
+		}
+		if end := header + 1 + n + int(size); end <= len(frame) {
+			return end, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("unknown format %q, want json or proto", kind)
+	}
+}
+
+// decodeFrame decodes a whole frame, from after its marker
+func decodeFrame(frame []byte) (*ScriptResult, error) {
+	header := bytes.IndexByte(frame, '\n')
+	kind, body, _ := bytes.Cut(bytes.TrimRight(frame[:header], "\r"), []byte(" "))
+	var result *ScriptResult
+	var err error
+	if string(kind) == "json" {
+		result, err = decodeJSONResult(body)
+	} else {
+		// frameLength has checked the length prefix
+		_, n := protowire.ConsumeVarint(frame[header+1:])
+		result, err = decodeProtoResult(frame[header+1+n:])
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.Status.Code == StatusUnknown {
+		return nil, errors.New("status has no code")
+	}
+	return result, nil
+}
+
+// decodeJSONResult decodes a JSON frame's line
+func decodeJSONResult(line []byte) (*ScriptResult, error) {
+	var frame struct {
+		Status  *ResultStatus   `json:"status"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(line, &frame); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	if frame.Status == nil {
+		return nil, errors.New("missing status")
+	}
+	result := &ScriptResult{Status: *frame.Status}
+	switch payload := bytes.TrimSpace(frame.Payload); {
+	case len(payload) == 0 || string(payload) == "null":
+	case payload[0] == '"':
+		var text string
+		if err := json.Unmarshal(payload, &text); err != nil {
+			return nil, fmt.Errorf("invalid payload: %v", err)
+		}
+		result.Payload = []byte(text)
+	default:
+		result.Payload = append([]byte(nil), payload...)
+	}
+	return result, nil
+}
+
+// decodeProtoResult decodes an envelope.v1.ScriptResult message
+func decodeProtoResult(b []byte) (*ScriptResult, error) {
+	result := &ScriptResult{}
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			status, err := decodeProtoStatus(v)
+			if err != nil {
+				return 0, fmt.Errorf("status: %w", err)
+			}
+			result.Status = *status
+			return n, nil
+		case num == 2 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			result.Payload = append([]byte(nil), v...)
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid protobuf: %w", err)
+	}
+	return result, nil
+}
+
+// decodeProtoStatus decodes an envelope.v1.ResultStatus message
+func decodeProtoStatus(b []byte) (*ResultStatus, error) {
+	status := &ResultStatus{}
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case (num == 1 || num == 3) && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if num == 1 {
+				status.Code = StatusCode(v)
+			} else {
+				status.TimestampMs = int64(v)
+			}
+			return n, nil
+		case (num == 2 || num == 4) && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if num == 2 {
+				status.Message = string(v)
+			} else {
+				status.ErrorDetails = string(v)
+			}
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := statusNames[status.Code]; !ok {
+		return nil, fmt.Errorf("unknown status code %d", status.Code)
+	}
+	return status, nil
+}
+
+// consumeFields calls field with each field of a message and the bytes
+// after its tag, to consume the field's value; field returns the length
+// consumed, or a protowire error code
+func consumeFields(b []byte, field func(protowire.Number, protowire.Type, []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		n, err := field(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return fmt.Errorf("field %d: %w", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// resultEnv returns the variable naming the result descriptor; nil on
+// Windows, where no descriptor can be passed
+func resultEnv() []string {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	return []string{ResultFDEnv + "=" + strconv.Itoa(ResultFD)}
+}
+
+// resultFile is the descriptor a command may write its result frame to;
+// the zero resultFile holds nothing
+type resultFile struct {
+	file *os.File
+}
+
+// newResultFile creates an empty result file
+func newResultFile() (*resultFile, error) {
+	file, err := os.CreateTemp("", "cyborg-result-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create result file: %w", err)
+	}
+	// The open descriptors are all that is needed
+	os.Remove(file.Name())
+	return &resultFile{file: file}, nil
+}
+
+// read scans what the command wrote
+func (f *resultFile) read() ([]*ScriptResult, error) {
+	if f.file == nil {
+		return nil, nil
+	}
+	scanner := newResultScanner(ResultFromFD, true)
+	info, err := f.file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read result file: %w", err)
+	}
+	if info.Size() > MaxResultFrame+64 {
+		scanner.fail(fmt.Sprintf("%d bytes passed the %d byte limit", info.Size(), MaxResultFrame))
+		return scanner.Close()
+	}
+	data := make([]byte, info.Size())
+	if _, err := f.file.ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read result file: %w", err)
+	}
+	scanner.Write(data)
+	return scanner.Close()
+}
+
+func (f *resultFile) Close() error {
+	return f.file.Close()
+}
+
+// collectResult returns the one result a command reported, if any
+func collectResult(stdout *resultScanner, fd *resultFile) (*ScriptResult, error) {
+	results, err := stdout.Close()
+	if err != nil {
+		return nil, err
+	}
+	fromFD, err := fd.read()
+	if err != nil {
+		return nil, err
+	}
+	results = append(results, fromFD...)
+	switch len(results) {
+	case 0:
+		return nil, nil
+	case 1:
+		return results[0], nil
+	default:
+		return nil, &ResultFrameError{Source: results[1].Source, Reason: fmt.Sprintf("%d result frames, want one", len(results))}
+	}
+}
@@ -0,0 +1,166 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// protoFrame encodes a result as a protobuf result frame
+func protoFrame(status ResultStatus, payload []byte) []byte {
+	var msg []byte
+	msg = protowire.AppendTag(msg, 1, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, uint64(status.Code))
+	msg = protowire.AppendTag(msg, 2, protowire.BytesType)
+	msg = protowire.AppendString(msg, status.Message)
+	msg = protowire.AppendTag(msg, 4, protowire.BytesType)
+	msg = protowire.AppendString(msg, status.ErrorDetails)
+
+	var result []byte
+	result = protowire.AppendTag(result, 1, protowire.BytesType)
+	result = protowire.AppendBytes(result, msg)
+	// Unknown fields are skipped
+	result = protowire.AppendTag(result, 9, protowire.VarintType)
+	result = protowire.AppendVarint(result, 7)
+	result = protowire.AppendTag(result, 2, protowire.BytesType)
+	result = protowire.AppendBytes(result, payload)
+
+	frame := []byte(ResultMarker + "proto\n")
+	frame = protowire.AppendVarint(frame, uint64(len(result)))
+	return append(frame, result...)
+}
+
+// scan feeds a stream to a scanner a few bytes at a time
+func scan(stream []byte, strict bool) ([]*ScriptResult, error) {
+	scanner := newResultScanner(ResultFromStdout, strict)
+	for len(stream) > 0 {
+		n := min(3, len(stream))
+		scanner.Write(stream[:n])
+		stream = stream[n:]
+	}
+	return scanner.Close()
+}
+
+// TestResultScanner tests that frames are found among other output
+func TestResultScanner(t *testing.T) {
+	stream := "building\n" + ResultMarker + `json {"status": {"code": "SUCCESS", "message": "done"}, "payload": {"files": 3}}` + "\nbye " + ResultMarker + "\n"
+	results, err := scan([]byte(stream), false)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, StatusSuccess, results[0].Status.Code)
+	assert.Equal(t, "done", results[0].Status.Message)
+	assert.JSONEq(t, `{"files": 3}`, string(results[0].Payload))
+	assert.NoError(t, results[0].Err())
+
+	status := ResultStatus{Code: StatusRetryableFailure, Message: "busy", ErrorDetails: "try later"}
+	stream = "log line\n" + string(protoFrame(status, []byte{0, 1, '\n', 2})) + "trailing output\n"
+	results, err = scan([]byte(stream), false)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, status, results[0].Status)
+	assert.Equal(t, []byte{0, 1, '\n', 2}, results[0].Payload)
+	assert.ErrorIs(t, results[0].Err(), ErrReportedFailure)
+	assert.EqualError(t, results[0].Err(), "command reported RETRYABLE_FAILURE: busy (try later)")
+
+	// A string payload is unquoted, and the last line needs no newline
+	results, err = scan([]byte(ResultMarker+`json {"status": {"code": 2}, "payload": "plain text"}`), true)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, StatusFailure, results[0].Status.Code)
+	assert.Equal(t, "plain text", string(results[0].Payload))
+
+	// Legacy output holds no frames
+	results, err = scan([]byte("just output\n:: not a frame\n"), false)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+// TestResultScannerMalformed tests that broken frames are reported
+func TestResultScannerMalformed(t *testing.T) {
+	oversized := []byte(ResultMarker + "proto\n")
+	oversized = protowire.AppendVarint(oversized, MaxResultFrame+1)
+	truncated := protoFrame(ResultStatus{Code: StatusSuccess}, []byte("payload"))
+
+	cases := []struct {
+		name   string
+		stream string
+		strict bool
+		reason string
+	}{
+		{"invalid JSON", ResultMarker + "json {\"status\":\n", false, "invalid JSON"},
+		{"missing status", ResultMarker + `json {"payload": 1}` + "\n", false, "missing status"},
+		{"unknown code", ResultMarker + `json {"status": {"code": "MAYBE"}}` + "\n", false, `unknown status code "MAYBE"`},
+		{"no code", ResultMarker + `json {"status": {"message": "hi"}}` + "\n", false, "status has no code"},
+		{"unknown format", ResultMarker + "yaml status: ok\n", false, `unknown format "yaml"`},
+		{"oversized", string(oversized), false, "passed the 1048576 byte limit"},
+		{"truncated", string(truncated[:len(truncated)-2]), false, "stream ended inside a frame"},
+		{"bad protobuf", ResultMarker + "proto\n\x02\x0a\x05", false, "invalid protobuf"},
+		{"output on descriptor", "hello\n", true, "unexpected output outside a frame"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := scan([]byte(tc.stream), tc.strict)
+			require.ErrorIs(t, err, ErrMalformedResult)
+			var frameErr *ResultFrameError
+			require.ErrorAs(t, err, &frameErr)
+			assert.Contains(t, frameErr.Reason, tc.reason)
+			assert.Contains(t, err.Error(), "malformed result frame on stdout")
+		})
+	}
+}
+
+// TestSubprocessRunnerResult tests that a run carries the result its
+// command reported on stdout or the result descriptor
+func TestSubprocessRunnerResult(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell")
+	}
+	runner := NewSubprocessRunner(testConfig())
+	run := func(script string) *RunResult {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		result, err := runner.Run(ctx, "sh", []string{"-c", script})
+		require.NoError(t, err)
+		return result
+	}
+
+	// A legacy script keeps its raw output
+	result := run("echo plain")
+	require.NoError(t, result.Err)
+	assert.Nil(t, result.Result)
+	assert.Equal(t, "plain\n", string(result.Stdout))
+
+	result = run(`echo working; echo '` + ResultMarker + `json {"status": {"code": "SUCCESS"}, "payload": "42"}'`)
+	require.NoError(t, result.Err)
+	require.NotNil(t, result.Result)
+	assert.Equal(t, ResultFromStdout, result.Result.Source)
+	assert.Equal(t, "42", string(result.Result.Payload))
+
+	frame := filepath.Join(t.TempDir(), "frame")
+	require.NoError(t, os.WriteFile(frame, protoFrame(ResultStatus{Code: StatusRejected, Message: "not mine"}, []byte("why")), 0o600))
+	result = run(`echo chatter; cat ` + frame + ` >&$` + ResultFDEnv)
+	require.NoError(t, result.Err)
+	require.NotNil(t, result.Result)
+	assert.Equal(t, ResultFromFD, result.Result.Source)
+	assert.Equal(t, StatusRejected, result.Result.Status.Code)
+	assert.Equal(t, "why", string(result.Result.Payload))
+	assert.Equal(t, "chatter\n", string(result.Stdout))
+
+	// A malformed frame fails the run, along with the command's own error
+	result = run(`echo '` + ResultMarker + `json {oops'; exit 3`)
+	assert.ErrorIs(t, result.Err, ErrMalformedResult)
+	assert.ErrorContains(t, result.Err, "exit status 3")
+	assert.Nil(t, result.Result)
+
+	// So does more than one frame
+	result = run(`echo '` + ResultMarker + `json {"status": {"code": 1}}'; cat ` + frame + ` >&3`)
+	assert.ErrorIs(t, result.Err, ErrMalformedResult)
+	assert.ErrorContains(t, result.Err, "2 result frames")
+}
@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// SecurityLevel mirrors cyborg_jobs.v1.SecurityRequirements.SecurityLevel;
+// higher levels isolate a job's commands further
+type SecurityLevel int
+
+const (
+	SecurityNone SecurityLevel = iota
+	SecurityLow
+	SecurityMedium
+	SecurityHigh
+	SecurityCritical
+)
+
+// securityLevels are the levels by their proto enum names
+var securityLevels = map[string]SecurityLevel{
+	"NONE":     SecurityNone,
+	"LOW":      SecurityLow,
+	"MEDIUM":   SecurityMedium,
+	"HIGH":     SecurityHigh,
+	"CRITICAL": SecurityCritical,
+}
+
+// ParseSecurityLevel reads a level by its proto enum name, ignoring case
+func ParseSecurityLevel(name string) (SecurityLevel, error) {
+	level, ok := securityLevels[strings.ToUpper(strings.TrimSpace(name))]
+	if !ok {
+		return SecurityNone, fmt.Errorf("unknown security level %q", name)
+	}
+	return level, nil
+}
+
+// Resources mirrors the parts of cyborg_jobs.v1.Resources the sandbox
+// enforces; zero values are not enforced
+type Resources struct {
+	CPUCores float64
+	MemoryGB float64
+	// NetworkMbps is zero for a job that needs no network
+	NetworkMbps float64
+}
+
+// Sandbox isolates a command from the conductor. The command runs in a
+// private temp directory, which is removed afterwards, with a scrubbed
+// environment; zero limits are not applied.
+type Sandbox struct {
+	// CPUTime caps the CPU time each process may use across its threads.
+	// It gets SIGXCPU at the limit and is killed a second later.
+	CPUTime time.Duration
+	// AddressSpace caps the virtual memory of each process, in bytes
+	AddressSpace uint64
+	// OpenFiles caps the file descriptors each process may hold open
+	OpenFiles uint64
+	// Processes caps the processes the command's user may run at once
+	Processes uint64
+
+	// Memory, in bytes, and CPUs cap the command and its children together
+	// through a cgroup v2 group under CgroupParent, when cgroup v2 is
+	// mounted and the conductor may create groups there
+	Memory       uint64
+	CPUs         float64
+	CgroupParent string
+
+	// Env lists NAME=VALUE entries added to the scrubbed environment
+	Env []string
+	// Unprivileged runs the command as nobody when the conductor runs as
+	// root; limiting another user's command needs CAP_SYS_RESOURCE
+	Unprivileged bool
+	// IsolateNetwork gives the command a network namespace with only a
+	// loopback interface; IsolateMounts gives it a private mount namespace
+	IsolateNetwork bool
+	IsolateMounts  bool
+}
+
+// DefaultCgroupParent is where sandboxes create their cgroups
+const DefaultCgroupParent = "/sys/fs/cgroup/cyborg-conductor"
+
+// addressSpaceHeadroom scales a job's memory into its address space
+// limit; runtimes reserve far more virtual memory than they touch, and the
+// cgroup holds actual use to the job's memory
+const addressSpaceHeadroom = 4
+
+// levelLimits are the descriptor and process caps by security level
+var levelLimits = map[SecurityLevel]struct{ openFiles, processes uint64 }{
+	SecurityNone:     {openFiles: 4096},
+	SecurityLow:      {openFiles: 1024, processes: 512},
+	SecurityMedium:   {openFiles: 512, processes: 256},
+	SecurityHigh:     {openFiles: 256, processes: 128},
+	SecurityCritical: {openFiles: 128, processes: 64},
+}
+
+// SandboxFor derives the isolation for a job from its resources, security
+// level and timeout:
+//   - CPU time is the job's cores over its timeout, and its memory caps
+//     the cgroup and, with headroom, the address space
+//   - descriptor and process caps tighten with the level
+//   - from MEDIUM the command drops root
+//   - from HIGH it gets a private mount namespace, and a private network
+//     namespace unless the job needs network bandwidth
+func SandboxFor(res Resources, level SecurityLevel, timeout time.Duration) *Sandbox {
+	limits := levelLimits[level]
+	sb := &Sandbox{
+		OpenFiles:      limits.openFiles,
+		Processes:      limits.processes,
+		CPUs:           res.CPUCores,
+		CgroupParent:   DefaultCgroupParent,
+		Unprivileged:   level >= SecurityMedium,
+		IsolateMounts:  level >= SecurityHigh,
+		IsolateNetwork: level >= SecurityHigh && res.NetworkMbps == 0,
+	}
+	if res.CPUCores > 0 && timeout > 0 {
+		sb.CPUTime = time.Duration(math.Ceil(res.CPUCores*timeout.Seconds())) * time.Second
+	}
+	if res.MemoryGB > 0 {
+		sb.Memory = uint64(res.MemoryGB * (1 << 30))
+		sb.AddressSpace = sb.Memory * addressSpaceHeadroom
+	}
+	return sb
+}
+
+// Sandboxed returns a runner that runs every command in sb; nil runs them
+// unisolated
+func (r *SubprocessRunner) Sandboxed(sb *Sandbox) *SubprocessRunner {
+	sandboxed := *r
+	sandboxed.sandbox = sb
+	return &sandboxed
+}
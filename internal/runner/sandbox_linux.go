@@ -0,0 +1,246 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// gateScript holds the command in a shell until its limits are in place,
+// then runs it in the shell's place so it inherits them. It is formatted
+// with the gate's descriptor.
+const gateScript = `read -r _ <&%[1]d || exit 126; exec %[1]d<&-; exec "$0" "$@"`
+
+// nobody is the user and group an unprivileged sandbox runs as
+const nobody = 65534
+
+// cgroupPeriod is the cpu.max accounting period, in microseconds
+const cgroupPeriod = 100000
+
+// sandboxRun is one command's sandbox
+type sandboxRun struct {
+	sb  *Sandbox
+	dir string
+	// gate is written once the gated shell's limits are set; gateReader
+	// is the shell's end
+	gate       *os.File
+	gateReader *os.File
+	// cgroup is the command's cgroup directory, if one could be made
+	cgroup   string
+	cgroupFD *os.File
+}
+
+// wrap sets cmd up to run in the sandbox: in a private directory with a
+// scrubbed environment plus env, behind a gate that holds it until release
+// has set its limits
+func (sb *Sandbox) wrap(cmd *exec.Cmd, env []string) (*sandboxRun, error) {
+	// The command starts in the sandbox directory, so a relative executable
+	// is resolved against the directory it was meant to start in
+	path := cmd.Path
+	if !filepath.IsAbs(path) {
+		abs, err := filepath.Abs(filepath.Join(cmd.Dir, path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		path = abs
+	}
+
+	dir, err := os.MkdirTemp("", "cyborg-sandbox-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox directory: %w", err)
+	}
+	run := &sandboxRun{sb: sb, dir: dir}
+
+	attr := &syscall.SysProcAttr{}
+	if sb.Unprivileged && os.Geteuid() == 0 {
+		attr.Credential = &syscall.Credential{Uid: nobody, Gid: nobody}
+		if err := os.Chown(dir, nobody, nobody); err != nil {
+			run.cleanup()
+			return nil, fmt.Errorf("failed to hand sandbox directory to nobody: %w", err)
+		}
+	}
+	if sb.IsolateNetwork {
+		attr.Unshareflags |= syscall.CLONE_NEWNET
+	}
+	if sb.IsolateMounts {
+		// Mounts are made private to the namespace as well
+		attr.Unshareflags |= syscall.CLONE_NEWNS
+	}
+	if attr.Unshareflags != 0 && os.Geteuid() != 0 {
+		// Without root the namespaces need a user namespace to own them
+		attr.Cloneflags |= syscall.CLONE_NEWUSER
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Geteuid(), HostID: os.Geteuid(), Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getegid(), HostID: os.Getegid(), Size: 1}}
+	}
+	run.joinCgroup(attr)
+	cmd.SysProcAttr = attr
+
+	cmd.Dir = dir
+	cmd.Env = append([]string{
+		"PATH=/usr/local/bin:/usr/bin:/bin",
+		"HOME=" + dir,
+		"TMPDIR=" + dir,
+		"LANG=C.UTF-8",
+	}, env...)
+
+	run.gateReader, run.gate, err = os.Pipe()
+	if err != nil {
+		run.cleanup()
+		return nil, fmt.Errorf("failed to create sandbox gate: %w", err)
+	}
+	// The gate follows any descriptors the command is given
+	cmd.ExtraFiles = append(cmd.ExtraFiles, run.gateReader)
+	script := fmt.Sprintf(gateScript, 2+len(cmd.ExtraFiles))
+	cmd.Args = append([]string{"sh", "-c", script, path}, cmd.Args[1:]...)
+	cmd.Path = "/bin/sh"
+	return run, nil
+}
+
+// joinCgroup creates a cgroup capping the command's memory and CPU and
+// has the command start in it. Without cgroup v2, or permission to create
+// groups, the command runs under its rlimits alone.
+func (run *sandboxRun) joinCgroup(attr *syscall.SysProcAttr) {
+	sb := run.sb
+	if sb.Memory == 0 && sb.CPUs == 0 {
+		return
+	}
+	parent := sb.CgroupParent
+	if parent == "" {
+		parent = DefaultCgroupParent
+	}
+	var fs unix.Statfs_t
+	if err := unix.Statfs(filepath.Dir(parent), &fs); err != nil || fs.Type != unix.CGROUP2_SUPER_MAGIC {
+		return
+	}
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return
+	}
+	// The sandboxes' groups need the controllers delegated to them
+	if err := os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("+memory +cpu"), 0); err != nil {
+		return
+	}
+	dir, err := os.MkdirTemp(parent, "job-")
+	if err != nil {
+		return
+	}
+	run.cgroup = dir
+
+	settings := map[string]string{}
+	if sb.Memory > 0 {
+		settings["memory.max"] = fmt.Sprint(sb.Memory)
+	}
+	if sb.CPUs > 0 {
+		settings["cpu.max"] = fmt.Sprintf("%d %d", int64(sb.CPUs*cgroupPeriod), cgroupPeriod)
+	}
+	for name, value := range settings {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0); err != nil {
+			run.removeCgroup()
+			return
+		}
+	}
+	if sb.Memory > 0 {
+		// Keep the job from swapping past its memory; absent without swap
+		_ = os.WriteFile(filepath.Join(dir, "memory.swap.max"), []byte("0"), 0)
+	}
+
+	fd, err := os.Open(dir)
+	if err != nil {
+		run.removeCgroup()
+		return
+	}
+	run.cgroupFD = fd
+	attr.UseCgroupFD = true
+	attr.CgroupFD = int(fd.Fd())
+}
+
+// release sets the started command's limits and opens its gate
+func (run *sandboxRun) release(pid int) error {
+	// The child has its own copy of the gate's read end
+	run.gateReader.Close()
+	run.gateReader = nil
+
+	for _, limit := range run.limits() {
+		// Limits cannot be raised past the conductor's own hard limit
+		var current unix.Rlimit
+		if err := unix.Prlimit(pid, limit.resource, nil, &current); err != nil {
+			return fmt.Errorf("failed to read %s limit: %w", limit.name, err)
+		}
+		want := unix.Rlimit{Cur: min(limit.soft, current.Max), Max: min(limit.hard, current.Max)}
+		if err := unix.Prlimit(pid, limit.resource, &want, nil); err != nil {
+			return fmt.Errorf("failed to set %s limit: %w", limit.name, err)
+		}
+	}
+
+	_, err := run.gate.Write([]byte("\n"))
+	run.gate.Close()
+	run.gate = nil
+	if err != nil {
+		return fmt.Errorf("failed to open sandbox gate: %w", err)
+	}
+	return nil
+}
+
+// rlimit is one resource limit to set
+type rlimit struct {
+	name       string
+	resource   int
+	soft, hard uint64
+}
+
+// limits returns the sandbox's resource limits
+func (run *sandboxRun) limits() []rlimit {
+	sb := run.sb
+	var limits []rlimit
+	if sb.CPUTime > 0 {
+		seconds := uint64((sb.CPUTime + time.Second - 1) / time.Second)
+		// The second of grace lets the command handle SIGXCPU
+		limits = append(limits, rlimit{"CPU time", unix.RLIMIT_CPU, seconds, seconds + 1})
+	}
+	if sb.AddressSpace > 0 {
+		limits = append(limits, rlimit{"address space", unix.RLIMIT_AS, sb.AddressSpace, sb.AddressSpace})
+	}
+	if sb.OpenFiles > 0 {
+		limits = append(limits, rlimit{"open files", unix.RLIMIT_NOFILE, sb.OpenFiles, sb.OpenFiles})
+	}
+	if sb.Processes > 0 {
+		limits = append(limits, rlimit{"processes", unix.RLIMIT_NPROC, sb.Processes, sb.Processes})
+	}
+	return limits
+}
+
+// cleanup removes the sandbox once its command has exited, killing
+// anything left in its cgroup
+func (run *sandboxRun) cleanup() {
+	for _, f := range []*os.File{run.gate, run.gateReader} {
+		if f != nil {
+			f.Close()
+		}
+	}
+	run.removeCgroup()
+	os.RemoveAll(run.dir)
+}
+
+// removeCgroup kills what is left in the command's cgroup and removes it
+func (run *sandboxRun) removeCgroup() {
+	if run.cgroupFD != nil {
+		run.cgroupFD.Close()
+		run.cgroupFD = nil
+	}
+	if run.cgroup == "" {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(run.cgroup, "cgroup.kill"), []byte("1"), 0)
+	// A killed group empties asynchronously
+	for i := 0; i < 50; i++ {
+		if err := os.Remove(run.cgroup); err == nil || os.IsNotExist(err) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	run.cgroup = ""
+}
@@ -0,0 +1,195 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runSandboxed runs a shell script in sb and returns its result
+func runSandboxed(t *testing.T, sb *Sandbox, script string) *RunResult {
+	runner := NewSubprocessRunner(testConfig()).Sandboxed(sb)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := runner.Run(ctx, "sh", []string{"-c", script})
+	require.NoError(t, err)
+	return result
+}
+
+// TestSandboxLimitsCPUTime tests that a command is killed once it has used
+// its CPU time
+func TestSandboxLimitsCPUTime(t *testing.T) {
+	result := runSandboxed(t, &Sandbox{CPUTime: time.Second}, "while :; do :; done")
+
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(result.Err, &exitErr))
+	status := exitErr.Sys().(syscall.WaitStatus)
+	require.True(t, status.Signaled())
+	assert.Contains(t, []syscall.Signal{syscall.SIGXCPU, syscall.SIGKILL}, status.Signal())
+}
+
+// TestSandboxLimitsAddressSpace tests that a command cannot grow past its
+// address space
+func TestSandboxLimitsAddressSpace(t *testing.T) {
+	sb := &Sandbox{AddressSpace: 32 << 20}
+
+	// Doubling a string to 128MB needs more than the limit
+	result := runSandboxed(t, sb, `x=a; while [ ${#x} -lt 134217728 ]; do x="$x$x"; done`)
+	assert.Error(t, result.Err)
+
+	result = runSandboxed(t, sb, "true")
+	assert.NoError(t, result.Err)
+}
+
+// TestSandboxLimitsOpenFiles tests that a command cannot open descriptors
+// past its limit
+func TestSandboxLimitsOpenFiles(t *testing.T) {
+	result := runSandboxed(t, &Sandbox{OpenFiles: 8}, "exec 9</dev/null")
+	assert.Error(t, result.Err)
+
+	result = runSandboxed(t, &Sandbox{OpenFiles: 16}, "exec 9</dev/null")
+	assert.NoError(t, result.Err)
+}
+
+// TestSandboxLimitsProcesses tests that a command cannot start processes
+// past its limit
+func TestSandboxLimitsProcesses(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("the process limit is per user, so needs the command to run as nobody")
+	}
+	runner := NewSubprocessRunner(testConfig()).Sandboxed(&Sandbox{Processes: 1, Unprivileged: true})
+	result, err := runner.Run(context.Background(), "sh", []string{"-c", "true & wait"})
+	if errors.Is(err, syscall.EPERM) {
+		t.Skip("limiting nobody's command needs CAP_SYS_RESOURCE:", err)
+	}
+	require.NoError(t, err)
+	assert.Error(t, result.Err)
+
+	result = runSandboxed(t, &Sandbox{Processes: 8, Unprivileged: true}, "true & wait")
+	assert.NoError(t, result.Err)
+}
+
+// TestSandboxEnvironment tests that a command runs with a scrubbed
+// environment in a private directory that is removed afterwards
+func TestSandboxEnvironment(t *testing.T) {
+	t.Setenv("CYBORG_SANDBOX_MARKER", "leaked")
+	sb := &Sandbox{Env: []string{"CYBORG_EXTRA=kept"}}
+
+	result := runSandboxed(t, sb, `echo "$CYBORG_SANDBOX_MARKER|$CYBORG_EXTRA|$TMPDIR|$(pwd)"`)
+	require.NoError(t, result.Err)
+
+	fields := strings.Split(strings.TrimSpace(string(result.Stdout)), "|")
+	require.Len(t, fields, 4)
+	assert.Empty(t, fields[0])
+	assert.Equal(t, "kept", fields[1])
+	assert.Equal(t, fields[2], fields[3])
+	assert.Equal(t, "cyborg-sandbox-", filepath.Base(fields[2])[:len("cyborg-sandbox-")])
+	_, err := os.Stat(fields[2])
+	assert.True(t, os.IsNotExist(err))
+
+	// The result descriptor gets past the gate
+	result = runSandboxed(t, sb, `echo '`+ResultMarker+`json {"status": {"code": "SUCCESS"}}' >&$`+ResultFDEnv)
+	require.NoError(t, result.Err)
+	require.NotNil(t, result.Result)
+	assert.Equal(t, ResultFromFD, result.Result.Source)
+}
+
+// TestSandboxRelativeExecutable tests that an executable given relative
+// to the runner's directory still runs from the sandbox directory
+func TestSandboxRelativeExecutable(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "s.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho ran\n"), 0o755))
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	relative, err := filepath.Rel(cwd, script)
+	require.NoError(t, err)
+
+	runner := NewSubprocessRunner(testConfig()).Sandboxed(&Sandbox{})
+	result, err := runner.Run(context.Background(), relative, nil)
+	require.NoError(t, err)
+	require.NoError(t, result.Err)
+	assert.Equal(t, "ran\n", string(result.Stdout))
+}
+
+// TestSandboxIsolatesNetwork tests that an isolated command sees only a
+// loopback interface
+func TestSandboxIsolatesNetwork(t *testing.T) {
+	runner := NewSubprocessRunner(testConfig()).Sandboxed(&Sandbox{IsolateNetwork: true, IsolateMounts: true})
+	result, err := runner.Run(context.Background(), "cat", []string{"/proc/net/dev"})
+	if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EINVAL) {
+		t.Skip("namespaces are not available here:", err)
+	}
+	require.NoError(t, err)
+	require.NoError(t, result.Err)
+
+	// Two header lines, then one line per interface
+	lines := strings.Split(strings.TrimSpace(string(result.Stdout)), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[2], "lo:")
+}
+
+// TestSandboxCgroup tests that a command runs in a cgroup capping its
+// memory, which is removed afterwards
+func TestSandboxCgroup(t *testing.T) {
+	parent := filepath.Join("/sys/fs/cgroup", "cyborg-conductor-test")
+	probe := &sandboxRun{sb: &Sandbox{Memory: 64 << 20, CgroupParent: parent}}
+	attr := &syscall.SysProcAttr{}
+	probe.joinCgroup(attr)
+	if !attr.UseCgroupFD {
+		t.Skip("cgroup v2 is not available here")
+	}
+	probe.removeCgroup()
+	defer os.Remove(parent)
+
+	result := runSandboxed(t, &Sandbox{Memory: 64 << 20, CPUs: 0.5, CgroupParent: parent},
+		`cat "/sys/fs/cgroup$(cut -d: -f3 /proc/self/cgroup)/memory.max"`)
+	require.NoError(t, result.Err)
+	assert.Equal(t, "67108864", strings.TrimSpace(string(result.Stdout)))
+
+	entries, err := os.ReadDir(parent)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.False(t, entry.IsDir(), "cgroup %s was left behind", entry.Name())
+	}
+}
+
+// TestSandboxFor tests how a job's resources and security level map onto
+// its sandbox
+func TestSandboxFor(t *testing.T) {
+	sb := SandboxFor(Resources{CPUCores: 2, MemoryGB: 0.5}, SecurityLow, 90*time.Second)
+	assert.Equal(t, 180*time.Second, sb.CPUTime)
+	assert.Equal(t, uint64(512<<20), sb.Memory)
+	assert.Equal(t, uint64(2<<30), sb.AddressSpace)
+	assert.Equal(t, 2.0, sb.CPUs)
+	assert.Equal(t, uint64(1024), sb.OpenFiles)
+	assert.False(t, sb.Unprivileged)
+	assert.False(t, sb.IsolateNetwork)
+
+	sb = SandboxFor(Resources{}, SecurityHigh, time.Minute)
+	assert.Zero(t, sb.CPUTime)
+	assert.Zero(t, sb.AddressSpace)
+	assert.True(t, sb.Unprivileged)
+	assert.True(t, sb.IsolateMounts)
+	assert.True(t, sb.IsolateNetwork)
+
+	// A job that needs bandwidth keeps the network
+	sb = SandboxFor(Resources{NetworkMbps: 10}, SecurityCritical, time.Minute)
+	assert.False(t, sb.IsolateNetwork)
+	assert.Equal(t, uint64(64), sb.Processes)
+
+	level, err := ParseSecurityLevel("medium")
+	require.NoError(t, err)
+	assert.Equal(t, SecurityMedium, level)
+	_, err = ParseSecurityLevel("extreme")
+	assert.Error(t, err)
+}
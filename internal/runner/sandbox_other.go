@@ -0,0 +1,23 @@
+//go:build !linux
+
+package runner
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// errSandboxUnsupported is returned for sandboxed commands off Linux
+var errSandboxUnsupported = errors.New("sandboxing is only supported on Linux")
+
+// sandboxRun is one command's sandbox
+type sandboxRun struct{}
+
+// wrap refuses to run a command unisolated when a sandbox was asked for
+func (sb *Sandbox) wrap(cmd *exec.Cmd, env []string) (*sandboxRun, error) {
+	return nil, errSandboxUnsupported
+}
+
+func (run *sandboxRun) release(pid int) error { return nil }
+
+func (run *sandboxRun) cleanup() {}
@@ -9,6 +9,10 @@ import (
 
 // Config represents the application configuration
 type Config struct {
+	// InstanceID identifies this conductor process; it must stay the same
+	// across restarts so in-flight work can be reclaimed
+	InstanceID string `json:"instance_id"`
+	
 	// Server configuration
 	Server struct {
 		Host string `json:"host"`
@@ -53,9 +57,218 @@ type Config struct {
 		Timeout struct {
 			JobTimeout     int64 `json:"job_timeout"`
 			TaskTimeout    int64 `json:"task_timeout"`
+			KillGrace      int64 `json:"kill_grace"`
 			Connection     int64 `json:"connection"`
 		} `json:"timeout"`
 	} `json:"runtime"`
+	
+	// Cron configuration for recurring job schedules
+	Cron struct {
+		// Enabled turns the built-in cron scheduler on
+		Enabled bool `json:"enabled"`
+		// TickInterval is how often schedules are checked, in seconds
+		TickInterval int64 `json:"tick_interval"`
+		// MisfireThreshold is how late an activation may fire before it is
+		// handled as missed, in seconds
+		MisfireThreshold int64 `json:"misfire_threshold"`
+	} `json:"cron"`
+	
+	// Queue configuration for submitted jobs
+	Queue struct {
+		// Backend is "postgres" for the durable queue or "memory"
+		Backend string `json:"backend"`
+		// Capacity is the maximum number of queued jobs
+		Capacity int `json:"capacity"`
+		// VisibilityTimeout is how long a dequeued job stays leased, in seconds
+		VisibilityTimeout int64 `json:"visibility_timeout"`
+		// PollInterval is how often an empty queue is polled, in milliseconds
+		PollInterval int64 `json:"poll_interval"`
+	} `json:"queue"`
+	
+	// Circuit breaker configuration for cyborg executions
+	Breaker struct {
+		Enabled bool `json:"enabled"`
+		// PerCapability keeps a breaker per cyborg and capability instead of
+		// one per cyborg
+		PerCapability bool `json:"per_capability"`
+		// WindowSize is how many recent executions the failure rate covers
+		WindowSize int `json:"window_size"`
+		// MinRequests is how many executions are needed before a breaker may trip
+		MinRequests int `json:"min_requests"`
+		// FailureRate at or above which a breaker opens, between 0 and 1
+		FailureRate float64 `json:"failure_rate"`
+		// OpenTimeout is how long a breaker stays open before probing, in seconds
+		OpenTimeout int64 `json:"open_timeout"`
+		// HalfOpenProbes is how many successful probes close a breaker
+		HalfOpenProbes int `json:"half_open_probes"`
+	} `json:"breaker"`
+	
+	// Hedging configuration for jobs on five-nines cyborgs
+	Hedge struct {
+		Enabled bool `json:"enabled"`
+		// Delay before a duplicate execution starts, in milliseconds; zero
+		// relies on the latency percentile alone
+		Delay int64 `json:"delay"`
+		// Percentile of observed latency after which a duplicate starts;
+		// zero relies on the delay alone
+		Percentile float64 `json:"percentile"`
+		// MinSamples is how many executions the percentile needs first
+		MinSamples int `json:"min_samples"`
+	} `json:"hedge"`
+	
+	// Idempotency configuration for job submissions
+	Idempotency struct {
+		// Window is how long an idempotency key is remembered, in seconds
+		Window int64 `json:"window"`
+	} `json:"idempotency"`
+	
+	// RateLimit configuration for external dependencies
+	RateLimit struct {
+		// Limits lists token buckets as comma-separated DEP=TOKENS/PERIOD[:BURST]
+		// entries; a CYBORG.DEP prefix sets a per-cyborg sub-limit
+		Limits string `json:"limits"`
+	} `json:"rate_limit"`
+	
+	// Preemption configuration for urgent jobs on full cyborgs
+	Preemption struct {
+		Enabled bool `json:"enabled"`
+		// MaxPriority is the least urgent priority that may preempt
+		MaxPriority int `json:"max_priority"`
+		// MinGap is how many priority levels more urgent the waiting job
+		// must be than the job it preempts
+		MinGap int `json:"min_gap"`
+		// MaxPerJob is how many times one job may be preempted
+		MaxPerJob int `json:"max_per_job"`
+	} `json:"preemption"`
+	
+	// Workers configuration for remote cyborg worker processes
+	Workers struct {
+		Enabled bool `json:"enabled"`
+		// AckTimeout is how long a worker has to ack a job, in seconds
+		AckTimeout int `json:"ack_timeout"`
+		// LeaseTimeout is how long an acked job may go without progress
+		// before it is redelivered, in seconds
+		LeaseTimeout int `json:"lease_timeout"`
+		// MaxDeliveries is how many times a job is delivered before it fails
+		MaxDeliveries int `json:"max_deliveries"`
+	} `json:"workers"`
+	
+	// Cluster configuration for running several conductor replicas
+	Cluster struct {
+		Enabled bool `json:"enabled"`
+		// HeartbeatInterval is how often a replica renews its membership
+		// and leases, in seconds
+		HeartbeatInterval int `json:"heartbeat_interval"`
+		// MemberTTL is how long a silent replica counts as alive, in seconds
+		MemberTTL int `json:"member_ttl"`
+		// LeaseTTL is how long a silent leader keeps its duties, in seconds
+		LeaseTTL int `json:"lease_ttl"`
+	} `json:"cluster"`
+	
+	// Affinity configuration for context routing and anti-affinity
+	Affinity struct {
+		// Window is how long a context stays routed to its cyborg and how
+		// long tagged jobs count for anti-affinity, in seconds
+		Window int64 `json:"window"`
+	} `json:"affinity"`
+	
+	// Backpressure configuration for refusing jobs under load
+	Backpressure struct {
+		// Shedding lists PRIORITY=FILL rules, such as "5=0.8,3=0.95": jobs
+		// of that priority or less urgent are refused once the queue is
+		// that fraction full. Empty sheds nothing before the queue is full.
+		Shedding string `json:"shedding"`
+	} `json:"backpressure"`
+	
+	// Budget configuration for LLM token and cost spend
+	Budget struct {
+		// Limits lists budgets as comma-separated
+		// SCOPE.KEY.WINDOW=LIMIT[/LIMIT] entries, see budget.ParseBudgets
+		Limits string `json:"limits"`
+		// DowngradePriority is the priority jobs run at once a downgrade
+		// limit is reached
+		DowngradePriority int `json:"downgrade_priority"`
+	} `json:"budget"`
+	
+	// Scheduling configuration for choosing among capable cyborgs
+	Scheduling struct {
+		// Policy is the scheduling policy used where no namespace or job
+		// class assignment applies
+		Policy string `json:"policy"`
+		// Policies assigns policies as comma-separated namespace.NAME=POLICY
+		// and class.NAME=POLICY entries, see policy.ParseAssignments
+		Policies string `json:"policies"`
+		// Weights tunes the weighted-score policy as comma-separated
+		// FACTOR=WEIGHT entries, see policy.ParseWeights
+		Weights string `json:"weights"`
+	} `json:"scheduling"`
+	
+	// Escalation configuration for rerouting jobs up the org chart
+	Escalation struct {
+		// Enabled reroutes rejected and unplaceable jobs to the manager of
+		// the cyborg they were meant for
+		Enabled bool `json:"enabled"`
+		// MaxHops is how many times one job may be escalated
+		MaxHops int `json:"max_hops"`
+		// OrgChart is the file holding the reporting lines
+		OrgChart string `json:"org_chart"`
+	} `json:"escalation"`
+	
+	// Sandbox configuration for isolating subprocesses
+	Sandbox struct {
+		// Enabled runs every subprocess under rlimits, in a private temp
+		// directory with a scrubbed environment
+		Enabled bool `json:"enabled"`
+		// Level is the security level the default isolation is derived
+		// from: none, low, medium, high or critical
+		Level string `json:"level"`
+		// CgroupParent is the cgroup v2 directory sandboxes create their
+		// groups under
+		CgroupParent string `json:"cgroup_parent"`
+	} `json:"sandbox"`
+	
+	// Output configuration for capturing job output
+	Output struct {
+		// MemoryLimit is how many bytes of each stream a run keeps in
+		// memory before the stream spills
+		MemoryLimit int64 `json:"memory_limit"`
+		// ExcerptBytes is how much of the start, and of the end, of a
+		// spilled stream is kept in memory
+		ExcerptBytes int `json:"excerpt_bytes"`
+		// SpillDir is where jobs' spilled output is written; empty keeps
+		// only the excerpts
+		SpillDir string `json:"spill_dir"`
+		// SpillRetention is how many hours spill files are kept
+		SpillRetention int64 `json:"spill_retention"`
+	} `json:"output"`
+	
+	// Executor configuration for choosing the backend jobs run on
+	Executor struct {
+		// Default is the backend for jobs whose cyborg names none: subprocess,
+		// adapter or remote. Empty picks remote when workers are enabled
+		// and subprocess otherwise.
+		Default string `json:"default"`
+		// JobTypes routes cyborgs' job types to backends as comma-separated
+		// JOB_TYPE=BACKEND entries, see executor.ParseJobTypes
+		JobTypes string `json:"job_types"`
+		// Policies is a JSON file of the execution policies attached to
+		// cyborgs and capabilities, see runner.LoadPolicies
+		Policies string `json:"policies"`
+	} `json:"executor"`
+	
+	// Adapter configuration for the pool of persistent adapter processes
+	Adapter struct {
+		// Enabled starts the pool and adds the adapter backend
+		Enabled bool `json:"enabled"`
+		// Command and Args start one adapter process; Args is split on spaces
+		Command string `json:"command"`
+		Args    string `json:"args"`
+		// PoolSize is how many adapter processes run
+		PoolSize int `json:"pool_size"`
+		// MaxRequests is how many requests a process serves before it is
+		// replaced; zero never replaces it
+		MaxRequests int `json:"max_requests"`
+	} `json:"adapter"`
 }
 
 // LoadConfig loads configuration from environment variables and defaults
@@ -102,6 +315,7 @@ func LoadConfig() (*Config, error) {
 			Timeout              struct {
 				JobTimeout     int64 `json:"job_timeout"`
 				TaskTimeout    int64 `json:"task_timeout"`
+				KillGrace      int64 `json:"kill_grace"`
 				Connection     int64 `json:"connection"`
 			} `json:"timeout"`
 		}{
@@ -109,15 +323,97 @@ func LoadConfig() (*Config, error) {
 			Timeout: struct {
 				JobTimeout     int64 `json:"job_timeout"`
 				TaskTimeout    int64 `json:"task_timeout"`
+				KillGrace      int64 `json:"kill_grace"`
 				Connection     int64 `json:"connection"`
 			}{
 				JobTimeout:  300, // 5 minutes
 				TaskTimeout: 60,  // 1 minute
+				KillGrace:   5,   // 5 seconds
 				Connection:  30,  // 30 seconds
 			},
 		},
 	}
 	
+	// Instance defaults to the host name, which is stable across restarts
+	if hostname, err := os.Hostname(); err == nil {
+		cfg.InstanceID = hostname
+	}
+	
+	// Cron defaults
+	cfg.Cron.Enabled = true
+	cfg.Cron.TickInterval = 1
+	cfg.Cron.MisfireThreshold = 60
+	
+	// Queue defaults
+	cfg.Queue.Backend = "postgres"
+	cfg.Queue.Capacity = 1000
+	cfg.Queue.VisibilityTimeout = 360 // job timeout plus a minute of slack
+	cfg.Queue.PollInterval = 200
+	
+	// Circuit breaker defaults
+	cfg.Breaker.Enabled = true
+	cfg.Breaker.WindowSize = 20
+	cfg.Breaker.MinRequests = 10
+	cfg.Breaker.FailureRate = 0.5
+	cfg.Breaker.OpenTimeout = 30
+	cfg.Breaker.HalfOpenProbes = 1
+	
+	// Hedging defaults
+	cfg.Hedge.Enabled = false
+	cfg.Hedge.Delay = 2000
+	cfg.Hedge.Percentile = 0.95
+	cfg.Hedge.MinSamples = 20
+	
+	// Idempotency defaults
+	cfg.Idempotency.Window = 86400 // one day
+	
+	// Preemption defaults
+	cfg.Preemption.Enabled = false
+	cfg.Preemption.MaxPriority = 1
+	cfg.Preemption.MinGap = 1
+	cfg.Preemption.MaxPerJob = 1
+	
+	// Remote worker defaults
+	cfg.Workers.Enabled = false
+	cfg.Workers.AckTimeout = 30
+	cfg.Workers.LeaseTimeout = 120
+	cfg.Workers.MaxDeliveries = 3
+	
+	// Cluster defaults
+	cfg.Cluster.Enabled = false
+	cfg.Cluster.HeartbeatInterval = 5
+	cfg.Cluster.MemberTTL = 15
+	cfg.Cluster.LeaseTTL = 15
+	
+	// Affinity defaults
+	cfg.Affinity.Window = 3600 // one hour
+	
+	// Budget defaults
+	cfg.Budget.DowngradePriority = 9
+	
+	// Scheduling defaults
+	cfg.Scheduling.Policy = "first-fit"
+	
+	// Escalation defaults
+	cfg.Escalation.MaxHops = 3
+	cfg.Escalation.OrgChart = "cyborgs/org_chart.txt"
+	
+	// Sandbox defaults
+	cfg.Sandbox.Level = "low"
+	cfg.Sandbox.CgroupParent = "/sys/fs/cgroup/cyborg-conductor"
+	
+	// Output defaults
+	cfg.Output.MemoryLimit = 1 << 20
+	cfg.Output.ExcerptBytes = 16 << 10
+	cfg.Output.SpillDir = "data/output"
+	cfg.Output.SpillRetention = 24
+	
+	// Adapter defaults
+	cfg.Adapter.Command = "python3"
+	cfg.Adapter.Args = "adapters/python/exec_wrapper.py --persistent"
+	cfg.Adapter.PoolSize = 2
+	cfg.Adapter.MaxRequests = 1000
+	
 	// Load from environment variables
 	loadFromEnv(cfg)
 	
@@ -148,6 +444,109 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("database password is required")
 	}
 	
+	// Validate queue configuration
+	if cfg.Queue.Backend != "postgres" && cfg.Queue.Backend != "memory" {
+		return fmt.Errorf("queue backend must be postgres or memory, got %q", cfg.Queue.Backend)
+	}
+	if cfg.Queue.Backend == "postgres" && cfg.InstanceID == "" {
+		return fmt.Errorf("instance ID is required for the postgres queue")
+	}
+	
+	// Validate circuit breaker configuration
+	if cfg.Breaker.FailureRate <= 0 || cfg.Breaker.FailureRate > 1 {
+		return fmt.Errorf("breaker failure rate must be in (0, 1], got %v", cfg.Breaker.FailureRate)
+	}
+	
+	// Validate hedging configuration
+	if cfg.Hedge.Percentile < 0 || cfg.Hedge.Percentile >= 1 {
+		return fmt.Errorf("hedge percentile must be in [0, 1), got %v", cfg.Hedge.Percentile)
+	}
+	if cfg.Hedge.Enabled && cfg.Hedge.Delay <= 0 && cfg.Hedge.Percentile == 0 {
+		return fmt.Errorf("hedging needs a delay or a latency percentile")
+	}
+	
+	// Validate idempotency configuration
+	if cfg.Idempotency.Window <= 0 {
+		return fmt.Errorf("idempotency window must be positive, got %d", cfg.Idempotency.Window)
+	}
+	
+	// Validate preemption configuration
+	if cfg.Preemption.MinGap < 1 {
+		return fmt.Errorf("preemption min gap must be at least 1, got %d", cfg.Preemption.MinGap)
+	}
+	if cfg.Preemption.MaxPerJob < 0 {
+		return fmt.Errorf("preemption max per job must not be negative, got %d", cfg.Preemption.MaxPerJob)
+	}
+	
+	// Validate remote worker configuration
+	if cfg.Workers.AckTimeout <= 0 {
+		return fmt.Errorf("worker ack timeout must be positive, got %d", cfg.Workers.AckTimeout)
+	}
+	if cfg.Workers.LeaseTimeout <= 0 {
+		return fmt.Errorf("worker lease timeout must be positive, got %d", cfg.Workers.LeaseTimeout)
+	}
+	if cfg.Workers.MaxDeliveries < 1 {
+		return fmt.Errorf("worker max deliveries must be at least 1, got %d", cfg.Workers.MaxDeliveries)
+	}
+	
+	if cfg.Affinity.Window <= 0 {
+		return fmt.Errorf("affinity window must be positive, got %d", cfg.Affinity.Window)
+	}
+	
+	if cfg.Budget.DowngradePriority < 1 {
+		return fmt.Errorf("budget downgrade priority must be at least 1, got %d", cfg.Budget.DowngradePriority)
+	}
+	
+	if cfg.Escalation.Enabled && cfg.Escalation.MaxHops < 1 {
+		return fmt.Errorf("escalation max hops must be at least 1, got %d", cfg.Escalation.MaxHops)
+	}
+	
+	if cfg.Sandbox.Enabled {
+		switch strings.ToLower(cfg.Sandbox.Level) {
+		case "none", "low", "medium", "high", "critical":
+		default:
+			return fmt.Errorf("invalid sandbox level %q", cfg.Sandbox.Level)
+		}
+	}
+	
+	if cfg.Output.MemoryLimit <= 0 {
+		return fmt.Errorf("output memory limit must be positive, got %d", cfg.Output.MemoryLimit)
+	}
+	if cfg.Output.ExcerptBytes < 0 || int64(cfg.Output.ExcerptBytes)*2 > cfg.Output.MemoryLimit {
+		return fmt.Errorf("output excerpts must fit in the memory limit, got %d bytes each", cfg.Output.ExcerptBytes)
+	}
+	if cfg.Output.SpillRetention <= 0 {
+		return fmt.Errorf("output spill retention must be positive, got %d", cfg.Output.SpillRetention)
+	}
+	
+	if cfg.Adapter.Enabled {
+		if cfg.Adapter.Command == "" {
+			return fmt.Errorf("adapter command is required when the adapter is enabled")
+		}
+		if cfg.Adapter.PoolSize < 1 {
+			return fmt.Errorf("adapter pool size must be at least 1, got %d", cfg.Adapter.PoolSize)
+		}
+		if cfg.Adapter.MaxRequests < 0 {
+			return fmt.Errorf("adapter max requests must not be negative, got %d", cfg.Adapter.MaxRequests)
+		}
+	}
+	
+	// Validate cluster configuration
+	if cfg.Cluster.Enabled {
+		if cfg.Queue.Backend != "postgres" {
+			return fmt.Errorf("cluster mode requires the postgres queue backend, got %q", cfg.Queue.Backend)
+		}
+		if cfg.Cluster.HeartbeatInterval <= 0 {
+			return fmt.Errorf("cluster heartbeat interval must be positive, got %d", cfg.Cluster.HeartbeatInterval)
+		}
+		if cfg.Cluster.MemberTTL <= cfg.Cluster.HeartbeatInterval {
+			return fmt.Errorf("cluster member TTL must exceed the heartbeat interval, got %d", cfg.Cluster.MemberTTL)
+		}
+		if cfg.Cluster.LeaseTTL <= cfg.Cluster.HeartbeatInterval {
+			return fmt.Errorf("cluster lease TTL must exceed the heartbeat interval, got %d", cfg.Cluster.LeaseTTL)
+		}
+	}
+	
 	// Validate server configuration
 	if cfg.Server.Host == "" {
 		return fmt.Errorf("server host is required")
@@ -229,11 +628,106 @@ func loadFromEnv(cfg *Config) {
 			cfg.Runtime.Timeout.TaskTimeout = taskTimeout
 		}
 	}
+	cfg.Runtime.Timeout.KillGrace = GetEnvInt64("TASK_KILL_GRACE", cfg.Runtime.Timeout.KillGrace)
 	if connTimeoutStr := os.Getenv("CONNECTION_TIMEOUT"); connTimeoutStr != "" {
 		if connTimeout, err := strconv.ParseInt(connTimeoutStr, 10, 64); err == nil {
 			cfg.Runtime.Timeout.Connection = connTimeout
 		}
 	}
+	
+	// Cron config
+	cfg.Cron.Enabled = GetEnvBool("CRON_ENABLED", cfg.Cron.Enabled)
+	cfg.Cron.TickInterval = GetEnvInt64("CRON_TICK_INTERVAL", cfg.Cron.TickInterval)
+	cfg.Cron.MisfireThreshold = GetEnvInt64("CRON_MISFIRE_THRESHOLD", cfg.Cron.MisfireThreshold)
+	
+	// Instance and queue config
+	cfg.InstanceID = GetEnv("INSTANCE_ID", cfg.InstanceID)
+	cfg.Queue.Backend = GetEnv("QUEUE_BACKEND", cfg.Queue.Backend)
+	cfg.Queue.Capacity = GetEnvInt("QUEUE_CAPACITY", cfg.Queue.Capacity)
+	cfg.Queue.VisibilityTimeout = GetEnvInt64("QUEUE_VISIBILITY_TIMEOUT", cfg.Queue.VisibilityTimeout)
+	cfg.Queue.PollInterval = GetEnvInt64("QUEUE_POLL_INTERVAL", cfg.Queue.PollInterval)
+	
+	// Circuit breaker config
+	cfg.Breaker.Enabled = GetEnvBool("BREAKER_ENABLED", cfg.Breaker.Enabled)
+	cfg.Breaker.PerCapability = GetEnvBool("BREAKER_PER_CAPABILITY", cfg.Breaker.PerCapability)
+	cfg.Breaker.WindowSize = GetEnvInt("BREAKER_WINDOW_SIZE", cfg.Breaker.WindowSize)
+	cfg.Breaker.MinRequests = GetEnvInt("BREAKER_MIN_REQUESTS", cfg.Breaker.MinRequests)
+	cfg.Breaker.FailureRate = GetEnvFloat64("BREAKER_FAILURE_RATE", cfg.Breaker.FailureRate)
+	cfg.Breaker.OpenTimeout = GetEnvInt64("BREAKER_OPEN_TIMEOUT", cfg.Breaker.OpenTimeout)
+	cfg.Breaker.HalfOpenProbes = GetEnvInt("BREAKER_HALF_OPEN_PROBES", cfg.Breaker.HalfOpenProbes)
+	
+	// Hedging config
+	cfg.Hedge.Enabled = GetEnvBool("HEDGE_ENABLED", cfg.Hedge.Enabled)
+	cfg.Hedge.Delay = GetEnvInt64("HEDGE_DELAY", cfg.Hedge.Delay)
+	cfg.Hedge.Percentile = GetEnvFloat64("HEDGE_PERCENTILE", cfg.Hedge.Percentile)
+	cfg.Hedge.MinSamples = GetEnvInt("HEDGE_MIN_SAMPLES", cfg.Hedge.MinSamples)
+	
+	// Idempotency config
+	cfg.Idempotency.Window = GetEnvInt64("IDEMPOTENCY_WINDOW", cfg.Idempotency.Window)
+	
+	// Rate limit config
+	cfg.RateLimit.Limits = GetEnv("RATE_LIMITS", cfg.RateLimit.Limits)
+	
+	// Preemption config
+	cfg.Preemption.Enabled = GetEnvBool("PREEMPTION_ENABLED", cfg.Preemption.Enabled)
+	cfg.Preemption.MaxPriority = GetEnvInt("PREEMPTION_MAX_PRIORITY", cfg.Preemption.MaxPriority)
+	cfg.Preemption.MinGap = GetEnvInt("PREEMPTION_MIN_GAP", cfg.Preemption.MinGap)
+	cfg.Preemption.MaxPerJob = GetEnvInt("PREEMPTION_MAX_PER_JOB", cfg.Preemption.MaxPerJob)
+	
+	// Remote worker config
+	cfg.Workers.Enabled = GetEnvBool("WORKERS_ENABLED", cfg.Workers.Enabled)
+	cfg.Workers.AckTimeout = GetEnvInt("WORKER_ACK_TIMEOUT", cfg.Workers.AckTimeout)
+	cfg.Workers.LeaseTimeout = GetEnvInt("WORKER_LEASE_TIMEOUT", cfg.Workers.LeaseTimeout)
+	cfg.Workers.MaxDeliveries = GetEnvInt("WORKER_MAX_DELIVERIES", cfg.Workers.MaxDeliveries)
+	
+	// Cluster config
+	cfg.Cluster.Enabled = GetEnvBool("CLUSTER_ENABLED", cfg.Cluster.Enabled)
+	cfg.Cluster.HeartbeatInterval = GetEnvInt("CLUSTER_HEARTBEAT_INTERVAL", cfg.Cluster.HeartbeatInterval)
+	cfg.Cluster.MemberTTL = GetEnvInt("CLUSTER_MEMBER_TTL", cfg.Cluster.MemberTTL)
+	cfg.Cluster.LeaseTTL = GetEnvInt("CLUSTER_LEASE_TTL", cfg.Cluster.LeaseTTL)
+	
+	// Affinity config
+	cfg.Affinity.Window = GetEnvInt64("AFFINITY_WINDOW", cfg.Affinity.Window)
+	
+	// Backpressure config
+	cfg.Backpressure.Shedding = GetEnv("LOAD_SHEDDING", cfg.Backpressure.Shedding)
+	
+	// Budget config
+	cfg.Budget.Limits = GetEnv("BUDGETS", cfg.Budget.Limits)
+	cfg.Budget.DowngradePriority = GetEnvInt("BUDGET_DOWNGRADE_PRIORITY", cfg.Budget.DowngradePriority)
+	
+	// Scheduling config
+	cfg.Scheduling.Policy = GetEnv("SCHEDULING_POLICY", cfg.Scheduling.Policy)
+	cfg.Scheduling.Policies = GetEnv("SCHEDULING_POLICIES", cfg.Scheduling.Policies)
+	cfg.Scheduling.Weights = GetEnv("SCHEDULING_WEIGHTS", cfg.Scheduling.Weights)
+	
+	// Escalation config
+	cfg.Escalation.Enabled = GetEnvBool("ESCALATION_ENABLED", cfg.Escalation.Enabled)
+	cfg.Escalation.MaxHops = GetEnvInt("ESCALATION_MAX_HOPS", cfg.Escalation.MaxHops)
+	cfg.Escalation.OrgChart = GetEnv("ORG_CHART", cfg.Escalation.OrgChart)
+	
+	// Sandbox config
+	cfg.Sandbox.Enabled = GetEnvBool("SANDBOX_ENABLED", cfg.Sandbox.Enabled)
+	cfg.Sandbox.Level = GetEnv("SANDBOX_LEVEL", cfg.Sandbox.Level)
+	cfg.Sandbox.CgroupParent = GetEnv("SANDBOX_CGROUP_PARENT", cfg.Sandbox.CgroupParent)
+	
+	// Output config
+	cfg.Output.MemoryLimit = GetEnvInt64("OUTPUT_MEMORY_LIMIT", cfg.Output.MemoryLimit)
+	cfg.Output.ExcerptBytes = GetEnvInt("OUTPUT_EXCERPT_BYTES", cfg.Output.ExcerptBytes)
+	cfg.Output.SpillDir = GetEnv("OUTPUT_SPILL_DIR", cfg.Output.SpillDir)
+	cfg.Output.SpillRetention = GetEnvInt64("OUTPUT_SPILL_RETENTION", cfg.Output.SpillRetention)
+	
+	// Executor config
+	cfg.Executor.Default = GetEnv("EXECUTOR_DEFAULT", cfg.Executor.Default)
+	cfg.Executor.JobTypes = GetEnv("EXECUTOR_JOB_TYPES", cfg.Executor.JobTypes)
+	cfg.Executor.Policies = GetEnv("EXECUTOR_POLICIES", cfg.Executor.Policies)
+	
+	// Adapter config
+	cfg.Adapter.Enabled = GetEnvBool("ADAPTER_ENABLED", cfg.Adapter.Enabled)
+	cfg.Adapter.Command = GetEnv("ADAPTER_COMMAND", cfg.Adapter.Command)
+	cfg.Adapter.Args = GetEnv("ADAPTER_ARGS", cfg.Adapter.Args)
+	cfg.Adapter.PoolSize = GetEnvInt("ADAPTER_POOL_SIZE", cfg.Adapter.PoolSize)
+	cfg.Adapter.MaxRequests = GetEnvInt("ADAPTER_MAX_REQUESTS", cfg.Adapter.MaxRequests)
 }
 
 // GetEnv gets an environment variable value with a default fallback
@@ -264,6 +758,16 @@ func GetEnvInt64(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+// GetEnvFloat64 gets an environment variable float64 value with a default fallback
+func GetEnvFloat64(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
 // GetEnvBool gets an environment variable boolean value with a default fallback
 func GetEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
@@ -54,6 +54,7 @@ func TestLoadConfig(t *testing.T) {
 	assert.Equal(t, 100, cfg.Runtime.MaxConcurrentStreams)
 	assert.Equal(t, int64(300), cfg.Runtime.Timeout.JobTimeout)
 	assert.Equal(t, int64(60), cfg.Runtime.Timeout.TaskTimeout)
+	assert.Equal(t, int64(5), cfg.Runtime.Timeout.KillGrace)
 	assert.Equal(t, int64(30), cfg.Runtime.Timeout.Connection)
 
 	// Test environment variable overrides
@@ -76,6 +77,103 @@ func TestLoadConfig(t *testing.T) {
 	assert.Equal(t, "/custom/path", cfg2.Cyborg.JobsMatrixPath)
 }
 
+func TestLoadConfigCron(t *testing.T) {
+	os.Unsetenv("CRON_ENABLED")
+	os.Unsetenv("CRON_TICK_INTERVAL")
+	defer os.Unsetenv("CRON_ENABLED")
+	defer os.Unsetenv("CRON_TICK_INTERVAL")
+
+	cfg, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.True(t, cfg.Cron.Enabled)
+	assert.Equal(t, int64(1), cfg.Cron.TickInterval)
+	assert.Equal(t, int64(60), cfg.Cron.MisfireThreshold)
+
+	os.Setenv("CRON_ENABLED", "false")
+	os.Setenv("CRON_TICK_INTERVAL", "5")
+
+	cfg, err = LoadConfig()
+	assert.NoError(t, err)
+	assert.False(t, cfg.Cron.Enabled)
+	assert.Equal(t, int64(5), cfg.Cron.TickInterval)
+}
+
+func TestLoadConfigQueue(t *testing.T) {
+	defer os.Unsetenv("QUEUE_BACKEND")
+	defer os.Unsetenv("INSTANCE_ID")
+
+	cfg, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres", cfg.Queue.Backend)
+	assert.Equal(t, 1000, cfg.Queue.Capacity)
+	assert.Equal(t, int64(360), cfg.Queue.VisibilityTimeout)
+	assert.NotEmpty(t, cfg.InstanceID)
+
+	os.Setenv("QUEUE_BACKEND", "memory")
+	os.Setenv("INSTANCE_ID", "conductor-a")
+	cfg, err = LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "memory", cfg.Queue.Backend)
+	assert.Equal(t, "conductor-a", cfg.InstanceID)
+
+	os.Setenv("QUEUE_BACKEND", "kafka")
+	_, err = LoadConfig()
+	assert.Error(t, err)
+}
+
+func TestLoadConfigBreaker(t *testing.T) {
+	defer os.Unsetenv("BREAKER_FAILURE_RATE")
+	defer os.Unsetenv("BREAKER_PER_CAPABILITY")
+
+	cfg, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.True(t, cfg.Breaker.Enabled)
+	assert.False(t, cfg.Breaker.PerCapability)
+	assert.Equal(t, 0.5, cfg.Breaker.FailureRate)
+
+	os.Setenv("BREAKER_FAILURE_RATE", "0.25")
+	os.Setenv("BREAKER_PER_CAPABILITY", "true")
+	cfg, err = LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, 0.25, cfg.Breaker.FailureRate)
+	assert.True(t, cfg.Breaker.PerCapability)
+
+	os.Setenv("BREAKER_FAILURE_RATE", "1.5")
+	_, err = LoadConfig()
+	assert.Error(t, err)
+}
+
+func TestLoadConfigAdapter(t *testing.T) {
+	defer os.Unsetenv("ADAPTER_ENABLED")
+	defer os.Unsetenv("ADAPTER_POOL_SIZE")
+	defer os.Unsetenv("EXECUTOR_JOB_TYPES")
+	defer os.Unsetenv("EXECUTOR_POLICIES")
+
+	cfg, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.False(t, cfg.Adapter.Enabled)
+	assert.Equal(t, "python3", cfg.Adapter.Command)
+	assert.Equal(t, 2, cfg.Adapter.PoolSize)
+	assert.Equal(t, 1000, cfg.Adapter.MaxRequests)
+	assert.Empty(t, cfg.Executor.Default)
+	assert.Empty(t, cfg.Executor.Policies)
+
+	os.Setenv("ADAPTER_ENABLED", "true")
+	os.Setenv("ADAPTER_POOL_SIZE", "4")
+	os.Setenv("EXECUTOR_JOB_TYPES", "LLM=adapter")
+	os.Setenv("EXECUTOR_POLICIES", "/etc/cyborg/exec-policies.json")
+	cfg, err = LoadConfig()
+	assert.NoError(t, err)
+	assert.True(t, cfg.Adapter.Enabled)
+	assert.Equal(t, 4, cfg.Adapter.PoolSize)
+	assert.Equal(t, "LLM=adapter", cfg.Executor.JobTypes)
+	assert.Equal(t, "/etc/cyborg/exec-policies.json", cfg.Executor.Policies)
+
+	os.Setenv("ADAPTER_POOL_SIZE", "0")
+	_, err = LoadConfig()
+	assert.Error(t, err)
+}
+
 func TestGetEnv(t *testing.T) {
 	// Test with default value
 	result := GetEnv("NONEXISTENT_VAR", "default")
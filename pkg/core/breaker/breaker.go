@@ -0,0 +1,278 @@
+// Package breaker implements circuit breakers that stop the schedulers from
+// sending work to cyborgs whose recent executions mostly fail.
+//
+// A breaker starts closed and counts outcomes over a rolling window. Once
+// the failure rate crosses a threshold it opens and rejects work; after a
+// cool-down it lets a few probe executions through (half-open) and closes
+// again if they succeed.
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the position of a circuit breaker
+type State int
+
+const (
+	// Closed lets all work through
+	Closed State = iota
+	// Open rejects all work until the cool-down expires
+	Open
+	// HalfOpen lets a limited number of probes through
+	HalfOpen
+)
+
+// String returns the lower-case name of the state
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half_open"
+	}
+	return "unknown"
+}
+
+// Config tunes when a breaker trips and recovers
+type Config struct {
+	// WindowSize is how many recent outcomes the failure rate covers
+	WindowSize int
+	// MinRequests is how many outcomes the window needs before it may trip
+	MinRequests int
+	// FailureRate in (0, 1] at or above which the breaker opens
+	FailureRate float64
+	// OpenTimeout is how long the breaker stays open before probing
+	OpenTimeout time.Duration
+	// HalfOpenProbes is how many successful probes close the breaker
+	HalfOpenProbes int
+}
+
+// DefaultConfig trips after half of at least 10 of the last 20 executions
+// fail and probes again after 30 seconds
+func DefaultConfig() Config {
+	return Config{
+		WindowSize:     20,
+		MinRequests:    10,
+		FailureRate:    0.5,
+		OpenTimeout:    30 * time.Second,
+		HalfOpenProbes: 1,
+	}
+}
+
+// withDefaults fills unset fields from DefaultConfig
+func (c Config) withDefaults() Config {
+	d := DefaultConfig()
+	if c.WindowSize <= 0 {
+		c.WindowSize = d.WindowSize
+	}
+	if c.MinRequests <= 0 {
+		c.MinRequests = d.MinRequests
+	}
+	if c.MinRequests > c.WindowSize {
+		c.MinRequests = c.WindowSize
+	}
+	if c.FailureRate <= 0 || c.FailureRate > 1 {
+		c.FailureRate = d.FailureRate
+	}
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = d.OpenTimeout
+	}
+	if c.HalfOpenProbes <= 0 {
+		c.HalfOpenProbes = d.HalfOpenProbes
+	}
+	return c
+}
+
+// Breaker is a single circuit breaker. It is safe for concurrent use.
+type Breaker struct {
+	mu  sync.Mutex
+	cfg Config
+
+	state State
+	// outcomes is a ring buffer of recent results; true means failure
+	outcomes []bool
+	next     int
+	count    int
+	failures int
+
+	openedAt time.Time
+	// probes counts probes handed out and succeeded while half-open
+	probes      int
+	probeOK     int
+	lastProbeAt time.Time
+
+	now      func() time.Time
+	onChange func(from, to State)
+}
+
+// New creates a closed breaker. onChange, if not nil, is called after every
+// state transition, outside the breaker's lock.
+func New(cfg Config, onChange func(from, to State)) *Breaker {
+	cfg = cfg.withDefaults()
+	return &Breaker{
+		cfg:      cfg,
+		outcomes: make([]bool, cfg.WindowSize),
+		now:      time.Now,
+		onChange: onChange,
+	}
+}
+
+// State returns the current state, moving an open breaker whose cool-down
+// has expired to half-open
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	from := b.state
+	to := b.advance()
+	b.mu.Unlock()
+	b.notify(from, to)
+	return to
+}
+
+// Allow reports whether work may be sent now. In the half-open state each
+// true result hands out one probe; a probe whose outcome is never recorded
+// is given up after OpenTimeout so the breaker cannot get stuck.
+func (b *Breaker) Allow() bool {
+	allowed, _ := b.take()
+	return allowed
+}
+
+// take is Allow, also reporting whether it handed out a probe
+func (b *Breaker) take() (allowed, probe bool) {
+	b.mu.Lock()
+	from := b.state
+	to := b.advance()
+	allowed = b.allow()
+	b.mu.Unlock()
+	b.notify(from, to)
+	return allowed, allowed && to == HalfOpen
+}
+
+// release gives back a probe that take handed out for work that was not
+// sent after all
+func (b *Breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == HalfOpen && b.probes > b.probeOK {
+		b.probes--
+	}
+}
+
+// Ready reports whether Allow would currently let work through without
+// handing out a probe
+func (b *Breaker) Ready() bool {
+	b.mu.Lock()
+	from := b.state
+	to := b.advance()
+	ready := to == Closed || (to == HalfOpen && b.probeAvailable())
+	b.mu.Unlock()
+	b.notify(from, to)
+	return ready
+}
+
+// Record reports the outcome of one execution
+func (b *Breaker) Record(failed bool) {
+	b.mu.Lock()
+	from := b.state
+	switch b.state {
+	case Closed:
+		b.push(failed)
+		if b.count >= b.cfg.MinRequests &&
+			float64(b.failures)/float64(b.count) >= b.cfg.FailureRate {
+			b.trip()
+		}
+	case HalfOpen:
+		if failed {
+			b.trip()
+			break
+		}
+		b.probeOK++
+		if b.probeOK >= b.cfg.HalfOpenProbes {
+			b.reset()
+		}
+	case Open:
+		// Late results from work sent before the breaker opened
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.notify(from, to)
+}
+
+// advance moves an expired open breaker to half-open. Callers hold b.mu.
+func (b *Breaker) advance() State {
+	if b.state == Open && b.now().Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.state = HalfOpen
+		b.probes, b.probeOK = 0, 0
+	}
+	return b.state
+}
+
+// allow hands out work or a probe. Callers hold b.mu.
+func (b *Breaker) allow() bool {
+	switch b.state {
+	case Closed:
+		return true
+	case HalfOpen:
+		if !b.probeAvailable() {
+			return false
+		}
+		if b.probes-b.probeOK >= b.cfg.HalfOpenProbes {
+			// Every outstanding probe timed out; start the round over
+			b.probes = b.probeOK
+		}
+		b.probes++
+		b.lastProbeAt = b.now()
+		return true
+	}
+	return false
+}
+
+// probeAvailable reports whether a half-open breaker can hand out a probe.
+// Callers hold b.mu.
+func (b *Breaker) probeAvailable() bool {
+	if b.probes-b.probeOK < b.cfg.HalfOpenProbes {
+		return true
+	}
+	return b.now().Sub(b.lastProbeAt) >= b.cfg.OpenTimeout
+}
+
+// push adds an outcome to the rolling window. Callers hold b.mu.
+func (b *Breaker) push(failed bool) {
+	if b.count == len(b.outcomes) {
+		if b.outcomes[b.next] {
+			b.failures--
+		}
+	} else {
+		b.count++
+	}
+	b.outcomes[b.next] = failed
+	if failed {
+		b.failures++
+	}
+	b.next = (b.next + 1) % len(b.outcomes)
+}
+
+// trip opens the breaker. Callers hold b.mu.
+func (b *Breaker) trip() {
+	b.state = Open
+	b.openedAt = b.now()
+}
+
+// reset closes the breaker with an empty window. Callers hold b.mu.
+func (b *Breaker) reset() {
+	b.state = Closed
+	b.next, b.count, b.failures = 0, 0, 0
+	for i := range b.outcomes {
+		b.outcomes[i] = false
+	}
+}
+
+// notify reports a transition to the change hook
+func (b *Breaker) notify(from, to State) {
+	if from != to && b.onChange != nil {
+		b.onChange(from, to)
+	}
+}
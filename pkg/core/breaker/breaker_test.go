@@ -0,0 +1,146 @@
+package breaker
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testBreaker returns a breaker with a controllable clock that records its
+// transitions
+func testBreaker(cfg Config, now *time.Time) (*Breaker, *[]State) {
+	var transitions []State
+	b := New(cfg, func(from, to State) { transitions = append(transitions, to) })
+	b.now = func() time.Time { return *now }
+	return b, &transitions
+}
+
+func TestBreakerTripsOnFailureRate(t *testing.T) {
+	now := time.Now()
+	b, transitions := testBreaker(Config{WindowSize: 10, MinRequests: 4, FailureRate: 0.5}, &now)
+
+	// Below MinRequests the breaker never trips
+	b.Record(true)
+	b.Record(true)
+	b.Record(true)
+	assert.Equal(t, Closed, b.State())
+
+	b.Record(false)
+	assert.Equal(t, Open, b.State(), "3 of 4 failed")
+	assert.False(t, b.Allow())
+	assert.Equal(t, []State{Open}, *transitions)
+}
+
+func TestBreakerWindowForgetsOldFailures(t *testing.T) {
+	now := time.Now()
+	b, _ := testBreaker(Config{WindowSize: 4, MinRequests: 4, FailureRate: 0.75}, &now)
+
+	b.Record(true)
+	b.Record(true)
+	for i := 0; i < 4; i++ {
+		b.Record(false)
+	}
+	b.Record(true)
+	b.Record(true)
+	assert.Equal(t, Closed, b.State(), "only 2 of the last 4 failed")
+}
+
+func TestBreakerHalfOpenRecovery(t *testing.T) {
+	now := time.Now()
+	cfg := Config{WindowSize: 2, MinRequests: 2, FailureRate: 1, OpenTimeout: 30 * time.Second, HalfOpenProbes: 2}
+	b, transitions := testBreaker(cfg, &now)
+
+	b.Record(true)
+	b.Record(true)
+	assert.False(t, b.Allow())
+
+	// After the cool-down two probes go through, and no more
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, HalfOpen, b.State())
+	assert.True(t, b.Allow())
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow())
+
+	// A failed probe reopens
+	b.Record(true)
+	assert.Equal(t, Open, b.State())
+
+	now = now.Add(30 * time.Second)
+	assert.True(t, b.Allow())
+	b.Record(false)
+	assert.Equal(t, HalfOpen, b.State())
+	assert.True(t, b.Allow())
+	b.Record(false)
+	assert.Equal(t, Closed, b.State())
+	assert.Equal(t, []State{Open, HalfOpen, Open, HalfOpen, Closed}, *transitions)
+}
+
+func TestBreakerAbandonedProbe(t *testing.T) {
+	now := time.Now()
+	cfg := Config{WindowSize: 1, MinRequests: 1, FailureRate: 1, OpenTimeout: 10 * time.Second}
+	b, _ := testBreaker(cfg, &now)
+
+	b.Record(true)
+	now = now.Add(10 * time.Second)
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow())
+
+	// The probe's outcome never arrives; another is handed out later
+	now = now.Add(10 * time.Second)
+	assert.True(t, b.Allow())
+}
+
+func TestSetPerCapability(t *testing.T) {
+	var mu sync.Mutex
+	var transitions []Transition
+	s := NewSet(Config{WindowSize: 1, MinRequests: 1, FailureRate: 1}, true, func(tr Transition) {
+		mu.Lock()
+		defer mu.Unlock()
+		transitions = append(transitions, tr)
+	})
+
+	s.Record("SALE0001", []string{"forecast"}, true)
+	assert.False(t, s.Allow("SALE0001", []string{"forecast"}))
+	assert.False(t, s.Allow("SALE0001", []string{"pipeline", "forecast"}))
+	assert.True(t, s.Allow("SALE0001", []string{"pipeline"}))
+	assert.True(t, s.Allow("SALE0002", []string{"forecast"}))
+
+	assert.Equal(t, []Transition{{Key: Key{"SALE0001", "forecast"}, From: Closed, To: Open}}, transitions)
+	assert.Equal(t, Open, s.States()[Key{"SALE0001", "forecast"}])
+}
+
+// TestSetReleasesProbes tests that a job refused by one capability's
+// breaker does not keep the probe another capability handed out
+func TestSetReleasesProbes(t *testing.T) {
+	now := time.Now()
+	s := NewSet(Config{WindowSize: 1, MinRequests: 1, FailureRate: 1, OpenTimeout: 10 * time.Second, HalfOpenProbes: 1}, true, nil)
+	s.now = func() time.Time { return now }
+
+	s.Record("SALE0001", []string{"pipeline"}, true)
+	now = now.Add(5 * time.Second)
+	s.Record("SALE0001", []string{"forecast"}, true)
+	now = now.Add(5 * time.Second)
+
+	// pipeline is half-open while forecast is still open
+	assert.False(t, s.Allow("SALE0001", []string{"pipeline", "forecast"}))
+	assert.True(t, s.Allow("SALE0001", []string{"pipeline"}))
+	assert.False(t, s.Allow("SALE0001", []string{"pipeline"}))
+
+	// A breaker can refuse after looking ready: listed twice, forecast
+	// hands out its one probe and then refuses
+	now = now.Add(5 * time.Second)
+	assert.False(t, s.Allow("SALE0001", []string{"forecast", "forecast"}))
+	assert.True(t, s.Allow("SALE0001", []string{"forecast"}))
+}
+
+func TestSetPerCyborg(t *testing.T) {
+	s := NewSet(Config{WindowSize: 1, MinRequests: 1, FailureRate: 1}, false, nil)
+	s.Record("SALE0001", []string{"forecast"}, true)
+	assert.False(t, s.Allow("SALE0001", []string{"pipeline"}))
+
+	var unset *Set
+	assert.True(t, unset.Allow("SALE0001", nil))
+	unset.Record("SALE0001", nil, true)
+}
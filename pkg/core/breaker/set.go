@@ -0,0 +1,150 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// Key identifies one breaker in a Set. Capability is empty for the breaker
+// guarding a cyborg as a whole.
+type Key struct {
+	CyborgID   string
+	Capability string
+}
+
+// Transition is a state change of one breaker in a Set
+type Transition struct {
+	Key
+	From State
+	To   State
+}
+
+// Set holds a breaker per cyborg, or per cyborg and capability, created on
+// first use. A nil *Set allows everything, so callers need not check
+// whether breakers are configured.
+type Set struct {
+	mu            sync.Mutex
+	cfg           Config
+	perCapability bool
+	breakers      map[Key]*Breaker
+	onChange      func(Transition)
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// NewSet creates a breaker set. With perCapability a cyborg that keeps
+// failing one capability still receives work for its others. onChange, if
+// not nil, is called after every transition.
+func NewSet(cfg Config, perCapability bool, onChange func(Transition)) *Set {
+	return &Set{
+		cfg:           cfg.withDefaults(),
+		perCapability: perCapability,
+		breakers:      make(map[Key]*Breaker),
+		onChange:      onChange,
+		now:           time.Now,
+	}
+}
+
+// Ready reports whether a job needing capabilities could be sent to the
+// cyborg, without taking a half-open probe
+func (s *Set) Ready(cyborgID string, capabilities []string) bool {
+	if s == nil {
+		return true
+	}
+	for _, b := range s.lookup(cyborgID, capabilities) {
+		if !b.Ready() {
+			return false
+		}
+	}
+	return true
+}
+
+// Allow reports whether a job needing capabilities may be sent to the
+// cyborg, handing out half-open probes when it does. A job refused by one
+// breaker takes no probe from the others.
+func (s *Set) Allow(cyborgID string, capabilities []string) bool {
+	if s == nil {
+		return true
+	}
+	var probes []*Breaker
+	for _, b := range s.lookup(cyborgID, capabilities) {
+		allowed, probe := b.take()
+		if !allowed {
+			for _, p := range probes {
+				p.release()
+			}
+			return false
+		}
+		if probe {
+			probes = append(probes, b)
+		}
+	}
+	return true
+}
+
+// Record reports the outcome of a job the cyborg executed
+func (s *Set) Record(cyborgID string, capabilities []string, failed bool) {
+	if s == nil {
+		return
+	}
+	for _, b := range s.lookup(cyborgID, capabilities) {
+		b.Record(failed)
+	}
+}
+
+// States returns the current state of every breaker in the set
+func (s *Set) States() map[Key]State {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	breakers := make(map[Key]*Breaker, len(s.breakers))
+	for key, b := range s.breakers {
+		breakers[key] = b
+	}
+	s.mu.Unlock()
+
+	states := make(map[Key]State, len(breakers))
+	for key, b := range breakers {
+		states[key] = b.State()
+	}
+	return states
+}
+
+// lookup returns the breakers guarding a job, creating missing ones
+func (s *Set) lookup(cyborgID string, capabilities []string) []*Breaker {
+	keys := []Key{{CyborgID: cyborgID}}
+	if s.perCapability && len(capabilities) > 0 {
+		keys = keys[:0]
+		for _, capability := range capabilities {
+			keys = append(keys, Key{CyborgID: cyborgID, Capability: capability})
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	breakers := make([]*Breaker, 0, len(keys))
+	for _, key := range keys {
+		b, exists := s.breakers[key]
+		if !exists {
+			b = s.newBreaker(key)
+			s.breakers[key] = b
+		}
+		breakers = append(breakers, b)
+	}
+	return breakers
+}
+
+// newBreaker creates the breaker for key. Callers hold s.mu.
+func (s *Set) newBreaker(key Key) *Breaker {
+	var onChange func(from, to State)
+	if s.onChange != nil {
+		onChange = func(from, to State) {
+			s.onChange(Transition{Key: key, From: from, To: to})
+		}
+	}
+	b := New(s.cfg, onChange)
+	b.now = s.now
+	return b
+}
@@ -0,0 +1,256 @@
+// Package budget accounts the model tokens and cost that LLM jobs spend,
+// per namespace and per cyborg, over daily and monthly windows, and checks
+// the spend against soft and hard limits.
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scope is what a budget is accounted to
+type Scope string
+
+const (
+	// ScopeNamespace budgets the jobs of one namespace
+	ScopeNamespace Scope = "namespace"
+	// ScopeCyborg budgets the jobs run on one cyborg
+	ScopeCyborg Scope = "cyborg"
+)
+
+// Window is the period a budget resets after
+type Window string
+
+const (
+	// WindowDaily resets at midnight UTC
+	WindowDaily Window = "daily"
+	// WindowMonthly resets at midnight UTC on the first of the month
+	WindowMonthly Window = "monthly"
+)
+
+// Windows lists every window usage is accounted over
+var Windows = []Window{WindowDaily, WindowMonthly}
+
+// Measure is what a budget limits
+type Measure string
+
+const (
+	// MeasureTokens limits model tokens
+	MeasureTokens Measure = "tokens"
+	// MeasureCost limits spend, in the currency cyborgs report cost in
+	MeasureCost Measure = "cost"
+)
+
+// Action is what happens to jobs once a limit is reached
+type Action string
+
+const (
+	// ActionWarn only reports the overrun
+	ActionWarn Action = "warn"
+	// ActionDowngrade runs further jobs at a less urgent priority
+	ActionDowngrade Action = "downgrade"
+	// ActionReject refuses further jobs until the window resets
+	ActionReject Action = "reject"
+)
+
+// severity orders actions from none to reject
+func (a Action) severity() int {
+	switch a {
+	case ActionWarn:
+		return 1
+	case ActionDowngrade:
+		return 2
+	case ActionReject:
+		return 3
+	}
+	return 0
+}
+
+// WildcardKey makes a budget apply to every namespace or cyborg separately
+const WildcardKey = "*"
+
+// Usage is what jobs spent
+type Usage struct {
+	Tokens int64   `json:"tokens"`
+	Cost   float64 `json:"cost"`
+}
+
+// ErrNegativeUsage is returned for usage with negative tokens or cost,
+// which would refund a budget
+var ErrNegativeUsage = errors.New("usage must not be negative")
+
+// IsZero reports whether nothing was spent
+func (u Usage) IsZero() bool {
+	return u.Tokens == 0 && u.Cost == 0
+}
+
+// amount returns the usage in a measure
+func (u Usage) amount(m Measure) float64 {
+	if m == MeasureCost {
+		return u.Cost
+	}
+	return float64(u.Tokens)
+}
+
+// Limit is a threshold and what happens once usage reaches it; a zero
+// Amount disables it
+type Limit struct {
+	Amount float64 `json:"amount"`
+	Action Action  `json:"action"`
+}
+
+// Budget caps the usage of a namespace or cyborg over a window
+type Budget struct {
+	Scope Scope `json:"scope"`
+	// Key is the namespace or cyborg ID, or WildcardKey
+	Key     string  `json:"key"`
+	Window  Window  `json:"window"`
+	Measure Measure `json:"measure"`
+	Soft    Limit   `json:"soft"`
+	Hard    Limit   `json:"hard"`
+}
+
+// Account identifies whose usage is counted
+type Account struct {
+	Scope Scope  `json:"scope"`
+	Key   string `json:"key"`
+}
+
+// Store persists usage per account and window period
+type Store interface {
+	// Add adds u to an account's usage in the period starting at period and
+	// returns the new total
+	Add(ctx context.Context, account Account, window Window, period time.Time, u Usage) (Usage, error)
+
+	// Get returns an account's usage in the period starting at period
+	Get(ctx context.Context, account Account, window Window, period time.Time) (Usage, error)
+
+	// List returns the usage of every account of scope with usage in the
+	// period starting at period, by key
+	List(ctx context.Context, scope Scope, window Window, period time.Time) (map[string]Usage, error)
+}
+
+// PeriodStart returns when the window's period containing t began
+func PeriodStart(window Window, t time.Time) time.Time {
+	t = t.UTC()
+	if window == WindowMonthly {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// PeriodEnd returns when the window's period containing t resets
+func PeriodEnd(window Window, t time.Time) time.Time {
+	start := PeriodStart(window, t)
+	if window == WindowMonthly {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// ParseBudgets reads budgets from a comma-separated list of
+// SCOPE.KEY.WINDOW=LIMIT[/LIMIT] entries. With one LIMIT it is the hard
+// limit; with two they are the soft and hard limits. Each LIMIT is
+// AMOUNT[:ACTION], where an AMOUNT prefixed with $ limits cost and a bare
+// AMOUNT limits tokens. Soft limits warn and hard limits reject unless an
+// action is given, for example
+// "namespace.finance.daily=$40:warn/$50,cyborg.*.monthly=4000000:downgrade/5000000".
+func ParseBudgets(spec string) ([]Budget, error) {
+	var budgets []Budget
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, limits, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("budget %q: want SCOPE.KEY.WINDOW=LIMIT[/LIMIT]", entry)
+		}
+		b, err := parseName(strings.TrimSpace(name))
+		if err != nil {
+			return nil, fmt.Errorf("budget %q: %w", entry, err)
+		}
+		if err := parseLimits(&b, limits); err != nil {
+			return nil, fmt.Errorf("budget %q: %w", entry, err)
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, nil
+}
+
+// parseName reads SCOPE.KEY.WINDOW; the key may itself contain dots
+func parseName(name string) (Budget, error) {
+	scope, rest, ok := strings.Cut(name, ".")
+	i := strings.LastIndex(rest, ".")
+	if !ok || i <= 0 {
+		return Budget{}, fmt.Errorf("name must be SCOPE.KEY.WINDOW")
+	}
+	b := Budget{Scope: Scope(scope), Key: rest[:i], Window: Window(rest[i+1:])}
+	if b.Scope != ScopeNamespace && b.Scope != ScopeCyborg {
+		return Budget{}, fmt.Errorf("scope must be %s or %s", ScopeNamespace, ScopeCyborg)
+	}
+	if b.Window != WindowDaily && b.Window != WindowMonthly {
+		return Budget{}, fmt.Errorf("window must be %s or %s", WindowDaily, WindowMonthly)
+	}
+	return b, nil
+}
+
+// parseLimits reads LIMIT[/LIMIT] into b's soft and hard limits
+func parseLimits(b *Budget, spec string) error {
+	parts := strings.Split(spec, "/")
+	if len(parts) > 2 {
+		return fmt.Errorf("want at most a soft and a hard limit")
+	}
+
+	var measures []Measure
+	var err error
+	if len(parts) == 2 {
+		var m Measure
+		if b.Soft, m, err = parseLimit(parts[0], ActionWarn); err != nil {
+			return fmt.Errorf("soft limit: %w", err)
+		}
+		measures = append(measures, m)
+	}
+	var m Measure
+	if b.Hard, m, err = parseLimit(parts[len(parts)-1], ActionReject); err != nil {
+		return fmt.Errorf("hard limit: %w", err)
+	}
+	measures = append(measures, m)
+
+	if len(measures) == 2 {
+		if measures[0] != measures[1] {
+			return fmt.Errorf("soft and hard limits must both be tokens or both be cost")
+		}
+		if b.Soft.Amount >= b.Hard.Amount {
+			return fmt.Errorf("soft limit must be below the hard limit")
+		}
+	}
+	b.Measure = measures[0]
+	return nil
+}
+
+// parseLimit reads AMOUNT[:ACTION]
+func parseLimit(s string, defaultAction Action) (Limit, Measure, error) {
+	amount, action, hasAction := strings.Cut(strings.TrimSpace(s), ":")
+	measure := MeasureTokens
+	if strings.HasPrefix(amount, "$") {
+		measure, amount = MeasureCost, amount[1:]
+	}
+
+	limit := Limit{Action: defaultAction}
+	var err error
+	if limit.Amount, err = strconv.ParseFloat(amount, 64); err != nil || limit.Amount <= 0 {
+		return Limit{}, "", fmt.Errorf("amount must be a positive number")
+	}
+	if hasAction {
+		limit.Action = Action(action)
+		if limit.Action.severity() == 0 {
+			return Limit{}, "", fmt.Errorf("action must be %s, %s or %s", ActionWarn, ActionDowngrade, ActionReject)
+		}
+	}
+	return limit, measure, nil
+}
@@ -0,0 +1,145 @@
+package budget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBudgets(t *testing.T) {
+	budgets, err := ParseBudgets("namespace.finance.daily=$40:warn/$50, cyborg.*.monthly=4000000:downgrade/5000000 ,namespace.eu.ops.monthly=100000")
+	require.NoError(t, err)
+	require.Len(t, budgets, 3)
+	assert.Equal(t, Budget{
+		Scope: ScopeNamespace, Key: "finance", Window: WindowDaily, Measure: MeasureCost,
+		Soft: Limit{Amount: 40, Action: ActionWarn}, Hard: Limit{Amount: 50, Action: ActionReject},
+	}, budgets[0])
+	assert.Equal(t, Budget{
+		Scope: ScopeCyborg, Key: WildcardKey, Window: WindowMonthly, Measure: MeasureTokens,
+		Soft: Limit{Amount: 4000000, Action: ActionDowngrade}, Hard: Limit{Amount: 5000000, Action: ActionReject},
+	}, budgets[1])
+	assert.Equal(t, Budget{
+		Scope: ScopeNamespace, Key: "eu.ops", Window: WindowMonthly, Measure: MeasureTokens,
+		Hard: Limit{Amount: 100000, Action: ActionReject},
+	}, budgets[2])
+
+	empty, err := ParseBudgets("")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	for _, bad := range []string{
+		"namespace.finance.daily",
+		"team.finance.daily=10",
+		"namespace.finance.weekly=10",
+		"namespace.daily=10",
+		"namespace.finance.daily=-1",
+		"namespace.finance.daily=10:ignore",
+		"namespace.finance.daily=$10/100",
+		"namespace.finance.daily=50/40",
+		"namespace.finance.daily=1/2/3",
+	} {
+		_, err := ParseBudgets(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestPeriods(t *testing.T) {
+	at := time.Date(2026, 2, 28, 23, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC), PeriodStart(WindowDaily, at))
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), PeriodEnd(WindowDaily, at))
+	assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), PeriodStart(WindowMonthly, at))
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), PeriodEnd(WindowMonthly, at))
+}
+
+func TestTrackerLevelsAndAlerts(t *testing.T) {
+	budgets, err := ParseBudgets("namespace.finance.daily=$40:downgrade/$50,cyborg.*.monthly=1000:warn/2000")
+	require.NoError(t, err)
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker(NewMemoryStore(), budgets)
+	tracker.now = func() time.Time { return now }
+	var alerts []Status
+	tracker.OnAlert(func(s Status) { alerts = append(alerts, s) })
+	ctx := context.Background()
+
+	status, err := tracker.Check(ctx, "finance", "FINC0001")
+	require.NoError(t, err)
+	assert.Nil(t, status)
+
+	// Crossing the cyborg's soft limit warns once
+	require.NoError(t, tracker.Record(ctx, "finance", "FINC0001", Usage{Tokens: 1200, Cost: 12}))
+	require.NoError(t, tracker.Record(ctx, "finance", "FINC0001", Usage{Tokens: 100, Cost: 1}))
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "FINC0001", alerts[0].Key)
+	assert.Equal(t, LevelSoft, alerts[0].Level)
+	status, err = tracker.Check(ctx, "finance", "FINC0001")
+	require.NoError(t, err)
+	assert.Equal(t, ActionWarn, status.Action)
+
+	// The namespace's downgrade is more severe than the cyborg's warning
+	require.NoError(t, tracker.Record(ctx, "finance", "FINC0002", Usage{Cost: 30}))
+	status, err = tracker.Check(ctx, "finance", "FINC0001")
+	require.NoError(t, err)
+	assert.Equal(t, ScopeNamespace, status.Scope)
+	assert.Equal(t, ActionDowngrade, status.Action)
+	assert.Equal(t, time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC), status.ResetAt)
+
+	// Other namespaces are not affected by the finance budget
+	status, err = tracker.Check(ctx, "marketing", "")
+	require.NoError(t, err)
+	assert.Nil(t, status)
+
+	require.NoError(t, tracker.Record(ctx, "finance", "FINC0002", Usage{Cost: 10}))
+	status, err = tracker.Check(ctx, "finance", "")
+	require.NoError(t, err)
+	assert.Equal(t, LevelHard, status.Level)
+	assert.Equal(t, ActionReject, status.Action)
+
+	statuses, err := tracker.Statuses(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	assert.Equal(t, "finance", statuses[0].Key)
+	assert.Equal(t, Usage{Tokens: 1300, Cost: 53}, statuses[0].Used)
+	assert.Equal(t, "FINC0001", statuses[1].Key)
+	assert.Equal(t, "FINC0002", statuses[2].Key)
+	assert.Equal(t, LevelOK, statuses[2].Level)
+
+	// The daily window resets at midnight UTC; the monthly one carries on
+	now = now.Add(12 * time.Hour)
+	status, err = tracker.Check(ctx, "finance", "")
+	require.NoError(t, err)
+	assert.Nil(t, status)
+	status, err = tracker.Check(ctx, "", "FINC0001")
+	require.NoError(t, err)
+	assert.Equal(t, ActionWarn, status.Action)
+}
+
+func TestTrackerRejectsNegativeUsage(t *testing.T) {
+	budgets, err := ParseBudgets("namespace.finance.daily=1000")
+	require.NoError(t, err)
+	tracker := NewTracker(NewMemoryStore(), budgets)
+	ctx := context.Background()
+
+	require.NoError(t, tracker.Record(ctx, "finance", "FINC0001", Usage{Tokens: 600}))
+	assert.ErrorIs(t, tracker.Record(ctx, "finance", "FINC0001", Usage{Tokens: -600}), ErrNegativeUsage)
+	assert.ErrorIs(t, tracker.Record(ctx, "finance", "FINC0001", Usage{Tokens: 10, Cost: -5}), ErrNegativeUsage)
+
+	statuses, err := tracker.Statuses(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, Usage{Tokens: 600}, statuses[0].Used)
+}
+
+func TestNilTracker(t *testing.T) {
+	var tracker *Tracker
+	ctx := context.Background()
+	require.NoError(t, tracker.Record(ctx, "finance", "FINC0001", Usage{Tokens: 10}))
+	status, err := tracker.Check(ctx, "finance", "FINC0001")
+	require.NoError(t, err)
+	assert.Nil(t, status)
+	statuses, err := tracker.Statuses(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, statuses)
+}
@@ -0,0 +1,59 @@
+package budget
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryKey identifies usage in a MemoryStore
+type memoryKey struct {
+	account Account
+	window  Window
+	period  int64
+}
+
+// MemoryStore is an in-memory Store for tests and single-process use
+type MemoryStore struct {
+	mu    sync.Mutex
+	usage map[memoryKey]Usage
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{usage: make(map[memoryKey]Usage)}
+}
+
+// Add adds u to an account's usage in a period and returns the new total
+func (s *MemoryStore) Add(ctx context.Context, account Account, window Window, period time.Time, u Usage) (Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := memoryKey{account, window, period.Unix()}
+	total := s.usage[k]
+	total.Tokens += u.Tokens
+	total.Cost += u.Cost
+	s.usage[k] = total
+	return total, nil
+}
+
+// Get returns an account's usage in a period
+func (s *MemoryStore) Get(ctx context.Context, account Account, window Window, period time.Time) (Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage[memoryKey{account, window, period.Unix()}], nil
+}
+
+// List returns the usage of every account of scope in a period, by key
+func (s *MemoryStore) List(ctx context.Context, scope Scope, window Window, period time.Time) (map[string]Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usage := make(map[string]Usage)
+	for k, u := range s.usage {
+		if k.account.Scope == scope && k.window == window && k.period == period.Unix() {
+			usage[k.account.Key] = u
+		}
+	}
+	return usage, nil
+}
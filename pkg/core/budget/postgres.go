@@ -0,0 +1,96 @@
+package budget
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PostgresStore persists usage in the conductor's PostgreSQL database, so
+// it is shared by replicas and survives restarts
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a usage store backed by db
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// EnsureSchema creates the usage table if it does not exist
+func (p *PostgresStore) EnsureSchema(ctx context.Context) error {
+	_, err := p.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS llm_usage (
+			scope        TEXT NOT NULL,
+			key          TEXT NOT NULL,
+			window_name  TEXT NOT NULL,
+			period_start TIMESTAMPTZ NOT NULL,
+			tokens       BIGINT NOT NULL DEFAULT 0,
+			cost         DOUBLE PRECISION NOT NULL DEFAULT 0,
+			PRIMARY KEY (scope, window_name, period_start, key)
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create llm_usage table: %w", err)
+	}
+	return nil
+}
+
+// Add adds u to an account's usage in a period and returns the new total.
+// The upsert is atomic, so replicas recording at once do not lose usage.
+func (p *PostgresStore) Add(ctx context.Context, account Account, window Window, period time.Time, u Usage) (Usage, error) {
+	var total Usage
+	err := p.db.QueryRowContext(ctx,
+		`INSERT INTO llm_usage (scope, key, window_name, period_start, tokens, cost)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (scope, window_name, period_start, key) DO UPDATE
+		   SET tokens = llm_usage.tokens + EXCLUDED.tokens, cost = llm_usage.cost + EXCLUDED.cost
+		 RETURNING tokens, cost`,
+		account.Scope, account.Key, window, period, u.Tokens, u.Cost,
+	).Scan(&total.Tokens, &total.Cost)
+	if err != nil {
+		return Usage{}, fmt.Errorf("failed to record usage of %s %s: %w", account.Scope, account.Key, err)
+	}
+	return total, nil
+}
+
+// Get returns an account's usage in a period
+func (p *PostgresStore) Get(ctx context.Context, account Account, window Window, period time.Time) (Usage, error) {
+	var used Usage
+	err := p.db.QueryRowContext(ctx,
+		`SELECT tokens, cost FROM llm_usage
+		 WHERE scope = $1 AND key = $2 AND window_name = $3 AND period_start = $4`,
+		account.Scope, account.Key, window, period,
+	).Scan(&used.Tokens, &used.Cost)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Usage{}, nil
+	}
+	if err != nil {
+		return Usage{}, fmt.Errorf("failed to read usage of %s %s: %w", account.Scope, account.Key, err)
+	}
+	return used, nil
+}
+
+// List returns the usage of every account of scope in a period, by key
+func (p *PostgresStore) List(ctx context.Context, scope Scope, window Window, period time.Time) (map[string]Usage, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT key, tokens, cost FROM llm_usage
+		 WHERE scope = $1 AND window_name = $2 AND period_start = $3`,
+		scope, window, period)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s usage: %w", scope, err)
+	}
+	defer rows.Close()
+
+	usage := make(map[string]Usage)
+	for rows.Next() {
+		var key string
+		var used Usage
+		if err := rows.Scan(&key, &used.Tokens, &used.Cost); err != nil {
+			return nil, fmt.Errorf("failed to scan %s usage: %w", scope, err)
+		}
+		usage[key] = used
+	}
+	return usage, rows.Err()
+}
@@ -0,0 +1,330 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/metrics"
+)
+
+// cacheTTL is how long usage read from the store is trusted before it is
+// read again; usage recorded by other replicas shows up within it
+const cacheTTL = 5 * time.Second
+
+// Levels a budget can be at
+const (
+	LevelOK   = "ok"
+	LevelSoft = "soft"
+	LevelHard = "hard"
+)
+
+// Status is where one account stands against one budget
+type Status struct {
+	Scope   Scope   `json:"scope"`
+	Key     string  `json:"key"`
+	Window  Window  `json:"window"`
+	Measure Measure `json:"measure"`
+	Soft    Limit   `json:"soft"`
+	Hard    Limit   `json:"hard"`
+
+	PeriodStart time.Time `json:"period_start"`
+	ResetAt     time.Time `json:"reset_at"`
+	Used        Usage     `json:"used"`
+	// Level is ok, soft or hard
+	Level string `json:"level"`
+	// Action is what happens to further jobs; empty at level ok
+	Action Action `json:"action,omitempty"`
+}
+
+// usageKey identifies an account's usage in one period
+type usageKey struct {
+	account Account
+	window  Window
+	period  int64
+}
+
+// cachedUsage is usage read from the store
+type cachedUsage struct {
+	usage Usage
+	read  time.Time
+}
+
+// alertKey identifies one level crossing of one budget in one period
+type alertKey struct {
+	budget int
+	key    string
+	period int64
+	level  string
+}
+
+// Tracker records usage and checks it against budgets. It is safe for
+// concurrent use, and a nil *Tracker has no budgets.
+type Tracker struct {
+	store   Store
+	budgets []Budget
+
+	mu      sync.Mutex
+	cache   map[usageKey]cachedUsage
+	alerted map[alertKey]bool
+	onAlert []func(Status)
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// NewTracker creates a tracker accounting usage in store
+func NewTracker(store Store, budgets []Budget) *Tracker {
+	return &Tracker{
+		store:   store,
+		budgets: budgets,
+		cache:   make(map[usageKey]cachedUsage),
+		alerted: make(map[alertKey]bool),
+		now:     time.Now,
+	}
+}
+
+// OnAlert registers fn to be called the first time an account reaches a
+// budget's soft or hard limit in a period
+func (t *Tracker) OnAlert(fn func(Status)) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onAlert = append(t.onAlert, fn)
+}
+
+// Budgets returns the configured budgets
+func (t *Tracker) Budgets() []Budget {
+	if t == nil {
+		return nil
+	}
+	return t.budgets
+}
+
+// Record adds what a job spent to its namespace and cyborg in every window.
+// An empty namespace or cyborg ID is not accounted, and negative usage is
+// refused with ErrNegativeUsage.
+func (t *Tracker) Record(ctx context.Context, namespace, cyborgID string, u Usage) error {
+	if t == nil || u.IsZero() {
+		return nil
+	}
+	if u.Tokens < 0 || u.Cost < 0 {
+		return fmt.Errorf("%w: %d tokens, cost %g", ErrNegativeUsage, u.Tokens, u.Cost)
+	}
+
+	now := t.now()
+	var errs []error
+	var alerts []Status
+	for _, account := range accounts(namespace, cyborgID) {
+		for _, window := range Windows {
+			period := PeriodStart(window, now)
+			total, err := t.store.Add(ctx, account, window, period, u)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			t.remember(usageKey{account, window, period.Unix()}, total, now)
+			alerts = append(alerts, t.crossed(account, window, now, total)...)
+		}
+	}
+
+	t.mu.Lock()
+	watchers := t.onAlert
+	t.mu.Unlock()
+	for _, status := range alerts {
+		for _, fn := range watchers {
+			fn(status)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Check returns the most severe status among the budgets of namespace and
+// cyborgID that have reached a limit, or nil while every one is under its
+// limits. An empty namespace or cyborg ID is not checked.
+func (t *Tracker) Check(ctx context.Context, namespace, cyborgID string) (*Status, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	now := t.now()
+	var worst *Status
+	for _, account := range accounts(namespace, cyborgID) {
+		for i := range t.budgets {
+			b := &t.budgets[i]
+			if !b.applies(account) {
+				continue
+			}
+			used, err := t.usage(ctx, account, b.Window, now)
+			if err != nil {
+				return nil, err
+			}
+			status := b.status(account.Key, used, now)
+			if worst == nil || status.Action.severity() > worst.Action.severity() {
+				worst = &status
+			}
+		}
+	}
+	if worst == nil || worst.Level == LevelOK {
+		return nil, nil
+	}
+	return worst, nil
+}
+
+// Statuses returns where every budgeted account stands in the current
+// period. A wildcard budget lists the accounts with usage this period.
+func (t *Tracker) Statuses(ctx context.Context) ([]Status, error) {
+	if t == nil {
+		return []Status{}, nil
+	}
+
+	now := t.now()
+	statuses := []Status{}
+	for i := range t.budgets {
+		b := &t.budgets[i]
+		if b.Key != WildcardKey {
+			used, err := t.usage(ctx, Account{Scope: b.Scope, Key: b.Key}, b.Window, now)
+			if err != nil {
+				return nil, err
+			}
+			statuses = append(statuses, b.status(b.Key, used, now))
+			continue
+		}
+
+		usage, err := t.store.List(ctx, b.Scope, b.Window, PeriodStart(b.Window, now))
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(usage))
+		for key := range usage {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			statuses = append(statuses, b.status(key, usage[key], now))
+		}
+	}
+	return statuses, nil
+}
+
+// usage returns an account's usage in the current period, from the cache
+// while it is fresh
+func (t *Tracker) usage(ctx context.Context, account Account, window Window, now time.Time) (Usage, error) {
+	period := PeriodStart(window, now)
+	k := usageKey{account, window, period.Unix()}
+
+	t.mu.Lock()
+	cached, ok := t.cache[k]
+	t.mu.Unlock()
+	if ok && now.Sub(cached.read) < cacheTTL {
+		return cached.usage, nil
+	}
+
+	used, err := t.store.Get(ctx, account, window, period)
+	if err != nil {
+		return Usage{}, err
+	}
+	t.remember(k, used, now)
+	return used, nil
+}
+
+// remember caches usage read at now, dropping periods that have ended
+func (t *Tracker) remember(k usageKey, used Usage, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.cache[k] = cachedUsage{usage: used, read: now}
+	if len(t.cache) > 4096 {
+		for key := range t.cache {
+			if key.period < PeriodStart(key.window, now).Unix() {
+				delete(t.cache, key)
+			}
+		}
+	}
+}
+
+// crossed returns the statuses of the budgets an account's new total
+// brought to a level they had not reached yet this period
+func (t *Tracker) crossed(account Account, window Window, now time.Time, total Usage) []Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var crossed []Status
+	for i := range t.budgets {
+		b := &t.budgets[i]
+		if b.Window != window || !b.applies(account) {
+			continue
+		}
+		status := b.status(account.Key, total, now)
+		if status.Level == LevelOK {
+			continue
+		}
+		k := alertKey{budget: i, key: account.Key, period: status.PeriodStart.Unix(), level: status.Level}
+		if !t.alerted[k] {
+			t.alerted[k] = true
+			crossed = append(crossed, status)
+		}
+	}
+	if len(t.alerted) > 4096 {
+		for k := range t.alerted {
+			if k.period < PeriodStart(t.budgets[k.budget].Window, now).Unix() {
+				delete(t.alerted, k)
+			}
+		}
+	}
+	return crossed
+}
+
+// applies reports whether the budget covers an account
+func (b *Budget) applies(account Account) bool {
+	return b.Scope == account.Scope && (b.Key == account.Key || b.Key == WildcardKey)
+}
+
+// status measures used against the budget for the account key and
+// publishes the share of the budget spent
+func (b *Budget) status(key string, used Usage, now time.Time) Status {
+	s := Status{
+		Scope:       b.Scope,
+		Key:         key,
+		Window:      b.Window,
+		Measure:     b.Measure,
+		Soft:        b.Soft,
+		Hard:        b.Hard,
+		PeriodStart: PeriodStart(b.Window, now),
+		ResetAt:     PeriodEnd(b.Window, now),
+		Used:        used,
+		Level:       LevelOK,
+	}
+	amount := used.amount(b.Measure)
+	switch {
+	case b.Hard.Amount > 0 && amount >= b.Hard.Amount:
+		s.Level, s.Action = LevelHard, b.Hard.Action
+	case b.Soft.Amount > 0 && amount >= b.Soft.Amount:
+		s.Level, s.Action = LevelSoft, b.Soft.Action
+	}
+
+	limit := b.Hard.Amount
+	if limit <= 0 {
+		limit = b.Soft.Amount
+	}
+	metrics.BudgetUsed.WithLabelValues(string(b.Scope), key, string(b.Window)).Set(amount / limit)
+	return s
+}
+
+// accounts returns the accounts a job of namespace on cyborgID counts
+// against
+func accounts(namespace, cyborgID string) []Account {
+	var accounts []Account
+	if namespace != "" {
+		accounts = append(accounts, Account{Scope: ScopeNamespace, Key: namespace})
+	}
+	if cyborgID != "" {
+		accounts = append(accounts, Account{Scope: ScopeCyborg, Key: cyborgID})
+	}
+	return accounts
+}
@@ -0,0 +1,96 @@
+// Package cluster coordinates conductor replicas that share one PostgreSQL
+// database. Replicas announce themselves with heartbeats, elect a leader
+// per singleton duty through expiring leases, split the queue's partitions
+// between the live members and hand the in-flight work of dead replicas
+// back to the queue.
+//
+// Leases are rows rather than advisory locks because advisory locks belong
+// to a database session, and database/sql does not pin one: a lock taken on
+// a pooled connection is silently kept or lost with that connection.
+package cluster
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Singleton duties run by exactly one replica at a time
+const (
+	// DutyCron fires recurring schedules
+	DutyCron = "cron"
+	// DutyReaper purges expired records and takes over orphaned jobs
+	DutyReaper = "reaper"
+)
+
+// Member is a live replica
+type Member struct {
+	ID          string
+	StartedAt   time.Time
+	HeartbeatAt time.Time
+}
+
+// Store holds replica membership and duty leases
+type Store interface {
+	// Heartbeat records that a replica is alive for the next ttl
+	Heartbeat(ctx context.Context, replicaID string, ttl time.Duration) error
+
+	// Members returns the replicas whose heartbeat has not expired,
+	// ordered by ID
+	Members(ctx context.Context) ([]Member, error)
+
+	// Expired returns the IDs of replicas whose heartbeat has expired
+	Expired(ctx context.Context) ([]string, error)
+
+	// Leave removes a replica from the membership
+	Leave(ctx context.Context, replicaID string) error
+
+	// AcquireLease takes or renews the lease on a duty for ttl. It returns
+	// false while another holder's lease is unexpired.
+	AcquireLease(ctx context.Context, duty, holder string, ttl time.Duration) (bool, error)
+
+	// ReleaseLease gives up a lease if holder still holds it
+	ReleaseLease(ctx context.Context, duty, holder string) error
+}
+
+// OwnedPartitions returns the partitions out of n that self owns among
+// members. Each partition goes to the member with the highest rendezvous
+// hash, so a membership change only moves the partitions of the members
+// that joined or left.
+func OwnedPartitions(members []string, self string, n int) []int {
+	owned := []int{}
+	for p := 0; p < n; p++ {
+		var owner string
+		var best uint64
+		for _, m := range members {
+			if score := rendezvous(m, p); owner == "" || score > best || (score == best && m < owner) {
+				owner, best = m, score
+			}
+		}
+		if owner == self {
+			owned = append(owned, p)
+		}
+	}
+	return owned
+}
+
+// rendezvous scores a member for a partition
+func rendezvous(member string, partition int) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(member))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.Itoa(partition)))
+	return h.Sum64()
+}
+
+// memberIDs returns the sorted IDs of members
+func memberIDs(members []Member) []string {
+	ids := make([]string, len(members))
+	for i, m := range members {
+		ids[i] = m.ID
+	}
+	sort.Strings(ids)
+	return ids
+}
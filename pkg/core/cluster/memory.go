@@ -0,0 +1,118 @@
+package cluster
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memoryLease is a duty lease held in a MemoryStore
+type memoryLease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+// MemoryStore is an in-memory Store. Replicas in one process can share it,
+// which makes it useful for tests; it does not coordinate across processes.
+type MemoryStore struct {
+	mu      sync.Mutex
+	members map[string]*Member
+	expires map[string]time.Time
+	leases  map[string]*memoryLease
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		members: make(map[string]*Member),
+		expires: make(map[string]time.Time),
+		leases:  make(map[string]*memoryLease),
+		now:     time.Now,
+	}
+}
+
+// Heartbeat records that a replica is alive for the next ttl
+func (s *MemoryStore) Heartbeat(ctx context.Context, replicaID string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	m, exists := s.members[replicaID]
+	if !exists {
+		m = &Member{ID: replicaID, StartedAt: now}
+		s.members[replicaID] = m
+	}
+	m.HeartbeatAt = now
+	s.expires[replicaID] = now.Add(ttl)
+	return nil
+}
+
+// Members returns the live replicas ordered by ID
+func (s *MemoryStore) Members(ctx context.Context) ([]Member, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var members []Member
+	for id, m := range s.members {
+		if now.Before(s.expires[id]) {
+			members = append(members, *m)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+	return members, nil
+}
+
+// Expired returns the replicas whose heartbeat has expired
+func (s *MemoryStore) Expired(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var expired []string
+	for id := range s.members {
+		if !now.Before(s.expires[id]) {
+			expired = append(expired, id)
+		}
+	}
+	sort.Strings(expired)
+	return expired, nil
+}
+
+// Leave removes a replica from the membership
+func (s *MemoryStore) Leave(ctx context.Context, replicaID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.members, replicaID)
+	delete(s.expires, replicaID)
+	return nil
+}
+
+// AcquireLease takes or renews the lease on a duty
+func (s *MemoryStore) AcquireLease(ctx context.Context, duty, holder string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if lease, exists := s.leases[duty]; exists && lease.holder != holder && now.Before(lease.expiresAt) {
+		return false, nil
+	}
+	s.leases[duty] = &memoryLease{holder: holder, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// ReleaseLease gives up a lease if holder still holds it
+func (s *MemoryStore) ReleaseLease(ctx context.Context, duty, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lease, exists := s.leases[duty]; exists && lease.holder == holder {
+		delete(s.leases, duty)
+	}
+	return nil
+}
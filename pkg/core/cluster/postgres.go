@@ -0,0 +1,139 @@
+package cluster
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PostgresStore keeps membership and leases in the conductor's PostgreSQL
+// database. Expiry is judged by the database clock, so replicas with
+// skewed clocks still agree on who is alive and who leads.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a cluster store backed by db
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// EnsureSchema creates the membership and lease tables
+func (p *PostgresStore) EnsureSchema(ctx context.Context) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS conductor_replicas (
+			id           TEXT PRIMARY KEY,
+			started_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+			heartbeat_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			expires_at   TIMESTAMPTZ NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS conductor_leases (
+			duty       TEXT PRIMARY KEY,
+			holder     TEXT NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL
+		)`,
+	}
+	for _, stmt := range statements {
+		if _, err := p.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create cluster schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// Heartbeat records that a replica is alive for the next ttl
+func (p *PostgresStore) Heartbeat(ctx context.Context, replicaID string, ttl time.Duration) error {
+	_, err := p.db.ExecContext(ctx,
+		`INSERT INTO conductor_replicas (id, expires_at)
+		 VALUES ($1, now() + $2 * interval '1 millisecond')
+		 ON CONFLICT (id) DO UPDATE
+		   SET heartbeat_at = now(), expires_at = EXCLUDED.expires_at`,
+		replicaID, ttl.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("failed to record heartbeat for %s: %w", replicaID, err)
+	}
+	return nil
+}
+
+// Members returns the live replicas ordered by ID
+func (p *PostgresStore) Members(ctx context.Context) ([]Member, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT id, started_at, heartbeat_at FROM conductor_replicas
+		  WHERE expires_at > now() ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicas: %w", err)
+	}
+	defer rows.Close()
+
+	var members []Member
+	for rows.Next() {
+		var m Member
+		if err := rows.Scan(&m.ID, &m.StartedAt, &m.HeartbeatAt); err != nil {
+			return nil, fmt.Errorf("failed to scan replica: %w", err)
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// Expired returns the replicas whose heartbeat has expired
+func (p *PostgresStore) Expired(ctx context.Context) ([]string, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT id FROM conductor_replicas WHERE expires_at <= now() ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired replicas: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan replica: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Leave removes a replica from the membership
+func (p *PostgresStore) Leave(ctx context.Context, replicaID string) error {
+	if _, err := p.db.ExecContext(ctx, `DELETE FROM conductor_replicas WHERE id = $1`, replicaID); err != nil {
+		return fmt.Errorf("failed to remove replica %s: %w", replicaID, err)
+	}
+	return nil
+}
+
+// AcquireLease takes or renews the lease on a duty. The upsert only
+// overwrites a row this holder owns or one that has expired, so concurrent
+// candidates resolve to a single winner.
+func (p *PostgresStore) AcquireLease(ctx context.Context, duty, holder string, ttl time.Duration) (bool, error) {
+	var current string
+	err := p.db.QueryRowContext(ctx,
+		`INSERT INTO conductor_leases (duty, holder, expires_at)
+		 VALUES ($1, $2, now() + $3 * interval '1 millisecond')
+		 ON CONFLICT (duty) DO UPDATE
+		   SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+		 WHERE conductor_leases.holder = EXCLUDED.holder OR conductor_leases.expires_at <= now()
+		 RETURNING holder`,
+		duty, holder, ttl.Milliseconds()).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire %s lease: %w", duty, err)
+	}
+	return true, nil
+}
+
+// ReleaseLease gives up a lease if holder still holds it
+func (p *PostgresStore) ReleaseLease(ctx context.Context, duty, holder string) error {
+	_, err := p.db.ExecContext(ctx,
+		`DELETE FROM conductor_leases WHERE duty = $1 AND holder = $2`, duty, holder)
+	if err != nil {
+		return fmt.Errorf("failed to release %s lease: %w", duty, err)
+	}
+	return nil
+}
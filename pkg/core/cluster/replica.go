@@ -0,0 +1,371 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/metrics"
+)
+
+// Config controls a replica's membership and elections
+type Config struct {
+	// ID identifies the replica; it should match the queue owner so dead
+	// replicas' leases can be found
+	ID string
+
+	// HeartbeatInterval is how often the replica renews its membership and
+	// leases and rebalances partitions
+	HeartbeatInterval time.Duration
+
+	// MemberTTL is how long a replica counts as alive after a heartbeat
+	MemberTTL time.Duration
+
+	// LeaseTTL is how long a duty lease lasts without renewal, and so how
+	// long a dead leader's duties go unattended
+	LeaseTTL time.Duration
+
+	// Duties lists the singleton duties the replica campaigns for
+	Duties []string
+
+	// Partitions is how many queue partitions are split between members;
+	// zero disables partitioning
+	Partitions int
+
+	// OnPartitions, if set, is called with the replica's partitions
+	// whenever they change
+	OnPartitions func(partitions []int)
+
+	// OnOrphaned, if set, is called by the reaper leader for each dead
+	// replica to hand its in-flight jobs back to the queue, and returns
+	// how many it recovered. The dead replica is forgotten once it
+	// succeeds.
+	OnOrphaned func(ctx context.Context, replicaID string) (int, error)
+}
+
+// Status is a replica's view of the cluster
+type Status struct {
+	ID         string   `json:"id"`
+	Members    []string `json:"members"`
+	Partitions []int    `json:"partitions"`
+	Leading    []string `json:"leading"`
+}
+
+// Replica is one conductor's membership in the cluster. A nil *Replica is
+// a cluster of one: it leads every duty.
+type Replica struct {
+	store Store
+	cfg   Config
+
+	mu         sync.Mutex
+	members    []string
+	partitions []int
+	// leading maps held duties to when this replica stops trusting the
+	// lease if it cannot renew it
+	leading  map[string]time.Time
+	watchers map[string][]func(leader bool)
+
+	stop chan struct{}
+	done chan struct{}
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// NewReplica creates a replica; zero durations take defaults derived from
+// a five second heartbeat, and nil duties campaign for every built-in duty
+func NewReplica(store Store, cfg Config) *Replica {
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = 5 * time.Second
+	}
+	if cfg.MemberTTL <= 0 {
+		cfg.MemberTTL = 3 * cfg.HeartbeatInterval
+	}
+	if cfg.LeaseTTL <= 0 {
+		cfg.LeaseTTL = 3 * cfg.HeartbeatInterval
+	}
+	if cfg.Duties == nil {
+		cfg.Duties = []string{DutyCron, DutyReaper}
+	}
+	return &Replica{
+		store:    store,
+		cfg:      cfg,
+		leading:  make(map[string]time.Time),
+		watchers: make(map[string][]func(bool)),
+		now:      time.Now,
+	}
+}
+
+// Start joins the cluster and keeps the membership fresh in the
+// background. The first round runs before Start returns, so partitions and
+// leadership are settled when it succeeds.
+func (r *Replica) Start(ctx context.Context) error {
+	r.mu.Lock()
+	if r.stop != nil {
+		r.mu.Unlock()
+		return nil
+	}
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	stop, done := r.stop, r.done
+	r.mu.Unlock()
+
+	if err := r.Tick(ctx); err != nil {
+		close(done)
+		r.mu.Lock()
+		r.stop, r.done = nil, nil
+		r.mu.Unlock()
+		return fmt.Errorf("failed to join cluster: %w", err)
+	}
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(r.cfg.HeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				// A failed round is retried on the next tick; leases
+				// lapse on their own if renewal keeps failing
+				_ = r.Tick(ctx)
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop leaves the cluster: it halts the background loop, gives up held
+// leases so another replica takes over at once, and removes the membership
+func (r *Replica) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	stop, done := r.stop, r.done
+	r.stop, r.done = nil, nil
+	r.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+
+	var errs []error
+	r.mu.Lock()
+	held := make(map[string]bool, len(r.leading))
+	for duty := range r.leading {
+		held[duty] = true
+	}
+	r.mu.Unlock()
+	for duty := range held {
+		if err := r.store.ReleaseLease(ctx, duty, r.cfg.ID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	r.settle(map[string]time.Time{})
+	if err := r.store.Leave(ctx, r.cfg.ID); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Tick runs one round: heartbeat, rebalance partitions, campaign for
+// duties and, as reaper, take over the jobs of dead replicas
+func (r *Replica) Tick(ctx context.Context) error {
+	now := r.now()
+	if err := r.store.Heartbeat(ctx, r.cfg.ID, r.cfg.MemberTTL); err != nil {
+		r.settle(r.unexpired(now))
+		return err
+	}
+	members, err := r.store.Members(ctx)
+	if err != nil {
+		r.settle(r.unexpired(now))
+		return err
+	}
+	r.rebalance(memberIDs(members))
+
+	var errs []error
+	leading := r.unexpired(now)
+	for _, duty := range r.cfg.Duties {
+		ok, err := r.store.AcquireLease(ctx, duty, r.cfg.ID, r.cfg.LeaseTTL)
+		switch {
+		case err != nil:
+			// Keep trusting the lease until it would have expired
+			errs = append(errs, err)
+		case ok:
+			leading[duty] = now.Add(r.cfg.LeaseTTL)
+		default:
+			delete(leading, duty)
+		}
+	}
+	r.settle(leading)
+
+	if r.IsLeader(DutyReaper) {
+		if err := r.reapOrphans(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// IsLeader reports whether this replica currently holds a duty's lease
+func (r *Replica) IsLeader(duty string) bool {
+	if r == nil {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.now().Before(r.leading[duty])
+}
+
+// OnLeadership registers fn to be called with true when the replica gains
+// a duty and false when it loses it. If the duty is already held, fn is
+// called at once.
+func (r *Replica) OnLeadership(duty string, fn func(leader bool)) {
+	if r == nil {
+		fn(true)
+		return
+	}
+	r.mu.Lock()
+	r.watchers[duty] = append(r.watchers[duty], fn)
+	r.mu.Unlock()
+
+	if r.IsLeader(duty) {
+		fn(true)
+	}
+}
+
+// Status returns the replica's current view of the cluster
+func (r *Replica) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := Status{
+		ID:         r.cfg.ID,
+		Members:    append([]string(nil), r.members...),
+		Partitions: append([]int(nil), r.partitions...),
+		Leading:    []string{},
+	}
+	now := r.now()
+	for duty, until := range r.leading {
+		if now.Before(until) {
+			status.Leading = append(status.Leading, duty)
+		}
+	}
+	sort.Strings(status.Leading)
+	return status
+}
+
+// unexpired copies the held leases that have not lapsed by now
+func (r *Replica) unexpired(now time.Time) map[string]time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	leading := make(map[string]time.Time, len(r.leading))
+	for duty, until := range r.leading {
+		if now.Before(until) {
+			leading[duty] = until
+		}
+	}
+	return leading
+}
+
+// settle replaces the held leases and tells watchers about every duty
+// gained or lost
+func (r *Replica) settle(leading map[string]time.Time) {
+	r.mu.Lock()
+	var notify []func()
+	for _, duty := range r.cfg.Duties {
+		_, was := r.leading[duty]
+		_, is := leading[duty]
+		if was == is {
+			continue
+		}
+		value := 0.0
+		if is {
+			value = 1
+		}
+		metrics.ClusterLeader.WithLabelValues(duty).Set(value)
+		for _, fn := range r.watchers[duty] {
+			fn, is := fn, is
+			notify = append(notify, func() { fn(is) })
+		}
+	}
+	r.leading = leading
+	r.mu.Unlock()
+
+	for _, fn := range notify {
+		fn()
+	}
+}
+
+// rebalance records the live members and recomputes owned partitions
+func (r *Replica) rebalance(members []string) {
+	metrics.ClusterMembers.Set(float64(len(members)))
+	if r.cfg.Partitions <= 0 {
+		r.mu.Lock()
+		r.members = members
+		r.mu.Unlock()
+		return
+	}
+
+	owned := OwnedPartitions(members, r.cfg.ID, r.cfg.Partitions)
+	r.mu.Lock()
+	r.members = members
+	changed := !equalInts(owned, r.partitions)
+	if changed {
+		r.partitions = owned
+	}
+	r.mu.Unlock()
+
+	if changed && r.cfg.OnPartitions != nil {
+		r.cfg.OnPartitions(owned)
+	}
+}
+
+// reapOrphans hands the jobs of every dead replica back to the queue
+func (r *Replica) reapOrphans(ctx context.Context) error {
+	if r.cfg.OnOrphaned == nil {
+		return nil
+	}
+	expired, err := r.store.Expired(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, id := range expired {
+		if id == r.cfg.ID {
+			continue
+		}
+		n, err := r.cfg.OnOrphaned(ctx, id)
+		if err != nil {
+			// Left in the membership so the next round tries again
+			errs = append(errs, fmt.Errorf("failed to take over jobs of %s: %w", id, err))
+			continue
+		}
+		metrics.OrphanedJobs.Add(float64(n))
+		if err := r.store.Leave(ctx, id); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// equalInts reports whether two int slices hold the same values in order
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) || (a == nil) != (b == nil) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+package cluster
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testReplica returns a replica on store whose clock reads *now
+func testReplica(store *MemoryStore, id string, now *time.Time, cfg Config) *Replica {
+	cfg.ID = id
+	cfg.HeartbeatInterval = time.Second
+	r := NewReplica(store, cfg)
+	r.now = func() time.Time { return *now }
+	return r
+}
+
+// testStore returns a memory store whose clock reads *now
+func testStore(now *time.Time) *MemoryStore {
+	store := NewMemoryStore()
+	store.now = func() time.Time { return *now }
+	return store
+}
+
+func TestReplicaElectsOneLeaderAndFailsOver(t *testing.T) {
+	now := time.Now()
+	store := testStore(&now)
+	ctx := context.Background()
+
+	a := testReplica(store, "conductor-a", &now, Config{})
+	b := testReplica(store, "conductor-b", &now, Config{})
+	var bLeads []bool
+	b.OnLeadership(DutyCron, func(leader bool) { bLeads = append(bLeads, leader) })
+
+	require.NoError(t, a.Tick(ctx))
+	require.NoError(t, b.Tick(ctx))
+	assert.True(t, a.IsLeader(DutyCron))
+	assert.True(t, a.IsLeader(DutyReaper))
+	assert.False(t, b.IsLeader(DutyCron))
+	assert.Empty(t, bLeads)
+
+	// a renews while it is alive
+	now = now.Add(2 * time.Second)
+	require.NoError(t, a.Tick(ctx))
+	require.NoError(t, b.Tick(ctx))
+	assert.True(t, a.IsLeader(DutyCron))
+	assert.False(t, b.IsLeader(DutyCron))
+
+	// a goes silent; its lease lapses on its own clock and b takes over
+	now = now.Add(4 * time.Second)
+	assert.False(t, a.IsLeader(DutyCron))
+	require.NoError(t, b.Tick(ctx))
+	assert.True(t, b.IsLeader(DutyCron))
+	assert.Equal(t, []bool{true}, bLeads)
+
+	// Stopping hands the duty over at once
+	require.NoError(t, b.Stop(ctx))
+	assert.Equal(t, []bool{true, false}, bLeads)
+	require.NoError(t, a.Tick(ctx))
+	assert.True(t, a.IsLeader(DutyCron))
+}
+
+func TestReplicaSplitsPartitions(t *testing.T) {
+	now := time.Now()
+	store := testStore(&now)
+	ctx := context.Background()
+
+	owned := make(map[string][]int)
+	replica := func(id string) *Replica {
+		return testReplica(store, id, &now, Config{
+			Partitions:   16,
+			OnPartitions: func(p []int) { owned[id] = p },
+		})
+	}
+	a, b, c := replica("conductor-a"), replica("conductor-b"), replica("conductor-c")
+
+	require.NoError(t, a.Tick(ctx))
+	assert.Len(t, owned["conductor-a"], 16, "a lone replica owns everything")
+
+	require.NoError(t, b.Tick(ctx))
+	require.NoError(t, c.Tick(ctx))
+	require.NoError(t, a.Tick(ctx))
+	require.NoError(t, b.Tick(ctx))
+
+	// Every partition has exactly one owner
+	var all []int
+	for _, p := range owned {
+		all = append(all, p...)
+	}
+	sort.Ints(all)
+	expected := make([]int, 16)
+	for i := range expected {
+		expected[i] = i
+	}
+	assert.Equal(t, expected, all)
+	assert.Equal(t, []string{"conductor-a", "conductor-b", "conductor-c"}, a.Status().Members)
+
+	// c leaves; only its partitions move
+	before := map[string][]int{"conductor-a": owned["conductor-a"], "conductor-b": owned["conductor-b"]}
+	require.NoError(t, c.Stop(ctx))
+	require.NoError(t, a.Tick(ctx))
+	require.NoError(t, b.Tick(ctx))
+	assert.Len(t, append(owned["conductor-a"], owned["conductor-b"]...), 16)
+	assert.Subset(t, owned["conductor-a"], before["conductor-a"])
+	assert.Subset(t, owned["conductor-b"], before["conductor-b"])
+}
+
+func TestReplicaTakesOverOrphanedJobs(t *testing.T) {
+	now := time.Now()
+	store := testStore(&now)
+	ctx := context.Background()
+
+	var orphaned []string
+	takeOver := func(ctx context.Context, replicaID string) (int, error) {
+		orphaned = append(orphaned, replicaID)
+		return 2, nil
+	}
+	a := testReplica(store, "conductor-a", &now, Config{OnOrphaned: takeOver})
+	b := testReplica(store, "conductor-b", &now, Config{OnOrphaned: takeOver})
+
+	require.NoError(t, a.Tick(ctx))
+	require.NoError(t, b.Tick(ctx))
+	assert.Empty(t, orphaned)
+
+	// b dies without leaving; the reaper leader recovers its jobs once
+	now = now.Add(4 * time.Second)
+	require.NoError(t, a.Tick(ctx))
+	assert.Equal(t, []string{"conductor-b"}, orphaned)
+
+	now = now.Add(time.Second)
+	require.NoError(t, a.Tick(ctx))
+	assert.Equal(t, []string{"conductor-b"}, orphaned)
+	assert.Equal(t, []string{"conductor-a"}, a.Status().Members)
+}
+
+func TestNilReplicaLeadsEverything(t *testing.T) {
+	var r *Replica
+	assert.True(t, r.IsLeader(DutyCron))
+
+	called := false
+	r.OnLeadership(DutyCron, func(leader bool) { called = leader })
+	assert.True(t, called)
+}
@@ -0,0 +1,240 @@
+package conductor
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/metrics"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/pb"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/types"
+)
+
+// defaultAffinityWindow is how long context routes and job tags are
+// remembered when WithAffinityWindow is not given
+const defaultAffinityWindow = time.Hour
+
+// Affinity constrains the cyborgs a job may be placed on. Tags are
+// compared without regard to case.
+type Affinity struct {
+	// CyborgTags lists tags the cyborg must carry
+	CyborgTags []string `json:"cyborg_tags,omitempty"`
+	// AvoidCyborgTags lists tags the cyborg must not carry
+	AvoidCyborgTags []string `json:"avoid_cyborg_tags,omitempty"`
+	// AvoidJobTags keeps the job off cyborgs that ran a job carrying all
+	// of these tags within the affinity window. The rule also keeps such
+	// jobs off a cyborg this job ran on, whichever is placed first.
+	AvoidJobTags []string `json:"avoid_job_tags,omitempty"`
+}
+
+// validateAffinity rejects constraints no cyborg can satisfy
+func validateAffinity(job *Job) error {
+	if job.Affinity == nil {
+		return nil
+	}
+	avoid := tagSet(job.Affinity.AvoidCyborgTags)
+	for _, tag := range job.Affinity.CyborgTags {
+		if avoid[normalizeTag(tag)] {
+			return fmt.Errorf("%w: job %s both requires and avoids cyborg tag %q", ErrInvalidJob, job.ID, tag)
+		}
+	}
+	return nil
+}
+
+// contextRoute pins a context ID to the cyborg that last ran it
+type contextRoute struct {
+	cyborgID string
+	expires  time.Time
+}
+
+// ranJob is the tags of a job a cyborg ran, kept for anti-affinity
+type ranJob struct {
+	tags    map[string]bool
+	avoid   []string
+	expires time.Time
+}
+
+// routes remembers which cyborg holds each context warm and which tagged
+// jobs each cyborg ran
+type routes struct {
+	window time.Duration
+
+	mu        sync.Mutex
+	contexts  map[string]contextRoute
+	history   map[string][]ranJob
+	lastSweep time.Time
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// newRoutes creates a route table remembering placements for window
+func newRoutes(window time.Duration) *routes {
+	return &routes{
+		window:   window,
+		contexts: make(map[string]contextRoute),
+		history:  make(map[string][]ranJob),
+		now:      time.Now,
+	}
+}
+
+// sticky returns the cyborg a context was last placed on
+func (r *routes) sticky(contextID string) (string, bool) {
+	if contextID == "" {
+		return "", false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	route, exists := r.contexts[contextID]
+	if !exists || !r.now().Before(route.expires) {
+		return "", false
+	}
+	return route.cyborgID, true
+}
+
+// record notes that a job was placed on its cyborg
+func (r *routes) record(job *Job) {
+	if job.ContextID == "" && len(job.Tags) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	expires := now.Add(r.window)
+	if job.ContextID != "" {
+		r.contexts[job.ContextID] = contextRoute{cyborgID: job.CyborgID, expires: expires}
+	}
+	if len(job.Tags) > 0 {
+		entry := ranJob{tags: tagSet(job.Tags), expires: expires}
+		if job.Affinity != nil {
+			entry.avoid = job.Affinity.AvoidJobTags
+		}
+		r.history[job.CyborgID] = append(live(r.history[job.CyborgID], now), entry)
+	}
+	if now.Sub(r.lastSweep) >= time.Minute {
+		r.sweep(now)
+	}
+}
+
+// conflicts reports whether placing job on cyborgID would break an
+// anti-affinity rule of the job or of a job the cyborg ran
+func (r *routes) conflicts(job *Job, cyborgID string) bool {
+	var avoid []string
+	if job.Affinity != nil {
+		avoid = job.Affinity.AvoidJobTags
+	}
+	if len(avoid) == 0 && len(job.Tags) == 0 {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tags := tagSet(job.Tags)
+	now := r.now()
+	for _, ran := range r.history[cyborgID] {
+		if !now.Before(ran.expires) {
+			continue
+		}
+		if len(avoid) > 0 && containsAll(ran.tags, avoid) {
+			return true
+		}
+		if len(ran.avoid) > 0 && containsAll(tags, ran.avoid) {
+			return true
+		}
+	}
+	return false
+}
+
+// sweep drops expired routes and history; callers hold mu
+func (r *routes) sweep(now time.Time) {
+	for id, route := range r.contexts {
+		if !now.Before(route.expires) {
+			delete(r.contexts, id)
+		}
+	}
+	for id, ran := range r.history {
+		if kept := live(ran, now); len(kept) > 0 {
+			r.history[id] = kept
+		} else {
+			delete(r.history, id)
+		}
+	}
+	r.lastSweep = now
+}
+
+// live returns the entries that have not expired by now
+func live(ran []ranJob, now time.Time) []ranJob {
+	kept := ran[:0]
+	for _, entry := range ran {
+		if now.Before(entry.expires) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// eligible reports whether a job's affinity rules allow a cyborg
+func (c *Conductor) eligible(cyborg *types.CyborgDescriptor, job *Job) bool {
+	if a := job.Affinity; a != nil {
+		if len(a.CyborgTags) > 0 && !pb.HasTags(cyborg, a.CyborgTags) {
+			return false
+		}
+		for _, tag := range a.AvoidCyborgTags {
+			if pb.HasTags(cyborg, []string{tag}) {
+				return false
+			}
+		}
+	}
+	return !c.routes.conflicts(job, cyborg.CyborgID)
+}
+
+// stickyCyborg returns the cyborg that ran the job's context before. It
+// reports false when there is none or it can no longer take the job, so
+// the job is placed afresh; a busy cyborg is waited for.
+func (c *Conductor) stickyCyborg(job *Job) (*types.CyborgDescriptor, bool, error) {
+	cyborgID, ok := c.routes.sticky(job.ContextID)
+	if !ok {
+		return nil, false, nil
+	}
+	cyborg, exists := c.registry.Get(cyborgID)
+	if !exists || !c.hasAllCapabilities(cyborg, job.Capabilities) || !c.eligible(cyborg, job) || c.overBudget(cyborg) != nil {
+		metrics.StickyRoutes.WithLabelValues("moved").Inc()
+		return nil, false, nil
+	}
+	if c.atCapacity(cyborg, job) {
+		return nil, true, &CapacityError{JobID: job.ID, CyborgIDs: []string{cyborgID}}
+	}
+	if !c.breakers.Allow(cyborgID, job.Capabilities) {
+		metrics.StickyRoutes.WithLabelValues("moved").Inc()
+		return nil, false, nil
+	}
+	metrics.StickyRoutes.WithLabelValues("hit").Inc()
+	return cyborg, true, nil
+}
+
+// normalizeTag puts a tag in the form tags are compared in
+func normalizeTag(tag string) string {
+	return strings.ToUpper(strings.TrimSpace(tag))
+}
+
+// tagSet returns the normalized set of tags
+func tagSet(tags []string) map[string]bool {
+	set := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		set[normalizeTag(tag)] = true
+	}
+	return set
+}
+
+// containsAll reports whether set holds every one of tags
+func containsAll(set map[string]bool, tags []string) bool {
+	for _, tag := range tags {
+		if !set[normalizeTag(tag)] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,195 @@
+package conductor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/metrics"
+)
+
+const (
+	// drainWindow is how many seconds of finished jobs the drain rate is
+	// measured over
+	drainWindow = 60
+	// minRetryAfter and maxRetryAfter bound the retry hint given to
+	// refused submitters
+	minRetryAfter = time.Second
+	maxRetryAfter = 5 * time.Minute
+	// unknownRetryAfter is the hint while no job has drained recently
+	unknownRetryAfter = 30 * time.Second
+)
+
+// ShedRule refuses jobs of priority MinPriority or less urgent once the
+// queue is Fill full, keeping the remaining room for more urgent work
+type ShedRule struct {
+	MinPriority int32   `json:"min_priority"`
+	Fill        float64 `json:"fill"`
+}
+
+// ParseShedRules parses comma-separated PRIORITY=FILL entries, such as
+// "5=0.8,3=0.95", where FILL is a fraction of the queue capacity
+func ParseShedRules(spec string) ([]ShedRule, error) {
+	var rules []ShedRule
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		priority, fill, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("shed rule %q: want PRIORITY=FILL", entry)
+		}
+		p, err := strconv.ParseInt(strings.TrimSpace(priority), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("shed rule %q: invalid priority: %w", entry, err)
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(fill), 64)
+		if err != nil || f <= 0 || f > 1 {
+			return nil, fmt.Errorf("shed rule %q: fill must be a fraction in (0, 1]", entry)
+		}
+		rules = append(rules, ShedRule{MinPriority: int32(p), Fill: f})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].MinPriority < rules[j].MinPriority })
+	return rules, nil
+}
+
+// QueueStats describes the job queue for clients that throttle themselves
+type QueueStats struct {
+	Depth int `json:"depth"`
+	// Capacity is the most jobs the queue holds; zero means unbounded
+	Capacity int `json:"capacity"`
+	// DrainRate is the jobs finished per second over the last minute
+	DrainRate float64 `json:"drain_rate"`
+	// Shedding lists the load-shedding rules in force
+	Shedding []ShedRule `json:"shedding,omitempty"`
+}
+
+// QueueStats returns the current queue depth, capacity and drain rate
+func (c *Conductor) QueueStats(ctx context.Context) (*QueueStats, error) {
+	depth, err := c.queue.Len(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &QueueStats{
+		Depth:     depth,
+		Capacity:  c.queue.Capacity(),
+		DrainRate: c.drain.rate(),
+		Shedding:  c.shedding,
+	}, nil
+}
+
+// shed refuses a job that a load-shedding rule keeps out of the queue at
+// its current depth
+func (c *Conductor) shed(ctx context.Context, job *Job) error {
+	capacity := c.queue.Capacity()
+	if len(c.shedding) == 0 || capacity <= 0 {
+		return nil
+	}
+
+	// The strictest rule that covers the job's priority applies
+	limit := capacity
+	for _, rule := range c.shedding {
+		if job.Priority >= rule.MinPriority {
+			if l := int(rule.Fill * float64(capacity)); l < limit {
+				limit = l
+			}
+		}
+	}
+	if limit >= capacity {
+		return nil
+	}
+
+	depth, err := c.queue.Len(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check queue depth for job %s: %w", job.ID, err)
+	}
+	if depth < limit {
+		return nil
+	}
+	metrics.JobsRefused.WithLabelValues("shed").Inc()
+	return &JobQueueFullError{
+		Message:    fmt.Sprintf("Job queue is shedding priority %d jobs", job.Priority),
+		Depth:      depth,
+		Capacity:   capacity,
+		Shed:       true,
+		RetryAfter: c.retryAfter(depth - limit + 1),
+	}
+}
+
+// queueFull builds the error for a job refused by a full queue
+func (c *Conductor) queueFull() error {
+	capacity := c.queue.Capacity()
+	metrics.JobsRefused.WithLabelValues("queue_full").Inc()
+	return &JobQueueFullError{
+		Message:    "Job queue is full",
+		Depth:      capacity,
+		Capacity:   capacity,
+		RetryAfter: c.retryAfter(1),
+	}
+}
+
+// retryAfter estimates how long the queue takes to drain excess jobs at
+// its recent rate
+func (c *Conductor) retryAfter(excess int) time.Duration {
+	rate := c.drain.rate()
+	if rate <= 0 {
+		return unknownRetryAfter
+	}
+	wait := time.Duration(math.Ceil(float64(excess)/rate)) * time.Second
+	if wait < minRetryAfter {
+		return minRetryAfter
+	}
+	if wait > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return wait
+}
+
+// drainMeter counts finished jobs in one-second buckets
+type drainMeter struct {
+	mu      sync.Mutex
+	counts  [drainWindow]int
+	seconds [drainWindow]int64
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// newDrainMeter creates an empty drain meter
+func newDrainMeter() *drainMeter {
+	return &drainMeter{now: time.Now}
+}
+
+// observe records one job leaving the queue
+func (m *drainMeter) observe() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	second := m.now().Unix()
+	i := second % drainWindow
+	if m.seconds[i] != second {
+		m.seconds[i], m.counts[i] = second, 0
+	}
+	m.counts[i]++
+}
+
+// rate returns the jobs finished per second over the window
+func (m *drainMeter) rate() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now().Unix()
+	total := 0
+	for i, second := range m.seconds {
+		if now-second < drainWindow {
+			total += m.counts[i]
+		}
+	}
+	return float64(total) / drainWindow
+}
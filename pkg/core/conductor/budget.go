@@ -0,0 +1,88 @@
+package conductor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/budget"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/metrics"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/types"
+)
+
+// defaultDowngradePriority is the priority downgraded jobs run at when
+// WithBudgets is given none
+const defaultDowngradePriority = 9
+
+// BudgetExceededError is returned for a job refused because a budget it
+// counts against reached a limit whose action is reject
+type BudgetExceededError struct {
+	JobID  string
+	Status *budget.Status
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("job %s refused: %s %s has spent its %s %s budget until %s",
+		e.JobID, e.Status.Scope, e.Status.Key, e.Status.Window, e.Status.Measure,
+		e.Status.ResetAt.Format("2006-01-02T15:04:05Z07:00"))
+}
+
+// Budgets returns the tracker accounting jobs' token and cost usage; nil
+// when budgets are disabled
+func (c *Conductor) Budgets() *budget.Tracker {
+	return c.budgets
+}
+
+// checkBudget applies the budgets of a submitted job's namespace, and of
+// its cyborg when it names one: a reject limit refuses the job and a
+// downgrade limit makes it less urgent
+func (c *Conductor) checkBudget(job *Job) error {
+	status, err := c.budgets.Check(context.Background(), job.Namespace, job.CyborgID)
+	if err != nil {
+		return fmt.Errorf("failed to check budget for job %s: %w", job.ID, err)
+	}
+	if status == nil {
+		return nil
+	}
+
+	metrics.BudgetActions.WithLabelValues(string(status.Scope), string(status.Action)).Inc()
+	switch status.Action {
+	case budget.ActionReject:
+		return &BudgetExceededError{JobID: job.ID, Status: status}
+	case budget.ActionDowngrade:
+		if job.Priority < c.downgradePriority {
+			job.Priority = c.downgradePriority
+		}
+	}
+	return nil
+}
+
+// overBudget returns the status of a reject limit the cyborg has reached,
+// or nil if it may take jobs. A budget that cannot be read lets the cyborg
+// through rather than stall placement.
+func (c *Conductor) overBudget(cyborg *types.CyborgDescriptor) *budget.Status {
+	status, err := c.budgets.Check(context.Background(), "", cyborg.CyborgID)
+	if err != nil || status == nil || status.Action != budget.ActionReject {
+		return nil
+	}
+	return status
+}
+
+// recordUsage accounts the tokens and cost an execution reported on the job
+// to its namespace and cyborg
+func (c *Conductor) recordUsage(job *Job) {
+	if job.Usage == nil {
+		return
+	}
+	usage := *job.Usage
+	// Cleared so a retry that reports nothing is not charged again
+	job.Usage = nil
+
+	metrics.LLMTokens.WithLabelValues(job.Namespace, job.CyborgID).Add(float64(usage.Tokens))
+	metrics.LLMCost.WithLabelValues(job.Namespace, job.CyborgID).Add(usage.Cost)
+	if usage.Cost > 0 {
+		c.costs.observe(job.CyborgID, usage.Cost)
+	}
+	// Usage that cannot be stored is lost to the budget but stays in the
+	// metrics above
+	_ = c.budgets.Record(context.Background(), job.Namespace, job.CyborgID, usage)
+}
@@ -0,0 +1,146 @@
+package conductor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/evidence"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/metrics"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/orgchart"
+)
+
+// ErrRejected is wrapped by a JobHandler's error when the cyborg declined
+// the job as outside its role rather than failing it
+var ErrRejected = errors.New("job rejected by cyborg")
+
+// Reasons a job is escalated
+const (
+	// EscalationRejected means the cyborg the job ran on rejected it
+	EscalationRejected = "rejected"
+	// EscalationNoCyborg means the cyborg the job was meant for could not
+	// take it
+	EscalationNoCyborg = "no_suitable_cyborg"
+)
+
+// Escalation is one hop of a job up the org chart
+type Escalation struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Reason string    `json:"reason"`
+	Error  string    `json:"error,omitempty"`
+	At     time.Time `json:"at"`
+	// EvidenceID is the evidence log entry recording the hop
+	EvidenceID string `json:"evidence_id,omitempty"`
+}
+
+// escalation follows a chart's reporting lines for at most maxHops
+type escalation struct {
+	chart   *orgchart.Chart
+	maxHops int
+}
+
+// escalationRecord is the evidence logged for each hop
+type escalationRecord struct {
+	Event  string `json:"event"`
+	From   string `json:"from"`
+	To     string `json:"to,omitempty"`
+	Reason string `json:"reason"`
+	Hop    int    `json:"hop"`
+	Error  string `json:"error,omitempty"`
+	// Chain is every cyborg the job was meant for so far, in order
+	Chain []string `json:"chain"`
+}
+
+// escalate reroutes a job to the manager of the cyborg it was meant for:
+// the one that rejected it, the one it was pinned to, or else its owner.
+// It returns false, leaving the job to its caller, when escalation is
+// disabled, the cyborg has no manager or the job has used its hops.
+func (c *Conductor) escalate(ctx context.Context, job *Job, reason string, cause error) bool {
+	if c.escalation == nil || job.lease == nil {
+		return false
+	}
+	from := job.Owner
+	if reason == EscalationRejected || job.pinned {
+		from = job.CyborgID
+	}
+	if from == "" {
+		return false
+	}
+
+	record := escalationRecord{
+		From:   from,
+		Reason: reason,
+		Hop:    len(job.Escalations) + 1,
+		Error:  cause.Error(),
+		Chain:  escalationChain(job, from),
+	}
+	manager, ok := c.escalation.chart.Manager(from)
+	if !ok || len(job.Escalations) >= c.escalation.maxHops {
+		if len(job.Escalations) > 0 {
+			// The chain ends here; record where, so the log explains why
+			// the escalated job was given up on
+			record.Event = "escalation_ended"
+			c.recordEvidence(ctx, job, record)
+		}
+		return false
+	}
+
+	record.Event, record.To = "escalated", manager
+	hop := Escalation{
+		From:       from,
+		To:         manager,
+		Reason:     reason,
+		Error:      record.Error,
+		At:         time.Now(),
+		EvidenceID: c.recordEvidence(ctx, job, record),
+	}
+	job.Escalations = append(job.Escalations, hop)
+	job.CyborgID, job.pinned = manager, true
+	metrics.Escalations.WithLabelValues(reason).Inc()
+
+	payload, err := encodeJob(job)
+	if err == nil {
+		job.lease.Payload = payload
+	}
+	// A lost lease means the job was already redelivered elsewhere
+	_ = c.queue.Release(ctx, job.lease, 0)
+	return true
+}
+
+// escalationChain lists the cyborgs a job has been meant for, ending with
+// from
+func escalationChain(job *Job, from string) []string {
+	chain := make([]string, 0, len(job.Escalations)+1)
+	for _, hop := range job.Escalations {
+		chain = append(chain, hop.From)
+	}
+	return append(chain, from)
+}
+
+// recordEvidence appends an audit entry about job to the evidence log,
+// following from the job's last escalation, and returns its ID. A job
+// whose evidence cannot be written still escalates; the entry is missing
+// from its log.
+func (c *Conductor) recordEvidence(ctx context.Context, job *Job, data interface{}) string {
+	if c.evidence == nil {
+		return ""
+	}
+	entry, err := evidence.NewEntry(job.ID, evidence.TypeAudit, data)
+	if err != nil {
+		return ""
+	}
+	if n := len(job.Escalations); n > 0 {
+		entry.ParentID = job.Escalations[n-1].EvidenceID
+	}
+	if err := c.evidence.Append(ctx, entry); err != nil {
+		return ""
+	}
+	return entry.ID
+}
+
+// Evidence returns the store holding jobs' evidence logs, or nil when
+// none is kept
+func (c *Conductor) Evidence() evidence.Store {
+	return c.evidence
+}
@@ -0,0 +1,58 @@
+package conductor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/executor"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/output"
+)
+
+// runExecutor runs a placed job on the conductor's executor, relaying its
+// output to the output hub
+func (c *Conductor) runExecutor(ctx context.Context, job *Job) error {
+	req := &executor.Request{
+		JobID:        job.ID,
+		CyborgID:     job.CyborgID,
+		Capabilities: job.Capabilities,
+		Payload:      job.Payload,
+		Sandbox:      c.sandboxFor(job),
+		Output: func(chunk output.Chunk) {
+			c.output.Publish(job.ID, chunk.Stream, chunk.Data, chunk.At)
+		},
+	}
+	if cyborg, ok := c.registry.Get(job.CyborgID); ok {
+		req.DeploymentSpec = string(cyborg.DeploymentSpec)
+		req.JobType = cyborg.JobType
+	}
+	if job.TimeoutMs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(job.TimeoutMs)*time.Millisecond)
+		defer cancel()
+	}
+
+	result, err := c.executor.Execute(ctx, req)
+	if result != nil && !result.Usage.IsZero() {
+		usage := result.Usage
+		job.Usage = &usage
+	}
+	if err == nil {
+		if result != nil {
+			job.Result = result.Payload
+		}
+		return nil
+	}
+
+	if errors.Is(err, executor.ErrRejected) {
+		// The cyborg declined the job rather than failed it; the conductor
+		// may escalate it
+		err = fmt.Errorf("%w: %w", ErrRejected, err)
+	}
+	execErr := &ExecError{Err: err}
+	if result != nil {
+		execErr.Stderr = string(result.Stderr)
+	}
+	return execErr
+}
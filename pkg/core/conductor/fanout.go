@@ -0,0 +1,251 @@
+package conductor
+
+import (
+	"context"
+	"fmt"
+)
+
+// Aggregation modes for a fan-out
+const (
+	// AggregateAll waits for every sub-job
+	AggregateAll = "all"
+	// AggregateFirstN stops once N sub-jobs have succeeded
+	AggregateFirstN = "first_n"
+)
+
+// Sub-job and fan-out statuses
+const (
+	FanOutSucceeded = "succeeded"
+	FanOutPartial   = "partial"
+	FanOutFailed    = "failed"
+	// FanOutSkipped marks a sub-job that was not waited for because a
+	// first-N fan-out already had enough results
+	FanOutSkipped = "skipped"
+)
+
+// Reducer combines the successful sub-job results of a fan-out into one
+// aggregate result
+type Reducer func(results []SubResult) ([]byte, error)
+
+// FanOut runs the same job once on every cyborg a registry query matches
+// and aggregates the results
+type FanOut struct {
+	ID string `json:"id"`
+	// Cyborgs is a glob over cyborg IDs such as "SALE*"; empty matches all
+	Cyborgs string `json:"cyborgs,omitempty"`
+	// Tags the matching cyborgs must all carry, such as "STRATEGY"
+	Tags []string `json:"tags,omitempty"`
+	// Job is the template each sub-job is made from; its ID and CyborgID
+	// are set per cyborg
+	Job Job `json:"job"`
+	// Concurrency caps the sub-jobs in flight at once; zero runs them all
+	Concurrency int `json:"concurrency,omitempty"`
+	// Aggregate is AggregateAll (the default) or AggregateFirstN
+	Aggregate string `json:"aggregate,omitempty"`
+	// N is how many successes a first-N fan-out waits for
+	N int `json:"n,omitempty"`
+	// Reducer names a reducer registered with WithReducer that turns the
+	// successful results into FanOutResult.Aggregate
+	Reducer string `json:"reducer,omitempty"`
+}
+
+// SubResult is the outcome of one sub-job of a fan-out
+type SubResult struct {
+	CyborgID string `json:"cyborg_id"`
+	JobID    string `json:"job_id"`
+	Status   string `json:"status"`
+	Result   []byte `json:"result,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// FanOutResult is the outcome of a fan-out. Successes are kept even when
+// other sub-jobs failed; the status is then FanOutPartial.
+type FanOutResult struct {
+	ID        string      `json:"id"`
+	Status    string      `json:"status"`
+	Results   []SubResult `json:"results"`
+	Succeeded int         `json:"succeeded"`
+	Failed    int         `json:"failed"`
+	Skipped   int         `json:"skipped"`
+	// Aggregate is the reducer's output, if one was named
+	Aggregate   []byte `json:"aggregate,omitempty"`
+	ReduceError string `json:"reduce_error,omitempty"`
+}
+
+// jobOutcome is what a waiter receives when its job finishes
+type jobOutcome struct {
+	job *Job
+	err error
+}
+
+// watch returns a channel that receives the outcome of a job once it
+// finishes. It must be called before the job is submitted.
+func (c *Conductor) watch(jobID string) <-chan jobOutcome {
+	ch := make(chan jobOutcome, 1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waiters[jobID] = ch
+	return ch
+}
+
+// unwatch drops a waiter that is no longer interested
+func (c *Conductor) unwatch(jobID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.waiters, jobID)
+}
+
+// FanOutTargets validates a fan-out and returns the IDs of the cyborgs it
+// would run on
+func (c *Conductor) FanOutTargets(f *FanOut) ([]string, error) {
+	if f.ID == "" {
+		return nil, fmt.Errorf("%w: fan-out ID is required", ErrInvalidJob)
+	}
+	switch f.Aggregate {
+	case "", AggregateAll:
+	case AggregateFirstN:
+		if f.N <= 0 {
+			return nil, fmt.Errorf("%w: first_n fan-out %s needs a positive n", ErrInvalidJob, f.ID)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown aggregation %q", ErrInvalidJob, f.Aggregate)
+	}
+	if f.Reducer != "" && c.reducers[f.Reducer] == nil {
+		return nil, fmt.Errorf("%w: unknown reducer %q", ErrInvalidJob, f.Reducer)
+	}
+
+	matched, err := c.registry.Find(f.Cyborgs, f.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJob, err)
+	}
+	var targets []string
+	for _, cyborg := range matched {
+		if c.hasAllCapabilities(cyborg, f.Job.Capabilities) {
+			targets = append(targets, cyborg.CyborgID)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, &NoSuitableCyborgError{JobID: f.ID}
+	}
+	return targets, nil
+}
+
+// RunFanOut submits one sub-job per cyborg matching the fan-out's query,
+// at most Concurrency at a time, and waits for them to finish. Sub-job
+// failures are reported in the result rather than as an error.
+func (c *Conductor) RunFanOut(ctx context.Context, f *FanOut) (*FanOutResult, error) {
+	targets, err := c.FanOutTargets(f)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := f.Concurrency
+	if limit <= 0 || limit > len(targets) {
+		limit = len(targets)
+	}
+
+	// Cancelling runCtx stops launching and waiting once a first-N
+	// fan-out has enough results
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// A sub-job's slot is freed only once its result has been counted, so
+	// none starts after a first-N fan-out is satisfied
+	results := make(chan subOutcome, len(targets))
+	slots := make(chan struct{}, limit)
+	for _, cyborgID := range targets {
+		go func(cyborgID string) {
+			results <- c.runSubJob(runCtx, f, cyborgID, slots)
+		}(cyborgID)
+	}
+
+	result := &FanOutResult{ID: f.ID}
+	var successes []SubResult
+	for range targets {
+		outcome := <-results
+		sub := outcome.SubResult
+		switch {
+		case sub.Status == FanOutSucceeded && (f.Aggregate != AggregateFirstN || result.Succeeded < f.N):
+			result.Succeeded++
+			successes = append(successes, sub)
+			if f.Aggregate == AggregateFirstN && result.Succeeded == f.N {
+				cancel()
+			}
+		case sub.Status == FanOutFailed:
+			result.Failed++
+		default:
+			// Successes beyond the first N are not needed either
+			sub.Status = FanOutSkipped
+			result.Skipped++
+		}
+		result.Results = append(result.Results, sub)
+		if outcome.heldSlot {
+			<-slots
+		}
+	}
+
+	switch {
+	case result.Succeeded == 0:
+		result.Status = FanOutFailed
+	case result.Failed > 0:
+		result.Status = FanOutPartial
+	default:
+		result.Status = FanOutSucceeded
+	}
+	if reduce := c.reducers[f.Reducer]; f.Reducer != "" && len(successes) > 0 {
+		if result.Aggregate, err = reduce(successes); err != nil {
+			result.ReduceError = err.Error()
+		}
+	}
+	return result, ctx.Err()
+}
+
+// subOutcome is a sub-job result and whether it took a concurrency slot
+// that the collector must free
+type subOutcome struct {
+	SubResult
+	heldSlot bool
+}
+
+// runSubJob submits the fan-out's job pinned to one cyborg once a
+// concurrency slot is free and waits for it to finish
+func (c *Conductor) runSubJob(ctx context.Context, f *FanOut, cyborgID string, slots chan struct{}) subOutcome {
+	job := f.Job
+	job.ID = f.ID + "-" + cyborgID
+	job.CyborgID = cyborgID
+	job.Attempts, job.Result = nil, nil
+	// Each sub-job answers separately; the fan-out as a whole is what a
+	// client deduplicates
+	job.IdempotencyKey = ""
+	sub := subOutcome{SubResult: SubResult{CyborgID: cyborgID, JobID: job.ID, Status: FanOutSkipped}}
+
+	select {
+	case slots <- struct{}{}:
+		sub.heldSlot = true
+	case <-ctx.Done():
+		return sub
+	}
+	if ctx.Err() != nil {
+		return sub
+	}
+
+	done := c.watch(job.ID)
+	if err := c.SubmitJob(&job); err != nil {
+		c.unwatch(job.ID)
+		sub.Status, sub.Error = FanOutFailed, err.Error()
+		return sub
+	}
+
+	select {
+	case outcome := <-done:
+		if outcome.err != nil {
+			sub.Status, sub.Error = FanOutFailed, outcome.err.Error()
+			return sub
+		}
+		sub.Status, sub.Result = FanOutSucceeded, outcome.job.Result
+	case <-ctx.Done():
+		// The sub-job keeps running; its result is no longer needed
+		c.unwatch(job.ID)
+	}
+	return sub
+}
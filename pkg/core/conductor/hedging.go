@@ -0,0 +1,206 @@
+package conductor
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/metrics"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/types"
+)
+
+// latencyWindow is how many recent successful executions per cyborg the
+// hedge percentile is computed over
+const latencyWindow = 200
+
+// minHedgeRetry is the shortest wait before a hedge that found no second
+// cyborg looks again
+const minHedgeRetry = 10 * time.Millisecond
+
+// HedgePolicy controls hedged execution of jobs placed on five-nines
+// cyborgs. When the first execution has not finished by the hedge delay, a
+// duplicate starts on a second eligible cyborg; the first success wins and
+// the other execution is cancelled. While no second cyborg has a free
+// slot, the duplicate is retried every hedge delay until the first
+// execution finishes.
+type HedgePolicy struct {
+	// Delay before the duplicate starts; zero relies on Percentile alone
+	Delay time.Duration
+
+	// Percentile of the cyborg's observed latency, such as 0.95, after
+	// which the duplicate starts; zero relies on Delay alone. When both are
+	// set the earlier one applies.
+	Percentile float64
+
+	// MinSamples is how many observed executions the percentile needs
+	// before it is trusted
+	MinSamples int
+}
+
+// latencies keeps recent successful execution times per cyborg
+type latencies struct {
+	mu      sync.Mutex
+	samples map[string][]time.Duration
+	next    map[string]int
+}
+
+func newLatencies() *latencies {
+	return &latencies{
+		samples: make(map[string][]time.Duration),
+		next:    make(map[string]int),
+	}
+}
+
+// observe records one successful execution
+func (l *latencies) observe(cyborgID string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	samples := l.samples[cyborgID]
+	if len(samples) < latencyWindow {
+		l.samples[cyborgID] = append(samples, d)
+		return
+	}
+	samples[l.next[cyborgID]] = d
+	l.next[cyborgID] = (l.next[cyborgID] + 1) % latencyWindow
+}
+
+// percentile returns the p-th latency percentile for a cyborg and whether
+// at least minSamples executions were observed
+func (l *latencies) percentile(cyborgID string, p float64, minSamples int) (time.Duration, bool) {
+	l.mu.Lock()
+	samples := append([]time.Duration(nil), l.samples[cyborgID]...)
+	l.mu.Unlock()
+
+	if len(samples) == 0 || len(samples) < minSamples {
+		return 0, false
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	idx := int(p*float64(len(samples))+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(samples) {
+		idx = len(samples) - 1
+	}
+	return samples[idx], true
+}
+
+// hedgeDelay returns how long to wait before hedging a job on cyborgID, or
+// false when the job should not be hedged
+func (c *Conductor) hedgeDelay(job *Job) (time.Duration, bool) {
+	if c.hedging == nil || job.pinned {
+		return 0, false
+	}
+	cyborg, exists := c.registry.Get(job.CyborgID)
+	if !exists || cyborg.ReliabilityTier != types.ReliabilityTierFiveNines {
+		return 0, false
+	}
+
+	delay := c.hedging.Delay
+	if c.hedging.Percentile > 0 {
+		if p, ok := c.latencies.percentile(job.CyborgID, c.hedging.Percentile, c.hedging.MinSamples); ok {
+			if delay == 0 || p < delay {
+				delay = p
+			}
+		}
+	}
+	return delay, delay > 0
+}
+
+// adopt takes an execution's outcome from the copy of job it ran on. Only
+// fields an execution sets are taken; others, such as the queue lease, may
+// be in use by the job's other goroutines.
+func adopt(job, execution *Job) {
+	job.CyborgID = execution.CyborgID
+	job.Result = execution.Result
+	job.Usage = execution.Usage
+}
+
+// runHedged executes a job on its cyborg and, if that has not finished
+// after delay, on a second eligible cyborg as well. The job is left as the
+// execution whose outcome was taken left it, including the cyborg it ran
+// on and its result.
+func (c *Conductor) runHedged(ctx context.Context, job *Job, handler JobHandler, delay time.Duration) error {
+	type outcome struct {
+		job   *Job
+		hedge bool
+		err   error
+	}
+
+	// Cancelling hedgeCtx on return stops whichever execution lost
+	hedgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan outcome, 2)
+	launch := func(j *Job, hedge bool) {
+		go func() {
+			results <- outcome{job: j, hedge: hedge, err: c.execute(hedgeCtx, j, handler)}
+		}()
+	}
+	// Each execution gets its own copy; the loser may still be reading it
+	// after the job has moved on
+	primary := *job
+	launch(&primary, false)
+	running, hedged := 1, false
+
+	retry := delay
+	if retry < minHedgeRetry {
+		retry = minHedgeRetry
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			cyborg, err := c.selectCyborg(job, job.CyborgID)
+			if err != nil || cyborg == nil {
+				// No second cyborg available - keep waiting on the first
+				// and look again later
+				timer.Reset(retry)
+				continue
+			}
+			if ok, _, _ := c.limiter.Take(cyborg.CyborgID, cyborg.Dependencies); !ok {
+				// A duplicate must not spend a vendor quota that is gone
+				timer.Reset(retry)
+				continue
+			}
+			duplicate := *job
+			duplicate.CyborgID = cyborg.CyborgID
+			// The duplicate holds a slot on its cyborg while it runs
+			c.occupy(cyborg.CyborgID)
+			go func() {
+				err := c.execute(hedgeCtx, &duplicate, handler)
+				c.vacate(duplicate.CyborgID)
+				results <- outcome{job: &duplicate, hedge: true, err: err}
+			}()
+			running++
+			hedged = true
+			metrics.Hedges.WithLabelValues("launched").Inc()
+
+		case r := <-results:
+			running--
+			if r.err == nil {
+				if hedged && r.hedge {
+					metrics.Hedges.WithLabelValues("hedge_won").Inc()
+				} else if hedged {
+					metrics.Hedges.WithLabelValues("primary_won").Inc()
+				}
+				adopt(job, r.job)
+				return nil
+			}
+			if running == 0 {
+				if hedged {
+					metrics.Hedges.WithLabelValues("both_failed").Inc()
+				}
+				adopt(job, r.job)
+				return r.err
+			}
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
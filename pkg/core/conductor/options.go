@@ -0,0 +1,214 @@
+package conductor
+
+import (
+	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/breaker"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/budget"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/deadletter"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/evidence"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/executor"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/idempotency"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/orgchart"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/output"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/policy"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/queue"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/ratelimit"
+)
+
+// Option configures a Conductor at construction time
+type Option func(*Conductor)
+
+// WithQueue replaces the default in-memory queue, for example with a
+// durable PostgreSQL queue
+func WithQueue(q queue.Queue) Option {
+	return func(c *Conductor) {
+		c.queue = q
+	}
+}
+
+// WithVisibilityTimeout sets how long a dequeued job stays leased before it
+// is redelivered. Running jobs renew their lease at half this interval.
+func WithVisibilityTimeout(d time.Duration) Option {
+	return func(c *Conductor) {
+		if d > 0 {
+			c.visibility = d
+		}
+	}
+}
+
+// WithPollInterval sets how long the dispatcher sleeps when the queue is
+// empty
+func WithPollInterval(d time.Duration) Option {
+	return func(c *Conductor) {
+		if d > 0 {
+			c.pollInterval = d
+		}
+	}
+}
+
+// WithWorkerCount sets the number of concurrent job workers
+func WithWorkerCount(n int) Option {
+	return func(c *Conductor) {
+		if n > 0 {
+			c.workerCount = n
+		}
+	}
+}
+
+// WithJobHandler sets the function that executes jobs once they are placed
+// on a cyborg
+func WithJobHandler(h JobHandler) Option {
+	return func(c *Conductor) {
+		c.handler = h
+	}
+}
+
+// WithExecutor runs placed jobs on e, on the backend of the cyborg each
+// is placed on. A job handler set with WithJobHandler takes precedence.
+func WithExecutor(e executor.Executor) Option {
+	return func(c *Conductor) {
+		c.executor = e
+	}
+}
+
+// WithSandbox runs each job's commands in a sandbox derived from its
+// resources and security level. Backends other than subprocesses ignore
+// it.
+func WithSandbox(policy SandboxPolicy) Option {
+	return func(c *Conductor) {
+		c.sandbox = &policy
+	}
+}
+
+// WithRetryBackoff sets the delay before a failed job's first retry; each
+// further retry doubles it
+func WithRetryBackoff(d time.Duration) Option {
+	return func(c *Conductor) {
+		if d > 0 {
+			c.retryBackoff = d
+		}
+	}
+}
+
+// WithDeadLetterStore replaces the default in-memory dead-letter store
+func WithDeadLetterStore(s deadletter.Store) Option {
+	return func(c *Conductor) {
+		c.deadLetters = s
+	}
+}
+
+// WithBreakers takes cyborgs whose breakers are open out of job placement
+// and records every execution's outcome against them
+func WithBreakers(s *breaker.Set) Option {
+	return func(c *Conductor) {
+		c.breakers = s
+	}
+}
+
+// WithHedging enables hedged execution of jobs placed on five-nines cyborgs
+func WithHedging(policy HedgePolicy) Option {
+	return func(c *Conductor) {
+		c.hedging = &policy
+	}
+}
+
+// WithIdempotency deduplicates submissions that carry an idempotency key,
+// remembering each key for window
+func WithIdempotency(store idempotency.Store, window time.Duration) Option {
+	return func(c *Conductor) {
+		c.idempotency = store
+		c.idempotencyWindow = window
+	}
+}
+
+// WithRateLimiter holds jobs until the external dependencies of the cyborg
+// they are placed on have quota left
+func WithRateLimiter(l *ratelimit.Limiter) Option {
+	return func(c *Conductor) {
+		c.limiter = l
+	}
+}
+
+// WithPreemption lets urgent jobs that find every eligible cyborg at
+// capacity cancel the least urgent running job and take its slot
+func WithPreemption(p PreemptionPolicy) Option {
+	return func(c *Conductor) {
+		c.preemption = &p
+	}
+}
+
+// WithReducer registers a reducer that fan-outs can name to aggregate
+// their successful results
+func WithReducer(name string, fn Reducer) Option {
+	return func(c *Conductor) {
+		c.reducers[name] = fn
+	}
+}
+
+// WithAffinityWindow sets how long a context stays routed to the cyborg
+// that last ran it, and how long tagged jobs count for anti-affinity
+func WithAffinityWindow(d time.Duration) Option {
+	return func(c *Conductor) {
+		if d > 0 {
+			c.routes.window = d
+		}
+	}
+}
+
+// WithLoadShedding refuses less urgent jobs once the queue fills past the
+// rules' thresholds
+func WithLoadShedding(rules []ShedRule) Option {
+	return func(c *Conductor) {
+		c.shedding = rules
+	}
+}
+
+// WithBudgets charges the tokens and cost jobs report to their namespace
+// and cyborg and enforces the tracker's budgets. Jobs downgraded by a
+// budget run at downgradePriority or less urgent; zero keeps the default.
+func WithBudgets(t *budget.Tracker, downgradePriority int32) Option {
+	return func(c *Conductor) {
+		c.budgets = t
+		if downgradePriority > 0 {
+			c.downgradePriority = downgradePriority
+		}
+	}
+}
+
+// WithPolicies replaces the default scheduling policies, which place every
+// job first-fit
+func WithPolicies(s *policy.Set) Option {
+	return func(c *Conductor) {
+		if s != nil {
+			c.policies = s
+		}
+	}
+}
+
+// WithEscalation reroutes jobs that a cyborg rejects, or that the cyborg
+// they are meant for cannot take, to its manager in chart, for at most
+// maxHops hops per job
+func WithEscalation(chart *orgchart.Chart, maxHops int) Option {
+	return func(c *Conductor) {
+		if chart != nil && maxHops > 0 {
+			c.escalation = &escalation{chart: chart, maxHops: maxHops}
+		}
+	}
+}
+
+// WithEvidence records escalations in the jobs' evidence logs
+func WithEvidence(store evidence.Store) Option {
+	return func(c *Conductor) {
+		c.evidence = store
+	}
+}
+
+// WithOutputSpool captures jobs' output in bounded memory, spilling
+// streams past the limit to spool; finished jobs carry the excerpts of
+// their spilled streams
+func WithOutputSpool(spool *output.Spool) Option {
+	return func(c *Conductor) {
+		c.output.SpillTo(spool)
+	}
+}
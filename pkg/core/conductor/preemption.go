@@ -0,0 +1,235 @@
+package conductor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/metrics"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/types"
+)
+
+// ErrPreempted is the cancellation cause seen by a handler whose job was
+// preempted by a more urgent one. A handler that wants to checkpoint can
+// check context.Cause(ctx) and leave its progress in job.Payload before
+// returning; the requeued job carries it.
+var ErrPreempted = errors.New("job preempted")
+
+// PreemptionPolicy controls when an urgent job that finds every eligible
+// cyborg at capacity may cancel a less urgent running job to take its slot.
+// Priorities follow the queue: lower values are more urgent.
+type PreemptionPolicy struct {
+	// MaxPriority is the least urgent priority that may preempt; jobs with
+	// a higher value wait for a free slot
+	MaxPriority int32
+
+	// MinGap is how many priority levels more urgent than the running job
+	// the waiting job must be
+	MinGap int32
+
+	// MaxPreemptions is how many times one job may be preempted; after
+	// that it runs to completion
+	MaxPreemptions int32
+}
+
+// canPreempt reports whether a waiting job may preempt a running one
+func (p *PreemptionPolicy) canPreempt(waiting *Job, running *placement) bool {
+	gap := p.MinGap
+	if gap < 1 {
+		gap = 1
+	}
+	return waiting.Priority <= p.MaxPriority &&
+		running.priority-waiting.Priority >= gap &&
+		running.preemptions < p.MaxPreemptions
+}
+
+// reservationTimeout is how long a slot freed by preemption is held for
+// the job that asked for it before anyone may take it
+const reservationTimeout = 30 * time.Second
+
+// reservation is a slot on a cyborg held for a preempting job
+type reservation struct {
+	cyborgID string
+	expires  time.Time
+}
+
+// placement is a job handed to a cyborg that has not finished yet
+type placement struct {
+	jobID       string
+	cyborgID    string
+	priority    int32
+	preemptions int32
+	seq         uint64
+	ctx         context.Context
+	cancel      context.CancelCauseFunc
+	// preemptedFor is the waiting job this one was cancelled for
+	preemptedFor string
+}
+
+// place records that a job has been handed to a cyborg along with the
+// context it will run under
+func (c *Conductor) place(ctx context.Context, job *Job) {
+	runCtx, cancel := context.WithCancelCause(ctx)
+
+	c.placeMu.Lock()
+	defer c.placeMu.Unlock()
+	if r, exists := c.reserved[job.ID]; exists {
+		// The job takes over the slot preemption freed for it
+		c.release(job.ID, r)
+	}
+	c.placeSeq++
+	c.placements[job.ID] = &placement{
+		jobID:       job.ID,
+		cyborgID:    job.CyborgID,
+		priority:    job.Priority,
+		preemptions: job.Preemptions,
+		seq:         c.placeSeq,
+		ctx:         runCtx,
+		cancel:      cancel,
+	}
+	c.load[job.CyborgID]++
+}
+
+// unplace frees the slot a job held and reports whether it was preempted
+func (c *Conductor) unplace(job *Job) bool {
+	c.placeMu.Lock()
+	defer c.placeMu.Unlock()
+
+	p, exists := c.placements[job.ID]
+	if !exists {
+		return false
+	}
+	p.cancel(nil)
+	delete(c.placements, job.ID)
+	if p.preemptedFor != "" {
+		// Keep the slot for the job that preempted this one, so the
+		// requeued job cannot take it back first
+		c.reserved[p.preemptedFor] = reservation{
+			cyborgID: p.cyborgID,
+			expires:  time.Now().Add(reservationTimeout),
+		}
+		return true
+	}
+	c.decLoad(p.cyborgID)
+	return false
+}
+
+// occupy takes a slot on a cyborg for an execution that is not placed on
+// it, such as a hedged duplicate, until vacate frees it
+func (c *Conductor) occupy(cyborgID string) {
+	c.placeMu.Lock()
+	defer c.placeMu.Unlock()
+	c.load[cyborgID]++
+}
+
+// vacate frees a slot taken by occupy
+func (c *Conductor) vacate(cyborgID string) {
+	c.placeMu.Lock()
+	defer c.placeMu.Unlock()
+	c.decLoad(cyborgID)
+}
+
+// release gives up a reservation; callers hold placeMu
+func (c *Conductor) release(jobID string, r reservation) {
+	delete(c.reserved, jobID)
+	c.decLoad(r.cyborgID)
+}
+
+// decLoad frees one slot on a cyborg; callers hold placeMu
+func (c *Conductor) decLoad(cyborgID string) {
+	if c.load[cyborgID]--; c.load[cyborgID] <= 0 {
+		delete(c.load, cyborgID)
+	}
+}
+
+// expireReservations frees slots whose preempting job never came back for
+// them; callers hold placeMu
+func (c *Conductor) expireReservations(now time.Time) {
+	for jobID, r := range c.reserved {
+		if now.After(r.expires) {
+			c.release(jobID, r)
+		}
+	}
+}
+
+// jobContext returns the context a placed job runs under
+func (c *Conductor) jobContext(ctx context.Context, job *Job) context.Context {
+	c.placeMu.Lock()
+	defer c.placeMu.Unlock()
+
+	if p, exists := c.placements[job.ID]; exists {
+		return p.ctx
+	}
+	return ctx
+}
+
+// atCapacity reports whether a cyborg already runs as many jobs as it
+// accepts, not counting a slot reserved for job. Cyborgs without a limit
+// are never full.
+func (c *Conductor) atCapacity(cyborg *types.CyborgDescriptor, job *Job) bool {
+	if cyborg.MaxConcurrentJobs <= 0 {
+		return false
+	}
+	c.placeMu.Lock()
+	defer c.placeMu.Unlock()
+
+	c.expireReservations(time.Now())
+	load := c.load[cyborg.CyborgID]
+	if r, exists := c.reserved[job.ID]; exists && r.cyborgID == cyborg.CyborgID {
+		load--
+	}
+	return int32(load) >= cyborg.MaxConcurrentJobs
+}
+
+// preempt cancels the least urgent running job on one of the given cyborgs
+// so that job can take its slot, unless a slot is already being freed for it
+func (c *Conductor) preempt(job *Job, cyborgIDs []string) {
+	if c.preemption == nil {
+		return
+	}
+	eligible := make(map[string]bool, len(cyborgIDs))
+	for _, id := range cyborgIDs {
+		eligible[id] = true
+	}
+
+	c.placeMu.Lock()
+	defer c.placeMu.Unlock()
+
+	if _, exists := c.reserved[job.ID]; exists {
+		// A freed slot is already waiting for this job
+		return
+	}
+	var victim *placement
+	for _, p := range c.placements {
+		if p.preemptedFor == job.ID {
+			// Already freeing a slot for this job
+			return
+		}
+		if p.preemptedFor != "" || !eligible[p.cyborgID] || !c.preemption.canPreempt(job, p) {
+			continue
+		}
+		// Least urgent first; among equals the most recently placed loses
+		// the least work
+		if victim == nil || p.priority > victim.priority ||
+			(p.priority == victim.priority && p.seq > victim.seq) {
+			victim = p
+		}
+	}
+	if victim == nil {
+		return
+	}
+
+	victim.preemptedFor = job.ID
+	victim.cancel(ErrPreempted)
+	metrics.Preemptions.WithLabelValues(victim.cyborgID).Inc()
+}
+
+// requeuePreempted returns a preempted job to the queue. The cancelled run
+// does not count as an attempt.
+func (c *Conductor) requeuePreempted(ctx context.Context, job *Job) {
+	if len(job.Attempts) > 0 {
+		job.Attempts = job.Attempts[:len(job.Attempts)-1]
+	}
+	job.Preemptions++
+	c.requeue(ctx, job, 0)
+}
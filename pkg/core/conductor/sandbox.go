@@ -0,0 +1,80 @@
+package conductor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/internal/runner"
+)
+
+// Resources mirrors the parts of cyborg_jobs.v1.Resources a job's sandbox
+// is derived from
+type Resources struct {
+	CPUCores float64 `json:"cpu_cores,omitempty"`
+	MemoryGB float64 `json:"memory_gb,omitempty"`
+	// NetworkMbps is zero for a job that needs no network
+	NetworkMbps float64 `json:"network_mbps,omitempty"`
+}
+
+// SecurityRequirements mirrors cyborg_jobs.v1.SecurityRequirements
+type SecurityRequirements struct {
+	// Level is a SecurityLevel name, such as "high"
+	Level string `json:"level,omitempty"`
+}
+
+// SandboxPolicy controls the sandbox each job runs its commands in. A
+// job's sandbox is derived from its resources and security level with
+// runner.SandboxFor.
+type SandboxPolicy struct {
+	// Level applies to jobs that state no security level
+	Level runner.SecurityLevel
+	// Timeout stands in for the timeout of jobs that set none when their
+	// CPU time is capped
+	Timeout time.Duration
+	// CgroupParent is where the jobs' cgroups are created; empty means
+	// runner.DefaultCgroupParent
+	CgroupParent string
+}
+
+// validateSecurity rejects a security level the sandbox does not know
+func validateSecurity(job *Job) error {
+	if job.Security == nil || job.Security.Level == "" {
+		return nil
+	}
+	if _, err := runner.ParseSecurityLevel(job.Security.Level); err != nil {
+		return fmt.Errorf("%w: job %s: %v", ErrInvalidJob, job.ID, err)
+	}
+	return nil
+}
+
+// sandboxFor derives the sandbox a job's commands run in, or nil when
+// sandboxing is disabled and the backend's own sandbox applies
+func (c *Conductor) sandboxFor(job *Job) *runner.Sandbox {
+	if c.sandbox == nil {
+		return nil
+	}
+
+	level := c.sandbox.Level
+	if job.Security != nil && job.Security.Level != "" {
+		// Levels are validated on submission
+		level, _ = runner.ParseSecurityLevel(job.Security.Level)
+	}
+	var res runner.Resources
+	if job.Resources != nil {
+		res = runner.Resources{
+			CPUCores:    job.Resources.CPUCores,
+			MemoryGB:    job.Resources.MemoryGB,
+			NetworkMbps: job.Resources.NetworkMbps,
+		}
+	}
+	timeout := c.sandbox.Timeout
+	if job.TimeoutMs > 0 {
+		timeout = time.Duration(job.TimeoutMs) * time.Millisecond
+	}
+
+	sb := runner.SandboxFor(res, level, timeout)
+	if c.sandbox.CgroupParent != "" {
+		sb.CgroupParent = c.sandbox.CgroupParent
+	}
+	return sb
+}
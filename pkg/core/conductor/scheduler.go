@@ -2,23 +2,109 @@ package conductor
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
-	pb "github.com/toxicoder/cyborg-conductor-core/pkg/proto/generated"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/breaker"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/budget"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/deadletter"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/evidence"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/executor"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/idempotency"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/metrics"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/output"
 	"github.com/toxicoder/cyborg-conductor-core/pkg/core/pb"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/policy"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/queue"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/ratelimit"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/types"
 )
 
 // Job represents a unit of work to be executed by a cyborg
 type Job struct {
-	ID          string
-	Capabilities []string
-	Payload     []byte
-	TimeoutMs   int32
+	ID           string   `json:"id"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	Payload      []byte   `json:"payload,omitempty"`
+	TimeoutMs    int32    `json:"timeout_ms,omitempty"`
 	// Add a reference to the cyborg that should execute this job
-	CyborgID    string
+	CyborgID string `json:"cyborg_id,omitempty"`
+	// Namespace the job is accounted to (defaults to the configured namespace)
+	Namespace string `json:"namespace,omitempty"`
+	// Priority of the job; lower values are more urgent
+	Priority int32 `json:"priority,omitempty"`
+	// MaxRetries is how many times a failed job is retried before it is
+	// dead-lettered
+	MaxRetries int32 `json:"max_retries,omitempty"`
+	// Attempts records every execution of the job so far
+	Attempts []deadletter.Attempt `json:"attempts,omitempty"`
+	// IdempotencyKey makes resubmissions within the retention window return
+	// this job instead of running again; it is scoped by Namespace
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// Result is the output a successful handler leaves on the job
+	Result []byte `json:"result,omitempty"`
+	// Preemptions counts how often the job was cancelled to make room for
+	// a more urgent one
+	Preemptions int32 `json:"preemptions,omitempty"`
+	// ContextID groups related jobs, such as one conversation; they are
+	// routed to the cyborg that ran the context before while it is
+	// available
+	ContextID string `json:"context_id,omitempty"`
+	// Tags label the job for other jobs' anti-affinity rules
+	Tags []string `json:"tags,omitempty"`
+	// Affinity constrains which cyborgs the job may run on
+	Affinity *Affinity `json:"affinity,omitempty"`
+	// Class groups jobs that share a scheduling policy, such as "batch"
+	Class string `json:"class,omitempty"`
+	// Usage is what the last execution spent on model tokens; handlers of
+	// LLM jobs set it so it is charged to the job's budgets
+	Usage *budget.Usage `json:"usage,omitempty"`
+	// Owner is the cyborg accountable for the job; a job no cyborg can take
+	// is escalated from it
+	Owner string `json:"owner,omitempty"`
+	// Escalations records each hop the job took up the org chart
+	Escalations []Escalation `json:"escalations,omitempty"`
+	// OutputExcerpts are the head and tail of each output stream that
+	// spilled, by stream
+	OutputExcerpts map[output.Stream]*output.Excerpt `json:"output_excerpts,omitempty"`
+	// Resources and Security size and tighten the sandbox the job's
+	// commands run in
+	Resources *Resources            `json:"resources,omitempty"`
+	Security  *SecurityRequirements `json:"security,omitempty"`
+
+	// lease is the queue delivery this job was read from
+	lease *queue.Lease
+	// pinned is set when the job named its cyborg before placement
+	pinned bool
+}
+
+// JobDoneFunc is called once a job leaves the conductor, with the error
+// that ended it (nil on success)
+type JobDoneFunc func(job *Job, err error)
+
+// JobHandler executes a job that has been placed on a cyborg
+type JobHandler func(ctx context.Context, job *Job) error
+
+// ExecError is returned by a JobHandler when the job ran and failed. The
+// captured stderr is kept with the job if it is dead-lettered.
+type ExecError struct {
+	Err    error
+	Stderr string
+}
+
+func (e *ExecError) Error() string {
+	return e.Err.Error()
 }
 
+func (e *ExecError) Unwrap() error {
+	return e.Err
+}
+
+// maxRetryBackoff caps the exponential delay between retries
+const maxRetryBackoff = 5 * time.Minute
+
 // Pool represents a back-pressure aware worker pool
 type Pool struct {
 	jobChan chan *Job
@@ -32,17 +118,117 @@ type Conductor struct {
 	mu          sync.RWMutex
 	running     bool
 	workerCount int
+	doneHooks   []JobDoneFunc
+	// waiters receive the outcome of individual jobs, such as the sub-jobs
+	// of a fan-out
+	waiters map[string]chan jobOutcome
+	// reducers aggregate fan-out results by name
+	reducers map[string]Reducer
+
+	// queue holds submitted jobs until the dispatcher places them
+	queue queue.Queue
+	// visibility is how long a dequeued job stays leased before another
+	// dispatcher may pick it up again
+	visibility time.Duration
+	// pollInterval is how long the dispatcher waits when the queue is empty
+	pollInterval time.Duration
+
+	// handler executes placed jobs; nil means runExecutor, or completing
+	// the job as placed without an executor
+	handler JobHandler
+	// executor runs placed jobs on their cyborg's backend
+	executor executor.Executor
+	// sandbox derives each job's sandbox; nil leaves it to the backend
+	sandbox *SandboxPolicy
+	// retryBackoff is the delay before the first retry; it doubles with
+	// each further attempt
+	retryBackoff time.Duration
+	// deadLetters keeps jobs that failed for good or could not be placed
+	deadLetters deadletter.Store
+	// breakers takes failing cyborgs out of selection; nil disables them
+	breakers *breaker.Set
+	// hedging duplicates stalled five-nines executions; nil disables it
+	hedging   *HedgePolicy
+	latencies *latencies
+	// idempotency remembers submissions by key for idempotencyWindow; nil
+	// disables deduplication
+	idempotency       idempotency.Store
+	idempotencyWindow time.Duration
+	// limiter holds jobs whose cyborg's dependencies are out of quota; nil
+	// disables rate limiting
+	limiter *ratelimit.Limiter
+	// preemption lets urgent jobs cancel less urgent ones on full cyborgs;
+	// nil disables it
+	preemption *PreemptionPolicy
+	// routes keeps contexts on their cyborg and remembers tagged jobs for
+	// anti-affinity
+	routes *routes
+	// drain measures how fast jobs leave the queue; shedding refuses less
+	// urgent jobs as the queue fills
+	drain    *drainMeter
+	shedding []ShedRule
+	// budgets charges reported usage and enforces token and cost limits;
+	// nil disables them. Downgraded jobs run at downgradePriority.
+	budgets           *budget.Tracker
+	downgradePriority int32
+	// policies pick among the cyborgs able to take a job; costs averages
+	// what jobs report spending per cyborg for them
+	policies *policy.Set
+	costs    *costs
+	// escalation reroutes rejected and unplaceable jobs to the manager of
+	// the cyborg they were meant for; nil disables it. evidence records
+	// each hop; nil keeps no record.
+	escalation *escalation
+	evidence   evidence.Store
+	// output relays the output of running jobs to their watchers
+	output *output.Hub
+
+	// placements tracks jobs handed to cyborgs and load counts them per
+	// cyborg, including slots reserved for preempting jobs
+	placeMu    sync.Mutex
+	placeSeq   uint64
+	placements map[string]*placement
+	load       map[string]int
+	reserved   map[string]reservation
+
+	// stop ends the dispatcher; dispatcherDone is closed once it exits
+	stop           chan struct{}
+	dispatcherDone chan struct{}
 }
 
 // NewConductor creates a new conductor with the given registry
-func NewConductor(registry *pb.Registry) *Conductor {
-	return &Conductor{
+func NewConductor(registry *pb.Registry, opts ...Option) *Conductor {
+	c := &Conductor{
 		registry: registry,
 		pool: &Pool{
 			jobChan: make(chan *Job, 1000), // Buffered channel for back-pressure
 		},
-		workerCount: 10, // Default worker count
+		workerCount:  10, // Default worker count
+		queue:        queue.NewMemoryQueue(1000),
+		visibility:   5 * time.Minute,
+		pollInterval: 50 * time.Millisecond,
+		retryBackoff: time.Second,
+		deadLetters:  deadletter.NewMemoryStore(),
+		latencies:    newLatencies(),
+		placements:   make(map[string]*placement),
+		load:         make(map[string]int),
+		reserved:     make(map[string]reservation),
+		waiters:      make(map[string]chan jobOutcome),
+		reducers:     make(map[string]Reducer),
+		routes:       newRoutes(defaultAffinityWindow),
+		drain:        newDrainMeter(),
+		policies:     policy.NewSet(policy.DefaultWeights),
+		costs:        newCosts(),
+		output:       output.NewHub(0, 0),
+
+		downgradePriority: defaultDowngradePriority,
+	}
+
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	return c
 }
 
 // Start initializes and starts the conductor workers
@@ -53,8 +239,19 @@ func (c *Conductor) Start(ctx context.Context) error {
 		return nil
 	}
 	c.running = true
+	c.pool.jobChan = make(chan *Job, cap(c.pool.jobChan))
+	c.stop = make(chan struct{})
+	c.dispatcherDone = make(chan struct{})
 	c.mu.Unlock()
 
+	// Requeue jobs this instance held when it last stopped or crashed
+	if _, err := c.queue.Recover(ctx); err != nil {
+		c.mu.Lock()
+		c.running = false
+		c.mu.Unlock()
+		return fmt.Errorf("failed to recover in-flight jobs: %w", err)
+	}
+
 	// Start worker goroutines
 	for i := 0; i < c.workerCount; i++ {
 		c.pool.wg.Add(1)
@@ -77,150 +274,719 @@ func (c *Conductor) Stop() error {
 	c.running = false
 	c.mu.Unlock()
 
+	// Stop the dispatcher before closing the channel it writes to
+	close(c.stop)
+	<-c.dispatcherDone
+
 	close(c.pool.jobChan)
 	c.pool.wg.Wait()
 	return nil
 }
 
-// SubmitJob submits a job to the conductor queue
+// SubmitJob submits a job to the conductor queue. A job whose idempotency
+// key was already used in its namespace is not queued; a DuplicateJobError
+// carrying the original job ID and outcome is returned instead.
 func (c *Conductor) SubmitJob(job *Job) error {
-	select {
-	case c.pool.jobChan <- job:
-		return nil
-	default:
+	if err := validateAffinity(job); err != nil {
+		return err
+	}
+	if err := validateSecurity(job); err != nil {
+		return err
+	}
+	// A retried submission gets its original job back even when the
+	// namespace has since run over budget
+	reserved, err := c.reserveKey(job)
+	if err != nil {
+		return err
+	}
+
+	if err = c.checkBudget(job); err == nil {
+		err = c.enqueue(job)
+	}
+	if err != nil {
+		if reserved {
+			// Let the client retry with the same key
+			_ = c.idempotency.Release(context.Background(), job.Namespace, job.IdempotencyKey)
+		}
+		return err
+	}
+	return nil
+}
+
+// reserveKey claims a job's idempotency key, reporting whether it did
+func (c *Conductor) reserveKey(job *Job) (bool, error) {
+	if job.IdempotencyKey == "" || c.idempotency == nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	held, created, err := c.idempotency.Reserve(context.Background(), &idempotency.Record{
+		Namespace: job.Namespace,
+		Key:       job.IdempotencyKey,
+		JobID:     job.ID,
+		Status:    idempotency.StatusPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(c.idempotencyWindow),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to check idempotency key for job %s: %w", job.ID, err)
+	}
+	if !created {
+		return false, &DuplicateJobError{Record: held}
+	}
+	return true, nil
+}
+
+// enqueue adds a job to the queue
+func (c *Conductor) enqueue(job *Job) error {
+	if err := c.shed(context.Background(), job); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job %s: %w", job.ID, err)
+	}
+
+	msg := &queue.Message{ID: job.ID, Payload: payload, Priority: job.Priority}
+	err = c.queue.Enqueue(context.Background(), msg, 0)
+	switch {
+	case errors.Is(err, queue.ErrFull):
 		// Queue is full - implement back-pressure
-		return &JobQueueFullError{Message: "Job queue is full"}
+		return c.queueFull()
+	case err != nil:
+		return fmt.Errorf("failed to enqueue job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// OnJobDone registers a hook that is called whenever a job finishes,
+// whether it succeeded, failed or could not be placed
+func (c *Conductor) OnJobDone(fn JobDoneFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.doneHooks = append(c.doneHooks, fn)
+}
+
+// finishJob acknowledges a job's queue lease and notifies the registered
+// hooks that it has finished
+func (c *Conductor) finishJob(ctx context.Context, job *Job, err error) {
+	if job.lease != nil {
+		// A lost lease means the job was redelivered elsewhere; the other
+		// delivery now owns it
+		_ = c.queue.Ack(ctx, job.lease)
+	}
+	c.drain.observe()
+	if excerpts := c.output.Finish(job.ID); excerpts != nil {
+		job.OutputExcerpts = excerpts
+	}
+
+	if job.IdempotencyKey != "" && c.idempotency != nil {
+		status, errMsg := idempotency.StatusSucceeded, ""
+		if err != nil {
+			status, errMsg = idempotency.StatusFailed, err.Error()
+		}
+		// Duplicates keep seeing the job as pending if this fails; the
+		// record still expires with its window
+		_ = c.idempotency.Complete(ctx, job.Namespace, job.IdempotencyKey, status, job.Result, errMsg)
+	}
+
+	c.mu.Lock()
+	hooks := c.doneHooks
+	waiter, watched := c.waiters[job.ID]
+	delete(c.waiters, job.ID)
+	c.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(job, err)
+	}
+	if watched {
+		waiter <- jobOutcome{job: job, err: err}
 	}
 }
 
 // worker processes jobs from the job channel
 func (c *Conductor) worker(ctx context.Context, workerID int) {
 	defer c.pool.wg.Done()
-	
+
 	for {
 		select {
 		case job, ok := <-c.pool.jobChan:
 			if !ok {
 				return // Channel closed
 			}
-			
-			// Process the job
-			err := c.processJob(ctx, job)
-			if err != nil {
-				// Handle job processing error
-				// In a real implementation, this would log and potentially retry
-				continue
+
+			// Process the job while keeping its queue lease alive
+			stopKeepAlive := c.keepLeaseAlive(ctx, job)
+			err := c.runAttempt(c.jobContext(ctx, job), job)
+			stopKeepAlive()
+			preempted := c.unplace(job)
+			switch {
+			case err == nil:
+				c.finishJob(ctx, job, nil)
+			case preempted:
+				c.requeuePreempted(ctx, job)
+			case errors.Is(err, ErrRejected) && c.escalate(ctx, job, EscalationRejected, err):
+				// Rerouted to the rejecting cyborg's manager
+			case int32(len(job.Attempts)) <= job.MaxRetries:
+				c.retryJob(ctx, job)
+			default:
+				c.deadLetterJob(ctx, job, deadletter.ReasonRetriesExhausted, err)
 			}
-			
+
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
+// runAttempt executes a job once and appends the outcome to its attempt
+// history
+func (c *Conductor) runAttempt(ctx context.Context, job *Job) error {
+	attempt := deadletter.Attempt{
+		Number:    len(job.Attempts) + 1,
+		CyborgID:  job.CyborgID,
+		StartedAt: time.Now(),
+	}
+
+	handler := c.handler
+	if handler == nil && c.executor != nil {
+		handler = c.runExecutor
+	}
+	if handler == nil {
+		// Without an executor there is no backend to run the job on, so
+		// it completes once placed
+		handler = func(context.Context, *Job) error { return nil }
+	}
+
+	var err error
+	if delay, ok := c.hedgeDelay(job); ok {
+		// The job reports the cyborg whose result was taken
+		err = c.runHedged(ctx, job, handler, delay)
+		attempt.CyborgID = job.CyborgID
+	} else {
+		err = c.execute(ctx, job, handler)
+	}
+
+	attempt.FinishedAt = time.Now()
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	job.Attempts = append(job.Attempts, attempt)
+	return err
+}
+
+// execute runs a job once on its cyborg and records the outcome against
+// that cyborg's breaker and latency history
+func (c *Conductor) execute(ctx context.Context, job *Job, handler JobHandler) error {
+	start := time.Now()
+	// Only what this execution reports is charged
+	job.Usage = nil
+	err := handler(ctx, job)
+	// Tokens are spent whether or not the execution succeeded
+	c.recordUsage(job)
+	if ctx.Err() != nil {
+		// Cancelled by shutdown or by losing a hedge - says nothing about
+		// the cyborg
+		return err
+	}
+	if errors.Is(err, executor.ErrRefused) {
+		// The backend would not start the job, as when a policy forbids it
+		return err
+	}
+
+	c.breakers.Record(job.CyborgID, job.Capabilities, err != nil)
+	if err == nil {
+		c.latencies.observe(job.CyborgID, time.Since(start))
+	}
+	return err
+}
+
+// encodeJob serializes a job for the queue or the dead-letter store. The
+// cyborg it ran on is dropped unless the job was pinned, so a retry may be
+// placed elsewhere.
+func encodeJob(job *Job) ([]byte, error) {
+	encoded := *job
+	if !job.pinned {
+		encoded.CyborgID = ""
+	}
+	return json.Marshal(&encoded)
+}
+
+// retryJob returns a failed job to the queue with its attempt history,
+// delayed by an exponential backoff
+func (c *Conductor) retryJob(ctx context.Context, job *Job) {
+	delay := c.retryBackoff
+	for i := 1; i < len(job.Attempts) && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	c.requeue(ctx, job, delay)
+}
+
+// requeueRecord is the evidence entry of a job queued afresh because it
+// held no queue lease to release
+type requeueRecord struct {
+	Event string `json:"event"`
+	Error string `json:"error,omitempty"`
+}
+
+// requeue returns a job to the queue after delay by releasing its lease.
+// A job run without a lease has no message to release, so it is queued
+// afresh; if that fails it is dead-lettered rather than dropped.
+func (c *Conductor) requeue(ctx context.Context, job *Job, delay time.Duration) {
+	payload, err := encodeJob(job)
+	if job.lease != nil {
+		if err == nil {
+			job.lease.Payload = payload
+		}
+		// A lost lease means the job was already redelivered elsewhere
+		_ = c.queue.Release(ctx, job.lease, delay)
+		return
+	}
+
+	if err == nil {
+		msg := &queue.Message{ID: job.ID, Payload: payload, Priority: job.Priority}
+		err = c.queue.Enqueue(ctx, msg, delay)
+	}
+	if err != nil {
+		c.recordEvidence(ctx, job, &requeueRecord{Event: "requeue_failed", Error: err.Error()})
+		c.deadLetterJob(ctx, job, deadletter.ReasonRequeueFailed, fmt.Errorf("failed to requeue job %s: %w", job.ID, err))
+		return
+	}
+	c.recordEvidence(ctx, job, &requeueRecord{Event: "requeued_without_lease"})
+}
+
+// deadLetterJob moves a job the conductor has given up on to the
+// dead-letter store and finishes it with cause
+func (c *Conductor) deadLetterJob(ctx context.Context, job *Job, reason string, cause error) {
+	// Job holds only JSON-safe fields, so encoding cannot fail
+	encoded, _ := encodeJob(job)
+
+	now := time.Now()
+	entry := &deadletter.Entry{
+		ID:        job.ID,
+		Namespace: job.Namespace,
+		CyborgID:  job.CyborgID,
+		Job:       encoded,
+		Reason:    reason,
+		Error:     cause.Error(),
+		Attempts:  job.Attempts,
+		DeadAt:    now,
+		UpdatedAt: now,
+	}
+	var execErr *ExecError
+	if errors.As(cause, &execErr) {
+		entry.LastStderr = execErr.Stderr
+	}
+
+	if err := c.deadLetters.Put(ctx, entry); err != nil {
+		// Leave the job queued rather than lose it; it comes back after the
+		// retry backoff and is dead-lettered again
+		if job.lease != nil {
+			_ = c.queue.Release(ctx, job.lease, c.retryBackoff)
+		}
+		return
+	}
+	c.finishJob(ctx, job, cause)
+}
+
+// Output returns the hub relaying the output of running jobs. Handlers
+// publish to it under the job's ID; its watchers are closed once the job
+// finishes.
+func (c *Conductor) Output() *output.Hub {
+	return c.output
+}
+
+// DeadLetters returns the store holding dead-lettered jobs
+func (c *Conductor) DeadLetters() deadletter.Store {
+	return c.deadLetters
+}
+
+// EditDeadLetter replaces the job held in a dead-letter entry, so that a
+// later requeue submits the corrected job
+func (c *Conductor) EditDeadLetter(ctx context.Context, id string, job *Job) (*deadletter.Entry, error) {
+	entry, err := c.deadLetters.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	job.ID = id
+	encoded, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job %s: %w", id, err)
+	}
+	entry.Job = encoded
+	entry.Namespace = job.Namespace
+	entry.CyborgID = job.CyborgID
+	entry.UpdatedAt = time.Now()
+
+	if err := c.deadLetters.Update(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// RequeueDeadLetter submits a dead-lettered job again with a fresh retry
+// budget and removes its entry
+func (c *Conductor) RequeueDeadLetter(ctx context.Context, id string) (*Job, error) {
+	entry, err := c.deadLetters.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var job Job
+	if err := json.Unmarshal(entry.Job, &job); err != nil {
+		return nil, fmt.Errorf("%w: dead-lettered job %s cannot be decoded, edit it first: %v",
+			ErrInvalidJob, id, err)
+	}
+	job.ID = id
+	job.Attempts = nil
+
+	// The key recorded the failure; the rerun claims it afresh
+	if job.IdempotencyKey != "" && c.idempotency != nil {
+		if err := c.idempotency.Release(ctx, job.Namespace, job.IdempotencyKey); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.SubmitJob(&job); err != nil {
+		return nil, err
+	}
+	if err := c.deadLetters.Delete(ctx, id); err != nil && !errors.Is(err, deadletter.ErrNotFound) {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// keepLeaseAlive extends a job's queue lease at half the visibility
+// timeout until the returned function is called
+func (c *Conductor) keepLeaseAlive(ctx context.Context, job *Job) func() {
+	if job.lease == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(c.visibility / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.queue.Extend(ctx, job.lease, c.visibility); err != nil {
+					return
+				}
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
 // dispatchJobs continuously dispatches jobs to available cyborgs
 func (c *Conductor) dispatchJobs(ctx context.Context) {
+	defer close(c.dispatcherDone)
+
 	for {
 		select {
-		case job, ok := <-c.pool.jobChan:
-			if !ok {
+		case <-c.stop:
+			return
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		job, err := c.nextJob(ctx)
+		if err != nil {
+			// Queue empty or unavailable - wait before polling again
+			select {
+			case <-time.After(c.pollInterval):
+			case <-c.stop:
+				return
+			case <-ctx.Done():
 				return
 			}
-			
-			// Find suitable cyborg for the job
-			cyborg, err := c.findSuitableCyborg(job)
-			if err != nil {
-				// Handle error - could log and potentially retry
+			continue
+		}
+
+		// Find suitable cyborg for the job
+		cyborg, err := c.findSuitableCyborg(job)
+		var atCapacity *CapacityError
+		if errors.As(err, &atCapacity) {
+			// Every capable cyborg is busy - free a slot if the job may
+			// preempt, then hold it until one opens up
+			c.preempt(job, atCapacity.CyborgIDs)
+			_ = c.queue.Release(ctx, job.lease, c.pollInterval)
+			continue
+		}
+		var circuitOpen *CircuitOpenError
+		if errors.As(err, &circuitOpen) {
+			// Capable cyborgs exist but are tripped - hold the job until
+			// their breakers let work through again
+			_ = c.queue.Release(ctx, job.lease, c.retryBackoff)
+			continue
+		}
+		var overBudget *BudgetExceededError
+		if errors.As(err, &overBudget) {
+			// Every capable cyborg spent its budget - keep the job for an
+			// operator to requeue once the window resets
+			metrics.BudgetActions.WithLabelValues(string(overBudget.Status.Scope), string(budget.ActionReject)).Inc()
+			c.deadLetterJob(ctx, job, deadletter.ReasonBudgetExceeded, err)
+			continue
+		}
+		if err != nil {
+			// Handle error - could log and potentially retry
+			c.finishJob(ctx, job, err)
+			continue
+		}
+
+		if cyborg != nil {
+			if ok, wait, dependency := c.limiter.Take(cyborg.CyborgID, cyborg.Dependencies); !ok {
+				// The vendor quota is spent - hold the job rather than let
+				// it fail on a 429
+				metrics.RateLimitHolds.WithLabelValues(dependency).Inc()
+				_ = c.queue.Release(ctx, job.lease, wait)
 				continue
 			}
-			
-			if cyborg != nil {
-				// Dispatch to cyborg
-				err := c.dispatchToCyborg(ctx, job, cyborg)
-				if err != nil {
-					// Handle dispatch error
-					continue
-				}
-			} else {
-				// No suitable cyborg found - could queue or fail
-				// For now, we'll just log and continue
+
+			// Dispatch to cyborg
+			err := c.dispatchToCyborg(ctx, job, cyborg)
+			if err != nil {
+				// Dispatch was interrupted - leave the job for redelivery
+				_ = c.queue.Release(ctx, job.lease, 0)
+				continue
 			}
-			
-		case <-ctx.Done():
-			return
+		} else if !c.escalate(ctx, job, EscalationNoCyborg, &NoSuitableCyborgError{JobID: job.ID}) {
+			// No suitable cyborg found and no manager to escalate to - keep
+			// the job for an operator
+			c.deadLetterJob(ctx, job, deadletter.ReasonNoSuitableCyborg, &NoSuitableCyborgError{JobID: job.ID})
 		}
 	}
 }
 
+// nextJob leases the next job from the queue and decodes it
+func (c *Conductor) nextJob(ctx context.Context) (*Job, error) {
+	lease, err := c.queue.Dequeue(ctx, c.visibility)
+	if err != nil {
+		return nil, err
+	}
+
+	var job Job
+	if err := json.Unmarshal(lease.Payload, &job); err != nil {
+		// An undecodable payload will never succeed; dead-letter it as is
+		err = fmt.Errorf("failed to decode job %s: %w", lease.ID, err)
+		c.deadLetterJob(ctx, undecodableJob(lease), deadletter.ReasonUndecodable, err)
+		return nil, err
+	}
+	job.lease = lease
+	job.pinned = job.CyborgID != ""
+	return &job, nil
+}
+
+// undecodableJob stands in for a queued payload that is not a valid job so
+// it can still be dead-lettered under its queue ID, raw bytes and all
+func undecodableJob(lease *queue.Lease) *Job {
+	return &Job{ID: lease.ID, Payload: lease.Payload, lease: lease}
+}
+
 // findSuitableCyborg selects the best cyborg for a job based on capabilities
-func (c *Conductor) findSuitableCyborg(job *Job) (*pb.CyborgDescriptor, error) {
-	// Get all registered cyborgs
-	cyborgs := c.registry.List()
-	
-	// Simple selection logic - in a real implementation this would be more sophisticated
-	// and consider factors like load, latency budget, etc.
-	// For now, we'll just return the first cyborg with all required capabilities
-	for _, cyborg := range cyborgs {
+func (c *Conductor) findSuitableCyborg(job *Job) (*types.CyborgDescriptor, error) {
+	return c.selectCyborg(job, "")
+}
+
+// selectCyborg picks a cyborg for a job other than the excluded one, which
+// is how a hedged execution finds its second cyborg
+func (c *Conductor) selectCyborg(job *Job, exclude string) (*types.CyborgDescriptor, error) {
+	// A job pinned to a cyborg only runs there
+	if job.pinned {
+		cyborg, exists := c.registry.Get(job.CyborgID)
+		if !exists {
+			return nil, nil
+		}
+		if c.atCapacity(cyborg, job) {
+			return nil, &CapacityError{JobID: job.ID, CyborgIDs: []string{cyborg.CyborgID}}
+		}
+		if !c.breakers.Allow(cyborg.CyborgID, job.Capabilities) {
+			return nil, &CircuitOpenError{JobID: job.ID}
+		}
+		if status := c.overBudget(cyborg); status != nil {
+			return nil, &BudgetExceededError{JobID: job.ID, Status: status}
+		}
+		return cyborg, nil
+	}
+
+	// A job continuing a context goes back to the cyborg that holds it
+	// warm; a hedge looks for a different cyborg
+	if exclude == "" {
+		if cyborg, ok, err := c.stickyCyborg(job); ok {
+			return cyborg, err
+		}
+	}
+
+	// Collect the cyborgs with all required capabilities whose circuit
+	// breaker lets work through and that have a free slot, then let the
+	// job's scheduling policy pick one of them
+	var capable []*types.CyborgDescriptor
+	tripped := false
+	var full []string
+	var exhausted *budget.Status
+	for _, cyborg := range c.registry.List() {
+		if cyborg.CyborgID == exclude {
+			continue
+		}
 		// Check if cyborg has all required capabilities
-		if c.hasAllCapabilities(cyborg, job.Capabilities) {
+		if !c.hasAllCapabilities(cyborg, job.Capabilities) {
+			continue
+		}
+		if !c.eligible(cyborg, job) {
+			continue
+		}
+		if status := c.overBudget(cyborg); status != nil {
+			exhausted = status
+			continue
+		}
+		if c.atCapacity(cyborg, job) {
+			full = append(full, cyborg.CyborgID)
+			continue
+		}
+		if !c.breakers.Ready(cyborg.CyborgID, job.Capabilities) {
+			tripped = true
+			continue
+		}
+		capable = append(capable, cyborg)
+	}
+	if len(capable) > 0 {
+		cyborg, refused := c.choose(job, capable)
+		if cyborg != nil {
 			return cyborg, nil
 		}
+		tripped = tripped || refused
+	}
+
+	if len(full) > 0 {
+		return nil, &CapacityError{JobID: job.ID, CyborgIDs: full}
+	}
+	if tripped {
+		return nil, &CircuitOpenError{JobID: job.ID}
+	}
+	if exhausted != nil {
+		return nil, &BudgetExceededError{JobID: job.ID, Status: exhausted}
 	}
-	
 	return nil, nil // No suitable cyborg found
 }
 
 // hasAllCapabilities checks if a cyborg has all the required capabilities
-func (c *Conductor) hasAllCapabilities(cyborg *pb.CyborgDescriptor, requiredCaps []string) bool {
+func (c *Conductor) hasAllCapabilities(cyborg *types.CyborgDescriptor, requiredCaps []string) bool {
 	if cyborg == nil || cyborg.Capabilities == nil {
 		return false
 	}
-	
+
 	// Convert cyborg capabilities to map for faster lookup
 	cyborgCaps := make(map[string]bool)
 	for _, cap := range cyborg.Capabilities {
 		cyborgCaps[cap.Name] = true // Assuming CapabilitySpec has a Name field
 	}
-	
+
 	// Check if all required capabilities are present
 	for _, requiredCap := range requiredCaps {
 		if !cyborgCaps[requiredCap] {
 			return false
 		}
 	}
-	
-	return true
-}
 
-// processJob handles the processing of a single job
-func (c *Conductor) processJob(ctx context.Context, job *Job) error {
-	// In a real implementation, this would:
-	// 1. Validate the job
-	// 2. Determine if it's deterministic or LLM-based
-	// 3. Call appropriate handler (SubprocessRunner or LLM streaming session)
-	
-	// For now, just simulate processing
-	time.Sleep(10 * time.Millisecond)
-	return nil
+	return true
 }
 
 // dispatchToCyborg dispatches a job to a specific cyborg
-func (c *Conductor) dispatchToCyborg(ctx context.Context, job *Job, cyborg *pb.CyborgDescriptor) error {
-	// In a real implementation, this would:
-	// 1. Create a SubmitJobMessage with the job details
-	// 2. Send it to the cyborg via appropriate transport
-	// 3. Handle the response
-	
-	// For now, just simulate dispatch
-	time.Sleep(5 * time.Millisecond)
-	return nil
+func (c *Conductor) dispatchToCyborg(ctx context.Context, job *Job, cyborg *types.CyborgDescriptor) error {
+	// The worker pool runs every placed job through runExecutor. The
+	// server's executor is an executor.Router, which hands jobs for cyborgs
+	// on the remote backend to the remote.Broker for a connected worker to
+	// pull, and runs the rest as subprocesses or through adapters.
+	job.CyborgID = cyborg.CyborgID
+	c.place(ctx, job)
+	c.routes.record(job)
+	select {
+	case c.pool.jobChan <- job:
+		return nil
+	case <-c.stop:
+		// Shutting down - return the job to the queue for the next start
+		c.unplace(job)
+		if job.lease != nil {
+			_ = c.queue.Release(ctx, job.lease, 0)
+		}
+		return nil
+	case <-ctx.Done():
+		c.unplace(job)
+		return ctx.Err()
+	}
 }
 
-// JobQueueFullError represents an error when job queue is full
+// ErrInvalidJob is returned when a job cannot be decoded or is malformed
+var ErrInvalidJob = errors.New("invalid job")
+
+// JobQueueFullError represents an error when job queue is full, or when
+// load shedding refuses the job's priority
 type JobQueueFullError struct {
 	Message string
+	// Depth and Capacity describe the queue when the job was refused
+	Depth    int
+	Capacity int
+	// Shed is set when a load-shedding rule refused the job before the
+	// queue was full
+	Shed bool
+	// RetryAfter estimates when the queue will have room, from the rate
+	// it drained at over the last minute
+	RetryAfter time.Duration
 }
 
 func (e *JobQueueFullError) Error() string {
 	return e.Message
-}
\ No newline at end of file
+}
+
+// NoSuitableCyborgError is reported when no registered cyborg can take a job
+type NoSuitableCyborgError struct {
+	JobID string
+}
+
+func (e *NoSuitableCyborgError) Error() string {
+	return "no suitable cyborg for job " + e.JobID
+}
+
+// CircuitOpenError is reported when every cyborg that could take a job is
+// behind an open circuit breaker
+type CircuitOpenError struct {
+	JobID string
+}
+
+func (e *CircuitOpenError) Error() string {
+	return "circuit open for every cyborg able to run job " + e.JobID
+}
+
+// CapacityError is reported when every cyborg that could take a job is
+// already running as many jobs as it accepts
+type CapacityError struct {
+	JobID string
+	// CyborgIDs are the capable cyborgs that are full
+	CyborgIDs []string
+}
+
+func (e *CapacityError) Error() string {
+	return "every cyborg able to run job " + e.JobID + " is at capacity"
+}
+
+// DuplicateJobError is returned by SubmitJob when the job's idempotency key
+// already belongs to another submission
+type DuplicateJobError struct {
+	// Record holds the original job ID, its status and, once finished, its
+	// result or error
+	Record *idempotency.Record
+}
+
+func (e *DuplicateJobError) Error() string {
+	return fmt.Sprintf("idempotency key %q already used by job %s", e.Record.Key, e.Record.JobID)
+}
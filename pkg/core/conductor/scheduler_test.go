@@ -2,12 +2,32 @@ package conductor
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
-	pb "github.com/toxicoder/cyborg-conductor-core/pkg/proto/pb"
+	"github.com/stretchr/testify/require"
+	"github.com/toxicoder/cyborg-conductor-core/internal/runner"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/breaker"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/budget"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/deadletter"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/evidence"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/executor"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/idempotency"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/orgchart"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/output"
 	"github.com/toxicoder/cyborg-conductor-core/pkg/core/pb"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/policy"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/queue"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/ratelimit"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/types"
 )
 
 // TestConductor tests the conductor implementation
@@ -131,4 +151,1348 @@ func TestConductorCapacityEnforcement(t *testing.T) {
 	
 	err = conductor.Stop()
 	assert.NoError(t, err)
-}
\ No newline at end of file
+}
+// testRegistry returns a registry holding one cyborg with the given capabilities
+func testRegistry(t *testing.T, cyborgID string, capabilities ...string) *pb.Registry {
+	registry := pb.NewRegistry()
+	descriptor := &types.CyborgDescriptor{CyborgID: cyborgID}
+	for _, name := range capabilities {
+		descriptor.Capabilities = append(descriptor.Capabilities, types.CapabilitySpec{Name: name})
+	}
+	assert.NoError(t, registry.Register(descriptor))
+	return registry
+}
+
+// waitForDone registers a hook on the conductor and returns a channel that
+// receives every finished job's error keyed by job ID
+func waitForDone(c *Conductor) chan map[string]error {
+	done := make(chan map[string]error, 100)
+	c.OnJobDone(func(job *Job, err error) {
+		done <- map[string]error{job.ID: err}
+	})
+	return done
+}
+
+// TestConductorQueueDelivery tests that queued jobs are dispatched and acknowledged
+func TestConductorQueueDelivery(t *testing.T) {
+	q := queue.NewMemoryQueue(10)
+	conductor := NewConductor(testRegistry(t, "FINC0001", "reporting"), WithQueue(q), WithPollInterval(time.Millisecond))
+	done := waitForDone(conductor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	assert.NoError(t, conductor.SubmitJob(&Job{ID: "month-end", Capabilities: []string{"reporting"}}))
+
+	select {
+	case result := <-done:
+		assert.Contains(t, result, "month-end")
+		assert.NoError(t, result["month-end"])
+	case <-time.After(2 * time.Second):
+		t.Fatal("job was not processed")
+	}
+
+	n, err := q.Len(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+// TestConductorRecoversInFlightJobs tests that jobs leased before a crash are redelivered on start
+func TestConductorRecoversInFlightJobs(t *testing.T) {
+	q := queue.NewMemoryQueue(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Simulate a previous process that leased a job and died
+	crashed := NewConductor(testRegistry(t, "PERS0001", "agenda"), WithQueue(q))
+	assert.NoError(t, crashed.SubmitJob(&Job{ID: "daily-agenda", Capabilities: []string{"agenda"}}))
+	_, err := q.Dequeue(ctx, time.Hour)
+	assert.NoError(t, err)
+
+	conductor := NewConductor(testRegistry(t, "PERS0001", "agenda"), WithQueue(q), WithPollInterval(time.Millisecond))
+	done := waitForDone(conductor)
+	assert.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	select {
+	case result := <-done:
+		assert.Contains(t, result, "daily-agenda")
+	case <-time.After(2 * time.Second):
+		t.Fatal("in-flight job was not recovered")
+	}
+}
+
+// TestConductorNoSuitableCyborg tests that unplaceable jobs finish with an error
+func TestConductorNoSuitableCyborg(t *testing.T) {
+	conductor := NewConductor(testRegistry(t, "SALE0001", "pipeline"), WithPollInterval(time.Millisecond))
+	done := waitForDone(conductor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	assert.NoError(t, conductor.SubmitJob(&Job{ID: "orphan", Capabilities: []string{"legal-review"}}))
+
+	select {
+	case result := <-done:
+		var noCyborg *NoSuitableCyborgError
+		assert.ErrorAs(t, result["orphan"], &noCyborg)
+	case <-time.After(2 * time.Second):
+		t.Fatal("job was not finished")
+	}
+}
+
+// TestConductorRetriesThenDeadLetters tests that a failing job is retried and
+// then kept in the dead-letter store with its history and stderr
+func TestConductorRetriesThenDeadLetters(t *testing.T) {
+	calls := 0
+	handler := func(ctx context.Context, job *Job) error {
+		calls++
+		return &ExecError{Err: errors.New("exit status 2"), Stderr: fmt.Sprintf("ledger locked (%d)", calls)}
+	}
+	conductor := NewConductor(testRegistry(t, "FINC0001", "close"),
+		WithJobHandler(handler), WithPollInterval(time.Millisecond), WithRetryBackoff(time.Millisecond), WithWorkerCount(1))
+	done := waitForDone(conductor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	assert.NoError(t, conductor.SubmitJob(&Job{ID: "close-books", Capabilities: []string{"close"}, MaxRetries: 2}))
+
+	select {
+	case result := <-done:
+		assert.Error(t, result["close-books"])
+	case <-time.After(2 * time.Second):
+		t.Fatal("job was not dead-lettered")
+	}
+	assert.Equal(t, 3, calls)
+
+	entry, err := conductor.DeadLetters().Get(ctx, "close-books")
+	require.NoError(t, err)
+	assert.Equal(t, deadletter.ReasonRetriesExhausted, entry.Reason)
+	assert.Equal(t, "ledger locked (3)", entry.LastStderr)
+	require.Len(t, entry.Attempts, 3)
+	assert.Equal(t, "FINC0001", entry.Attempts[2].CyborgID)
+	assert.Equal(t, "exit status 2", entry.Attempts[2].Error)
+}
+
+// TestConductorRetryWithoutLease tests that a failed job that holds no
+// queue lease is queued afresh, and dead-lettered if it cannot be
+func TestConductorRetryWithoutLease(t *testing.T) {
+	q := queue.NewMemoryQueue(1)
+	conductor := NewConductor(testRegistry(t, "FINC0001", "close"), WithQueue(q), WithRetryBackoff(time.Millisecond))
+	done := waitForDone(conductor)
+	ctx := context.Background()
+
+	job := &Job{ID: "close-books", Capabilities: []string{"close"}, MaxRetries: 2,
+		Attempts: []deadletter.Attempt{{Number: 1, CyborgID: "FINC0001", Error: "exit status 2"}}}
+	conductor.retryJob(ctx, job)
+	n, err := q.Len(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// The queue is now full, so the next one cannot be requeued
+	conductor.retryJob(ctx, &Job{ID: "close-ledger", Capabilities: []string{"close"}, MaxRetries: 2})
+	select {
+	case result := <-done:
+		assert.ErrorIs(t, result["close-ledger"], queue.ErrFull)
+	case <-time.After(2 * time.Second):
+		t.Fatal("job was not dead-lettered")
+	}
+	entry, err := conductor.DeadLetters().Get(ctx, "close-ledger")
+	require.NoError(t, err)
+	assert.Equal(t, deadletter.ReasonRequeueFailed, entry.Reason)
+
+	// The requeued job is delivered once its backoff has passed
+	var lease *queue.Lease
+	require.Eventually(t, func() bool {
+		lease, err = q.Dequeue(ctx, time.Minute)
+		return err == nil && lease != nil
+	}, time.Second, time.Millisecond)
+	var requeued Job
+	require.NoError(t, json.Unmarshal(lease.Payload, &requeued))
+	assert.Equal(t, "close-books", requeued.ID)
+	assert.Len(t, requeued.Attempts, 1)
+}
+
+// TestConductorRequeueEditedDeadLetter tests that an unplaceable job is
+// dead-lettered and runs once an operator fixes and requeues it
+func TestConductorRequeueEditedDeadLetter(t *testing.T) {
+	conductor := NewConductor(testRegistry(t, "LEGL0001", "contract-review"), WithPollInterval(time.Millisecond))
+	done := waitForDone(conductor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	assert.NoError(t, conductor.SubmitJob(&Job{ID: "nda", Capabilities: []string{"contract-reveiw"}}))
+	<-done
+
+	entry, err := conductor.DeadLetters().Get(ctx, "nda")
+	require.NoError(t, err)
+	assert.Equal(t, deadletter.ReasonNoSuitableCyborg, entry.Reason)
+
+	_, err = conductor.EditDeadLetter(ctx, "nda", &Job{Capabilities: []string{"contract-review"}})
+	require.NoError(t, err)
+	job, err := conductor.RequeueDeadLetter(ctx, "nda")
+	require.NoError(t, err)
+	assert.Equal(t, "nda", job.ID)
+
+	select {
+	case result := <-done:
+		assert.NoError(t, result["nda"])
+	case <-time.After(2 * time.Second):
+		t.Fatal("requeued job was not processed")
+	}
+	_, err = conductor.DeadLetters().Get(ctx, "nda")
+	assert.ErrorIs(t, err, deadletter.ErrNotFound)
+}
+
+// TestConductorBreakerSkipsTrippedCyborg tests that a cyborg whose breaker
+// opened receives no more work while others take its jobs
+func TestConductorBreakerSkipsTrippedCyborg(t *testing.T) {
+	registry := testRegistry(t, "FINC0001", "close")
+	require.NoError(t, registry.Register(&types.CyborgDescriptor{
+		CyborgID:     "FINC0002",
+		Capabilities: []types.CapabilitySpec{{Name: "close"}},
+	}))
+
+	handler := func(ctx context.Context, job *Job) error {
+		if job.CyborgID == "FINC0001" {
+			return errors.New("timed out")
+		}
+		return nil
+	}
+	breakers := breaker.NewSet(breaker.Config{WindowSize: 1, MinRequests: 1, FailureRate: 1, OpenTimeout: time.Hour}, false,
+		func(tr breaker.Transition) { registry.SetBreakerState(tr.CyborgID, tr.Capability, tr.To.String()) })
+	q := queue.NewMemoryQueue(10)
+	conductor := NewConductor(registry, WithQueue(q), WithJobHandler(handler), WithBreakers(breakers),
+		WithPollInterval(time.Millisecond), WithRetryBackoff(time.Millisecond))
+	done := waitForDone(conductor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	assert.NoError(t, conductor.SubmitJob(&Job{ID: "q1-close", CyborgID: "FINC0001"}))
+	assert.Error(t, (<-done)["q1-close"])
+
+	status, ok := registry.Status("FINC0001")
+	require.True(t, ok)
+	assert.Equal(t, pb.StatusUnavailable, status.State)
+
+	// Unpinned work goes to the healthy cyborg
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("q2-close-%d", i)
+		assert.NoError(t, conductor.SubmitJob(&Job{ID: id, Capabilities: []string{"close"}}))
+		select {
+		case result := <-done:
+			assert.NoError(t, result[id])
+		case <-time.After(2 * time.Second):
+			t.Fatal("job was not processed")
+		}
+	}
+
+	// Work pinned to the tripped cyborg is held, not dead-lettered
+	assert.NoError(t, conductor.SubmitJob(&Job{ID: "q3-close", CyborgID: "FINC0001"}))
+	time.Sleep(50 * time.Millisecond)
+	_, err := conductor.DeadLetters().Get(ctx, "q3-close")
+	assert.ErrorIs(t, err, deadletter.ErrNotFound)
+	n, err := q.Len(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+// fiveNinesRegistry returns a registry of five-nines cyborgs sharing one capability
+func fiveNinesRegistry(t *testing.T, capability string, ids ...string) *pb.Registry {
+	registry := pb.NewRegistry()
+	for _, id := range ids {
+		require.NoError(t, registry.Register(&types.CyborgDescriptor{
+			CyborgID:        id,
+			Capabilities:    []types.CapabilitySpec{{Name: capability}},
+			ReliabilityTier: types.ReliabilityTierFiveNines,
+		}))
+	}
+	return registry
+}
+
+// TestConductorHedgesStalledExecution tests that a stalled five-nines
+// execution is duplicated on a second cyborg and the loser is cancelled
+func TestConductorHedgesStalledExecution(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	cancelled := make(chan string, 1)
+	handler := func(ctx context.Context, job *Job) error {
+		mu.Lock()
+		calls = append(calls, job.CyborgID)
+		first := len(calls) == 1
+		mu.Unlock()
+		if first {
+			// Stall until the hedge wins
+			<-ctx.Done()
+			cancelled <- job.CyborgID
+			return ctx.Err()
+		}
+		return nil
+	}
+	conductor := NewConductor(fiveNinesRegistry(t, "settlement", "FINC0001", "FINC0002"),
+		WithJobHandler(handler), WithHedging(HedgePolicy{Delay: 20 * time.Millisecond}), WithPollInterval(time.Millisecond))
+	done := make(chan *Job, 1)
+	conductor.OnJobDone(func(job *Job, err error) {
+		assert.NoError(t, err)
+		done <- job
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	assert.NoError(t, conductor.SubmitJob(&Job{ID: "wire-settlement", Capabilities: []string{"settlement"}}))
+
+	var job *Job
+	select {
+	case job = <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("hedged job did not finish")
+	}
+	loser := <-cancelled
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, calls, 2)
+	assert.NotEqual(t, calls[0], calls[1])
+	assert.Equal(t, calls[0], loser)
+	assert.Equal(t, calls[1], job.CyborgID)
+	require.Len(t, job.Attempts, 1)
+	assert.Equal(t, calls[1], job.Attempts[0].CyborgID)
+}
+
+// TestConductorHedgeResultAndCapacity tests that a hedge waits for a free
+// slot on a second cyborg, holds that slot while it runs, and that the job
+// finishes with the winning execution's result and charged usage
+func TestConductorHedgeResultAndCapacity(t *testing.T) {
+	registry := pb.NewRegistry()
+	for _, id := range []string{"FINC0001", "FINC0002"} {
+		require.NoError(t, registry.Register(&types.CyborgDescriptor{
+			CyborgID:          id,
+			Capabilities:      []types.CapabilitySpec{{Name: "settlement"}},
+			ReliabilityTier:   types.ReliabilityTierFiveNines,
+			MaxConcurrentJobs: 1,
+		}))
+	}
+	budgets, err := budget.ParseBudgets("namespace.finance.daily=1000")
+	require.NoError(t, err)
+	tracker := budget.NewTracker(budget.NewMemoryStore(), budgets)
+
+	blocking, release := make(chan struct{}, 1), make(chan struct{})
+	stalled := make(chan struct{}, 1)
+	hedging, finish := make(chan struct{}, 1), make(chan struct{})
+	handler := func(ctx context.Context, job *Job) error {
+		switch {
+		case job.ID == "blocker":
+			blocking <- struct{}{}
+			<-release
+			return nil
+		case job.CyborgID == "FINC0001":
+			// The primary stalls until the hedge wins
+			stalled <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		default:
+			hedging <- struct{}{}
+			<-finish
+			job.Result = []byte("settled on " + job.CyborgID)
+			job.Usage = &budget.Usage{Tokens: 42}
+			return nil
+		}
+	}
+	conductor := NewConductor(registry, WithJobHandler(handler), WithBudgets(tracker, 0),
+		WithHedging(HedgePolicy{Delay: 20 * time.Millisecond}), WithPollInterval(time.Millisecond))
+	finished := make(chan *Job, 2)
+	conductor.OnJobDone(func(job *Job, err error) {
+		assert.NoError(t, err)
+		finished <- job
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	wait := func(ch <-chan struct{}, what string) {
+		select {
+		case <-ch:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s did not happen", what)
+		}
+	}
+
+	// The second cyborg is full when the hedge is first due, so the
+	// hedge keeps looking until the blocker frees it
+	require.NoError(t, conductor.SubmitJob(&Job{ID: "blocker", CyborgID: "FINC0002"}))
+	wait(blocking, "blocker start")
+	require.NoError(t, conductor.SubmitJob(&Job{ID: "wire-settlement", Namespace: "finance", Capabilities: []string{"settlement"}}))
+	wait(stalled, "primary start")
+	time.Sleep(60 * time.Millisecond)
+	close(release)
+	wait(hedging, "hedge start")
+
+	// The running hedge fills the second cyborg
+	second, ok := registry.Get("FINC0002")
+	require.True(t, ok)
+	assert.True(t, conductor.atCapacity(second, &Job{ID: "other"}))
+	close(finish)
+
+	var job *Job
+	for job == nil || job.ID != "wire-settlement" {
+		select {
+		case job = <-finished:
+		case <-time.After(2 * time.Second):
+			t.Fatal("hedged job did not finish")
+		}
+	}
+	assert.Equal(t, "FINC0002", job.CyborgID)
+	assert.Equal(t, "settled on FINC0002", string(job.Result))
+	// The winner's usage was charged once, when its execution finished
+	assert.Nil(t, job.Usage)
+	statuses, err := tracker.Statuses(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, budget.Usage{Tokens: 42}, statuses[0].Used)
+	assert.Eventually(t, func() bool { return !conductor.atCapacity(second, &Job{ID: "other"}) },
+		time.Second, time.Millisecond, "the hedge's slot is freed")
+}
+
+// TestConductorHedgingOnlyFiveNines tests that other tiers and pinned jobs are never hedged
+func TestConductorHedgingOnlyFiveNines(t *testing.T) {
+	registry := fiveNinesRegistry(t, "settlement", "FINC0001", "FINC0002")
+	require.NoError(t, registry.Register(&types.CyborgDescriptor{
+		CyborgID:        "FINC0003",
+		Capabilities:    []types.CapabilitySpec{{Name: "reconcile"}},
+		ReliabilityTier: "RELIABILITY_TIER_FOUR_NINES",
+	}))
+
+	var mu sync.Mutex
+	calls := 0
+	handler := func(ctx context.Context, job *Job) error {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	}
+	conductor := NewConductor(registry, WithJobHandler(handler), WithWorkerCount(1),
+		WithHedging(HedgePolicy{Delay: time.Millisecond}), WithPollInterval(time.Millisecond))
+	done := waitForDone(conductor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	assert.NoError(t, conductor.SubmitJob(&Job{ID: "reconcile", Capabilities: []string{"reconcile"}}))
+	assert.NoError(t, conductor.SubmitJob(&Job{ID: "pinned-settlement", CyborgID: "FINC0001"}))
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("job did not finish")
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, calls)
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	l := newLatencies()
+	_, ok := l.percentile("FINC0001", 0.95, 1)
+	assert.False(t, ok)
+
+	for i := 1; i <= 100; i++ {
+		l.observe("FINC0001", time.Duration(i)*time.Millisecond)
+	}
+	p, ok := l.percentile("FINC0001", 0.95, 50)
+	require.True(t, ok)
+	assert.Equal(t, 95*time.Millisecond, p)
+
+	_, ok = l.percentile("FINC0001", 0.95, 101)
+	assert.False(t, ok)
+}
+
+// TestConductorIdempotentSubmission tests that a resubmitted key returns the
+// original job and its result, also after a restart
+func TestConductorIdempotentSubmission(t *testing.T) {
+	keys := idempotency.NewMemoryStore()
+	q := queue.NewMemoryQueue(10)
+	var mu sync.Mutex
+	runs := 0
+	handler := func(ctx context.Context, job *Job) error {
+		mu.Lock()
+		runs++
+		mu.Unlock()
+		job.Result = []byte("invoice sent")
+		return nil
+	}
+	newConductor := func() *Conductor {
+		return NewConductor(testRegistry(t, "FINC0001", "invoicing"), WithQueue(q), WithJobHandler(handler),
+			WithIdempotency(keys, time.Hour), WithPollInterval(time.Millisecond))
+	}
+
+	conductor := newConductor()
+	done := waitForDone(conductor)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, conductor.Start(ctx))
+
+	job := &Job{ID: "invoice-1", Namespace: "finance", IdempotencyKey: "inv-42", Capabilities: []string{"invoicing"}}
+	assert.NoError(t, conductor.SubmitJob(job))
+
+	// A retry before the job finished sees it pending
+	err := conductor.SubmitJob(&Job{ID: "invoice-1-retry", Namespace: "finance", IdempotencyKey: "inv-42"})
+	var duplicate *DuplicateJobError
+	require.ErrorAs(t, err, &duplicate)
+	assert.Equal(t, "invoice-1", duplicate.Record.JobID)
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("job was not processed")
+	}
+	assert.NoError(t, conductor.Stop())
+
+	// After a restart the duplicate returns the finished result
+	restarted := newConductor()
+	err = restarted.SubmitJob(&Job{ID: "invoice-1-again", Namespace: "finance", IdempotencyKey: "inv-42"})
+	require.ErrorAs(t, err, &duplicate)
+	assert.Equal(t, "invoice-1", duplicate.Record.JobID)
+	assert.Equal(t, idempotency.StatusSucceeded, duplicate.Record.Status)
+	assert.Equal(t, []byte("invoice sent"), duplicate.Record.Result)
+
+	// The same key in another namespace is a different submission
+	assert.NoError(t, restarted.SubmitJob(&Job{ID: "invoice-sales", Namespace: "sales", IdempotencyKey: "inv-42"}))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, runs)
+}
+
+// TestConductorRateLimitHoldsJobs tests that jobs beyond a dependency's quota
+// are held rather than run or failed
+func TestConductorRateLimitHoldsJobs(t *testing.T) {
+	registry := pb.NewRegistry()
+	require.NoError(t, registry.Register(&types.CyborgDescriptor{
+		CyborgID:     "SALE0001",
+		Capabilities: []types.CapabilitySpec{{Name: "crm-sync"}},
+		Dependencies: []string{"SALESFORCE"},
+	}))
+	limiter := ratelimit.NewLimiter(ratelimit.Config{
+		Dependencies: map[string]ratelimit.Limit{"SALESFORCE": {Tokens: 2, Period: time.Hour, Burst: 2}},
+	})
+	q := queue.NewMemoryQueue(10)
+	conductor := NewConductor(registry, WithQueue(q), WithRateLimiter(limiter), WithPollInterval(time.Millisecond))
+	done := waitForDone(conductor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, conductor.SubmitJob(&Job{ID: fmt.Sprintf("sync-%d", i), Capabilities: []string{"crm-sync"}}))
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case result := <-done:
+			for _, err := range result {
+				assert.NoError(t, err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("job within quota was not processed")
+		}
+	}
+
+	select {
+	case result := <-done:
+		t.Fatalf("job over quota finished: %v", result)
+	case <-time.After(50 * time.Millisecond):
+	}
+	n, err := q.Len(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "the third job stays queued")
+}
+
+// preemptionHandler blocks the "batch" job until it is cancelled and
+// checkpoints it if that was a preemption; reruns report what they resumed
+func preemptionHandler(started chan<- struct{}, resumed chan<- *Job) JobHandler {
+	return func(ctx context.Context, job *Job) error {
+		if job.ID != "batch" {
+			return nil
+		}
+		if job.Preemptions > 0 {
+			resumed <- job
+			return nil
+		}
+		close(started)
+		<-ctx.Done()
+		if errors.Is(context.Cause(ctx), ErrPreempted) {
+			job.Payload = []byte("checkpoint")
+		}
+		return ctx.Err()
+	}
+}
+
+// TestConductorPreemptsLowPriorityJob tests that an urgent job takes the
+// slot of a running batch job, which is requeued with its checkpoint
+func TestConductorPreemptsLowPriorityJob(t *testing.T) {
+	registry := pb.NewRegistry()
+	require.NoError(t, registry.Register(&types.CyborgDescriptor{
+		CyborgID:          "EXEC0001",
+		Capabilities:      []types.CapabilitySpec{{Name: "briefing"}},
+		MaxConcurrentJobs: 1,
+	}))
+	started, resumed := make(chan struct{}), make(chan *Job, 1)
+	conductor := NewConductor(registry,
+		WithJobHandler(preemptionHandler(started, resumed)),
+		WithPreemption(PreemptionPolicy{MaxPriority: 1, MinGap: 1, MaxPreemptions: 1}),
+		WithPollInterval(time.Millisecond))
+	done := waitForDone(conductor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	require.NoError(t, conductor.SubmitJob(&Job{ID: "batch", Capabilities: []string{"briefing"}, Priority: 9, MaxRetries: 0}))
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("batch job did not start")
+	}
+	require.NoError(t, conductor.SubmitJob(&Job{ID: "board-prep", Capabilities: []string{"briefing"}, Priority: 1}))
+
+	for _, want := range []string{"board-prep", "batch"} {
+		select {
+		case result := <-done:
+			err, ok := result[want]
+			require.True(t, ok, "expected %s to finish next, got %v", want, result)
+			assert.NoError(t, err)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s did not finish", want)
+		}
+	}
+
+	job := <-resumed
+	assert.Equal(t, []byte("checkpoint"), job.Payload)
+	assert.Equal(t, int32(1), job.Preemptions)
+	assert.Len(t, job.Attempts, 1, "the preempted run is not an attempt")
+}
+
+// TestConductorPreemptionPolicy tests that jobs the policy does not allow
+// to preempt wait for a free slot
+func TestConductorPreemptionPolicy(t *testing.T) {
+	registry := pb.NewRegistry()
+	require.NoError(t, registry.Register(&types.CyborgDescriptor{
+		CyborgID:          "EXEC0001",
+		Capabilities:      []types.CapabilitySpec{{Name: "briefing"}},
+		MaxConcurrentJobs: 1,
+	}))
+	started, resumed := make(chan struct{}), make(chan *Job, 1)
+	conductor := NewConductor(registry,
+		WithJobHandler(preemptionHandler(started, resumed)),
+		WithPreemption(PreemptionPolicy{MaxPriority: 1, MinGap: 1, MaxPreemptions: 1}),
+		WithPollInterval(time.Millisecond))
+	done := waitForDone(conductor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+	// The batch job only returns once cancelled
+	defer cancel()
+
+	require.NoError(t, conductor.SubmitJob(&Job{ID: "batch", Capabilities: []string{"briefing"}, Priority: 9}))
+	<-started
+	require.NoError(t, conductor.SubmitJob(&Job{ID: "digest", Capabilities: []string{"briefing"}, Priority: 5}))
+
+	select {
+	case result := <-done:
+		t.Fatalf("job finished while the only slot was busy: %v", result)
+	case <-resumed:
+		t.Fatal("batch job was preempted by a job above MaxPriority")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// fanOutRegistry registers three sales cyborgs and one finance cyborg
+func fanOutRegistry(t *testing.T) *pb.Registry {
+	registry := pb.NewRegistry()
+	for _, id := range []string{"SALE0001", "SALE0002", "SALE0003", "FINC0001"} {
+		require.NoError(t, registry.Register(&types.CyborgDescriptor{
+			CyborgID:     id,
+			Tags:         []byte("STRATEGY"),
+			Capabilities: []types.CapabilitySpec{{Name: "summarize"}},
+		}))
+	}
+	return registry
+}
+
+// TestConductorFanOutKeepsPartialResults tests that a fan-out runs one
+// sub-job per matching cyborg within its concurrency limit and reduces the
+// successes while reporting the failure
+func TestConductorFanOutKeepsPartialResults(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	handler := func(ctx context.Context, job *Job) error {
+		mu.Lock()
+		inFlight++
+		if inFlight > peak {
+			peak = inFlight
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		time.Sleep(5 * time.Millisecond)
+		if job.CyborgID == "SALE0002" {
+			return errors.New("pipeline export failed")
+		}
+		job.Result = []byte(job.CyborgID)
+		return nil
+	}
+	concat := func(results []SubResult) ([]byte, error) {
+		var ids []string
+		for _, r := range results {
+			ids = append(ids, string(r.Result))
+		}
+		sort.Strings(ids)
+		return []byte(strings.Join(ids, ",")), nil
+	}
+	conductor := NewConductor(fanOutRegistry(t),
+		WithJobHandler(handler), WithReducer("concat", concat), WithPollInterval(time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	result, err := conductor.RunFanOut(ctx, &FanOut{
+		ID:          "pipeline-review",
+		Cyborgs:     "SALE*",
+		Job:         Job{Capabilities: []string{"summarize"}},
+		Concurrency: 2,
+		Reducer:     "concat",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, FanOutPartial, result.Status)
+	assert.Equal(t, 2, result.Succeeded)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, "SALE0001,SALE0003", string(result.Aggregate))
+	assert.LessOrEqual(t, peak, 2)
+	for _, sub := range result.Results {
+		if sub.CyborgID == "SALE0002" {
+			assert.Equal(t, FanOutFailed, sub.Status)
+			assert.Contains(t, sub.Error, "pipeline export failed")
+		}
+	}
+}
+
+// TestConductorFanOutFirstN tests that a first-N fan-out stops once enough
+// sub-jobs have succeeded
+func TestConductorFanOutFirstN(t *testing.T) {
+	handler := func(ctx context.Context, job *Job) error {
+		job.Result = []byte("ok")
+		return nil
+	}
+	conductor := NewConductor(fanOutRegistry(t), WithJobHandler(handler), WithPollInterval(time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	result, err := conductor.RunFanOut(ctx, &FanOut{
+		ID:          "weigh-in",
+		Tags:        []string{"strategy"},
+		Job:         Job{Capabilities: []string{"summarize"}},
+		Concurrency: 1,
+		Aggregate:   AggregateFirstN,
+		N:           1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, FanOutSucceeded, result.Status)
+	assert.Equal(t, 1, result.Succeeded)
+	assert.Equal(t, 3, result.Skipped)
+
+	_, err = conductor.RunFanOut(ctx, &FanOut{ID: "nobody", Cyborgs: "HRIS*"})
+	var noCyborg *NoSuitableCyborgError
+	assert.ErrorAs(t, err, &noCyborg)
+}
+
+// affinityRegistry registers two cyborgs with the given tags and the
+// "brief" capability
+func affinityRegistry(t *testing.T, tags map[string]string) *pb.Registry {
+	registry := pb.NewRegistry()
+	for id, tag := range tags {
+		require.NoError(t, registry.Register(&types.CyborgDescriptor{
+			CyborgID:     id,
+			Tags:         []byte(tag),
+			Capabilities: []types.CapabilitySpec{{Name: "brief"}},
+		}))
+	}
+	return registry
+}
+
+// TestConductorStickyContextRouting tests that a context stays on the
+// cyborg that ran it until that cyborg is unavailable
+func TestConductorStickyContextRouting(t *testing.T) {
+	breakers := breaker.NewSet(breaker.Config{WindowSize: 1, MinRequests: 1, FailureRate: 1, OpenTimeout: time.Hour}, false, nil)
+	conductor := NewConductor(affinityRegistry(t, map[string]string{"CHIEF0001": "OPS", "CHIEF0002": "OPS"}),
+		WithBreakers(breakers))
+	conductor.routes.record(&Job{ContextID: "conversation-7", CyborgID: "CHIEF0002"})
+
+	for i := 0; i < 5; i++ {
+		cyborg, err := conductor.findSuitableCyborg(&Job{ContextID: "conversation-7", Capabilities: []string{"brief"}})
+		require.NoError(t, err)
+		assert.Equal(t, "CHIEF0002", cyborg.CyborgID)
+	}
+
+	// The pinned cyborg's breaker opens; the context moves and sticks to
+	// its new cyborg
+	breakers.Record("CHIEF0002", []string{"brief"}, true)
+	job := &Job{ID: "turn-3", ContextID: "conversation-7", Capabilities: []string{"brief"}}
+	cyborg, err := conductor.findSuitableCyborg(job)
+	require.NoError(t, err)
+	assert.Equal(t, "CHIEF0001", cyborg.CyborgID)
+	job.CyborgID = cyborg.CyborgID
+	conductor.routes.record(job)
+
+	moved, ok := conductor.routes.sticky("conversation-7")
+	assert.True(t, ok)
+	assert.Equal(t, "CHIEF0001", moved)
+}
+
+// TestConductorAffinityRules tests cyborg tag affinity and job tag
+// anti-affinity in both directions
+func TestConductorAffinityRules(t *testing.T) {
+	conductor := NewConductor(affinityRegistry(t, map[string]string{"DEAL0001": "LEGAL,EU", "DEAL0002": "LEGAL,US"}))
+	brief := []string{"brief"}
+
+	cyborg, err := conductor.findSuitableCyborg(&Job{Capabilities: brief, Affinity: &Affinity{CyborgTags: []string{"us"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "DEAL0002", cyborg.CyborgID)
+	cyborg, err = conductor.findSuitableCyborg(&Job{Capabilities: brief, Affinity: &Affinity{AvoidCyborgTags: []string{"US"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "DEAL0001", cyborg.CyborgID)
+
+	// A sales job for deal 42 ran on DEAL0001, so the legal job for the
+	// same deal that avoids it goes elsewhere
+	conductor.routes.record(&Job{CyborgID: "DEAL0001", Tags: []string{"deal:42", "SALE"}})
+	legal := &Job{
+		Capabilities: brief,
+		Tags:         []string{"deal:42", "LEGL"},
+		Affinity:     &Affinity{AvoidJobTags: []string{"deal:42", "SALE"}},
+	}
+	cyborg, err = conductor.findSuitableCyborg(legal)
+	require.NoError(t, err)
+	assert.Equal(t, "DEAL0002", cyborg.CyborgID)
+	legal.CyborgID = cyborg.CyborgID
+	conductor.routes.record(legal)
+
+	// The legal job's rule also keeps later sales jobs for the deal off
+	// its cyborg
+	cyborg, err = conductor.findSuitableCyborg(&Job{Capabilities: brief, Tags: []string{"DEAL:42", "sale"}})
+	require.NoError(t, err)
+	assert.Equal(t, "DEAL0001", cyborg.CyborgID)
+
+	// Other deals are unaffected by either rule
+	cyborg, err = conductor.findSuitableCyborg(&Job{
+		Capabilities: brief,
+		Tags:         []string{"deal:7", "SALE"},
+		Affinity:     &Affinity{CyborgTags: []string{"US"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "DEAL0002", cyborg.CyborgID)
+
+	err = conductor.SubmitJob(&Job{ID: "bad", Affinity: &Affinity{CyborgTags: []string{"EU"}, AvoidCyborgTags: []string{"eu"}}})
+	assert.ErrorIs(t, err, ErrInvalidJob)
+}
+
+// TestConductorLoadShedding tests that load shedding refuses less urgent
+// jobs first and that refusals carry the queue state and a retry hint
+func TestConductorLoadShedding(t *testing.T) {
+	rules, err := ParseShedRules("5=0.5, 3=0.8")
+	require.NoError(t, err)
+	assert.Equal(t, []ShedRule{{MinPriority: 3, Fill: 0.8}, {MinPriority: 5, Fill: 0.5}}, rules)
+	_, err = ParseShedRules("5=1.5")
+	assert.Error(t, err)
+
+	conductor := NewConductor(testRegistry(t, "FINC0001", "reporting"),
+		WithQueue(queue.NewMemoryQueue(10)), WithLoadShedding(rules))
+	submit := func(id string, priority int32) error {
+		return conductor.SubmitJob(&Job{ID: id, Capabilities: []string{"reporting"}, Priority: priority})
+	}
+	for i := 0; i < 5; i++ {
+		require.NoError(t, submit(fmt.Sprintf("batch-%d", i), 5))
+	}
+
+	// Half full: batch work is shed while urgent work still fits
+	err = submit("batch-5", 5)
+	var refused *JobQueueFullError
+	require.ErrorAs(t, err, &refused)
+	assert.True(t, refused.Shed)
+	assert.Equal(t, 5, refused.Depth)
+	assert.Equal(t, 10, refused.Capacity)
+	assert.Equal(t, unknownRetryAfter, refused.RetryAfter, "nothing has drained yet")
+	for i := 0; i < 5; i++ {
+		require.NoError(t, submit(fmt.Sprintf("urgent-%d", i), 1))
+	}
+	err = submit("urgent-5", 1)
+	require.ErrorAs(t, err, &refused)
+	assert.False(t, refused.Shed)
+
+	// Thirty jobs finished in the last minute drain one slot in two seconds
+	now := time.Now()
+	conductor.drain.now = func() time.Time { return now }
+	for i := 0; i < 30; i++ {
+		conductor.drain.observe()
+	}
+	assert.Equal(t, 2*time.Second, conductor.retryAfter(1))
+	assert.Equal(t, maxRetryAfter, conductor.retryAfter(10000))
+
+	stats, err := conductor.QueueStats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 10, stats.Depth)
+	assert.Equal(t, 10, stats.Capacity)
+	assert.InDelta(t, 0.5, stats.DrainRate, 1e-9)
+
+	// Finished jobs age out of the window
+	now = now.Add(drainWindow * time.Second)
+	assert.Zero(t, conductor.drain.rate())
+}
+
+// TestConductorBudgets tests that reported usage is charged to budgets,
+// which downgrade and then refuse submissions, and that a cyborg over its
+// budget takes no more jobs
+func TestConductorBudgets(t *testing.T) {
+	budgets, err := budget.ParseBudgets("namespace.finance.daily=1000:downgrade/1500,cyborg.FINC0001.daily=$8")
+	require.NoError(t, err)
+	tracker := budget.NewTracker(budget.NewMemoryStore(), budgets)
+
+	q := queue.NewMemoryQueue(10)
+	handler := func(ctx context.Context, job *Job) error {
+		job.Usage = &budget.Usage{Tokens: 600, Cost: 3}
+		return nil
+	}
+	conductor := NewConductor(testRegistry(t, "FINC0001", "reporting"), WithQueue(q),
+		WithPollInterval(time.Millisecond), WithJobHandler(handler), WithBudgets(tracker, 7))
+	done := waitForDone(conductor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	run := func(id string) *Job {
+		job := &Job{ID: id, Namespace: "finance", Capabilities: []string{"reporting"}, Priority: 2}
+		require.NoError(t, conductor.SubmitJob(job))
+		select {
+		case result := <-done:
+			require.Contains(t, result, id)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("job %s was not finished", id)
+		}
+		return job
+	}
+
+	assert.Equal(t, int32(2), run("report-1").Priority)
+	// 1200 tokens spent is past the soft limit, so the next job is
+	// downgraded. Its run puts the cyborg at $9, past its own limit.
+	run("report-2")
+	assert.Equal(t, int32(7), run("report-3").Priority)
+
+	// Past the namespace's hard limit nothing more is accepted
+	err = conductor.SubmitJob(&Job{ID: "report-4", Namespace: "finance", Capabilities: []string{"reporting"}})
+	var exceeded *BudgetExceededError
+	require.ErrorAs(t, err, &exceeded)
+	assert.Equal(t, budget.ScopeNamespace, exceeded.Status.Scope)
+
+	// Another namespace is accepted, but the only cyborg has spent its
+	// budget, so the job is kept for an operator
+	require.NoError(t, conductor.SubmitJob(&Job{ID: "audit-1", Namespace: "audit", Capabilities: []string{"reporting"}}))
+	select {
+	case result := <-done:
+		require.ErrorAs(t, result["audit-1"], &exceeded)
+		assert.Equal(t, budget.ScopeCyborg, exceeded.Status.Scope)
+	case <-time.After(2 * time.Second):
+		t.Fatal("job was not finished")
+	}
+	entries, err := conductor.DeadLetters().List(context.Background(), deadletter.Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, deadletter.ReasonBudgetExceeded, entries[0].Reason)
+
+	statuses, err := tracker.Statuses(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.Equal(t, budget.Usage{Tokens: 1800, Cost: 9}, statuses[0].Used)
+}
+
+// TestConductorBudgetIdempotentRetry tests that a retried submission gets
+// its original job back after its namespace ran over budget, and that a
+// refused submission leaves its key free
+func TestConductorBudgetIdempotentRetry(t *testing.T) {
+	budgets, err := budget.ParseBudgets("namespace.finance.daily=500")
+	require.NoError(t, err)
+	tracker := budget.NewTracker(budget.NewMemoryStore(), budgets)
+	keys := idempotency.NewMemoryStore()
+
+	handler := func(ctx context.Context, job *Job) error {
+		job.Usage = &budget.Usage{Tokens: 600}
+		return nil
+	}
+	conductor := NewConductor(testRegistry(t, "FINC0001", "reporting"), WithQueue(queue.NewMemoryQueue(10)),
+		WithPollInterval(time.Millisecond), WithJobHandler(handler), WithBudgets(tracker, 0),
+		WithIdempotency(keys, time.Hour))
+	done := waitForDone(conductor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	require.NoError(t, conductor.SubmitJob(&Job{ID: "report-1", Namespace: "finance", IdempotencyKey: "q3", Capabilities: []string{"reporting"}}))
+	select {
+	case result := <-done:
+		require.NoError(t, result["report-1"])
+	case <-time.After(2 * time.Second):
+		t.Fatal("job was not finished")
+	}
+
+	err = conductor.SubmitJob(&Job{ID: "report-1-retry", Namespace: "finance", IdempotencyKey: "q3", Capabilities: []string{"reporting"}})
+	var duplicate *DuplicateJobError
+	require.ErrorAs(t, err, &duplicate)
+	assert.Equal(t, "report-1", duplicate.Record.JobID)
+
+	err = conductor.SubmitJob(&Job{ID: "report-2", Namespace: "finance", IdempotencyKey: "q4", Capabilities: []string{"reporting"}})
+	var exceeded *BudgetExceededError
+	require.ErrorAs(t, err, &exceeded)
+	_, created, err := keys.Reserve(context.Background(), &idempotency.Record{Namespace: "finance", Key: "q4", JobID: "report-3"})
+	require.NoError(t, err)
+	assert.True(t, created)
+}
+
+// TestConductorSchedulingPolicies tests that jobs are placed by the policy
+// assigned to their namespace or class, and that assignments can change
+// while the conductor runs
+func TestConductorSchedulingPolicies(t *testing.T) {
+	breakers := breaker.NewSet(breaker.Config{WindowSize: 1, MinRequests: 1, FailureRate: 1, OpenTimeout: time.Hour}, false, nil)
+	conductor := NewConductor(affinityRegistry(t, map[string]string{"CHIEF0001": "OPS", "CHIEF0002": "OPS", "CHIEF0003": "OPS"}),
+		WithBreakers(breakers))
+	conductor.load["CHIEF0001"] = 2
+	conductor.load["CHIEF0002"] = 1
+	conductor.costs.observe("CHIEF0001", 0.01)
+	conductor.costs.observe("CHIEF0002", 0.03)
+	conductor.costs.observe("CHIEF0003", 0.02)
+
+	place := func(job *Job) string {
+		job.Capabilities = []string{"brief"}
+		cyborg, err := conductor.findSuitableCyborg(job)
+		require.NoError(t, err)
+		require.NotNil(t, cyborg)
+		return cyborg.CyborgID
+	}
+
+	// First-fit by default, in cyborg ID order
+	assert.Equal(t, "CHIEF0001", place(&Job{Namespace: "ops"}))
+
+	assignments, err := policy.ParseAssignments(policy.LeastLoaded, "namespace.finance=cost-optimized,class.batch=round-robin")
+	require.NoError(t, err)
+	require.NoError(t, conductor.Policies().Assign(assignments))
+
+	assert.Equal(t, "CHIEF0003", place(&Job{Namespace: "ops"}))
+	assert.Equal(t, "CHIEF0001", place(&Job{Namespace: "finance"}))
+	var rotation []string
+	for i := 0; i < 4; i++ {
+		rotation = append(rotation, place(&Job{Namespace: "finance", Class: "batch"}))
+	}
+	assert.Equal(t, []string{"CHIEF0001", "CHIEF0002", "CHIEF0003", "CHIEF0001"}, rotation)
+
+	// A cyborg whose breaker is open is left out of the choice
+	breakers.Record("CHIEF0001", []string{"brief"}, true)
+	assert.Equal(t, "CHIEF0003", place(&Job{Namespace: "finance"}))
+}
+
+// TestConductorEscalation tests that rejected and unplaceable jobs are
+// rerouted up the org chart, for a limited number of hops, and that each
+// hop is recorded in the job's evidence log
+func TestConductorEscalation(t *testing.T) {
+	chart, err := orgchart.Parse(strings.NewReader(`
+		SWEN1001 -> SWEN0005 # Software Engineer -> Engineering Manager
+		SWEN0005 -> SWEN0004 # Engineering Manager -> Director
+		SWEN0004 -> SWEN0001 # Director -> VP Engineering
+	`))
+	require.NoError(t, err)
+
+	registry := testRegistry(t, "SWEN1001", "code")
+	require.NoError(t, registry.Register(&types.CyborgDescriptor{
+		CyborgID:     "SWEN0005",
+		Capabilities: []types.CapabilitySpec{{Name: "code"}},
+	}))
+	handler := func(ctx context.Context, job *Job) error {
+		if job.CyborgID == "SWEN1001" {
+			return &ExecError{Err: fmt.Errorf("%w: needs a design review", ErrRejected)}
+		}
+		return nil
+	}
+	store := evidence.NewMemoryStore()
+	conductor := NewConductor(registry, WithPollInterval(time.Millisecond), WithJobHandler(handler),
+		WithEscalation(chart, 2), WithEvidence(store))
+	finished := make(chan *Job, 10)
+	conductor.OnJobDone(func(job *Job, err error) {
+		finished <- job
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	wait := func() *Job {
+		select {
+		case job := <-finished:
+			return job
+		case <-time.After(2 * time.Second):
+			t.Fatal("job was not finished")
+			return nil
+		}
+	}
+
+	// The engineer rejects the job and its manager takes it
+	require.NoError(t, conductor.SubmitJob(&Job{ID: "review", CyborgID: "SWEN1001", Capabilities: []string{"code"}}))
+	job := wait()
+	assert.Equal(t, "SWEN0005", job.CyborgID)
+	require.Len(t, job.Escalations, 1)
+	assert.Equal(t, EscalationRejected, job.Escalations[0].Reason)
+	assert.Equal(t, "SWEN1001", job.Escalations[0].From)
+	entries, err := store.List(ctx, "review")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, job.Escalations[0].EvidenceID, entries[0].ID)
+	assert.Equal(t, evidence.TypeAudit, entries[0].Type)
+
+	// No cyborg can design, so the job climbs from its owner past managers
+	// that are not registered until it runs out of hops
+	require.NoError(t, conductor.SubmitJob(&Job{ID: "design", Owner: "SWEN0005", Capabilities: []string{"design"}}))
+	job = wait()
+	require.Len(t, job.Escalations, 2)
+	assert.Equal(t, "SWEN0004", job.Escalations[0].To)
+	assert.Equal(t, "SWEN0001", job.Escalations[1].To)
+	dead, err := conductor.DeadLetters().Get(ctx, "design")
+	require.NoError(t, err)
+	assert.Equal(t, deadletter.ReasonNoSuitableCyborg, dead.Reason)
+
+	entries, err = store.List(ctx, "design")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.NoError(t, evidence.Verify(entries))
+	assert.Equal(t, entries[0].ID, entries[1].ParentID)
+	assert.Contains(t, string(entries[2].Data), `"event":"escalation_ended"`)
+	assert.Contains(t, string(entries[2].Data), `"chain":["SWEN0005","SWEN0004","SWEN0001"]`)
+}
+
+// TestConductorStreamsOutput tests that output a handler publishes reaches
+// the job's watchers, whose watch ends when the job finishes
+func TestConductorStreamsOutput(t *testing.T) {
+	var conductor *Conductor
+	handler := func(ctx context.Context, job *Job) error {
+		conductor.Output().Publish(job.ID, output.Stdout, []byte("step 1\n"), time.Now())
+		conductor.Output().Publish(job.ID, output.Stderr, []byte("warning\n"), time.Now())
+		return nil
+	}
+	conductor = NewConductor(testRegistry(t, "FINC0001", "reporting"),
+		WithPollInterval(time.Millisecond), WithJobHandler(handler))
+
+	// Watch before the job is submitted, as a client following it would
+	chunks, stop := conductor.Output().Watch("month-end", 0)
+	defer stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+	require.NoError(t, conductor.SubmitJob(&Job{ID: "month-end", Capabilities: []string{"reporting"}}))
+
+	var streams []output.Stream
+	for {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				assert.Equal(t, []output.Stream{output.Stdout, output.Stderr}, streams)
+				assert.True(t, conductor.Output().Finished("month-end"))
+				return
+			}
+			streams = append(streams, chunk.Stream)
+		case <-time.After(2 * time.Second):
+			t.Fatal("watch did not end with the job")
+		}
+	}
+}
+
+// TestConductorSpillsOutput tests that a job's output past the spool's
+// memory limit is spilled to a file and its excerpts kept on the job
+func TestConductorSpillsOutput(t *testing.T) {
+	spool, err := output.NewSpool(t.TempDir(), output.Limits{Memory: 8, Head: 4, Tail: 4}, time.Hour)
+	require.NoError(t, err)
+
+	var conductor *Conductor
+	handler := func(ctx context.Context, job *Job) error {
+		for i := 0; i < 3; i++ {
+			conductor.Output().Publish(job.ID, output.Stdout, []byte(fmt.Sprintf("line %d\n", i)), time.Now())
+		}
+		return nil
+	}
+	conductor = NewConductor(testRegistry(t, "FINC0001", "reporting"),
+		WithPollInterval(time.Millisecond), WithJobHandler(handler), WithOutputSpool(spool))
+	done := make(chan *Job, 1)
+	conductor.OnJobDone(func(job *Job, err error) { done <- job })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+	require.NoError(t, conductor.SubmitJob(&Job{ID: "month-end", Capabilities: []string{"reporting"}}))
+
+	var job *Job
+	select {
+	case job = <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("job did not finish")
+	}
+	require.Contains(t, job.OutputExcerpts, output.Stdout)
+	excerpt := job.OutputExcerpts[output.Stdout]
+	assert.Equal(t, int64(21), excerpt.Size)
+	assert.Equal(t, "line", string(excerpt.Head))
+	assert.Equal(t, " 2\n", string(excerpt.Tail[1:]))
+
+	f, err := conductor.Output().Spool().Open("month-end", output.Stdout)
+	require.NoError(t, err)
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "line 0\nline 1\nline 2\n", string(data))
+}
+
+// TestConductorExecutor tests that placed jobs run on the executor with
+// their cyborg's deployment spec, and that a refused job does not count
+// against the cyborg's breaker
+func TestConductorExecutor(t *testing.T) {
+	registry := pb.NewRegistry()
+	require.NoError(t, registry.Register(&types.CyborgDescriptor{
+		CyborgID:       "FINC0001",
+		DeploymentSpec: []byte("ns=cyborg-finance executor=remote"),
+		JobType:        "LLM",
+		Capabilities:   []types.CapabilitySpec{{Name: "forecast"}},
+	}))
+	require.NoError(t, registry.Register(&types.CyborgDescriptor{
+		CyborgID:     "FINC0002",
+		Capabilities: []types.CapabilitySpec{{Name: "close"}},
+	}))
+
+	fake := executor.NewFake()
+	fake.Answer("FINC0001", &executor.Result{Payload: []byte("q3: +4%")}, nil)
+	fake.Answer("FINC0002", nil, fmt.Errorf("%w: sh is not allowed", executor.ErrRefused))
+	breakers := breaker.NewSet(breaker.Config{WindowSize: 1, MinRequests: 1, FailureRate: 1, OpenTimeout: time.Hour}, false,
+		func(tr breaker.Transition) { registry.SetBreakerState(tr.CyborgID, tr.Capability, tr.To.String()) })
+	conductor := NewConductor(registry, WithExecutor(fake), WithBreakers(breakers), WithPollInterval(time.Millisecond))
+	finished := make(chan *Job, 10)
+	conductor.OnJobDone(func(job *Job, err error) {
+		finished <- job
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	wait := func() *Job {
+		select {
+		case job := <-finished:
+			return job
+		case <-time.After(2 * time.Second):
+			t.Fatal("job was not finished")
+			return nil
+		}
+	}
+
+	require.NoError(t, conductor.SubmitJob(&Job{ID: "forecast", Capabilities: []string{"forecast"}, Payload: []byte("q3")}))
+	job := wait()
+	assert.Equal(t, "q3: +4%", string(job.Result))
+	requests := fake.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "ns=cyborg-finance executor=remote", requests[0].DeploymentSpec)
+	assert.Equal(t, "LLM", requests[0].JobType)
+	assert.Equal(t, []byte("q3"), requests[0].Payload)
+
+	require.NoError(t, conductor.SubmitJob(&Job{ID: "close-books", Capabilities: []string{"close"}}))
+	job = wait()
+	require.NotEmpty(t, job.Attempts)
+	assert.Contains(t, job.Attempts[0].Error, "sh is not allowed")
+	status, ok := registry.Status("FINC0002")
+	require.True(t, ok)
+	assert.NotEqual(t, pb.StatusUnavailable, status.State)
+}
+
+// TestConductorJobSandbox tests that each job runs in a sandbox derived
+// from its resources and security level
+func TestConductorJobSandbox(t *testing.T) {
+	fake := executor.NewFake()
+	conductor := NewConductor(testRegistry(t, "FINC0001", "forecast"), WithExecutor(fake), WithPollInterval(time.Millisecond),
+		WithSandbox(SandboxPolicy{Level: runner.SecurityLow, Timeout: time.Minute, CgroupParent: "/sys/fs/cgroup/test"}))
+	done := waitForDone(conductor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, conductor.Start(ctx))
+	defer conductor.Stop()
+
+	run := func(job *Job) *runner.Sandbox {
+		require.NoError(t, conductor.SubmitJob(job))
+		select {
+		case result := <-done:
+			require.NoError(t, result[job.ID])
+		case <-time.After(2 * time.Second):
+			t.Fatalf("job %s was not finished", job.ID)
+		}
+		requests := fake.Requests()
+		return requests[len(requests)-1].Sandbox
+	}
+
+	sb := run(&Job{ID: "forecast-1", Capabilities: []string{"forecast"}, TimeoutMs: 30000,
+		Resources: &Resources{CPUCores: 2, MemoryGB: 1}, Security: &SecurityRequirements{Level: "high"}})
+	require.NotNil(t, sb)
+	assert.Equal(t, 60*time.Second, sb.CPUTime)
+	assert.Equal(t, uint64(1<<30), sb.Memory)
+	assert.True(t, sb.IsolateNetwork)
+	assert.Equal(t, "/sys/fs/cgroup/test", sb.CgroupParent)
+
+	// A job without its own level or timeout gets the policy's
+	sb = run(&Job{ID: "forecast-2", Capabilities: []string{"forecast"}, Resources: &Resources{CPUCores: 1}})
+	require.NotNil(t, sb)
+	assert.Equal(t, time.Minute, sb.CPUTime)
+	assert.False(t, sb.Unprivileged)
+
+	err := conductor.SubmitJob(&Job{ID: "forecast-3", Capabilities: []string{"forecast"}, Security: &SecurityRequirements{Level: "top-secret"}})
+	assert.ErrorIs(t, err, ErrInvalidJob)
+}
@@ -0,0 +1,102 @@
+package conductor
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/metrics"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/policy"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/types"
+)
+
+// costSmoothing is the weight a job's reported cost gets in its cyborg's
+// running average
+const costSmoothing = 0.2
+
+// reliability ranks reliability tiers for scheduling policies
+var reliability = map[string]float64{
+	types.ReliabilityTierFiveNines: 1,
+	"RELIABILITY_TIER_FOUR_NINES":  0.75,
+	"RELIABILITY_TIER_THREE_NINES": 0.5,
+	"RELIABILITY_TIER_TWO_NINES":   0.25,
+}
+
+// costs keeps a running average of the cost jobs report per cyborg
+type costs struct {
+	mu      sync.Mutex
+	average map[string]float64
+}
+
+func newCosts() *costs {
+	return &costs{average: make(map[string]float64)}
+}
+
+// observe folds one job's cost into its cyborg's average
+func (c *costs) observe(cyborgID string, cost float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if avg, ok := c.average[cyborgID]; ok {
+		c.average[cyborgID] = avg + costSmoothing*(cost-avg)
+		return
+	}
+	c.average[cyborgID] = cost
+}
+
+// get returns a cyborg's average cost, zero when none was reported
+func (c *costs) get(cyborgID string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.average[cyborgID]
+}
+
+// Policies returns the scheduling policies, whose assignments can be
+// changed while the conductor runs
+func (c *Conductor) Policies() *policy.Set {
+	return c.policies
+}
+
+// choose lets the job's scheduling policy pick one of the cyborgs, which
+// all can take the job. A cyborg whose breaker refuses the job after it was
+// picked is dropped and the policy picks again; choose reports whether any
+// breaker refused.
+func (c *Conductor) choose(job *Job, cyborgs []*types.CyborgDescriptor) (*types.CyborgDescriptor, bool) {
+	sort.Slice(cyborgs, func(i, j int) bool { return cyborgs[i].CyborgID < cyborgs[j].CyborgID })
+	req := policy.Request{JobID: job.ID, Namespace: job.Namespace, Class: job.Class, Priority: job.Priority}
+	p := c.policies.For(req)
+
+	tripped := false
+	i := policy.Choose(p, req, c.candidates(cyborgs), func(i int) bool {
+		if c.breakers.Allow(cyborgs[i].CyborgID, job.Capabilities) {
+			return true
+		}
+		tripped = true
+		return false
+	})
+	if i < 0 {
+		return nil, tripped
+	}
+	metrics.Placements.WithLabelValues(p.Name()).Inc()
+	return cyborgs[i], tripped
+}
+
+// candidates describes cyborgs to scheduling policies
+func (c *Conductor) candidates(cyborgs []*types.CyborgDescriptor) []policy.Candidate {
+	candidates := make([]policy.Candidate, len(cyborgs))
+	c.placeMu.Lock()
+	for i, cyborg := range cyborgs {
+		candidates[i] = policy.Candidate{
+			ID:          cyborg.CyborgID,
+			Load:        c.load[cyborg.CyborgID],
+			Capacity:    int(cyborg.MaxConcurrentJobs),
+			Reliability: reliability[cyborg.ReliabilityTier],
+		}
+	}
+	c.placeMu.Unlock()
+
+	for i := range candidates {
+		candidates[i].Latency, _ = c.latencies.percentile(candidates[i].ID, 0.5, 1)
+		candidates[i].Cost = c.costs.get(candidates[i].ID)
+	}
+	return candidates
+}
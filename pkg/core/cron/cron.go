@@ -0,0 +1,350 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SubmitFunc submits the job for one activation of a schedule and returns
+// the ID of the submitted job
+type SubmitFunc func(ctx context.Context, s *Schedule, scheduledFor time.Time) (string, error)
+
+// Cron fires persisted schedules and hands their jobs to a SubmitFunc
+type Cron struct {
+	store  Store
+	submit SubmitFunc
+
+	// interval is how often schedules are checked for due activations
+	interval time.Duration
+
+	// misfireThreshold is how late an activation may be before it is
+	// treated as missed and handled by the schedule's MissedRunPolicy
+	misfireThreshold time.Duration
+
+	// now is replaceable for tests
+	now func() time.Time
+
+	mu sync.Mutex
+	// running maps schedule IDs to the job ID of their in-flight run
+	running map[string]string
+	// jobs maps in-flight job IDs back to their schedule
+	jobs map[string]string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewCron creates a cron runner that checks schedules every interval
+func NewCron(store Store, submit SubmitFunc, interval, misfireThreshold time.Duration) *Cron {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	if misfireThreshold <= 0 {
+		misfireThreshold = time.Minute
+	}
+
+	return &Cron{
+		store:            store,
+		submit:           submit,
+		interval:         interval,
+		misfireThreshold: misfireThreshold,
+		now:              time.Now,
+		running:          make(map[string]string),
+		jobs:             make(map[string]string),
+	}
+}
+
+// Start begins checking schedules in the background
+func (c *Cron) Start(ctx context.Context) {
+	c.mu.Lock()
+	if c.stop != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+	stop, done := c.stop, c.done
+	c.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				// Errors are per schedule and retried on the next tick
+				c.Tick(ctx)
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts the background loop and waits for it to exit
+func (c *Cron) Stop() {
+	c.mu.Lock()
+	stop, done := c.stop, c.done
+	c.stop, c.done = nil, nil
+	c.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// Create validates and stores a new schedule
+func (c *Cron) Create(ctx context.Context, s *Schedule) (*Schedule, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := c.now().UTC()
+	next, err := s.NextAfter(now)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+
+	s.ID = newID()
+	s.NextRunAt = next
+	s.CreatedAt = now
+	s.UpdatedAt = now
+
+	if err := c.store.Create(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Update replaces the definition of an existing schedule, keeping its run
+// history
+func (c *Cron) Update(ctx context.Context, s *Schedule) (*Schedule, error) {
+	existing, err := c.store.Get(ctx, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := c.now().UTC()
+	next, err := s.NextAfter(now)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+
+	s.NextRunAt = next
+	s.LastRunAt = existing.LastRunAt
+	s.LastJobID = existing.LastJobID
+	s.CreatedAt = existing.CreatedAt
+	s.UpdatedAt = now
+
+	if err := c.store.Update(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns a schedule by ID
+func (c *Cron) Get(ctx context.Context, id string) (*Schedule, error) {
+	return c.store.Get(ctx, id)
+}
+
+// List returns all schedules
+func (c *Cron) List(ctx context.Context) ([]*Schedule, error) {
+	return c.store.List(ctx)
+}
+
+// Delete removes a schedule
+func (c *Cron) Delete(ctx context.Context, id string) error {
+	if err := c.store.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if jobID, ok := c.running[id]; ok {
+		delete(c.jobs, jobID)
+		delete(c.running, id)
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// JobFinished marks the run that submitted jobID as complete, allowing the
+// next activation of its schedule to start
+func (c *Cron) JobFinished(jobID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if scheduleID, ok := c.jobs[jobID]; ok {
+		delete(c.jobs, jobID)
+		delete(c.running, scheduleID)
+	}
+}
+
+// Tick fires every due schedule once and returns the errors encountered
+func (c *Cron) Tick(ctx context.Context) []error {
+	schedules, err := c.store.List(ctx)
+	if err != nil {
+		return []error{fmt.Errorf("failed to list schedules: %w", err)}
+	}
+
+	now := c.now().UTC()
+	var errs []error
+	for _, s := range schedules {
+		if !s.Enabled || s.NextRunAt.IsZero() || s.NextRunAt.After(now) {
+			continue
+		}
+		if err := c.fire(ctx, s, now); err != nil {
+			errs = append(errs, fmt.Errorf("schedule %s: %w", s.ID, err))
+		}
+	}
+	return errs
+}
+
+// fire handles one due schedule according to its overlap and missed run
+// policies, then persists the new next-run time
+func (c *Cron) fire(ctx context.Context, s *Schedule, now time.Time) error {
+	late := now.Sub(s.NextRunAt) > c.misfireThreshold
+
+	var runAt time.Time
+	var next time.Time
+	var err error
+
+	switch {
+	case !late:
+		runAt = s.NextRunAt
+		next, err = s.NextAfter(now)
+	case s.MissedRunPolicy == MissedRunSkip:
+		next, err = s.NextAfter(now)
+	case s.MissedRunPolicy == MissedRunCatchUp:
+		first, ferr := c.catchUpStart(s, now)
+		if ferr != nil {
+			return ferr
+		}
+		runAt = first
+		// Advance one activation at a time so each missed run is replayed
+		// on its own tick once the previous one finishes
+		next, err = s.NextAfter(first)
+	default:
+		runAt, err = c.latestMissed(s, now)
+		if err == nil {
+			next, err = s.NextAfter(now)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if !runAt.IsZero() && c.overlapping(s) {
+		// Overlap prevention: a catch-up schedule keeps its position and
+		// retries, others drop this activation
+		if s.MissedRunPolicy == MissedRunCatchUp {
+			return nil
+		}
+		runAt = time.Time{}
+		next, err = s.NextAfter(now)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !runAt.IsZero() {
+		jobID, err := c.submit(ctx, s, runAt)
+		if err != nil {
+			// Leave NextRunAt unchanged so the activation is retried
+			return fmt.Errorf("failed to submit job: %w", err)
+		}
+		c.markRunning(s, jobID)
+		s.LastRunAt = runAt
+		s.LastJobID = jobID
+	}
+
+	s.NextRunAt = next
+	s.UpdatedAt = now
+	return c.store.Update(ctx, s)
+}
+
+// overlapping reports whether the previous run of s is still in flight and
+// the schedule forbids overlap
+func (c *Cron) overlapping(s *Schedule) bool {
+	if s.AllowOverlap {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, running := c.running[s.ID]
+	return running
+}
+
+// markRunning records jobID as the in-flight run of s
+func (c *Cron) markRunning(s *Schedule, jobID string) {
+	if s.AllowOverlap {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running[s.ID] = jobID
+	c.jobs[jobID] = s.ID
+}
+
+// catchUpStart returns the first missed activation to replay, skipping
+// ahead so that at most MaxCatchUp activations remain before now
+func (c *Cron) catchUpStart(s *Schedule, now time.Time) (time.Time, error) {
+	limit := s.MaxCatchUp
+	if limit <= 0 {
+		limit = DefaultMaxCatchUp
+	}
+
+	nextAfter, err := s.activations()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Keep a sliding window of the last `limit` activations up to now
+	window := []time.Time{s.NextRunAt}
+	t := s.NextRunAt
+	for {
+		next, err := nextAfter(t)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if next.After(now) {
+			break
+		}
+		window = append(window, next)
+		if len(window) > limit {
+			window = window[1:]
+		}
+		t = next
+	}
+	return window[0], nil
+}
+
+// latestMissed returns the most recent activation at or before now
+func (c *Cron) latestMissed(s *Schedule, now time.Time) (time.Time, error) {
+	nextAfter, err := s.activations()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	latest := s.NextRunAt
+	for {
+		next, err := nextAfter(latest)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if next.After(now) {
+			return latest, nil
+		}
+		latest = next
+	}
+}
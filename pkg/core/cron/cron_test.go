@@ -0,0 +1,196 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAndNext(t *testing.T) {
+	base := time.Date(2024, time.January, 15, 10, 30, 0, 0, time.UTC) // Monday
+
+	tests := []struct {
+		name string
+		expr string
+		want time.Time
+	}{
+		{"every minute", "* * * * *", time.Date(2024, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"daily macro", "@daily", time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"step", "*/15 * * * *", time.Date(2024, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"range and list", "0 9-17 * * MON-FRI", time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"month end report", "0 6 1 * *", time.Date(2024, 2, 1, 6, 0, 0, 0, time.UTC)},
+		{"named month", "0 0 1 MAR *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"sunday as seven", "0 8 * * 7", time.Date(2024, 1, 21, 8, 0, 0, 0, time.UTC)},
+		{"dom or dow", "0 0 20 * MON", time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, expr.Next(base))
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "@fortnightly", "* * * FOO *"} {
+		_, err := Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestNextInTimeZone(t *testing.T) {
+	s := &Schedule{Expression: "0 8 * * *", TimeZone: "America/New_York"}
+	loc, err := s.Location()
+	require.NoError(t, err)
+
+	// 08:00 New York across the spring DST change
+	next, err := s.NextAfter(time.Date(2024, 3, 9, 18, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 10, 8, 0, 0, 0, loc).UTC(), next)
+	assert.Equal(t, 12, next.Hour())
+}
+
+func TestNeverFires(t *testing.T) {
+	s := &Schedule{Expression: "0 0 30 2 *"}
+	_, err := s.NextAfter(time.Now())
+	assert.Error(t, err)
+}
+
+func TestScheduleValidate(t *testing.T) {
+	s := &Schedule{Name: "report", Expression: "@daily", Job: JobTemplate{CyborgID: "FINC0001"}}
+	require.NoError(t, s.Validate())
+	assert.Equal(t, MissedRunSkip, s.MissedRunPolicy)
+	assert.Equal(t, DefaultMaxCatchUp, s.MaxCatchUp)
+
+	assert.ErrorIs(t, (&Schedule{Name: "x", Expression: "@daily"}).Validate(), ErrInvalidSchedule)
+	assert.Error(t, (&Schedule{Name: "x", Expression: "@daily", TimeZone: "Mars/Olympus", Job: JobTemplate{CyborgID: "A"}}).Validate())
+	assert.Error(t, (&Schedule{Name: "x", Expression: "@daily", MissedRunPolicy: "sometimes", Job: JobTemplate{CyborgID: "A"}}).Validate())
+}
+
+// testCron builds a Cron with a controllable clock and a recording submitter
+func testCron(now *time.Time) (*Cron, *[]time.Time) {
+	var fired []time.Time
+	submit := func(ctx context.Context, s *Schedule, at time.Time) (string, error) {
+		fired = append(fired, at)
+		return fmt.Sprintf("job-%d", len(fired)), nil
+	}
+	c := NewCron(NewMemoryStore(), submit, time.Second, time.Minute)
+	c.now = func() time.Time { return *now }
+	return c, &fired
+}
+
+func TestCronFiresOnSchedule(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 30, 0, time.UTC)
+	c, fired := testCron(&now)
+	ctx := context.Background()
+
+	s, err := c.Create(ctx, &Schedule{Name: "agenda", Expression: "*/5 * * * *", Enabled: true, AllowOverlap: true, Job: JobTemplate{CyborgID: "PERS0001"}})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 15, 10, 5, 0, 0, time.UTC), s.NextRunAt)
+
+	c.Tick(ctx)
+	assert.Empty(t, *fired)
+
+	now = time.Date(2024, 1, 15, 10, 5, 1, 0, time.UTC)
+	assert.Empty(t, c.Tick(ctx))
+	require.Len(t, *fired, 1)
+
+	stored, err := c.Get(ctx, s.ID)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 15, 10, 10, 0, 0, time.UTC), stored.NextRunAt)
+	assert.Equal(t, "job-1", stored.LastJobID)
+}
+
+func TestCronMissedRunPolicies(t *testing.T) {
+	tests := []struct {
+		policy MissedRunPolicy
+		want   int
+	}{
+		{MissedRunSkip, 0},
+		{MissedRunOnce, 1},
+		{MissedRunCatchUp, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			now := time.Date(2024, 1, 15, 10, 0, 30, 0, time.UTC)
+			c, fired := testCron(&now)
+			ctx := context.Background()
+
+			_, err := c.Create(ctx, &Schedule{Name: "hourly", Expression: "@hourly", Enabled: true, AllowOverlap: true, MissedRunPolicy: tt.policy, Job: JobTemplate{CyborgID: "SREL1001"}})
+			require.NoError(t, err)
+
+			// Down from 10:00 until 13:30, missing 11:00, 12:00 and 13:00
+			now = time.Date(2024, 1, 15, 13, 30, 0, 0, time.UTC)
+			for i := 0; i < 5; i++ {
+				c.Tick(ctx)
+			}
+			assert.Len(t, *fired, tt.want)
+		})
+	}
+}
+
+func TestCronCatchUpLimit(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 30, 0, time.UTC)
+	c, fired := testCron(&now)
+	ctx := context.Background()
+
+	_, err := c.Create(ctx, &Schedule{Name: "hourly", Expression: "@hourly", Enabled: true, AllowOverlap: true, MissedRunPolicy: MissedRunCatchUp, MaxCatchUp: 2, Job: JobTemplate{CyborgID: "SREL1001"}})
+	require.NoError(t, err)
+
+	now = time.Date(2024, 1, 16, 10, 30, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		c.Tick(ctx)
+	}
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 16, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 16, 10, 0, 0, 0, time.UTC),
+	}, *fired)
+}
+
+func TestCronOverlapPrevention(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 30, 0, time.UTC)
+	c, fired := testCron(&now)
+	ctx := context.Background()
+
+	_, err := c.Create(ctx, &Schedule{Name: "review", Expression: "* * * * *", Enabled: true, Job: JobTemplate{CyborgID: "SREL1001"}})
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	c.Tick(ctx)
+	require.Len(t, *fired, 1)
+
+	// The first run is still in flight, so the next activation is dropped
+	now = now.Add(time.Minute)
+	c.Tick(ctx)
+	assert.Len(t, *fired, 1)
+
+	c.JobFinished("job-1")
+	now = now.Add(time.Minute)
+	c.Tick(ctx)
+	assert.Len(t, *fired, 2)
+}
+
+func TestCronDisabledAndDelete(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 30, 0, time.UTC)
+	c, fired := testCron(&now)
+	ctx := context.Background()
+
+	s, err := c.Create(ctx, &Schedule{Name: "paused", Expression: "* * * * *", Job: JobTemplate{CyborgID: "FINC0001"}})
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	c.Tick(ctx)
+	assert.Empty(t, *fired)
+
+	require.NoError(t, c.Delete(ctx, s.ID))
+	_, err = c.Get(ctx, s.ID)
+	assert.ErrorIs(t, err, ErrScheduleNotFound)
+	assert.ErrorIs(t, c.Delete(ctx, s.ID), ErrScheduleNotFound)
+}
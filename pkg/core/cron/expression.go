@@ -0,0 +1,248 @@
+// Package cron provides recurring job schedules driven by cron expressions.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expression is a parsed five-field cron expression (minute, hour,
+// day-of-month, month, day-of-week)
+type Expression struct {
+	// Source is the expression as it was written
+	Source string
+
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+
+	// domStar and dowStar record whether the day fields were unrestricted,
+	// which changes how they combine (see matchesDay)
+	domStar bool
+	dowStar bool
+}
+
+// fieldBounds describes the valid range and optional names of a cron field
+type fieldBounds struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	minuteBounds = fieldBounds{name: "minute", min: 0, max: 59}
+	hourBounds   = fieldBounds{name: "hour", min: 0, max: 23}
+	domBounds    = fieldBounds{name: "day-of-month", min: 1, max: 31}
+	monthBounds  = fieldBounds{name: "month", min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	// Day-of-week accepts 0-7 where both 0 and 7 mean Sunday
+	dowBounds = fieldBounds{name: "day-of-week", min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}}
+)
+
+// macros maps the supported shorthand descriptors to their expansions
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a standard five-field cron expression or one of the
+// @yearly, @monthly, @weekly, @daily and @hourly shorthands
+func Parse(spec string) (*Expression, error) {
+	source := strings.TrimSpace(spec)
+	expanded := source
+	if strings.HasPrefix(expanded, "@") {
+		macro, ok := macros[strings.ToLower(expanded)]
+		if !ok {
+			return nil, fmt.Errorf("unknown cron descriptor %q", source)
+		}
+		expanded = macro
+	}
+
+	fields := strings.Fields(expanded)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", source, len(fields))
+	}
+
+	expr := &Expression{Source: source}
+	var err error
+	if expr.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return nil, err
+	}
+	if expr.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return nil, err
+	}
+	if expr.dom, err = parseField(fields[2], domBounds); err != nil {
+		return nil, err
+	}
+	if expr.month, err = parseField(fields[3], monthBounds); err != nil {
+		return nil, err
+	}
+	if expr.dow, err = parseField(fields[4], dowBounds); err != nil {
+		return nil, err
+	}
+
+	// Fold Sunday-as-7 onto Sunday-as-0
+	if expr.dow&(1<<7) != 0 {
+		expr.dow |= 1
+		expr.dow &^= 1 << 7
+	}
+
+	expr.domStar = isWildcard(fields[2])
+	expr.dowStar = isWildcard(fields[4])
+	return expr, nil
+}
+
+// isWildcard reports whether a field places no restriction on its unit
+func isWildcard(field string) bool {
+	return field == "*" || field == "?"
+}
+
+// parseField converts a comma-separated list of values, ranges and steps
+// into a bitset over the field's range
+func parseField(field string, bounds fieldBounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		partBits, err := parseRange(part, bounds)
+		if err != nil {
+			return 0, err
+		}
+		bits |= partBits
+	}
+	return bits, nil
+}
+
+// parseRange handles a single list element: "*", "n", "a-b" with an
+// optional "/step" suffix
+func parseRange(part string, bounds fieldBounds) (uint64, error) {
+	rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepPart)
+		if err != nil || step <= 0 {
+			return 0, fmt.Errorf("invalid step %q in %s field", stepPart, bounds.name)
+		}
+	}
+
+	var start, end int
+	switch {
+	case rangePart == "*" || rangePart == "?":
+		start, end = bounds.min, bounds.max
+	case strings.Contains(rangePart, "-"):
+		lo, hi, _ := strings.Cut(rangePart, "-")
+		var err error
+		if start, err = parseValue(lo, bounds); err != nil {
+			return 0, err
+		}
+		if end, err = parseValue(hi, bounds); err != nil {
+			return 0, err
+		}
+		if start > end {
+			return 0, fmt.Errorf("invalid range %q in %s field", rangePart, bounds.name)
+		}
+	default:
+		value, err := parseValue(rangePart, bounds)
+		if err != nil {
+			return 0, err
+		}
+		start, end = value, value
+		// "n/step" means starting at n through the end of the range
+		if hasStep {
+			end = bounds.max
+		}
+	}
+
+	var bits uint64
+	for v := start; v <= end; v += step {
+		bits |= 1 << uint(v)
+	}
+	return bits, nil
+}
+
+// parseValue parses a single numeric or named value and checks its bounds
+func parseValue(value string, bounds fieldBounds) (int, error) {
+	if bounds.names != nil {
+		if v, ok := bounds.names[strings.ToUpper(value)]; ok {
+			return v, nil
+		}
+	}
+
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", value, bounds.name)
+	}
+	if v < bounds.min || v > bounds.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d] in %s field", v, bounds.min, bounds.max, bounds.name)
+	}
+	return v, nil
+}
+
+// Next returns the first activation strictly after t, evaluated in t's
+// location. It returns the zero time if no activation exists within five
+// years (for example "0 0 30 2 *").
+func (e *Expression) Next(t time.Time) time.Time {
+	loc := t.Location()
+	// Start from the next whole minute
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if e.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !e.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if e.hour&(1<<uint(t.Hour())) == 0 {
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			// A DST fall-back can map the next wall hour onto the current
+			// instant; step in absolute time instead to keep moving forward
+			if !next.After(t) {
+				next = t.Truncate(time.Hour).Add(time.Hour)
+			}
+			t = next
+			continue
+		}
+		if e.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// matchesDay applies the classic cron rule: when both day-of-month and
+// day-of-week are restricted, a day matching either field is accepted
+func (e *Expression) matchesDay(t time.Time) bool {
+	domMatch := e.dom&(1<<uint(t.Day())) != 0
+	dowMatch := e.dow&(1<<uint(t.Weekday())) != 0
+
+	if e.domStar || e.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// String returns the expression as it was written
+func (e *Expression) String() string {
+	return e.Source
+}
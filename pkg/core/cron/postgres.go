@@ -0,0 +1,137 @@
+package cron
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// PostgresStore persists schedules in the conductor's PostgreSQL database
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a schedule store backed by db
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// EnsureSchema creates the schedules table if it does not exist
+func (p *PostgresStore) EnsureSchema(ctx context.Context) error {
+	_, err := p.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS cron_schedules (
+			id          TEXT PRIMARY KEY,
+			name        TEXT NOT NULL,
+			definition  JSONB NOT NULL,
+			next_run_at TIMESTAMPTZ,
+			updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create cron_schedules table: %w", err)
+	}
+	return nil
+}
+
+// Create inserts a new schedule
+func (p *PostgresStore) Create(ctx context.Context, s *Schedule) error {
+	definition, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode schedule %s: %w", s.ID, err)
+	}
+
+	_, err = p.db.ExecContext(ctx,
+		`INSERT INTO cron_schedules (id, name, definition, next_run_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		s.ID, s.Name, definition, s.NextRunAt, s.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert schedule %s: %w", s.ID, err)
+	}
+	return nil
+}
+
+// Get loads a schedule by ID
+func (p *PostgresStore) Get(ctx context.Context, id string) (*Schedule, error) {
+	var definition []byte
+	err := p.db.QueryRowContext(ctx,
+		`SELECT definition FROM cron_schedules WHERE id = $1`, id).Scan(&definition)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schedule %s: %w", id, err)
+	}
+	return decodeSchedule(definition)
+}
+
+// List loads all schedules ordered by name
+func (p *PostgresStore) List(ctx context.Context) ([]*Schedule, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT definition FROM cron_schedules ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*Schedule
+	for rows.Next() {
+		var definition []byte
+		if err := rows.Scan(&definition); err != nil {
+			return nil, fmt.Errorf("failed to scan schedule: %w", err)
+		}
+		s, err := decodeSchedule(definition)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+// Update replaces an existing schedule
+func (p *PostgresStore) Update(ctx context.Context, s *Schedule) error {
+	definition, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode schedule %s: %w", s.ID, err)
+	}
+
+	res, err := p.db.ExecContext(ctx,
+		`UPDATE cron_schedules SET name = $2, definition = $3, next_run_at = $4, updated_at = $5
+		 WHERE id = $1`,
+		s.ID, s.Name, definition, s.NextRunAt, s.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update schedule %s: %w", s.ID, err)
+	}
+	return requireRow(res)
+}
+
+// Delete removes a schedule
+func (p *PostgresStore) Delete(ctx context.Context, id string) error {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM cron_schedules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete schedule %s: %w", id, err)
+	}
+	return requireRow(res)
+}
+
+// decodeSchedule unmarshals a stored schedule definition
+func decodeSchedule(definition []byte) (*Schedule, error) {
+	var s Schedule
+	if err := json.Unmarshal(definition, &s); err != nil {
+		return nil, fmt.Errorf("failed to decode schedule: %w", err)
+	}
+	return &s, nil
+}
+
+// requireRow maps an update that touched no rows to ErrScheduleNotFound
+func requireRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if n == 0 {
+		return ErrScheduleNotFound
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package cron
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MissedRunPolicy decides what happens to activations that were missed
+// while the conductor was down or the schedule was blocked
+type MissedRunPolicy string
+
+const (
+	// MissedRunSkip drops missed activations and waits for the next one
+	MissedRunSkip MissedRunPolicy = "skip"
+
+	// MissedRunOnce collapses any number of missed activations into one run
+	MissedRunOnce MissedRunPolicy = "run_once"
+
+	// MissedRunCatchUp runs every missed activation, one at a time
+	MissedRunCatchUp MissedRunPolicy = "catch_up"
+)
+
+// ErrInvalidSchedule wraps every schedule validation failure
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// DefaultMaxCatchUp bounds how many missed activations a catch-up schedule
+// replays when no explicit limit is set
+const DefaultMaxCatchUp = 10
+
+// JobTemplate describes the job submitted on every activation
+type JobTemplate struct {
+	// CyborgID pins the job to a specific cyborg; empty lets the
+	// conductor pick one by capability
+	CyborgID     string   `json:"cyborg_id,omitempty"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	Payload      []byte   `json:"payload,omitempty"`
+	TimeoutMs    int32    `json:"timeout_ms,omitempty"`
+	Priority     int32    `json:"priority,omitempty"`
+}
+
+// Schedule is a persisted recurring job definition
+type Schedule struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	// Expression is a five-field cron expression or an @-descriptor
+	Expression string `json:"expression"`
+
+	// TimeZone is an IANA location name; empty means UTC
+	TimeZone string `json:"time_zone"`
+
+	Job JobTemplate `json:"job"`
+
+	MissedRunPolicy MissedRunPolicy `json:"missed_run_policy"`
+
+	// MaxCatchUp caps the activations replayed under MissedRunCatchUp
+	MaxCatchUp int `json:"max_catch_up"`
+
+	// AllowOverlap lets a new activation start while the previous run of
+	// the same schedule is still in flight
+	AllowOverlap bool `json:"allow_overlap"`
+
+	Enabled bool `json:"enabled"`
+
+	NextRunAt time.Time `json:"next_run_at"`
+	LastRunAt time.Time `json:"last_run_at,omitempty"`
+	LastJobID string    `json:"last_job_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks the schedule definition and fills in defaults. Failures
+// wrap ErrInvalidSchedule.
+func (s *Schedule) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSchedule)
+	}
+	if _, err := Parse(s.Expression); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	if _, err := s.Location(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	if s.Job.CyborgID == "" && len(s.Job.Capabilities) == 0 {
+		return fmt.Errorf("%w: job must name a cyborg or at least one capability", ErrInvalidSchedule)
+	}
+
+	switch s.MissedRunPolicy {
+	case "":
+		s.MissedRunPolicy = MissedRunSkip
+	case MissedRunSkip, MissedRunOnce, MissedRunCatchUp:
+	default:
+		return fmt.Errorf("%w: unknown missed run policy %q", ErrInvalidSchedule, s.MissedRunPolicy)
+	}
+
+	if s.MaxCatchUp < 0 {
+		return fmt.Errorf("%w: max catch up cannot be negative", ErrInvalidSchedule)
+	}
+	if s.MaxCatchUp == 0 {
+		s.MaxCatchUp = DefaultMaxCatchUp
+	}
+
+	return nil
+}
+
+// Location resolves the schedule's time zone
+func (s *Schedule) Location() (*time.Location, error) {
+	if s.TimeZone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", s.TimeZone, err)
+	}
+	return loc, nil
+}
+
+// NextAfter returns the first activation of the schedule strictly after t
+func (s *Schedule) NextAfter(t time.Time) (time.Time, error) {
+	next, err := s.activations()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return next(t)
+}
+
+// activations compiles the schedule into a function returning the first
+// activation after a given time, so loops avoid re-parsing the expression
+func (s *Schedule) activations() (func(time.Time) (time.Time, error), error) {
+	expr, err := Parse(s.Expression)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := s.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(t time.Time) (time.Time, error) {
+		next := expr.Next(t.In(loc))
+		if next.IsZero() {
+			return time.Time{}, fmt.Errorf("schedule expression %q never fires", s.Expression)
+		}
+		return next.UTC(), nil
+	}, nil
+}
+
+// newID returns a random identifier for a new schedule
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand does not fail on supported platforms
+		panic(fmt.Sprintf("failed to generate schedule ID: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
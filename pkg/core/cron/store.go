@@ -0,0 +1,94 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrScheduleNotFound is returned when a schedule ID is unknown
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// Store persists schedules
+type Store interface {
+	Create(ctx context.Context, s *Schedule) error
+	Get(ctx context.Context, id string) (*Schedule, error)
+	List(ctx context.Context) ([]*Schedule, error)
+	Update(ctx context.Context, s *Schedule) error
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryStore is a Store kept in process memory, used for tests and for
+// running without a database
+type MemoryStore struct {
+	mu        sync.RWMutex
+	schedules map[string]*Schedule
+}
+
+// NewMemoryStore creates an empty in-memory schedule store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{schedules: make(map[string]*Schedule)}
+}
+
+// Create stores a new schedule
+func (m *MemoryStore) Create(ctx context.Context, s *Schedule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	copied := *s
+	m.schedules[s.ID] = &copied
+	return nil
+}
+
+// Get returns a copy of the schedule with the given ID
+func (m *MemoryStore) Get(ctx context.Context, id string) (*Schedule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, exists := m.schedules[id]
+	if !exists {
+		return nil, ErrScheduleNotFound
+	}
+	copied := *s
+	return &copied, nil
+}
+
+// List returns copies of all schedules ordered by name
+func (m *MemoryStore) List(ctx context.Context) ([]*Schedule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]*Schedule, 0, len(m.schedules))
+	for _, s := range m.schedules {
+		copied := *s
+		result = append(result, &copied)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// Update replaces an existing schedule
+func (m *MemoryStore) Update(ctx context.Context, s *Schedule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.schedules[s.ID]; !exists {
+		return ErrScheduleNotFound
+	}
+	copied := *s
+	m.schedules[s.ID] = &copied
+	return nil
+}
+
+// Delete removes a schedule
+func (m *MemoryStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.schedules[id]; !exists {
+		return ErrScheduleNotFound
+	}
+	delete(m.schedules, id)
+	return nil
+}
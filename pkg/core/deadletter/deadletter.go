@@ -0,0 +1,103 @@
+// Package deadletter keeps jobs the conductor gave up on, together with
+// the reason and the history of every attempt, so operators can inspect,
+// fix and requeue them instead of losing them.
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Reasons a job is dead-lettered
+const (
+	// ReasonRetriesExhausted means every allowed attempt failed
+	ReasonRetriesExhausted = "retries_exhausted"
+
+	// ReasonNoSuitableCyborg means no registered cyborg could take the job
+	ReasonNoSuitableCyborg = "no_suitable_cyborg"
+
+	// ReasonUndecodable means the queued payload could not be decoded as a job
+	ReasonUndecodable = "undecodable"
+
+	// ReasonBudgetExceeded means every capable cyborg had spent its token
+	// or cost budget
+	ReasonBudgetExceeded = "budget_exceeded"
+
+	// ReasonRequeueFailed means a job to be retried could not be put back
+	// on the queue
+	ReasonRequeueFailed = "requeue_failed"
+)
+
+// ErrNotFound is returned when no dead-letter entry has the requested ID
+var ErrNotFound = errors.New("dead-letter entry not found")
+
+// Attempt records one execution of a job
+type Attempt struct {
+	Number     int       `json:"number"`
+	CyborgID   string    `json:"cyborg_id,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Entry is a dead-lettered job
+type Entry struct {
+	// ID is the job ID
+	ID        string `json:"id"`
+	Namespace string `json:"namespace,omitempty"`
+	CyborgID  string `json:"cyborg_id,omitempty"`
+
+	// Job is the encoded job as it was last queued; editing it changes what
+	// a requeue submits
+	Job json.RawMessage `json:"job"`
+
+	Reason     string    `json:"reason"`
+	Error      string    `json:"error,omitempty"`
+	Attempts   []Attempt `json:"attempts,omitempty"`
+	LastStderr string    `json:"last_stderr,omitempty"`
+
+	DeadAt    time.Time `json:"dead_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Filter selects entries for List and Purge; zero fields match everything
+type Filter struct {
+	Namespace string
+	Reason    string
+
+	// Before matches entries dead-lettered before this time
+	Before time.Time
+}
+
+// Matches reports whether e is selected by f
+func (f Filter) Matches(e *Entry) bool {
+	if f.Namespace != "" && e.Namespace != f.Namespace {
+		return false
+	}
+	if f.Reason != "" && e.Reason != f.Reason {
+		return false
+	}
+	if !f.Before.IsZero() && !e.DeadAt.Before(f.Before) {
+		return false
+	}
+	return true
+}
+
+// Store persists dead-letter entries
+type Store interface {
+	// Put stores e, replacing any entry with the same ID
+	Put(ctx context.Context, e *Entry) error
+	Get(ctx context.Context, id string) (*Entry, error)
+
+	// List returns matching entries, oldest first
+	List(ctx context.Context, f Filter) ([]*Entry, error)
+
+	// Update replaces an existing entry
+	Update(ctx context.Context, e *Entry) error
+	Delete(ctx context.Context, id string) error
+
+	// Purge deletes matching entries and returns how many were removed
+	Purge(ctx context.Context, f Filter) (int, error)
+}
@@ -0,0 +1,101 @@
+package deadletter
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryStore is an in-memory Store for tests and single-process use
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string]*Entry
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*Entry)}
+}
+
+// Put stores a copy of e, replacing any entry with the same ID
+func (s *MemoryStore) Put(ctx context.Context, e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[e.ID] = copyEntry(e)
+	return nil
+}
+
+// Get returns a copy of the entry with the given ID
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, exists := s.entries[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return copyEntry(e), nil
+}
+
+// List returns copies of the matching entries, oldest first
+func (s *MemoryStore) List(ctx context.Context, f Filter) ([]*Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]*Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		if f.Matches(e) {
+			entries = append(entries, copyEntry(e))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].DeadAt.Equal(entries[j].DeadAt) {
+			return entries[i].ID < entries[j].ID
+		}
+		return entries[i].DeadAt.Before(entries[j].DeadAt)
+	})
+	return entries, nil
+}
+
+// Update replaces an existing entry
+func (s *MemoryStore) Update(ctx context.Context, e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.entries[e.ID]; !exists {
+		return ErrNotFound
+	}
+	s.entries[e.ID] = copyEntry(e)
+	return nil
+}
+
+// Delete removes an entry
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.entries[id]; !exists {
+		return ErrNotFound
+	}
+	delete(s.entries, id)
+	return nil
+}
+
+// Purge removes the matching entries
+func (s *MemoryStore) Purge(ctx context.Context, f Filter) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	purged := 0
+	for id, e := range s.entries {
+		if f.Matches(e) {
+			delete(s.entries, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// copyEntry returns a copy of e that shares no slices with it
+func copyEntry(e *Entry) *Entry {
+	c := *e
+	c.Job = append([]byte(nil), e.Job...)
+	c.Attempts = append([]Attempt(nil), e.Attempts...)
+	return &c
+}
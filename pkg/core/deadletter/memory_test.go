@@ -0,0 +1,76 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreFilterAndPurge(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	entries := []*Entry{
+		{ID: "close-books", Namespace: "finance", Reason: ReasonRetriesExhausted, DeadAt: base},
+		{ID: "lead-score", Namespace: "sales", Reason: ReasonNoSuitableCyborg, DeadAt: base.Add(time.Hour)},
+		{ID: "forecast", Namespace: "finance", Reason: ReasonNoSuitableCyborg, DeadAt: base.Add(2 * time.Hour)},
+	}
+	for _, e := range entries {
+		require.NoError(t, store.Put(ctx, e))
+	}
+
+	all, err := store.List(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "close-books", all[0].ID, "oldest first")
+
+	finance, err := store.List(ctx, Filter{Namespace: "finance", Reason: ReasonNoSuitableCyborg})
+	require.NoError(t, err)
+	require.Len(t, finance, 1)
+	assert.Equal(t, "forecast", finance[0].ID)
+
+	purged, err := store.Purge(ctx, Filter{Before: base.Add(90 * time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+
+	_, err = store.Get(ctx, "lead-score")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Get(ctx, "forecast")
+	assert.NoError(t, err)
+}
+
+func TestMemoryStoreUpdateAndDelete(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	assert.ErrorIs(t, store.Update(ctx, &Entry{ID: "missing"}), ErrNotFound)
+	assert.ErrorIs(t, store.Delete(ctx, "missing"), ErrNotFound)
+
+	entry := &Entry{
+		ID:       "payroll",
+		Job:      json.RawMessage(`{"id":"payroll"}`),
+		Attempts: []Attempt{{Number: 1, Error: "exit status 1"}},
+	}
+	require.NoError(t, store.Put(ctx, entry))
+
+	// Stored entries are copies
+	entry.Attempts[0].Error = "changed"
+	got, err := store.Get(ctx, "payroll")
+	require.NoError(t, err)
+	assert.Equal(t, "exit status 1", got.Attempts[0].Error)
+
+	got.Job = json.RawMessage(`{"id":"payroll","priority":1}`)
+	require.NoError(t, store.Update(ctx, got))
+	got, err = store.Get(ctx, "payroll")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"payroll","priority":1}`, string(got.Job))
+
+	require.NoError(t, store.Delete(ctx, "payroll"))
+	_, err = store.Get(ctx, "payroll")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
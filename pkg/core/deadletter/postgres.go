@@ -0,0 +1,165 @@
+package deadletter
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// PostgresStore persists dead-letter entries in the conductor's PostgreSQL
+// database
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a dead-letter store backed by db
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// EnsureSchema creates the dead-letter table if it does not exist
+func (p *PostgresStore) EnsureSchema(ctx context.Context) error {
+	_, err := p.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS dead_letters (
+			id        TEXT PRIMARY KEY,
+			namespace TEXT NOT NULL DEFAULT '',
+			reason    TEXT NOT NULL,
+			entry     JSONB NOT NULL,
+			dead_at   TIMESTAMPTZ NOT NULL
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create dead_letters table: %w", err)
+	}
+	return nil
+}
+
+// Put stores e, replacing any entry with the same ID
+func (p *PostgresStore) Put(ctx context.Context, e *Entry) error {
+	entry, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode dead-letter entry %s: %w", e.ID, err)
+	}
+
+	_, err = p.db.ExecContext(ctx,
+		`INSERT INTO dead_letters (id, namespace, reason, entry, dead_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (id) DO UPDATE
+		   SET namespace = EXCLUDED.namespace, reason = EXCLUDED.reason,
+		       entry = EXCLUDED.entry, dead_at = EXCLUDED.dead_at`,
+		e.ID, e.Namespace, e.Reason, entry, e.DeadAt)
+	if err != nil {
+		return fmt.Errorf("failed to store dead-letter entry %s: %w", e.ID, err)
+	}
+	return nil
+}
+
+// Get loads an entry by ID
+func (p *PostgresStore) Get(ctx context.Context, id string) (*Entry, error) {
+	var entry []byte
+	err := p.db.QueryRowContext(ctx,
+		`SELECT entry FROM dead_letters WHERE id = $1`, id).Scan(&entry)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dead-letter entry %s: %w", id, err)
+	}
+	return decodeEntry(entry)
+}
+
+// List loads matching entries, oldest first
+func (p *PostgresStore) List(ctx context.Context, f Filter) ([]*Entry, error) {
+	where, args := filterClause(f)
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT entry FROM dead_letters WHERE `+where+` ORDER BY dead_at, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead-letter entries: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*Entry
+	for rows.Next() {
+		var entry []byte
+		if err := rows.Scan(&entry); err != nil {
+			return nil, fmt.Errorf("failed to scan dead-letter entry: %w", err)
+		}
+		e, err := decodeEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
+
+// Update replaces an existing entry
+func (p *PostgresStore) Update(ctx context.Context, e *Entry) error {
+	entry, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode dead-letter entry %s: %w", e.ID, err)
+	}
+
+	res, err := p.db.ExecContext(ctx,
+		`UPDATE dead_letters SET namespace = $2, reason = $3, entry = $4 WHERE id = $1`,
+		e.ID, e.Namespace, e.Reason, entry)
+	if err != nil {
+		return fmt.Errorf("failed to update dead-letter entry %s: %w", e.ID, err)
+	}
+	return requireRow(res)
+}
+
+// Delete removes an entry
+func (p *PostgresStore) Delete(ctx context.Context, id string) error {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM dead_letters WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete dead-letter entry %s: %w", id, err)
+	}
+	return requireRow(res)
+}
+
+// Purge deletes matching entries
+func (p *PostgresStore) Purge(ctx context.Context, f Filter) (int, error) {
+	where, args := filterClause(f)
+	res, err := p.db.ExecContext(ctx, `DELETE FROM dead_letters WHERE `+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge dead-letter entries: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count purged entries: %w", err)
+	}
+	return int(n), nil
+}
+
+// filterClause renders f as a WHERE condition; unset fields match all rows
+func filterClause(f Filter) (string, []interface{}) {
+	var before interface{}
+	if !f.Before.IsZero() {
+		before = f.Before
+	}
+	return `($1 = '' OR namespace = $1) AND ($2 = '' OR reason = $2) AND ($3::timestamptz IS NULL OR dead_at < $3)`,
+		[]interface{}{f.Namespace, f.Reason, before}
+}
+
+// decodeEntry unmarshals a stored entry
+func decodeEntry(entry []byte) (*Entry, error) {
+	var e Entry
+	if err := json.Unmarshal(entry, &e); err != nil {
+		return nil, fmt.Errorf("failed to decode dead-letter entry: %w", err)
+	}
+	return &e, nil
+}
+
+// requireRow maps a statement that touched no rows to ErrNotFound
+func requireRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
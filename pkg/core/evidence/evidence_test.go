@@ -0,0 +1,67 @@
+package evidence
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreChainsEntries(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	first, err := NewEntry("job-1", TypeAudit, map[string]string{"event": "escalated", "to": "SWEN0005"})
+	require.NoError(t, err)
+	require.NoError(t, store.Append(ctx, first))
+	assert.Equal(t, "job-1:1", first.ID)
+	assert.Empty(t, first.PrevHash)
+
+	second, err := NewEntry("job-1", TypeAudit, map[string]string{"event": "escalated", "to": "SWEN0004"})
+	require.NoError(t, err)
+	second.ParentID = first.ID
+	require.NoError(t, store.Append(ctx, second))
+	assert.Equal(t, 2, second.Seq)
+	assert.Equal(t, first.Hash, second.PrevHash)
+
+	other, err := NewEntry("job-2", TypeError, "boom")
+	require.NoError(t, err)
+	require.NoError(t, store.Append(ctx, other))
+	assert.Equal(t, 1, other.Seq)
+
+	entries, err := store.List(ctx, "job-1")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.NoError(t, Verify(entries))
+	var data map[string]string
+	require.NoError(t, json.Unmarshal(entries[1].Data, &data))
+	assert.Equal(t, "SWEN0004", data["to"])
+
+	empty, err := store.List(ctx, "job-3")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+	assert.NoError(t, Verify(empty))
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	for _, to := range []string{"SWEN0005", "SWEN0004", "SWEN0001"} {
+		e, err := NewEntry("job-1", TypeAudit, map[string]string{"to": to})
+		require.NoError(t, err)
+		require.NoError(t, store.Append(ctx, e))
+	}
+	entries, err := store.List(ctx, "job-1")
+	require.NoError(t, err)
+	require.NoError(t, Verify(entries))
+
+	edited := append([]Entry{}, entries...)
+	edited[1].Data = json.RawMessage(`{"to":"EXEC0001"}`)
+	assert.ErrorIs(t, Verify(edited), ErrTampered)
+
+	// Dropping an entry breaks the chain even with every hash intact
+	assert.ErrorIs(t, Verify([]Entry{entries[0], entries[2]}), ErrTampered)
+	assert.ErrorIs(t, Verify(entries[1:]), ErrTampered)
+}
@@ -0,0 +1,40 @@
+package evidence
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-memory Store for tests and single-process use
+type MemoryStore struct {
+	mu   sync.Mutex
+	logs map[string][]Entry
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{logs: make(map[string][]Entry)}
+}
+
+// Append adds e to the end of its job's log
+func (s *MemoryStore) Append(ctx context.Context, e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log := s.logs[e.JobID]
+	var prev *Entry
+	if len(log) > 0 {
+		prev = &log[len(log)-1]
+	}
+	seal(e, prev, time.Now())
+	s.logs[e.JobID] = append(log, *e)
+	return nil
+}
+
+// List returns a job's entries in order
+func (s *MemoryStore) List(ctx context.Context, jobID string) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Entry{}, s.logs[jobID]...), nil
+}
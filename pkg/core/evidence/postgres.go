@@ -0,0 +1,103 @@
+package evidence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PostgresStore persists the log in the conductor's PostgreSQL database,
+// so it is shared by replicas and survives restarts
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates an evidence store backed by db
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// EnsureSchema creates the evidence table if it does not exist
+func (p *PostgresStore) EnsureSchema(ctx context.Context) error {
+	_, err := p.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS evidence_log (
+			job_id    TEXT NOT NULL,
+			seq       INTEGER NOT NULL,
+			type      TEXT NOT NULL,
+			data      BYTEA NOT NULL,
+			parent_id TEXT NOT NULL DEFAULT '',
+			at        TIMESTAMPTZ NOT NULL,
+			prev_hash TEXT NOT NULL DEFAULT '',
+			hash      TEXT NOT NULL,
+			PRIMARY KEY (job_id, seq)
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create evidence_log table: %w", err)
+	}
+	return nil
+}
+
+// Append adds e to the end of its job's log. Replicas appending to the same
+// job at once collide on the primary key, and the loser fails rather than
+// fork the chain.
+func (p *PostgresStore) Append(ctx context.Context, e *Entry) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to append evidence for job %s: %w", e.JobID, err)
+	}
+	defer tx.Rollback()
+
+	var prev Entry
+	err = tx.QueryRowContext(ctx,
+		`SELECT seq, hash FROM evidence_log WHERE job_id = $1 ORDER BY seq DESC LIMIT 1`,
+		e.JobID,
+	).Scan(&prev.Seq, &prev.Hash)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		seal(e, nil, time.Now())
+	case err != nil:
+		return fmt.Errorf("failed to read evidence for job %s: %w", e.JobID, err)
+	default:
+		seal(e, &prev, time.Now())
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO evidence_log (job_id, seq, type, data, parent_id, at, prev_hash, hash)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		e.JobID, e.Seq, e.Type, []byte(e.Data), e.ParentID, e.At, e.PrevHash, e.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to append evidence for job %s: %w", e.JobID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to append evidence for job %s: %w", e.JobID, err)
+	}
+	return nil
+}
+
+// List returns a job's entries in order
+func (p *PostgresStore) List(ctx context.Context, jobID string) ([]Entry, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT seq, type, data, parent_id, at, prev_hash, hash FROM evidence_log
+		 WHERE job_id = $1 ORDER BY seq`,
+		jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list evidence for job %s: %w", jobID, err)
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		e := Entry{JobID: jobID}
+		var data []byte
+		if err := rows.Scan(&e.Seq, &e.Type, &data, &e.ParentID, &e.At, &e.PrevHash, &e.Hash); err != nil {
+			return nil, fmt.Errorf("failed to scan evidence for job %s: %w", jobID, err)
+		}
+		e.ID = fmt.Sprintf("%s:%d", jobID, e.Seq)
+		e.Data = data
+		e.At = e.At.UTC()
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,81 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/internal/runner/adapter"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/output"
+)
+
+// Adapter runs jobs through a pool of persistent adapter processes, such
+// as adapters/python/exec_wrapper.py --persistent, with their run method.
+// A job's payload is passed as the command's text input, and its stdout
+// is the result payload.
+type Adapter struct {
+	pool *adapter.Pool
+}
+
+// NewAdapter creates an adapter backend calling pool
+func NewAdapter(pool *adapter.Pool) *Adapter {
+	return &Adapter{pool: pool}
+}
+
+// runParams and runReply are the adapter's run method
+type runParams struct {
+	Script string   `json:"script"`
+	Args   []string `json:"args"`
+	Input  string   `json:"input,omitempty"`
+}
+
+type runReply struct {
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	Returncode int    `json:"returncode"`
+}
+
+// Execute has an adapter process run the job's command, or its deployment
+// spec's command. Output is relayed once the command has finished.
+func (a *Adapter) Execute(ctx context.Context, req *Request) (*Result, error) {
+	command := req.Command
+	if command == "" {
+		command = SpecValue(req.DeploymentSpec, "command")
+	}
+	if command == "" {
+		return nil, fmt.Errorf("%w: no command to run for cyborg %s", ErrRefused, req.CyborgID)
+	}
+
+	params := runParams{Script: command, Args: req.Args, Input: string(req.Payload)}
+	if params.Args == nil {
+		params.Args = []string{}
+	}
+	var reply runReply
+	if err := a.pool.Call(ctx, "run", params, &reply); err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Stdout:  []byte(reply.Stdout),
+		Stderr:  []byte(reply.Stderr),
+		Payload: []byte(reply.Stdout),
+	}
+	if req.Output != nil {
+		now := time.Now()
+		var seq uint64
+		for _, chunk := range []output.Chunk{
+			{Stream: output.Stdout, Data: result.Stdout},
+			{Stream: output.Stderr, Data: result.Stderr},
+		} {
+			if len(chunk.Data) > 0 {
+				seq++
+				chunk.Seq, chunk.At = seq, now
+				req.Output(chunk)
+			}
+		}
+	}
+	if reply.Returncode != 0 {
+		return result, fmt.Errorf("adapter command exited with status %d", reply.Returncode)
+	}
+	return result, nil
+}
@@ -0,0 +1,84 @@
+// Package executor runs placed jobs on whatever backend their cyborg
+// uses: a local subprocess, a persistent adapter process, a remote worker
+// or an in-process Go function. Schedulers depend on the Executor
+// interface, and a Router picks each cyborg's backend.
+package executor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/toxicoder/cyborg-conductor-core/internal/runner"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/budget"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/output"
+)
+
+var (
+	// ErrRefused is wrapped by the error for a job a backend would not
+	// start, such as a command an execution policy forbids. It says
+	// nothing about the cyborg's health.
+	ErrRefused = errors.New("execution refused")
+	// ErrRejected is wrapped by the error for a job the cyborg declined
+	ErrRejected = errors.New("job rejected by cyborg")
+	// ErrUnknownBackend is returned for a job routed to a backend the
+	// router does not have
+	ErrUnknownBackend = errors.New("unknown executor backend")
+)
+
+// Executor runs one job
+type Executor interface {
+	// Execute runs req to completion. A job that ran and failed returns
+	// its result along with the error; a job that could not run returns a
+	// nil result.
+	Execute(ctx context.Context, req *Request) (*Result, error)
+}
+
+// Request is a job to execute
+type Request struct {
+	JobID    string
+	CyborgID string
+	// DeploymentSpec and JobType are the cyborg's, and pick its backend
+	DeploymentSpec string
+	JobType        string
+	// Capabilities the job was matched on
+	Capabilities []string
+
+	// Command and Args are what a subprocess or adapter backend runs; a
+	// subprocess falls back to the deployment spec's command
+	Command string
+	Args    []string
+	// Payload is the job's input
+	Payload []byte
+
+	// Output, if set, receives the job's output as it is produced instead
+	// of it being buffered in the result
+	Output func(output.Chunk)
+	// Sandbox and Capture, if set, replace a subprocess backend's own
+	// sandbox and capture limits; other backends ignore them
+	Sandbox *runner.Sandbox
+	Capture *runner.CaptureLimits
+}
+
+// Result is what a job left behind
+type Result struct {
+	Stdout []byte
+	Stderr []byte
+	// StdoutExcerpt and StderrExcerpt are set for a stream that passed its
+	// capture's memory limit
+	StdoutExcerpt *output.Excerpt
+	StderrExcerpt *output.Excerpt
+	// Payload is the job's output, as the backend reports it
+	Payload []byte
+	// Status is the status the job reported, if it reported one
+	Status *runner.ResultStatus
+	// Usage is what an LLM job spent on model tokens
+	Usage budget.Usage
+}
+
+// Func is an in-process Go function that runs jobs
+type Func func(ctx context.Context, req *Request) (*Result, error)
+
+// Execute calls f
+func (f Func) Execute(ctx context.Context, req *Request) (*Result, error) {
+	return f(ctx, req)
+}
@@ -0,0 +1,207 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toxicoder/cyborg-conductor-core/internal/runner"
+	"github.com/toxicoder/cyborg-conductor-core/internal/runner/adapter"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/config"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/output"
+)
+
+// testRunner returns a subprocess runner with a task timeout long enough
+// for the tests' commands
+func testRunner() *runner.SubprocessRunner {
+	cfg := &config.Config{}
+	cfg.Runtime.Timeout.TaskTimeout = 10
+	return runner.NewSubprocessRunner(cfg)
+}
+
+// named returns an executor that answers with its name
+func named(name string) Executor {
+	return Func(func(ctx context.Context, req *Request) (*Result, error) {
+		return &Result{Payload: []byte(name)}, nil
+	})
+}
+
+// TestSpecValue tests reading keys from deployment specs
+func TestSpecValue(t *testing.T) {
+	assert.Equal(t, "adapter", SpecValue("ns=cyborg-sre executor=adapter", "executor"))
+	assert.Equal(t, "remote", SpecValue("ns=cyborg-sre;executor=remote", "executor"))
+	assert.Equal(t, "cyborg-sre", SpecValue("ns=cyborg-sre,executor=remote", "ns"))
+	assert.Empty(t, SpecValue("ns=cyborg-sre", "executor"))
+	assert.Empty(t, SpecValue("", "executor"))
+}
+
+// TestParseJobTypes tests parsing job type routes
+func TestParseJobTypes(t *testing.T) {
+	routes, err := ParseJobTypes(" LLM=remote, DETERMINISTIC = subprocess ,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"LLM": "remote", "DETERMINISTIC": "subprocess"}, routes)
+
+	routes, err = ParseJobTypes("")
+	require.NoError(t, err)
+	assert.Empty(t, routes)
+
+	for _, invalid := range []string{"LLM", "LLM=", "=remote"} {
+		_, err := ParseJobTypes(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+// TestRouter tests backend selection by deployment spec, then job type,
+// then the router's default
+func TestRouter(t *testing.T) {
+	router := NewRouter(BackendSubprocess)
+	router.Handle(BackendSubprocess, named(BackendSubprocess))
+	router.Handle(BackendRemote, named(BackendRemote))
+	router.Handle("forecast", named("forecast"))
+	router.RouteJobType("LLM", BackendRemote)
+
+	for _, tc := range []struct {
+		req  *Request
+		want string
+	}{
+		{&Request{CyborgID: "SRE0001"}, BackendSubprocess},
+		{&Request{CyborgID: "FINC0001", JobType: "LLM"}, BackendRemote},
+		{&Request{CyborgID: "FINC0001", JobType: "LLM", DeploymentSpec: "ns=cyborg-finance executor=forecast"}, "forecast"},
+	} {
+		assert.Equal(t, tc.want, router.Backend(tc.req))
+		result, err := router.Execute(context.Background(), tc.req)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, string(result.Payload))
+	}
+
+	_, err := router.Execute(context.Background(), &Request{CyborgID: "SRE0001", DeploymentSpec: "executor=adapter"})
+	assert.ErrorIs(t, err, ErrUnknownBackend)
+}
+
+// TestFake tests the fake executor's answers, delay and recorded requests
+func TestFake(t *testing.T) {
+	fake := NewFake()
+	result, err := fake.Execute(context.Background(), &Request{JobID: "j1", CyborgID: "SRE0001"})
+	require.NoError(t, err)
+	assert.NotNil(t, result)
+
+	failure := errors.New("out of memory")
+	fake.Answer("", &Result{Payload: []byte("ok")}, nil)
+	fake.Answer("FINC0001", nil, failure)
+	result, err = fake.Execute(context.Background(), &Request{JobID: "j2", CyborgID: "SRE0001"})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(result.Payload))
+	_, err = fake.Execute(context.Background(), &Request{JobID: "j3", CyborgID: "FINC0001"})
+	assert.ErrorIs(t, err, failure)
+
+	fake.Delay(time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = fake.Execute(ctx, &Request{JobID: "j4", CyborgID: "SRE0001"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	requests := fake.Requests()
+	require.Len(t, requests, 4)
+	assert.Equal(t, "j4", requests[3].JobID)
+}
+
+// TestSubprocess tests the subprocess backend's input, result payload and
+// streamed output
+func TestSubprocess(t *testing.T) {
+	sub := NewSubprocess(testRunner(), nil)
+
+	// A legacy script's stdout is its payload
+	result, err := sub.Execute(context.Background(), &Request{
+		CyborgID: "SRE0001", Command: "cat", Payload: []byte("q3 numbers"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "q3 numbers", string(result.Payload))
+	assert.Nil(t, result.Status)
+
+	// A reported payload replaces stdout, and the command may come from
+	// the deployment spec
+	var chunks []output.Chunk
+	result, err = sub.Execute(context.Background(), &Request{
+		CyborgID:       "SRE0001",
+		DeploymentSpec: "ns=cyborg-sre command=sh",
+		Args:           []string{"-c", `echo working; echo '::cyborg-result::json {"status":{"code":"SUCCESS"},"payload":"done"}'`},
+		Output:         func(chunk output.Chunk) { chunks = append(chunks, chunk) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "done", string(result.Payload))
+	require.NotNil(t, result.Status)
+	assert.Equal(t, runner.StatusSuccess, result.Status.Code)
+	assert.NotEmpty(t, chunks)
+	assert.Contains(t, string(result.Stdout), "working")
+
+	_, err = sub.Execute(context.Background(), &Request{CyborgID: "SRE0001"})
+	assert.ErrorIs(t, err, ErrRefused)
+}
+
+// TestSubprocessFailures tests that a policy refusal and a rejection are
+// told apart from other failures
+func TestSubprocessFailures(t *testing.T) {
+	policies := &runner.PolicySet{Cyborgs: map[string]*runner.ExecPolicy{
+		"SRE0001": {Executables: []runner.AllowedExecutable{{Name: "echo"}}},
+	}}
+	sub := NewSubprocess(testRunner(), policies)
+
+	_, err := sub.Execute(context.Background(), &Request{CyborgID: "SRE0001", Command: "cat"})
+	assert.ErrorIs(t, err, ErrRefused)
+	assert.ErrorIs(t, err, runner.ErrPolicyViolation)
+
+	result, err := sub.Execute(context.Background(), &Request{
+		CyborgID: "FINC0001",
+		Command:  "sh",
+		Args:     []string{"-c", `echo '::cyborg-result::json {"status":{"code":"REJECTED","message":"out of scope"}}'`},
+	})
+	assert.ErrorIs(t, err, ErrRejected)
+	assert.ErrorIs(t, err, runner.ErrReportedFailure)
+	require.NotNil(t, result)
+	assert.Equal(t, runner.StatusRejected, result.Status.Code)
+
+	_, err = sub.Execute(context.Background(), &Request{CyborgID: "FINC0001", Command: "sh", Args: []string{"-c", "exit 3"}})
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrRefused) || errors.Is(err, ErrRejected))
+}
+
+// TestAdapter tests the adapter backend against the Python adapter
+func TestAdapter(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not installed")
+	}
+	script, err := filepath.Abs(filepath.Join("..", "..", "..", "adapters", "python", "exec_wrapper.py"))
+	require.NoError(t, err)
+	if _, err := os.Stat(script); err != nil {
+		t.Skip("python adapter not found")
+	}
+
+	pool := adapter.NewPool(adapter.Config{Name: "python", Command: python, Args: []string{script, "--persistent"}, Size: 1})
+	defer pool.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	backend := NewAdapter(pool)
+
+	var chunks []output.Chunk
+	result, err := backend.Execute(ctx, &Request{
+		CyborgID: "SRE0001",
+		Command:  "cat",
+		Payload:  []byte("q3 numbers"),
+		Output:   func(chunk output.Chunk) { chunks = append(chunks, chunk) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "q3 numbers", string(result.Payload))
+	require.Len(t, chunks, 1)
+	assert.Equal(t, output.Stdout, chunks[0].Stream)
+
+	_, err = backend.Execute(ctx, &Request{CyborgID: "SRE0001", Command: "sh", Args: []string{"-c", "exit 3"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Fake is an Executor for tests. It records every request and answers
+// with what was set for the job's cyborg, or else for every cyborg; the
+// zero answer is an empty successful result.
+type Fake struct {
+	mu       sync.Mutex
+	answers  map[string]fakeAnswer
+	delay    time.Duration
+	requests []*Request
+}
+
+type fakeAnswer struct {
+	result *Result
+	err    error
+}
+
+// NewFake creates a fake executor
+func NewFake() *Fake {
+	return &Fake{answers: make(map[string]fakeAnswer)}
+}
+
+// Answer sets what jobs on a cyborg return; an empty cyborgID sets the
+// answer for every cyborg without one of its own
+func (f *Fake) Answer(cyborgID string, result *Result, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.answers[cyborgID] = fakeAnswer{result: result, err: err}
+}
+
+// Delay holds each execution for d, or until it is cancelled
+func (f *Fake) Delay(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.delay = d
+}
+
+// Execute records req and returns its cyborg's answer
+func (f *Fake) Execute(ctx context.Context, req *Request) (*Result, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	answer, ok := f.answers[req.CyborgID]
+	if !ok {
+		answer, ok = f.answers[""]
+	}
+	delay := f.delay
+	f.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if !ok {
+		return &Result{}, nil
+	}
+	return answer.result, answer.err
+}
+
+// Requests returns the requests executed so far, in order
+func (f *Fake) Requests() []*Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*Request(nil), f.requests...)
+}
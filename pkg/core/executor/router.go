@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Built-in backend names. In-process functions are added to a router
+// under names of their own.
+const (
+	BackendSubprocess = "subprocess"
+	BackendAdapter    = "adapter"
+	BackendRemote     = "remote"
+)
+
+// SpecValue returns the value of key in a deployment spec, a list of
+// key=value pairs separated by commas, semicolons or spaces such as
+// "ns=cyborg-sre executor=adapter"; empty if the key is absent
+func SpecValue(spec, key string) string {
+	fields := strings.FieldsFunc(spec, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n'
+	})
+	for _, field := range fields {
+		if k, v, ok := strings.Cut(field, "="); ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// ParseJobTypes parses job type routes of the form "LLM=remote,DETERMINISTIC=subprocess"
+func ParseJobTypes(s string) (map[string]string, error) {
+	routes := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		jobType, backend, ok := strings.Cut(entry, "=")
+		jobType, backend = strings.TrimSpace(jobType), strings.TrimSpace(backend)
+		if !ok || jobType == "" || backend == "" {
+			return nil, fmt.Errorf("invalid job type route %q, want JOB_TYPE=backend", entry)
+		}
+		routes[jobType] = backend
+	}
+	return routes, nil
+}
+
+// Router is an Executor that hands each job to its cyborg's backend. A
+// cyborg names its backend with an executor key in its deployment spec;
+// otherwise its job type's route applies, and then the router's default.
+type Router struct {
+	mu       sync.RWMutex
+	backends map[string]Executor
+	jobTypes map[string]string
+	fallback string
+}
+
+// NewRouter creates a router whose jobs go to the fallback backend unless
+// their cyborg says otherwise
+func NewRouter(fallback string) *Router {
+	return &Router{
+		backends: make(map[string]Executor),
+		jobTypes: make(map[string]string),
+		fallback: fallback,
+	}
+}
+
+// Handle adds a backend under name, replacing any of the same name
+func (r *Router) Handle(name string, e Executor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.backends[name] = e
+}
+
+// RouteJobType sends the jobs of cyborgs of a job type, such as "LLM", to
+// the named backend
+func (r *Router) RouteJobType(jobType, backend string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobTypes[jobType] = backend
+}
+
+// Backend returns the name of the backend a job goes to
+func (r *Router) Backend(req *Request) string {
+	if name := SpecValue(req.DeploymentSpec, "executor"); name != "" {
+		return name
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if name, ok := r.jobTypes[req.JobType]; ok {
+		return name
+	}
+	return r.fallback
+}
+
+// Execute runs a job on its backend
+func (r *Router) Execute(ctx context.Context, req *Request) (*Result, error) {
+	name := r.Backend(req)
+	r.mu.RLock()
+	backend, ok := r.backends[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q for cyborg %s", ErrUnknownBackend, name, req.CyborgID)
+	}
+	return backend.Execute(ctx, req)
+}
@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/toxicoder/cyborg-conductor-core/internal/runner"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/output"
+)
+
+// Subprocess runs jobs as local subprocesses. A job's payload is written
+// to the command's stdin. Its result payload is the one the command
+// reported in a result frame or, for a legacy script, its stdout.
+type Subprocess struct {
+	runner   *runner.SubprocessRunner
+	policies *runner.PolicySet
+}
+
+// NewSubprocess creates a subprocess backend over r, restricting each
+// job's command by the execution policies attached to its cyborg and
+// capabilities; nil policies restrict nothing
+func NewSubprocess(r *runner.SubprocessRunner, policies *runner.PolicySet) *Subprocess {
+	return &Subprocess{runner: r, policies: policies}
+}
+
+// Execute runs the job's command, or its deployment spec's command
+func (s *Subprocess) Execute(ctx context.Context, req *Request) (*Result, error) {
+	command := req.Command
+	if command == "" {
+		command = SpecValue(req.DeploymentSpec, "command")
+	}
+	if command == "" {
+		return nil, fmt.Errorf("%w: no command to run for cyborg %s", ErrRefused, req.CyborgID)
+	}
+
+	r := s.runner
+	if req.Sandbox != nil {
+		r = r.Sandboxed(req.Sandbox)
+	}
+	r = r.WithPolicies(s.policies.For(req.CyborgID, req.Capabilities)...)
+	if req.Payload != nil {
+		r = r.WithInput(req.Payload)
+	}
+
+	var run *runner.RunResult
+	var err error
+	var captures map[output.Stream]*output.Capture
+	switch {
+	case req.Output != nil:
+		// Keep the streamed output as well, within bounds
+		limits := output.DefaultLimits()
+		if req.Capture != nil {
+			limits = req.Capture.Limits
+		}
+		captures = map[output.Stream]*output.Capture{
+			output.Stdout: output.NewCapture(limits, nil),
+			output.Stderr: output.NewCapture(limits, nil),
+		}
+		run, err = r.RunStreaming(ctx, command, req.Args, func(chunk output.Chunk) {
+			captures[chunk.Stream].Write(chunk.Data)
+			req.Output(chunk)
+		})
+	case req.Capture != nil:
+		run, err = r.RunCaptured(ctx, command, req.Args, *req.Capture)
+	default:
+		run, err = r.Run(ctx, command, req.Args)
+	}
+	if errors.Is(err, runner.ErrPolicyViolation) {
+		return nil, fmt.Errorf("%w: %w", ErrRefused, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if captures != nil {
+		run.Stdout, run.StdoutExcerpt = captures[output.Stdout].Result()
+		run.Stderr, run.StderrExcerpt = captures[output.Stderr].Result()
+	}
+
+	result := &Result{
+		Stdout:        run.Stdout,
+		Stderr:        run.Stderr,
+		StdoutExcerpt: run.StdoutExcerpt,
+		StderrExcerpt: run.StderrExcerpt,
+		Payload:       run.Stdout,
+	}
+	err = run.Err
+	if reported := run.Result; reported != nil {
+		result.Status = &reported.Status
+		result.Payload = reported.Payload
+		// A command that exited cleanly may still have reported a failure
+		if err == nil {
+			err = reported.Err()
+		}
+		if reported.Status.Code == runner.StatusRejected && errors.Is(err, runner.ErrReportedFailure) {
+			err = fmt.Errorf("%w: %w", ErrRejected, err)
+		}
+	}
+	return result, err
+}
@@ -0,0 +1,56 @@
+// Package idempotency remembers job submissions by client-supplied key so
+// that a retried submission returns the original job instead of running it
+// again. Keys are scoped per namespace and forgotten after a retention
+// window.
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Record states
+const (
+	// StatusPending means the job is queued or running
+	StatusPending = "pending"
+	// StatusSucceeded means the job finished successfully
+	StatusSucceeded = "succeeded"
+	// StatusFailed means the job failed for good
+	StatusFailed = "failed"
+)
+
+// Record is what a key resolves to
+type Record struct {
+	Namespace string    `json:"namespace"`
+	Key       string    `json:"key"`
+	JobID     string    `json:"job_id"`
+	Status    string    `json:"status"`
+	Result    []byte    `json:"result,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the record is past its retention window at now
+func (r *Record) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// Store persists idempotency records
+type Store interface {
+	// Reserve stores rec unless an unexpired record already holds its key.
+	// It returns the record that holds the key and whether it is rec.
+	Reserve(ctx context.Context, rec *Record) (*Record, bool, error)
+
+	// Put stores rec, replacing any record for its key
+	Put(ctx context.Context, rec *Record) error
+
+	// Complete records the outcome of the job holding a key
+	Complete(ctx context.Context, namespace, key, status string, result []byte, errMsg string) error
+
+	// Release forgets a key, for example when its job could not be queued
+	Release(ctx context.Context, namespace, key string) error
+
+	// Purge deletes records that expired before now and returns how many
+	Purge(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,92 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryKey identifies a record in a MemoryStore
+type memoryKey struct {
+	namespace string
+	key       string
+}
+
+// MemoryStore is an in-memory Store for tests and single-process use
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[memoryKey]*Record
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[memoryKey]*Record), now: time.Now}
+}
+
+// Reserve stores rec unless an unexpired record holds its key
+func (s *MemoryStore) Reserve(ctx context.Context, rec *Record) (*Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := memoryKey{rec.Namespace, rec.Key}
+	if existing, exists := s.records[k]; exists && !existing.Expired(s.now()) {
+		return copyRecord(existing), false, nil
+	}
+	s.records[k] = copyRecord(rec)
+	return copyRecord(rec), true, nil
+}
+
+// Put stores rec, replacing any record for its key
+func (s *MemoryStore) Put(ctx context.Context, rec *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[memoryKey{rec.Namespace, rec.Key}] = copyRecord(rec)
+	return nil
+}
+
+// Complete records the outcome of the job holding a key
+func (s *MemoryStore) Complete(ctx context.Context, namespace, key, status string, result []byte, errMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, exists := s.records[memoryKey{namespace, key}]
+	if !exists {
+		return nil
+	}
+	rec.Status = status
+	rec.Result = append([]byte(nil), result...)
+	rec.Error = errMsg
+	return nil
+}
+
+// Release forgets a key
+func (s *MemoryStore) Release(ctx context.Context, namespace, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, memoryKey{namespace, key})
+	return nil
+}
+
+// Purge deletes records that expired before now
+func (s *MemoryStore) Purge(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	purged := 0
+	for k, rec := range s.records {
+		if rec.Expired(now) {
+			delete(s.records, k)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// copyRecord returns a copy of rec that shares no slices with it
+func copyRecord(rec *Record) *Record {
+	c := *rec
+	c.Result = append([]byte(nil), rec.Result...)
+	return &c
+}
@@ -0,0 +1,60 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreReserve(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	first := &Record{Namespace: "finance", Key: "inv-42", JobID: "job-1", Status: StatusPending, ExpiresAt: now.Add(time.Hour)}
+	held, created, err := store.Reserve(ctx, first)
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "job-1", held.JobID)
+
+	// Same key in the same namespace resolves to the first job
+	held, created, err = store.Reserve(ctx, &Record{Namespace: "finance", Key: "inv-42", JobID: "job-2", ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, "job-1", held.JobID)
+
+	// Keys are scoped per namespace
+	_, created, err = store.Reserve(ctx, &Record{Namespace: "sales", Key: "inv-42", JobID: "job-3", ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	require.NoError(t, store.Complete(ctx, "finance", "inv-42", StatusSucceeded, []byte("ok"), ""))
+	held, _, err = store.Reserve(ctx, &Record{Namespace: "finance", Key: "inv-42", JobID: "job-4", ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, StatusSucceeded, held.Status)
+	assert.Equal(t, []byte("ok"), held.Result)
+
+	// After the window the key can be reused
+	now = now.Add(time.Hour)
+	held, created, err = store.Reserve(ctx, &Record{Namespace: "finance", Key: "inv-42", JobID: "job-5", ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "job-5", held.JobID)
+}
+
+func TestMemoryStorePurge(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryStore()
+
+	require.NoError(t, store.Put(ctx, &Record{Namespace: "hr", Key: "old", ExpiresAt: now.Add(-time.Minute)}))
+	require.NoError(t, store.Put(ctx, &Record{Namespace: "hr", Key: "new", ExpiresAt: now.Add(time.Minute)}))
+
+	purged, err := store.Purge(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+}
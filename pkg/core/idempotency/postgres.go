@@ -0,0 +1,133 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PostgresStore persists idempotency records in the conductor's PostgreSQL
+// database, so duplicates are caught across restarts
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates an idempotency store backed by db
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// EnsureSchema creates the idempotency table if it does not exist
+func (p *PostgresStore) EnsureSchema(ctx context.Context) error {
+	_, err := p.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS idempotency_keys (
+			namespace  TEXT NOT NULL,
+			key        TEXT NOT NULL,
+			job_id     TEXT NOT NULL,
+			status     TEXT NOT NULL,
+			result     BYTEA,
+			error      TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (namespace, key)
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency_keys table: %w", err)
+	}
+	return nil
+}
+
+// Reserve stores rec unless an unexpired record holds its key. The insert
+// only replaces an expired row, so concurrent reservations of one key
+// resolve to a single winner.
+func (p *PostgresStore) Reserve(ctx context.Context, rec *Record) (*Record, bool, error) {
+	var jobID string
+	err := p.db.QueryRowContext(ctx,
+		`INSERT INTO idempotency_keys (namespace, key, job_id, status, result, error, created_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 ON CONFLICT (namespace, key) DO UPDATE
+		   SET job_id = EXCLUDED.job_id, status = EXCLUDED.status, result = EXCLUDED.result,
+		       error = EXCLUDED.error, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		 WHERE idempotency_keys.expires_at <= now()
+		 RETURNING job_id`,
+		rec.Namespace, rec.Key, rec.JobID, rec.Status, rec.Result, rec.Error, rec.CreatedAt, rec.ExpiresAt,
+	).Scan(&jobID)
+	if err == nil {
+		return rec, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, fmt.Errorf("failed to reserve idempotency key %s: %w", rec.Key, err)
+	}
+
+	existing, err := p.get(ctx, rec.Namespace, rec.Key)
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
+// Put stores rec, replacing any record for its key
+func (p *PostgresStore) Put(ctx context.Context, rec *Record) error {
+	_, err := p.db.ExecContext(ctx,
+		`INSERT INTO idempotency_keys (namespace, key, job_id, status, result, error, created_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 ON CONFLICT (namespace, key) DO UPDATE
+		   SET job_id = EXCLUDED.job_id, status = EXCLUDED.status, result = EXCLUDED.result,
+		       error = EXCLUDED.error, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at`,
+		rec.Namespace, rec.Key, rec.JobID, rec.Status, rec.Result, rec.Error, rec.CreatedAt, rec.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotency key %s: %w", rec.Key, err)
+	}
+	return nil
+}
+
+// Complete records the outcome of the job holding a key
+func (p *PostgresStore) Complete(ctx context.Context, namespace, key, status string, result []byte, errMsg string) error {
+	_, err := p.db.ExecContext(ctx,
+		`UPDATE idempotency_keys SET status = $3, result = $4, error = $5
+		 WHERE namespace = $1 AND key = $2`,
+		namespace, key, status, result, errMsg)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key %s: %w", key, err)
+	}
+	return nil
+}
+
+// Release forgets a key
+func (p *PostgresStore) Release(ctx context.Context, namespace, key string) error {
+	_, err := p.db.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE namespace = $1 AND key = $2`, namespace, key)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key %s: %w", key, err)
+	}
+	return nil
+}
+
+// Purge deletes records that expired before now
+func (p *PostgresStore) Purge(ctx context.Context, now time.Time) (int, error) {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count purged idempotency keys: %w", err)
+	}
+	return int(n), nil
+}
+
+// get loads the record holding a key
+func (p *PostgresStore) get(ctx context.Context, namespace, key string) (*Record, error) {
+	rec := &Record{Namespace: namespace, Key: key}
+	err := p.db.QueryRowContext(ctx,
+		`SELECT job_id, status, result, error, created_at, expires_at
+		   FROM idempotency_keys WHERE namespace = $1 AND key = $2`,
+		namespace, key,
+	).Scan(&rec.JobID, &rec.Status, &rec.Result, &rec.Error, &rec.CreatedAt, &rec.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load idempotency key %s: %w", key, err)
+	}
+	return rec, nil
+}
@@ -0,0 +1,147 @@
+// Package metrics declares the conductor's Prometheus metrics. They are
+// registered with the default registry, which the server exposes on
+// /metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "cbg"
+
+var (
+	// BreakerState is the current circuit breaker state per cyborg and
+	// capability: 0 closed, 1 open, 2 half-open. The capability label is
+	// empty for breakers that guard the whole cyborg.
+	BreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "breaker_state",
+		Help:      "Circuit breaker state per cyborg and capability (0 closed, 1 open, 2 half-open).",
+	}, []string{"cyborg_id", "capability"})
+
+	// BreakerTransitions counts circuit breaker state changes by target state
+	BreakerTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "breaker_transitions_total",
+		Help:      "Circuit breaker state changes per cyborg and capability.",
+	}, []string{"cyborg_id", "capability", "to"})
+
+	// Hedges counts hedged executions by outcome: launched when a duplicate
+	// starts, then primary_won, hedge_won or both_failed
+	Hedges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "hedges_total",
+		Help:      "Hedged job executions by outcome.",
+	}, []string{"outcome"})
+
+	// RateLimitHolds counts jobs held back because a dependency's rate
+	// limit was spent
+	RateLimitHolds = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_holds_total",
+		Help:      "Jobs held because an external dependency's rate limit was spent.",
+	}, []string{"dependency"})
+
+	// Preemptions counts running jobs cancelled to make room for more
+	// urgent ones
+	Preemptions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "preemptions_total",
+		Help:      "Running jobs preempted by more urgent jobs.",
+	}, []string{"cyborg_id"})
+
+	// ClusterMembers is the number of live conductor replicas this replica
+	// sees
+	ClusterMembers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cluster_members",
+		Help:      "Live conductor replicas.",
+	})
+
+	// ClusterLeader is 1 for each singleton duty this replica leads
+	ClusterLeader = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cluster_leader",
+		Help:      "Whether this replica leads a singleton duty (1) or not (0).",
+	}, []string{"duty"})
+
+	// OrphanedJobs counts in-flight jobs of dead replicas handed back to
+	// the queue
+	OrphanedJobs = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orphaned_jobs_total",
+		Help:      "In-flight jobs of dead replicas returned to the queue.",
+	})
+
+	// StickyRoutes counts jobs routed by context ID: hit when the job went
+	// to the cyborg that ran its context before, moved when that cyborg
+	// could no longer take it
+	StickyRoutes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sticky_routes_total",
+		Help:      "Jobs routed by context ID, by outcome.",
+	}, []string{"outcome"})
+
+	// JobsRefused counts submissions refused for backpressure: queue_full
+	// when the queue had no room, shed when a load-shedding rule refused
+	// the job's priority
+	JobsRefused = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jobs_refused_total",
+		Help:      "Job submissions refused because the queue was full or shedding load.",
+	}, []string{"reason"})
+
+	// LLMTokens counts model tokens reported by jobs
+	LLMTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_tokens_total",
+		Help:      "Model tokens spent by jobs, by namespace and cyborg.",
+	}, []string{"namespace", "cyborg_id"})
+
+	// LLMCost counts the model cost reported by jobs
+	LLMCost = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_cost_total",
+		Help:      "Model cost spent by jobs, by namespace and cyborg.",
+	}, []string{"namespace", "cyborg_id"})
+
+	// BudgetUsed is the share of a budget's limit spent this period; it
+	// measures against the hard limit, or the soft limit if there is none
+	BudgetUsed = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "budget_used_ratio",
+		Help:      "Share of a token or cost budget spent in the current window.",
+	}, []string{"scope", "key", "window"})
+
+	// BudgetActions counts jobs warned about, downgraded or rejected for
+	// an exhausted budget
+	BudgetActions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "budget_actions_total",
+		Help:      "Jobs warned about, downgraded or rejected for an exhausted budget, by scope and action.",
+	}, []string{"scope", "action"})
+
+	// Placements counts jobs placed by each scheduling policy
+	Placements = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "placements_total",
+		Help:      "Jobs placed on a cyborg, by scheduling policy.",
+	}, []string{"policy"})
+
+	// Escalations counts jobs rerouted up the org chart
+	Escalations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "escalations_total",
+		Help:      "Jobs escalated to a cyborg's manager, by reason.",
+	}, []string{"reason"})
+
+	// AdapterRestarts counts adapter processes replaced in a pool: crashed,
+	// unhealthy when they stopped answering pings, or recycled after
+	// serving their share of requests
+	AdapterRestarts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "adapter_restarts_total",
+		Help:      "Adapter processes replaced, by adapter and reason.",
+	}, []string{"adapter", "reason"})
+)
@@ -0,0 +1,27 @@
+package orchestrator
+
+import (
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/breaker"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/policy"
+)
+
+// Option configures a Scheduler at construction time
+type Option func(*Scheduler)
+
+// WithBreakers takes cyborgs whose breakers are open out of SelectCyborg
+// and records every task's outcome against them
+func WithBreakers(s *breaker.Set) Option {
+	return func(sch *Scheduler) {
+		sch.breakers = s
+	}
+}
+
+// WithPolicies replaces the default scheduling policies, which place every
+// task first-fit. Tasks are placed by the set's default policy.
+func WithPolicies(s *policy.Set) Option {
+	return func(sch *Scheduler) {
+		if s != nil {
+			sch.policies = s
+		}
+	}
+}
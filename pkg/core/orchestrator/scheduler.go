@@ -2,10 +2,17 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/breaker"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/executor"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/output"
 	"github.com/toxicoder/cyborg-conductor-core/pkg/core/pb"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/policy"
 	"github.com/toxicoder/cyborg-conductor-core/pkg/memory/manager"
 	"github.com/toxicoder/cyborg-conductor-core/internal/runner"
 )
@@ -21,8 +28,8 @@ type Scheduler struct {
 	// Memory manager for context size tracking
 	memoryManager *manager.MemoryCacheManager
 	
-	// Execution manager for running tasks
-	execManager *runner.SubprocessRunner
+	// Executor for running tasks on their cyborg's backend
+	executor executor.Executor
 	
 	// Worker pool channels
 	workerPool chan chan *Task
@@ -33,6 +40,17 @@ type Scheduler struct {
 	
 	// Current worker count
 	workerCount int32
+	
+	// Circuit breakers that take failing cyborgs out of selection
+	breakers *breaker.Set
+	
+	// Scheduling policies that pick among capable cyborgs
+	policies *policy.Set
+	
+	// Tasks placed on each cyborg and not yet finished, and each cyborg's
+	// smoothed execution time
+	load    map[string]int
+	latency map[string]time.Duration
 }
 
 // Task represents a unit of work to be scheduled
@@ -54,6 +72,21 @@ type Task struct {
 	
 	// Timeout duration for the task
 	Timeout time.Duration
+	
+	// Capabilities the task was matched on
+	Capabilities []string
+	
+	// Output, if set, receives the task's output as it is produced
+	// instead of it being buffered in the result
+	Output runner.ChunkFunc
+	
+	// Sandbox, if set, isolates the task's command in place of a
+	// subprocess backend's own sandbox; see runner.SandboxFor
+	Sandbox *runner.Sandbox
+	
+	// Capture, if set, bounds the task's buffered output in place of a
+	// subprocess backend's limits, such as to spill into a per-task directory
+	Capture *runner.CaptureLimits
 }
 
 // TaskResult represents the result of a scheduled task
@@ -72,18 +105,38 @@ type TaskResult struct {
 	
 	// Cyborg used for execution
 	CyborgID string
+	
+	// Excerpts of output that passed its capture limit; Stdout or Stderr
+	// then hold its head, a truncation marker and its tail
+	StdoutExcerpt *output.Excerpt
+	StderrExcerpt *output.Excerpt
+	
+	// Payload is the task's output as its backend reports it, such as the
+	// payload of a command's result frame
+	Payload []byte
+	
+	// Status the task reported, if any; a reported status other than
+	// success fails the task
+	Status *runner.ResultStatus
 }
 
-// NewScheduler creates a new scheduler with the given memory manager and execution manager
-func NewScheduler(memoryManager *manager.MemoryCacheManager, execManager *runner.SubprocessRunner) *Scheduler {
+// NewScheduler creates a new scheduler with the given memory manager and executor
+func NewScheduler(memoryManager *manager.MemoryCacheManager, exec executor.Executor, opts ...Option) *Scheduler {
 	s := &Scheduler{
 		registry:      make(map[string]*pb.CyborgDescriptor),
 		memoryManager: memoryManager,
-		execManager:   execManager,
+		executor:      exec,
 		workerPool:    make(chan chan *Task, 10), // Buffered channel for worker pool
 		taskQueue:     make(chan *Task, 100),     // Buffered channel for tasks
 		shutdown:      make(chan struct{}),
 		workerCount:   0,
+		policies:      policy.NewSet(policy.DefaultWeights),
+		load:          make(map[string]int),
+		latency:       make(map[string]time.Duration),
+	}
+	
+	for _, opt := range opts {
+		opt(s)
 	}
 	
 	// Start the scheduler loop
@@ -125,64 +178,64 @@ func (s *Scheduler) ListCyborgs() []*pb.CyborgDescriptor {
 
 // SelectCyborg selects an appropriate cyborg based on capability match, latency budget, and current load
 func (s *Scheduler) SelectCyborg(capabilities []string, latencyBudget time.Duration) *pb.CyborgDescriptor {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	cyborgs := s.ListCyborgs()
+	sort.Slice(cyborgs, func(i, j int) bool { return cyborgs[i].Id < cyborgs[j].Id })
 	
-	// In a real implementation, this would use more sophisticated logic:
-	// - Match capabilities
-	// - Consider latency budgets
-	// - Check current load on cyborgs
-	// - Consider resource quotas
-	// For now, we'll select the first available cyborg
+	// Tasks carry no namespace or class, so the default policy places them
+	p := s.policies.For(policy.Request{})
 	
-	for _, descriptor := range s.registry {
-		// Simple capability matching logic
-		if s.hasAllCapabilities(descriptor, capabilities) {
-			// In a real implementation, we'd also check:
-			// - Current load (number of concurrent tasks)
-			// - Available resources
-			// - Latency constraints
-			return descriptor
+	// Skip cyborgs whose circuit breaker is open
+	describe := func(i int) (policy.Candidate, bool) {
+		descriptor := cyborgs[i]
+		if !s.breakers.Ready(descriptor.Id, capabilities) {
+			return policy.Candidate{}, false
 		}
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return policy.Candidate{
+			ID:      descriptor.Id,
+			Load:    s.load[descriptor.Id],
+			Latency: s.latency[descriptor.Id],
+		}, true
 	}
-	
-	return nil
-}
-
-// hasAllCapabilities checks if a cyborg has all required capabilities
-func (s *Scheduler) hasAllCapabilities(descriptor *pb.CyborgDescriptor, required []string) bool {
-	// Convert the descriptor's capabilities to a set for efficient lookup
-	descriptorCaps := make(map[string]bool)
-	for _, cap := range descriptor.Capabilities {
-		descriptorCaps[cap] = true
+	have := make([][]string, len(cyborgs))
+	for i, descriptor := range cyborgs {
+		have[i] = descriptor.Capabilities
 	}
-	
-	// Check if all required capabilities are present
-	for _, req := range required {
-		if !descriptorCaps[req] {
-			return false
-		}
+	i := policy.Place(p, policy.Request{}, capabilities, have, describe, func(i int) bool {
+		return s.breakers.Allow(cyborgs[i].Id, capabilities)
+	})
+	if i < 0 {
+		return nil
 	}
-	
-	return true
+	return cyborgs[i]
 }
 
 // SubmitTask submits a task to the scheduler for execution
 func (s *Scheduler) SubmitTask(ctx context.Context, command string, args []string, capabilities []string, timeout time.Duration) (*TaskResult, error) {
+	return s.StreamTask(ctx, command, args, capabilities, timeout, nil)
+}
+
+// StreamTask submits a task like SubmitTask, handing its stdout and stderr
+// to onChunk as they are produced; the result then carries no output
+func (s *Scheduler) StreamTask(ctx context.Context, command string, args []string, capabilities []string, timeout time.Duration, onChunk runner.ChunkFunc) (*TaskResult, error) {
 	// Select an appropriate cyborg
 	cyborg := s.SelectCyborg(capabilities, timeout)
 	if cyborg == nil {
 		return nil, &NoCyborgAvailableError{Capabilities: capabilities}
 	}
+	s.placed(cyborg.Id, 1)
 	
 	// Create task
 	task := &Task{
-		Cyborg:  cyborg,
-		Command: command,
-		Args:    args,
-		Context: ctx,
-		Result:  make(chan *TaskResult, 1),
-		Timeout: timeout,
+		Cyborg:       cyborg,
+		Command:      command,
+		Args:         args,
+		Context:      ctx,
+		Result:       make(chan *TaskResult, 1),
+		Timeout:      timeout,
+		Capabilities: capabilities,
+		Output:       onChunk,
 	}
 	
 	// Submit to task queue
@@ -191,7 +244,8 @@ func (s *Scheduler) SubmitTask(ctx context.Context, command string, args []strin
 		// Task submitted successfully
 	default:
 		// Task queue is full, return an error
-		return nil, &QueueFullError{}
+		s.placed(cyborg.Id, -1)
+		return nil, &QueueFullError{Capacity: cap(s.taskQueue)}
 	}
 	
 	// Wait for result
@@ -298,17 +352,68 @@ func (s *Scheduler) startWorker() {
 func (s *Scheduler) executeTask(task *Task) *TaskResult {
 	start := time.Now()
 	
-	// Run the command using the execution manager
-	result, err := s.execManager.Run(task.Context, task.Command, task.Args)
+	// Run the task on its cyborg's backend, streaming its output if the
+	// task asked for it
+	result, err := s.executor.Execute(task.Context, &executor.Request{
+		CyborgID:       task.Cyborg.Id,
+		DeploymentSpec: task.Cyborg.DeploymentSpec,
+		Capabilities:   task.Capabilities,
+		Command:        task.Command,
+		Args:           task.Args,
+		Output:         task.Output,
+		Sandbox:        task.Sandbox,
+		Capture:        task.Capture,
+	})
 	
 	duration := time.Since(start)
+	s.finished(task.Cyborg.Id, duration)
+	
+	// Feed the outcome to the cyborg's circuit breaker; a task its backend
+	// refused, such as by execution policy, says nothing about the cyborg
+	if task.Context.Err() != context.Canceled && !errors.Is(err, executor.ErrRefused) {
+		s.breakers.Record(task.Cyborg.Id, task.Capabilities, err != nil)
+	}
+	if result == nil {
+		// The task could not run, leaving no output
+		return &TaskResult{Err: err, Duration: duration, CyborgID: task.Cyborg.Id}
+	}
 	
 	return &TaskResult{
-		Stdout:   result.Stdout,
-		Stderr:   result.Stderr,
-		Err:      err,
-		Duration: duration,
-		CyborgID: task.Cyborg.Id,
+		Stdout:        result.Stdout,
+		Stderr:        result.Stderr,
+		Err:           err,
+		Duration:      duration,
+		CyborgID:      task.Cyborg.Id,
+		StdoutExcerpt: result.StdoutExcerpt,
+		StderrExcerpt: result.StderrExcerpt,
+		Payload:       result.Payload,
+		Status:        result.Status,
+	}
+}
+
+// placed adjusts the count of unfinished tasks placed on a cyborg
+func (s *Scheduler) placed(cyborgID string, delta int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	s.load[cyborgID] += delta
+	if s.load[cyborgID] <= 0 {
+		delete(s.load, cyborgID)
+	}
+}
+
+// finished records that a task on a cyborg ran for d, smoothing the
+// cyborg's execution time with a moving average
+func (s *Scheduler) finished(cyborgID string, d time.Duration) {
+	s.placed(cyborgID, -1)
+	
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if avg, ok := s.latency[cyborgID]; ok {
+		s.latency[cyborgID] = avg + (d-avg)/5
+	} else {
+		s.latency[cyborgID] = d
 	}
 }
 
@@ -327,8 +432,11 @@ func (e *NoCyborgAvailableError) Error() string {
 }
 
 // QueueFullError is returned when the task queue is full
-type QueueFullError struct{}
+type QueueFullError struct {
+	// Capacity is how many tasks the queue holds
+	Capacity int
+}
 
 func (e *QueueFullError) Error() string {
-	return "task queue is full"
+	return fmt.Sprintf("task queue is full (capacity %d)", e.Capacity)
 }
\ No newline at end of file
@@ -0,0 +1,100 @@
+// Package orgchart holds the reporting lines between cyborgs, taken from
+// the organization charts of the job role docs, so work a cyborg cannot do
+// can be escalated to its manager.
+package orgchart
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Chart maps each cyborg to the cyborg it reports to. A nil *Chart has no
+// reporting lines.
+type Chart struct {
+	managers map[string]string
+}
+
+// Load reads a chart from a file, see Parse
+func Load(path string) (*Chart, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open org chart: %w", err)
+	}
+	defer f.Close()
+
+	chart, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("org chart %s: %w", path, err)
+	}
+	return chart, nil
+}
+
+// Parse reads reporting lines of the form "CYBORG -> MANAGER", one per
+// line. Blank lines and text after a # are ignored. A cyborg reports to at
+// most one manager and the lines may not form a cycle.
+func Parse(r io.Reader) (*Chart, error) {
+	chart := &Chart{managers: make(map[string]string)}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		report, manager, ok := strings.Cut(text, "->")
+		report, manager = strings.TrimSpace(report), strings.TrimSpace(manager)
+		if !ok || report == "" || manager == "" {
+			return nil, fmt.Errorf("line %d: want CYBORG -> MANAGER", line)
+		}
+		if report == manager {
+			return nil, fmt.Errorf("line %d: %s cannot report to itself", line, report)
+		}
+		if existing, dup := chart.managers[report]; dup {
+			return nil, fmt.Errorf("line %d: %s already reports to %s", line, report, existing)
+		}
+		chart.managers[report] = manager
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for report := range chart.managers {
+		seen := map[string]bool{report: true}
+		for id, ok := chart.managers[report]; ok; id, ok = chart.managers[id] {
+			if seen[id] {
+				return nil, fmt.Errorf("reporting lines from %s form a cycle", report)
+			}
+			seen[id] = true
+		}
+	}
+	return chart, nil
+}
+
+// Manager returns the cyborg that cyborgID reports to
+func (c *Chart) Manager(cyborgID string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	manager, ok := c.managers[cyborgID]
+	return manager, ok
+}
+
+// Chain returns the managers above cyborgID, nearest first
+func (c *Chart) Chain(cyborgID string) []string {
+	var chain []string
+	for id, ok := c.Manager(cyborgID); ok; id, ok = c.Manager(id) {
+		chain = append(chain, id)
+	}
+	return chain
+}
+
+// Size returns the number of reporting lines
+func (c *Chart) Size() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.managers)
+}
@@ -0,0 +1,76 @@
+package orgchart
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	chart, err := Parse(strings.NewReader(`
+# Engineering
+SWEN1001 -> SWEN0005  # Software Engineer -> Engineering Manager
+SWEN0005->SWEN0001
+`))
+	require.NoError(t, err)
+	assert.Equal(t, 2, chart.Size())
+
+	manager, ok := chart.Manager("SWEN1001")
+	assert.True(t, ok)
+	assert.Equal(t, "SWEN0005", manager)
+	_, ok = chart.Manager("SWEN0001")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"SWEN0005", "SWEN0001"}, chart.Chain("SWEN1001"))
+
+	var none *Chart
+	_, ok = none.Manager("SWEN1001")
+	assert.False(t, ok)
+	assert.Empty(t, none.Chain("SWEN1001"))
+
+	for _, bad := range []string{
+		"SWEN1001 SWEN0005",
+		"SWEN1001 ->",
+		"SWEN1001 -> SWEN1001",
+		"SWEN1001 -> SWEN0005\nSWEN1001 -> SWEN0004",
+		"A -> B\nB -> C\nC -> A",
+	} {
+		_, err := Parse(strings.NewReader(bad))
+		assert.Error(t, err, bad)
+	}
+}
+
+// TestShippedChart tests that the chart shipped with the cyborgs names only
+// known cyborgs and leads every one of them up to the CEO
+func TestShippedChart(t *testing.T) {
+	dir := filepath.Join("..", "..", "..", "cyborgs")
+	chart, err := Load(filepath.Join(dir, "org_chart.txt"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"SWEN0005", "SWEN0004", "SWEN0001", "EXEC0002", "EXEC0001"}, chart.Chain("SWEN1001"))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.txtpb"))
+	require.NoError(t, err)
+	known := make(map[string]bool)
+	for _, f := range files {
+		known[strings.TrimSuffix(filepath.Base(f), ".txtpb")] = true
+	}
+	for id := range known {
+		if id == "EXEC0001" {
+			continue
+		}
+		chain := chart.Chain(id)
+		if assert.NotEmpty(t, chain, id) {
+			assert.Equal(t, "EXEC0001", chain[len(chain)-1], id)
+		}
+		for _, manager := range chain {
+			assert.True(t, known[manager], "%s reports to unknown cyborg %s", id, manager)
+		}
+	}
+
+	_, err = Load(filepath.Join(dir, "missing.txt"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
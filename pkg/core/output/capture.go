@@ -0,0 +1,198 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Limits bounds how much of a stream a Capture keeps in memory
+type Limits struct {
+	// Memory is how many bytes are kept before the stream spills; zero
+	// keeps the whole stream in memory
+	Memory int64 `json:"memory"`
+	// Head and Tail are how many bytes from the start and end of a spilled
+	// stream are kept as excerpts
+	Head int `json:"head"`
+	Tail int `json:"tail"`
+}
+
+// Defaults for capture limits
+const (
+	DefaultCaptureMemory  = 1 << 20
+	DefaultCaptureExcerpt = 16 << 10
+)
+
+// DefaultLimits returns the default capture limits
+func DefaultLimits() Limits {
+	return Limits{Memory: DefaultCaptureMemory, Head: DefaultCaptureExcerpt, Tail: DefaultCaptureExcerpt}
+}
+
+// Excerpt describes a stream that passed its capture's memory limit
+type Excerpt struct {
+	// Size is how many bytes the stream wrote in all
+	Size int64 `json:"size"`
+	// Head and Tail are the start and end of the stream
+	Head []byte `json:"head"`
+	Tail []byte `json:"tail"`
+	// SpillPath is the file holding the whole stream; empty if there was
+	// nowhere to spill to, in which case only the excerpts are kept
+	SpillPath string `json:"spill_path,omitempty"`
+}
+
+// Omitted is how many bytes of the stream the excerpts leave out
+func (e *Excerpt) Omitted() int64 {
+	return e.Size - int64(len(e.Head)) - int64(len(e.Tail))
+}
+
+// Marker is the truncation marker placed between a spilled stream's
+// excerpts
+func (e *Excerpt) Marker() string {
+	if e.SpillPath == "" {
+		return fmt.Sprintf("\n[... %d bytes truncated ...]\n", e.Omitted())
+	}
+	return fmt.Sprintf("\n[... %d bytes truncated, full output in %s ...]\n", e.Omitted(), e.SpillPath)
+}
+
+// SpillFunc returns the path a stream spills to
+type SpillFunc func() (string, error)
+
+// Capture collects a stream of output in bounded memory. Up to the memory
+// limit it keeps everything; past it, the whole stream goes to a spill
+// file and only the head and tail stay in memory. It is safe for
+// concurrent use.
+type Capture struct {
+	mu     sync.Mutex
+	limits Limits
+	spill  SpillFunc
+	size   int64
+	// buf holds the stream until it spills, and the head after
+	buf []byte
+	// tail is a ring of the last limits.Tail bytes once spilled; tailAt is
+	// where the next byte goes
+	tail    []byte
+	tailAt  int
+	spilled bool
+	file    *os.File
+	path    string
+}
+
+// NewCapture creates a capture with the given limits. spill names the file
+// the stream spills to and is called at most once; nil keeps only the
+// excerpts of a stream past the limit.
+func NewCapture(limits Limits, spill SpillFunc) *Capture {
+	return &Capture{limits: limits, spill: spill}
+}
+
+// Write adds output to the capture. A spill file that cannot be written is
+// abandoned, keeping the excerpts; Write itself never fails.
+func (c *Capture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.size += int64(len(p))
+	if !c.spilled {
+		if c.limits.Memory <= 0 || int64(len(c.buf)+len(p)) <= c.limits.Memory {
+			c.buf = append(c.buf, p...)
+			return len(p), nil
+		}
+		c.startSpill()
+	}
+
+	if c.file != nil {
+		if _, err := c.file.Write(p); err != nil {
+			c.abandonSpill()
+		}
+	}
+	c.keepExcerpts(p)
+	return len(p), nil
+}
+
+// startSpill moves what is in memory to the spill file and trims it to
+// the excerpts; callers hold mu
+func (c *Capture) startSpill() {
+	c.spilled = true
+	if c.spill != nil {
+		if path, err := c.spill(); err == nil {
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+				if file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600); err == nil {
+					c.file, c.path = file, path
+				}
+			}
+		}
+	}
+	if c.file != nil {
+		if _, err := c.file.Write(c.buf); err != nil {
+			c.abandonSpill()
+		}
+	}
+
+	held := c.buf
+	c.buf = nil
+	c.tail = make([]byte, 0, c.limits.Tail)
+	c.keepExcerpts(held)
+}
+
+// keepExcerpts adds output past the head to the tail ring; callers hold mu
+func (c *Capture) keepExcerpts(p []byte) {
+	if room := c.limits.Head - len(c.buf); room > 0 {
+		n := min(room, len(p))
+		c.buf = append(c.buf, p[:n]...)
+		p = p[n:]
+	}
+	if c.limits.Tail <= 0 {
+		return
+	}
+	if len(p) >= c.limits.Tail {
+		c.tail = append(c.tail[:0], p[len(p)-c.limits.Tail:]...)
+		c.tailAt = 0
+		return
+	}
+	for _, b := range p {
+		if len(c.tail) < c.limits.Tail {
+			c.tail = append(c.tail, b)
+			continue
+		}
+		c.tail[c.tailAt] = b
+		c.tailAt = (c.tailAt + 1) % c.limits.Tail
+	}
+}
+
+// abandonSpill gives up on a spill file that failed; callers hold mu
+func (c *Capture) abandonSpill() {
+	c.file.Close()
+	os.Remove(c.path)
+	c.file, c.path = nil, ""
+}
+
+// Close closes the spill file, if any
+func (c *Capture) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+// Result returns the captured output and, if the stream passed the memory
+// limit, its excerpt. The output of a spilled stream is its head, a
+// truncation marker and its tail.
+func (c *Capture) Result() ([]byte, *Excerpt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.spilled {
+		return append([]byte(nil), c.buf...), nil
+	}
+
+	excerpt := &Excerpt{
+		Size:      c.size,
+		Head:      append([]byte(nil), c.buf...),
+		Tail:      append(append([]byte(nil), c.tail[c.tailAt:]...), c.tail[:c.tailAt]...),
+		SpillPath: c.path,
+	}
+	out := append(append([]byte(nil), excerpt.Head...), excerpt.Marker()...)
+	return append(out, excerpt.Tail...), excerpt
+}
@@ -0,0 +1,141 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCaptureKeepsSmallOutput tests that output within the memory limit is
+// kept whole
+func TestCaptureKeepsSmallOutput(t *testing.T) {
+	capture := NewCapture(Limits{Memory: 10, Head: 2, Tail: 2}, nil)
+	capture.Write([]byte("hello"))
+	capture.Write([]byte("world"))
+
+	out, excerpt := capture.Result()
+	assert.Equal(t, "helloworld", string(out))
+	assert.Nil(t, excerpt)
+}
+
+// TestCaptureSpills tests that a stream past the memory limit goes whole
+// to its spill file while only its head and tail stay in memory
+func TestCaptureSpills(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job", "stdout.log")
+	capture := NewCapture(Limits{Memory: 8, Head: 3, Tail: 4}, func() (string, error) { return path, nil })
+
+	var written strings.Builder
+	for _, piece := range []string{"abcdef", "ghij", "k", "lmnopqrstuvwxyz", "0", "12"} {
+		capture.Write([]byte(piece))
+		written.WriteString(piece)
+	}
+	require.NoError(t, capture.Close())
+
+	out, excerpt := capture.Result()
+	require.NotNil(t, excerpt)
+	assert.Equal(t, int64(written.Len()), excerpt.Size)
+	assert.Equal(t, "abc", string(excerpt.Head))
+	assert.Equal(t, "z012", string(excerpt.Tail))
+	assert.Equal(t, int64(written.Len()-7), excerpt.Omitted())
+	assert.Equal(t, path, excerpt.SpillPath)
+	assert.Equal(t, "abc"+excerpt.Marker()+"z012", string(out))
+	assert.Contains(t, excerpt.Marker(), "22 bytes truncated")
+
+	spilled, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, written.String(), string(spilled))
+}
+
+// TestCaptureWithoutSpillFile tests that a stream with nowhere to spill
+// keeps its excerpts
+func TestCaptureWithoutSpillFile(t *testing.T) {
+	capture := NewCapture(Limits{Memory: 4, Head: 2, Tail: 2}, nil)
+	capture.Write([]byte("0123456789"))
+
+	out, excerpt := capture.Result()
+	require.NotNil(t, excerpt)
+	assert.Empty(t, excerpt.SpillPath)
+	assert.Equal(t, "01\n[... 6 bytes truncated ...]\n89", string(out))
+}
+
+// TestSpool tests that a job's spilled streams can be read after it
+// finishes, and that streams within the limit are not spilled
+func TestSpool(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewSpool(dir, Limits{Memory: 4, Head: 1, Tail: 1}, time.Hour)
+	require.NoError(t, err)
+	hub := NewHub(10, time.Minute)
+	hub.SpillTo(spool)
+
+	hub.Publish("job-1", Stdout, []byte("first "), time.Now())
+	hub.Publish("job-1", Stdout, []byte("second"), time.Now())
+	hub.Publish("job-1", Stderr, []byte("ok"), time.Now())
+	excerpts := hub.Finish("job-1")
+	require.Len(t, excerpts, 1)
+	assert.Equal(t, int64(12), excerpts[Stdout].Size)
+
+	f, err := spool.Open("job-1", Stdout)
+	require.NoError(t, err)
+	data, err := io.ReadAll(f)
+	f.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "first second", string(data))
+
+	_, err = spool.Open("job-1", Stderr)
+	assert.ErrorIs(t, err, ErrNotSpilled)
+	_, err = spool.Open("../job-1", Stdout)
+	assert.ErrorIs(t, err, ErrNotSpilled)
+
+	// Expired spill files are removed when another job finishes
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "job-1"), old, old))
+	hub.Finish("job-2")
+	_, err = spool.Open("job-1", Stdout)
+	assert.ErrorIs(t, err, ErrNotSpilled)
+
+	var none *Spool
+	none.Write("job-3", Stdout, []byte("x"))
+	assert.Nil(t, none.Finish("job-3"))
+}
+
+// TestSpoolKeepsChunkOrder tests that a job's spill file holds its chunks
+// in sequence order when they are published concurrently
+func TestSpoolKeepsChunkOrder(t *testing.T) {
+	spool, err := NewSpool(t.TempDir(), Limits{Memory: 4, Head: 1, Tail: 1}, time.Hour)
+	require.NoError(t, err)
+	hub := NewHub(0, time.Minute)
+	hub.SpillTo(spool)
+	ch, _ := hub.Watch("job-1", 0)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				hub.Publish("job-1", Stdout, []byte(fmt.Sprintf("%d.%d;", w, i)), time.Now())
+			}
+		}(w)
+	}
+	wg.Wait()
+	hub.Finish("job-1")
+
+	var want strings.Builder
+	for _, chunk := range drain(t, ch) {
+		want.Write(chunk.Data)
+	}
+	f, err := spool.Open("job-1", Stdout)
+	require.NoError(t, err)
+	data, err := io.ReadAll(f)
+	f.Close()
+	require.NoError(t, err)
+	assert.Equal(t, want.String(), string(data))
+}
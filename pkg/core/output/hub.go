@@ -0,0 +1,244 @@
+// Package output relays the stdout and stderr of running jobs to watchers
+// as ordered, timestamped chunks.
+package output
+
+import (
+	"sync"
+	"time"
+)
+
+// Stream names the output a chunk was read from
+type Stream string
+
+const (
+	// Stdout is the job's standard output
+	Stdout Stream = "stdout"
+	// Stderr is the job's standard error
+	Stderr Stream = "stderr"
+)
+
+// Chunk is a piece of a job's output, as read
+type Chunk struct {
+	JobID string `json:"job_id,omitempty"`
+	// Seq numbers the job's chunks from 1 across both streams, in the
+	// order they were read
+	Seq    uint64    `json:"seq"`
+	Stream Stream    `json:"stream"`
+	Data   []byte    `json:"data"`
+	At     time.Time `json:"at"`
+}
+
+// Defaults for a hub
+const (
+	// DefaultBacklog is how many recent chunks a job keeps for watchers
+	// that join late or reconnect
+	DefaultBacklog = 1000
+	// DefaultRetention is how long a finished job's output can still be
+	// watched
+	DefaultRetention = 5 * time.Minute
+	// watchBuffer is how far a watcher may fall behind before it is
+	// dropped
+	watchBuffer = 256
+)
+
+// Hub fans each job's output out to its watchers. Chunks are kept in a
+// bounded backlog, so memory does not grow with the job's output.
+type Hub struct {
+	mu        sync.Mutex
+	jobs      map[string]*jobOutput
+	backlog   int
+	retention time.Duration
+	// spool, if set, also captures every job's output
+	spool *Spool
+}
+
+// jobOutput is the output and watchers of one job
+type jobOutput struct {
+	// spooling orders the job's spool writes, which are made without
+	// holding the hub's mu; it is taken before mu
+	spooling sync.Mutex
+	seq      uint64
+	chunks   []Chunk
+	watchers map[chan Chunk]struct{}
+	// finishedAt is set once the job has finished
+	finishedAt time.Time
+}
+
+// NewHub creates a hub keeping backlog chunks per job for retention after
+// the job finishes; zero values use the defaults
+func NewHub(backlog int, retention time.Duration) *Hub {
+	if backlog <= 0 {
+		backlog = DefaultBacklog
+	}
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	return &Hub{jobs: make(map[string]*jobOutput), backlog: backlog, retention: retention}
+}
+
+// SpillTo has the hub also capture each job's output in spool, so output
+// beyond the backlog can be read once the job finishes
+func (h *Hub) SpillTo(spool *Spool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.spool = spool
+}
+
+// Spool returns the spool capturing the hub's output; nil if there is none
+func (h *Hub) Spool() *Spool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.spool
+}
+
+// job returns a job's output, creating it if needed; callers hold mu
+func (h *Hub) job(jobID string) *jobOutput {
+	out, ok := h.jobs[jobID]
+	if !ok {
+		out = &jobOutput{watchers: make(map[chan Chunk]struct{})}
+		h.jobs[jobID] = out
+	}
+	return out
+}
+
+// Publish numbers a chunk of a job's output and sends it to the job's
+// watchers. A watcher too far behind to take it is dropped; it can watch
+// again from the last chunk it saw.
+func (h *Hub) Publish(jobID string, stream Stream, data []byte, at time.Time) {
+	if h == nil || len(data) == 0 {
+		return
+	}
+	out := h.lockSpooling(jobID)
+	defer out.spooling.Unlock()
+
+	if !out.finishedAt.IsZero() {
+		h.mu.Unlock()
+		return
+	}
+	out.seq++
+	chunk := Chunk{JobID: jobID, Seq: out.seq, Stream: stream, Data: append([]byte(nil), data...), At: at}
+	out.chunks = append(out.chunks, chunk)
+	if len(out.chunks) > h.backlog {
+		out.chunks = append(out.chunks[:0:0], out.chunks[len(out.chunks)-h.backlog:]...)
+	}
+	for ch := range out.watchers {
+		select {
+		case ch <- chunk:
+		default:
+			delete(out.watchers, ch)
+			close(ch)
+		}
+	}
+	spool := h.spool
+	h.mu.Unlock()
+
+	// The job's spooling lock keeps the file in the chunks' order without
+	// stalling other jobs on the write
+	spool.Write(jobID, stream, data)
+}
+
+// lockSpooling returns a job's output, creating it if needed, with its
+// spooling lock and the hub's mu held
+func (h *Hub) lockSpooling(jobID string) *jobOutput {
+	for {
+		h.mu.Lock()
+		out := h.job(jobID)
+		h.mu.Unlock()
+
+		out.spooling.Lock()
+		h.mu.Lock()
+		// The job may have been forgotten in between
+		if h.jobs[jobID] == out {
+			return out
+		}
+		h.mu.Unlock()
+		out.spooling.Unlock()
+	}
+}
+
+// Watch returns a job's chunks after sequence number after: first those
+// still in the backlog, then new ones as they are published. The channel
+// is closed when the job finishes, when the watcher falls too far behind,
+// or when stop is called. A job that has not started yet can be watched.
+func (h *Hub) Watch(jobID string, after uint64) (<-chan Chunk, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	out := h.job(jobID)
+	var backlog []Chunk
+	for _, chunk := range out.chunks {
+		if chunk.Seq > after {
+			backlog = append(backlog, chunk)
+		}
+	}
+	ch := make(chan Chunk, len(backlog)+watchBuffer)
+	for _, chunk := range backlog {
+		ch <- chunk
+	}
+	if !out.finishedAt.IsZero() {
+		close(ch)
+		return ch, func() {}
+	}
+
+	out.watchers[ch] = struct{}{}
+	stop := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := out.watchers[ch]; ok {
+			delete(out.watchers, ch)
+			close(ch)
+		}
+		// Forget a job that was watched but never produced output
+		if len(out.watchers) == 0 && out.seq == 0 && out.finishedAt.IsZero() {
+			delete(h.jobs, jobID)
+		}
+	}
+	return ch, stop
+}
+
+// Finished reports whether a job's output is complete
+func (h *Hub) Finished(jobID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out, ok := h.jobs[jobID]
+	return ok && !out.finishedAt.IsZero()
+}
+
+// Finish marks a job's output complete and closes its watchers. Its
+// backlog stays watchable for the retention period, after which it is
+// dropped along with other expired jobs. It returns the excerpts of the
+// job's streams that spilled, if the hub has a spool.
+func (h *Hub) Finish(jobID string) map[Stream]*Excerpt {
+	if h == nil {
+		return nil
+	}
+	out := h.finish(jobID)
+	// Nothing more is spooled for a finished job once a write in progress
+	// is done
+	out.spooling.Lock()
+	defer out.spooling.Unlock()
+	return h.Spool().Finish(jobID)
+}
+
+// finish marks a job's output complete, drops expired jobs and returns
+// the job's output
+func (h *Hub) finish(jobID string) *jobOutput {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	out := h.job(jobID)
+	if out.finishedAt.IsZero() {
+		out.finishedAt = now
+		for ch := range out.watchers {
+			close(ch)
+		}
+		out.watchers = nil
+	}
+	for id, other := range h.jobs {
+		if !other.finishedAt.IsZero() && now.Sub(other.finishedAt) > h.retention {
+			delete(h.jobs, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drain collects what a watcher receives until its channel is closed
+func drain(t *testing.T, ch <-chan Chunk) []Chunk {
+	var chunks []Chunk
+	for {
+		select {
+		case chunk, ok := <-ch:
+			if !ok {
+				return chunks
+			}
+			chunks = append(chunks, chunk)
+		case <-time.After(time.Second):
+			t.Fatal("watch was not closed")
+		}
+	}
+}
+
+// TestHubOrdersAndReplaysChunks tests that watchers see a job's chunks in
+// order, that late watchers catch up from the backlog, and that watches
+// end when the job finishes
+func TestHubOrdersAndReplaysChunks(t *testing.T) {
+	hub := NewHub(3, time.Minute)
+	now := time.Now()
+
+	early, _ := hub.Watch("job-1", 0)
+	hub.Publish("job-1", Stdout, []byte("a"), now)
+	hub.Publish("job-1", Stderr, []byte("b"), now)
+	hub.Publish("job-1", Stdout, nil, now)
+	hub.Publish("job-1", Stdout, []byte("c"), now)
+	hub.Publish("job-1", Stdout, []byte("d"), now)
+
+	// A late watcher only gets what the backlog still holds after the
+	// sequence number it saw
+	late, _ := hub.Watch("job-1", 2)
+	assert.False(t, hub.Finished("job-1"))
+	hub.Finish("job-1")
+	assert.True(t, hub.Finished("job-1"))
+
+	chunks := drain(t, early)
+	require.Len(t, chunks, 4)
+	for i, chunk := range chunks {
+		assert.Equal(t, uint64(i+1), chunk.Seq)
+		assert.Equal(t, "job-1", chunk.JobID)
+	}
+	assert.Equal(t, Stderr, chunks[1].Stream)
+	assert.Equal(t, []byte("d"), chunks[3].Data)
+
+	chunks = drain(t, late)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, uint64(3), chunks[0].Seq)
+
+	// A finished job can still be watched, and takes no more output
+	hub.Publish("job-1", Stdout, []byte("e"), now)
+	replay, _ := hub.Watch("job-1", 0)
+	assert.Len(t, drain(t, replay), 3)
+}
+
+// TestHubDropsSlowWatchers tests that a watcher that stops reading is
+// dropped rather than holding up the job
+func TestHubDropsSlowWatchers(t *testing.T) {
+	hub := NewHub(10, time.Minute)
+	slow, _ := hub.Watch("job-1", 0)
+	fast, stop := hub.Watch("job-1", 0)
+
+	for i := 0; i < watchBuffer+1; i++ {
+		hub.Publish("job-1", Stdout, []byte("x"), time.Now())
+		if i < watchBuffer {
+			<-fast
+		}
+	}
+	chunks := drain(t, slow)
+	assert.Len(t, chunks, watchBuffer)
+	assert.Equal(t, uint64(watchBuffer+1), (<-fast).Seq)
+
+	stop()
+	_, open := <-fast
+	assert.False(t, open)
+	stop()
+}
@@ -0,0 +1,134 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSpillRetention is how long a finished job's spill files are kept
+const DefaultSpillRetention = 24 * time.Hour
+
+// ErrNotSpilled is returned for a job stream that has no spill file
+var ErrNotSpilled = errors.New("output was not spilled")
+
+// Spool captures each running job's streams in bounded memory, spilling a
+// stream past the memory limit to its job's directory, where it can be
+// read after the job finishes. Spill files are removed once they are older
+// than the retention period. A nil *Spool captures nothing.
+type Spool struct {
+	dir       string
+	limits    Limits
+	retention time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]map[Stream]*Capture
+}
+
+// NewSpool creates a spool keeping spill files under dir for retention;
+// zero retention uses DefaultSpillRetention
+func NewSpool(dir string, limits Limits, retention time.Duration) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spill directory: %w", err)
+	}
+	if retention <= 0 {
+		retention = DefaultSpillRetention
+	}
+	return &Spool{dir: dir, limits: limits, retention: retention, jobs: make(map[string]map[Stream]*Capture)}, nil
+}
+
+// Path returns the file a job's stream spills to
+func (s *Spool) Path(jobID string, stream Stream) string {
+	return filepath.Join(s.dir, jobID, string(stream)+".log")
+}
+
+// Write adds output to a job's stream
+func (s *Spool) Write(jobID string, stream Stream, data []byte) {
+	if s == nil || !validJobID(jobID) {
+		return
+	}
+	s.mu.Lock()
+	streams, ok := s.jobs[jobID]
+	if !ok {
+		streams = make(map[Stream]*Capture)
+		s.jobs[jobID] = streams
+	}
+	capture, ok := streams[stream]
+	if !ok {
+		path := s.Path(jobID, stream)
+		capture = NewCapture(s.limits, func() (string, error) { return path, nil })
+		streams[stream] = capture
+	}
+	s.mu.Unlock()
+	capture.Write(data)
+}
+
+// Finish closes a job's captures and returns the excerpts of its streams
+// that spilled, by stream; nil if none did. Expired spill files are
+// removed along the way.
+func (s *Spool) Finish(jobID string) map[Stream]*Excerpt {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	streams := s.jobs[jobID]
+	delete(s.jobs, jobID)
+	s.mu.Unlock()
+
+	var excerpts map[Stream]*Excerpt
+	for stream, capture := range streams {
+		capture.Close()
+		if _, excerpt := capture.Result(); excerpt != nil {
+			if excerpts == nil {
+				excerpts = make(map[Stream]*Excerpt)
+			}
+			excerpts[stream] = excerpt
+		}
+	}
+	s.expire()
+	return excerpts
+}
+
+// Open opens the spill file of a job's stream. It returns ErrNotSpilled if
+// the stream never passed the memory limit, or its file has expired.
+func (s *Spool) Open(jobID string, stream Stream) (*os.File, error) {
+	if s == nil || !validJobID(jobID) {
+		return nil, ErrNotSpilled
+	}
+	f, err := os.Open(s.Path(jobID, stream))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotSpilled
+	}
+	return f, err
+}
+
+// expire removes the spill directories of finished jobs not written to
+// within the retention period
+func (s *Spool) expire() {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-s.retention)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !entry.IsDir() || info.ModTime().After(cutoff) {
+			continue
+		}
+		s.mu.Lock()
+		_, running := s.jobs[entry.Name()]
+		s.mu.Unlock()
+		if !running {
+			os.RemoveAll(filepath.Join(s.dir, entry.Name()))
+		}
+	}
+}
+
+// validJobID reports whether a job ID is safe to name a directory after
+func validJobID(jobID string) bool {
+	return jobID != "" && jobID != "." && jobID != ".." && !strings.ContainsAny(jobID, `/\`)
+}
@@ -3,21 +3,49 @@ package pb
 import (
 	"fmt"
 	"io/ioutil"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 	"github.com/toxicoder/cyborg-conductor-core/pkg/core/types"
 	"google.golang.org/protobuf/proto"
 )
 
 // Registry manages all registered cyborgs with their capabilities and configurations
 type Registry struct {
-	mu    sync.RWMutex
-	items map[string]*types.CyborgDescriptor
+	mu     sync.RWMutex
+	items  map[string]*types.CyborgDescriptor
+	status map[string]*CyborgStatus
+}
+
+// Cyborg availability derived from circuit breaker states
+const (
+	// StatusAvailable means every breaker for the cyborg is closed
+	StatusAvailable = "available"
+	// StatusDegraded means some capabilities are tripped or being probed
+	StatusDegraded = "degraded"
+	// StatusUnavailable means the breaker for the whole cyborg is open
+	StatusUnavailable = "unavailable"
+)
+
+// CyborgStatus is the live health of a registered cyborg
+type CyborgStatus struct {
+	// State is StatusAvailable, StatusDegraded or StatusUnavailable
+	State string `json:"state"`
+	// Breakers maps a capability name, or "" for the cyborg as a whole, to
+	// the state of its circuit breaker
+	Breakers  map[string]string `json:"breakers,omitempty"`
+	UpdatedAt time.Time         `json:"updated_at"`
 }
 
 // NewRegistry creates a new thread-safe cyborg registry
 func NewRegistry() *Registry {
-	return &Registry{items: make(map[string]*types.CyborgDescriptor)}
+	return &Registry{
+		items:  make(map[string]*types.CyborgDescriptor),
+		status: make(map[string]*CyborgStatus),
+	}
 }
 
 // Register adds a cyborg descriptor to the registry
@@ -89,9 +117,99 @@ func (r *Registry) LoadFromTxtpb(dir string) error {
 	return nil
 }
 
+// Find returns the registered cyborgs whose ID matches pattern, a glob such
+// as "SALE*", and that carry every one of tags, sorted by ID. An empty
+// pattern matches every ID.
+func (r *Registry) Find(pattern string, tags []string) ([]*types.CyborgDescriptor, error) {
+	if pattern != "" {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid cyborg pattern %q: %w", pattern, err)
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*types.CyborgDescriptor
+	for id, descriptor := range r.items {
+		if pattern != "" {
+			if ok, _ := path.Match(pattern, id); !ok {
+				continue
+			}
+		}
+		if !HasTags(descriptor, tags) {
+			continue
+		}
+		result = append(result, descriptor)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CyborgID < result[j].CyborgID })
+	return result, nil
+}
+
+// HasTags reports whether a descriptor's comma-separated tags include all
+// of the wanted ones, ignoring case
+func HasTags(descriptor *types.CyborgDescriptor, wanted []string) bool {
+	have := make(map[string]bool)
+	for _, tag := range strings.Split(string(descriptor.Tags), ",") {
+		have[strings.ToUpper(strings.TrimSpace(tag))] = true
+	}
+	for _, tag := range wanted {
+		if !have[strings.ToUpper(strings.TrimSpace(tag))] {
+			return false
+		}
+	}
+	return true
+}
+
 // Size returns the number of registered cyborgs
 func (r *Registry) Size() int {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	return len(r.items)
-}
\ No newline at end of file
+}
+
+// SetBreakerState records the circuit breaker state for a cyborg, or for one
+// of its capabilities, and recomputes the cyborg's status
+func (r *Registry) SetBreakerState(id, capability, state string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status, exists := r.status[id]
+	if !exists {
+		status = &CyborgStatus{Breakers: make(map[string]string)}
+		r.status[id] = status
+	}
+	status.Breakers[capability] = state
+	status.UpdatedAt = time.Now()
+
+	status.State = StatusAvailable
+	for name, breakerState := range status.Breakers {
+		switch {
+		case name == "" && breakerState == "open":
+			status.State = StatusUnavailable
+		case breakerState != "closed" && status.State == StatusAvailable:
+			status.State = StatusDegraded
+		}
+	}
+}
+
+// Status returns the live status of a registered cyborg. Cyborgs without
+// breaker activity are available.
+func (r *Registry) Status(id string) (CyborgStatus, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.items[id]; !exists {
+		return CyborgStatus{}, false
+	}
+	status, exists := r.status[id]
+	if !exists {
+		return CyborgStatus{State: StatusAvailable}, true
+	}
+	result := *status
+	result.Breakers = make(map[string]string, len(status.Breakers))
+	for name, state := range status.Breakers {
+		result.Breakers[name] = state
+	}
+	return result, true
+}
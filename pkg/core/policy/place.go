@@ -0,0 +1,47 @@
+package policy
+
+// HasCapabilities reports whether have includes every capability in
+// required
+func HasCapabilities(have, required []string) bool {
+	set := make(map[string]bool, len(have))
+	for _, capability := range have {
+		set[capability] = true
+	}
+	for _, capability := range required {
+		if !set[capability] {
+			return false
+		}
+	}
+	return true
+}
+
+// Place picks a cyborg for a job that needs the required capabilities.
+// capabilities lists each cyborg's capabilities by index. Of the cyborgs
+// that have every required capability and that describe reports
+// available, p picks one and allow admits it; a refused pick is dropped
+// and p picks again, as in Choose. Place returns the index of the cyborg,
+// or -1 if none is picked. A nil allow admits every pick.
+func Place(p SchedulingPolicy, req Request, required []string, capabilities [][]string,
+	describe func(i int) (Candidate, bool), allow func(i int) bool) int {
+	var capable []int
+	var candidates []Candidate
+	for i, have := range capabilities {
+		if !HasCapabilities(have, required) {
+			continue
+		}
+		if candidate, ok := describe(i); ok {
+			capable = append(capable, i)
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	var admit func(int) bool
+	if allow != nil {
+		admit = func(i int) bool { return allow(capable[i]) }
+	}
+	i := Choose(p, req, candidates, admit)
+	if i < 0 {
+		return -1
+	}
+	return capable[i]
+}
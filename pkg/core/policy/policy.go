@@ -0,0 +1,297 @@
+// Package policy holds the scheduling policies that decide which of the
+// cyborgs able to take a job runs it. The conductor, the orchestrator and
+// the scheduling simulator all place work through them, and the policy in
+// force can be chosen per namespace or per job class and changed at runtime.
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Built-in policy names
+const (
+	// FirstFit takes the first candidate in ID order
+	FirstFit = "first-fit"
+	// LeastLoaded takes the candidate with the most free capacity
+	LeastLoaded = "least-loaded"
+	// WeightedScore takes the candidate with the best blend of free
+	// capacity, latency, cost and reliability
+	WeightedScore = "weighted-score"
+	// RoundRobin rotates through the candidates, separately per job class
+	RoundRobin = "round-robin"
+	// CostOptimized takes the cheapest candidate, then the least loaded
+	CostOptimized = "cost-optimized"
+)
+
+// Candidate is a cyborg able to take the job being placed
+type Candidate struct {
+	ID string
+	// Load is how many jobs the cyborg is running
+	Load int
+	// Capacity is how many jobs the cyborg runs at once; zero means no
+	// limit
+	Capacity int
+	// Latency is the cyborg's typical execution time; zero when unknown
+	Latency time.Duration
+	// Cost is what a job on the cyborg typically costs; zero when unknown
+	Cost float64
+	// Reliability ranks the cyborg's reliability tier from 0 (unknown) to
+	// 1 (five nines)
+	Reliability float64
+}
+
+// headroom is the share of the candidate's capacity that is free. A
+// cyborg without a limit has less headroom the more jobs it runs.
+func (c Candidate) headroom() float64 {
+	if c.Capacity > 0 {
+		return 1 - float64(c.Load)/float64(c.Capacity)
+	}
+	return 1 / float64(1+c.Load)
+}
+
+// Request describes the job being placed
+type Request struct {
+	JobID     string
+	Namespace string
+	// Class groups jobs that share a policy, such as "batch"
+	Class    string
+	Priority int32
+}
+
+// SchedulingPolicy picks the cyborg a job runs on. Select is given the
+// candidates sorted by ID, every one of them able to take the job, and
+// returns the index of its choice, or -1 to leave the job unplaced.
+// Implementations must be safe for concurrent use.
+type SchedulingPolicy interface {
+	Name() string
+	Select(req Request, candidates []Candidate) int
+}
+
+// Choose lets p pick one of candidates that allow admits and returns its
+// index in candidates, or -1 if none is picked. A refused pick is dropped
+// and p picks again from the rest. A nil allow admits every pick.
+func Choose(p SchedulingPolicy, req Request, candidates []Candidate, allow func(i int) bool) int {
+	remaining := append([]Candidate(nil), candidates...)
+	index := make([]int, len(candidates))
+	for i := range index {
+		index[i] = i
+	}
+
+	for len(remaining) > 0 {
+		i := p.Select(req, remaining)
+		if i < 0 || i >= len(remaining) {
+			return -1
+		}
+		if allow == nil || allow(index[i]) {
+			return index[i]
+		}
+		remaining = append(remaining[:i:i], remaining[i+1:]...)
+		index = append(index[:i:i], index[i+1:]...)
+	}
+	return -1
+}
+
+// firstFit implements FirstFit
+type firstFit struct{}
+
+func (firstFit) Name() string { return FirstFit }
+
+func (firstFit) Select(_ Request, candidates []Candidate) int {
+	if len(candidates) == 0 {
+		return -1
+	}
+	return 0
+}
+
+// leastLoaded implements LeastLoaded; ties go to the fewest running jobs,
+// then to the first candidate
+type leastLoaded struct{}
+
+func (leastLoaded) Name() string { return LeastLoaded }
+
+func (leastLoaded) Select(_ Request, candidates []Candidate) int {
+	best := -1
+	for i, c := range candidates {
+		if best < 0 || lessLoaded(c, candidates[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// lessLoaded reports whether a has more headroom than b
+func lessLoaded(a, b Candidate) bool {
+	if ha, hb := a.headroom(), b.headroom(); ha != hb {
+		return ha > hb
+	}
+	return a.Load < b.Load
+}
+
+// costOptimized implements CostOptimized. Cyborgs of unknown cost count as
+// free, so their cost gets measured.
+type costOptimized struct{}
+
+func (costOptimized) Name() string { return CostOptimized }
+
+func (costOptimized) Select(_ Request, candidates []Candidate) int {
+	best := -1
+	for i, c := range candidates {
+		if best < 0 || c.Cost < candidates[best].Cost ||
+			(c.Cost == candidates[best].Cost && lessLoaded(c, candidates[best])) {
+			best = i
+		}
+	}
+	return best
+}
+
+// roundRobin implements RoundRobin. It takes the first candidate after the
+// one it last chose for the class, so cyborgs joining or leaving do not
+// reset the rotation.
+type roundRobin struct {
+	mu   sync.Mutex
+	last map[string]string
+}
+
+func newRoundRobin() *roundRobin {
+	return &roundRobin{last: make(map[string]string)}
+}
+
+func (*roundRobin) Name() string { return RoundRobin }
+
+func (r *roundRobin) Select(req Request, candidates []Candidate) int {
+	if len(candidates) == 0 {
+		return -1
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next := 0
+	if last, ok := r.last[req.Class]; ok {
+		next = sort.Search(len(candidates), func(i int) bool { return candidates[i].ID > last })
+		if next == len(candidates) {
+			next = 0
+		}
+	}
+	r.last[req.Class] = candidates[next].ID
+	return next
+}
+
+// Weights balance the factors of the weighted-score policy. Each factor is
+// scored from 0 to 1 among the candidates, and the candidate with the
+// highest weighted sum wins.
+type Weights struct {
+	// Load favours free capacity
+	Load float64 `json:"load"`
+	// Latency favours cyborgs that finish fastest
+	Latency float64 `json:"latency"`
+	// Cost favours the cheapest cyborgs
+	Cost float64 `json:"cost"`
+	// Reliability favours higher reliability tiers
+	Reliability float64 `json:"reliability"`
+}
+
+// DefaultWeights are the weights used when none are configured
+var DefaultWeights = Weights{Load: 0.4, Latency: 0.3, Cost: 0.2, Reliability: 0.1}
+
+// ParseWeights reads weights from comma-separated FACTOR=WEIGHT entries,
+// such as "load=0.5,cost=0.5". Factors left out weigh nothing; an empty
+// spec returns DefaultWeights.
+func ParseWeights(spec string) (Weights, error) {
+	if strings.TrimSpace(spec) == "" {
+		return DefaultWeights, nil
+	}
+	var w Weights
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		factor, weight, ok := strings.Cut(entry, "=")
+		if !ok {
+			return Weights{}, fmt.Errorf("weight %q: want FACTOR=WEIGHT", entry)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+		if err != nil || value < 0 {
+			return Weights{}, fmt.Errorf("weight %q: weight must be a non-negative number", entry)
+		}
+		switch strings.TrimSpace(factor) {
+		case "load":
+			w.Load = value
+		case "latency":
+			w.Latency = value
+		case "cost":
+			w.Cost = value
+		case "reliability":
+			w.Reliability = value
+		default:
+			return Weights{}, fmt.Errorf("weight %q: factor must be load, latency, cost or reliability", entry)
+		}
+	}
+	return w, nil
+}
+
+// weightedScore implements WeightedScore. Unknown latency and cost score
+// as well as the best candidate's, so new cyborgs get measured.
+type weightedScore struct {
+	weights Weights
+}
+
+func (*weightedScore) Name() string { return WeightedScore }
+
+func (p *weightedScore) Select(_ Request, candidates []Candidate) int {
+	var fastest time.Duration
+	cheapest := -1.0
+	for _, c := range candidates {
+		if c.Latency > 0 && (fastest == 0 || c.Latency < fastest) {
+			fastest = c.Latency
+		}
+		if c.Cost > 0 && (cheapest < 0 || c.Cost < cheapest) {
+			cheapest = c.Cost
+		}
+	}
+
+	best, bestScore := -1, 0.0
+	for i, c := range candidates {
+		latency, cost := 1.0, 1.0
+		if c.Latency > 0 {
+			latency = float64(fastest) / float64(c.Latency)
+		}
+		if c.Cost > 0 {
+			cost = cheapest / c.Cost
+		}
+		score := p.weights.Load*c.headroom() +
+			p.weights.Latency*latency +
+			p.weights.Cost*cost +
+			p.weights.Reliability*c.Reliability
+		if best < 0 || score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// New returns a fresh instance of the built-in policy called name. Weights
+// apply to the weighted-score policy.
+func New(name string, weights Weights) (SchedulingPolicy, error) {
+	switch name {
+	case FirstFit:
+		return firstFit{}, nil
+	case LeastLoaded:
+		return leastLoaded{}, nil
+	case WeightedScore:
+		return &weightedScore{weights: weights}, nil
+	case RoundRobin:
+		return newRoundRobin(), nil
+	case CostOptimized:
+		return costOptimized{}, nil
+	}
+	return nil, fmt.Errorf("unknown scheduling policy %q", name)
+}
+
+// Builtin lists the names of the built-in policies
+var Builtin = []string{FirstFit, LeastLoaded, WeightedScore, RoundRobin, CostOptimized}
@@ -0,0 +1,161 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinPolicies(t *testing.T) {
+	candidates := []Candidate{
+		{ID: "A", Load: 3, Capacity: 4, Latency: 100 * time.Millisecond, Cost: 0.02, Reliability: 0.5},
+		{ID: "B", Load: 1, Capacity: 4, Latency: 400 * time.Millisecond, Cost: 0.05, Reliability: 0.25},
+		{ID: "C", Load: 1, Capacity: 2, Latency: 200 * time.Millisecond, Cost: 0.01, Reliability: 1},
+	}
+	req := Request{JobID: "job-1"}
+
+	selectWith := func(name string) string {
+		p, err := New(name, DefaultWeights)
+		require.NoError(t, err)
+		assert.Equal(t, name, p.Name())
+		i := p.Select(req, candidates)
+		require.GreaterOrEqual(t, i, 0, name)
+		return candidates[i].ID
+	}
+	assert.Equal(t, "A", selectWith(FirstFit))
+	assert.Equal(t, "B", selectWith(LeastLoaded))
+	assert.Equal(t, "C", selectWith(CostOptimized))
+	assert.Equal(t, "C", selectWith(WeightedScore))
+
+	// Load alone makes the weighted score least-loaded
+	p, err := New(WeightedScore, Weights{Load: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.Select(req, candidates))
+
+	for _, name := range Builtin {
+		p, err := New(name, DefaultWeights)
+		require.NoError(t, err)
+		assert.Equal(t, -1, p.Select(req, nil), name)
+	}
+	_, err = New("random", DefaultWeights)
+	assert.Error(t, err)
+}
+
+func TestChoose(t *testing.T) {
+	p, err := New(LeastLoaded, DefaultWeights)
+	require.NoError(t, err)
+	candidates := []Candidate{{ID: "A", Load: 2}, {ID: "B", Load: 0}, {ID: "C", Load: 1}}
+
+	assert.Equal(t, 1, Choose(p, Request{}, candidates, nil))
+
+	// Refused picks are dropped and the policy picks again
+	var asked []string
+	i := Choose(p, Request{}, candidates, func(i int) bool {
+		asked = append(asked, candidates[i].ID)
+		return candidates[i].ID == "A"
+	})
+	assert.Equal(t, 0, i)
+	assert.Equal(t, []string{"B", "C", "A"}, asked)
+
+	assert.Equal(t, -1, Choose(p, Request{}, candidates, func(int) bool { return false }))
+	assert.Equal(t, -1, Choose(p, Request{}, nil, nil))
+}
+
+func TestPlace(t *testing.T) {
+	p, err := New(LeastLoaded, DefaultWeights)
+	require.NoError(t, err)
+	capabilities := [][]string{{"pipeline"}, {"pipeline", "forecast"}, {"forecast"}, {"pipeline", "forecast"}}
+	load := []int{0, 2, 0, 1}
+	describe := func(i int) (Candidate, bool) {
+		return Candidate{ID: string(rune('A' + i)), Load: load[i]}, i != 3 || load[3] < 5
+	}
+
+	assert.True(t, HasCapabilities([]string{"pipeline", "forecast"}, []string{"forecast"}))
+	assert.False(t, HasCapabilities([]string{"pipeline"}, []string{"forecast"}))
+	assert.True(t, HasCapabilities(nil, nil))
+
+	// Only cyborgs with both capabilities are candidates
+	assert.Equal(t, 3, Place(p, Request{}, []string{"pipeline", "forecast"}, capabilities, describe, nil))
+
+	// A refused pick falls to the next
+	assert.Equal(t, 1, Place(p, Request{}, []string{"pipeline", "forecast"}, capabilities, describe,
+		func(i int) bool { return i != 3 }))
+
+	// Unavailable cyborgs are not candidates
+	load[3] = 5
+	assert.Equal(t, 1, Place(p, Request{}, []string{"pipeline", "forecast"}, capabilities, describe, nil))
+	assert.Equal(t, -1, Place(p, Request{}, []string{"payroll"}, capabilities, describe, nil))
+}
+
+func TestRoundRobinRotatesPerClass(t *testing.T) {
+	p, err := New(RoundRobin, DefaultWeights)
+	require.NoError(t, err)
+	candidates := []Candidate{{ID: "A"}, {ID: "B"}, {ID: "C"}}
+
+	var batch []string
+	for i := 0; i < 4; i++ {
+		batch = append(batch, candidates[p.Select(Request{Class: "batch"}, candidates)].ID)
+	}
+	assert.Equal(t, []string{"A", "B", "C", "A"}, batch)
+
+	// Other classes keep their own place in the rotation
+	assert.Equal(t, 0, p.Select(Request{Class: "interactive"}, candidates))
+
+	// A cyborg leaving does not restart the rotation
+	assert.Equal(t, 1, p.Select(Request{Class: "batch"}, []Candidate{{ID: "A"}, {ID: "C"}}))
+}
+
+func TestParseWeights(t *testing.T) {
+	w, err := ParseWeights("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultWeights, w)
+
+	w, err = ParseWeights("load=0.5, cost=0.5")
+	require.NoError(t, err)
+	assert.Equal(t, Weights{Load: 0.5, Cost: 0.5}, w)
+
+	for _, bad := range []string{"load", "load=-1", "speed=1", "load=x"} {
+		_, err := ParseWeights(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestSetAssignments(t *testing.T) {
+	a, err := ParseAssignments(LeastLoaded, "namespace.finance=cost-optimized, class.batch=round-robin")
+	require.NoError(t, err)
+	assert.Equal(t, Assignments{
+		Default:    LeastLoaded,
+		Namespaces: map[string]string{"finance": CostOptimized},
+		Classes:    map[string]string{"batch": RoundRobin},
+	}, a)
+
+	s := NewSet(DefaultWeights)
+	assert.Equal(t, FirstFit, s.For(Request{Namespace: "finance"}).Name())
+	require.NoError(t, s.Assign(a))
+
+	assert.Equal(t, LeastLoaded, s.For(Request{Namespace: "ops"}).Name())
+	assert.Equal(t, CostOptimized, s.For(Request{Namespace: "finance"}).Name())
+	assert.Equal(t, RoundRobin, s.For(Request{Namespace: "finance", Class: "batch"}).Name())
+
+	// An unknown policy leaves the assignments in force
+	assert.Error(t, s.Assign(Assignments{Default: FirstFit, Classes: map[string]string{"batch": "random"}}))
+	assert.Equal(t, a, s.Assignments())
+
+	// The returned assignments are a copy
+	s.Assignments().Namespaces["finance"] = FirstFit
+	assert.Equal(t, CostOptimized, s.For(Request{Namespace: "finance"}).Name())
+
+	require.NoError(t, s.Assign(Assignments{}))
+	assert.Equal(t, FirstFit, s.For(Request{Namespace: "finance", Class: "batch"}).Name())
+	assert.ElementsMatch(t, Builtin, s.Names())
+
+	var none *Set
+	assert.Equal(t, FirstFit, none.For(Request{}).Name())
+
+	for _, bad := range []string{"finance=first-fit", "team.finance=first-fit", "namespace.=first-fit"} {
+		_, err := ParseAssignments(FirstFit, bad)
+		assert.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,165 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Assignments say which policy places which jobs. A job class's policy
+// wins over its namespace's, which wins over the default.
+type Assignments struct {
+	Default    string            `json:"default"`
+	Namespaces map[string]string `json:"namespaces"`
+	Classes    map[string]string `json:"classes"`
+}
+
+// ParseAssignments reads per-namespace and per-class policies from
+// comma-separated namespace.NAME=POLICY and class.NAME=POLICY entries, such
+// as "namespace.finance=cost-optimized,class.batch=round-robin"
+func ParseAssignments(defaultPolicy, spec string) (Assignments, error) {
+	a := Assignments{
+		Default:    defaultPolicy,
+		Namespaces: make(map[string]string),
+		Classes:    make(map[string]string),
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		target, name, ok := strings.Cut(entry, "=")
+		kind, key, hasKey := strings.Cut(strings.TrimSpace(target), ".")
+		if !ok || !hasKey || key == "" {
+			return Assignments{}, fmt.Errorf("policy assignment %q: want namespace.NAME=POLICY or class.NAME=POLICY", entry)
+		}
+		name = strings.TrimSpace(name)
+		switch kind {
+		case "namespace":
+			a.Namespaces[key] = name
+		case "class":
+			a.Classes[key] = name
+		default:
+			return Assignments{}, fmt.Errorf("policy assignment %q: must assign a namespace or a class", entry)
+		}
+	}
+	return a, nil
+}
+
+// Set holds the available policies and which jobs each one places. It is
+// safe for concurrent use, and assignments can be replaced while jobs are
+// being placed. A nil *Set places every job first-fit.
+type Set struct {
+	mu          sync.RWMutex
+	policies    map[string]SchedulingPolicy
+	assignments Assignments
+}
+
+// NewSet creates a set of the built-in policies, with weights for the
+// weighted-score policy, placing every job first-fit until assigned
+// otherwise
+func NewSet(weights Weights) *Set {
+	s := &Set{policies: make(map[string]SchedulingPolicy)}
+	for _, name := range Builtin {
+		p, _ := New(name, weights)
+		s.policies[name] = p
+	}
+	s.assignments = Assignments{Default: FirstFit}
+	return s
+}
+
+// Register adds a policy, replacing any policy of the same name
+func (s *Set) Register(p SchedulingPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policies[p.Name()] = p
+}
+
+// Names returns the names of the available policies, sorted
+func (s *Set) Names() []string {
+	if s == nil {
+		return []string{FirstFit}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.policies))
+	for name := range s.policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Assign replaces the assignments. It fails, leaving the current ones in
+// force, when one names a policy the set does not have. An empty default
+// means first-fit.
+func (s *Set) Assign(a Assignments) error {
+	if a.Default == "" {
+		a.Default = FirstFit
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.policies[a.Default]; !ok {
+		return fmt.Errorf("default: unknown scheduling policy %q", a.Default)
+	}
+	for namespace, name := range a.Namespaces {
+		if _, ok := s.policies[name]; !ok {
+			return fmt.Errorf("namespace %s: unknown scheduling policy %q", namespace, name)
+		}
+	}
+	for class, name := range a.Classes {
+		if _, ok := s.policies[name]; !ok {
+			return fmt.Errorf("class %s: unknown scheduling policy %q", class, name)
+		}
+	}
+	s.assignments = Assignments{
+		Default:    a.Default,
+		Namespaces: copyMap(a.Namespaces),
+		Classes:    copyMap(a.Classes),
+	}
+	return nil
+}
+
+// Assignments returns a copy of the assignments in force
+func (s *Set) Assignments() Assignments {
+	if s == nil {
+		return Assignments{Default: FirstFit, Namespaces: map[string]string{}, Classes: map[string]string{}}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return Assignments{
+		Default:    s.assignments.Default,
+		Namespaces: copyMap(s.assignments.Namespaces),
+		Classes:    copyMap(s.assignments.Classes),
+	}
+}
+
+// For returns the policy that places req
+func (s *Set) For(req Request) SchedulingPolicy {
+	if s == nil {
+		return firstFit{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	name := s.assignments.Default
+	if n, ok := s.assignments.Namespaces[req.Namespace]; ok && req.Namespace != "" {
+		name = n
+	}
+	if n, ok := s.assignments.Classes[req.Class]; ok && req.Class != "" {
+		name = n
+	}
+	return s.policies[name]
+}
+
+// copyMap returns a copy of m that is never nil
+func copyMap(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
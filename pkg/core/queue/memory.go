@@ -0,0 +1,201 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// memoryEntry is a message with its delivery state
+type memoryEntry struct {
+	msg       Message
+	visibleAt time.Time
+	token     string
+	seq       uint64
+}
+
+// MemoryQueue is a Queue held in process memory. Its contents are lost
+// when the process exits, so it is meant for tests and single-shot runs.
+type MemoryQueue struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*memoryEntry
+	seq      uint64
+
+	// partitions limits Dequeue to these partitions when non-nil
+	partitions map[int]bool
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// NewMemoryQueue creates an in-memory queue holding at most capacity
+// messages; a capacity of zero or less means unbounded
+func NewMemoryQueue(capacity int) *MemoryQueue {
+	return &MemoryQueue{
+		capacity: capacity,
+		entries:  make(map[string]*memoryEntry),
+		now:      time.Now,
+	}
+}
+
+// Enqueue adds a message that becomes visible after delay
+func (q *MemoryQueue) Enqueue(ctx context.Context, msg *Message, delay time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, exists := q.entries[msg.ID]; exists {
+		return ErrDuplicate
+	}
+	if q.capacity > 0 && len(q.entries) >= q.capacity {
+		return ErrFull
+	}
+
+	now := q.now()
+	entry := &memoryEntry{msg: *msg, visibleAt: now.Add(delay), seq: q.seq}
+	if entry.msg.EnqueuedAt.IsZero() {
+		entry.msg.EnqueuedAt = now
+	}
+	q.seq++
+	q.entries[msg.ID] = entry
+	return nil
+}
+
+// Dequeue leases the most urgent visible message
+func (q *MemoryQueue) Dequeue(ctx context.Context, visibility time.Duration) (*Lease, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	var best *memoryEntry
+	for _, entry := range q.entries {
+		if entry.visibleAt.After(now) {
+			continue
+		}
+		if q.partitions != nil && !q.partitions[PartitionOf(entry.msg.ID)] {
+			continue
+		}
+		if best == nil || entry.msg.Priority < best.msg.Priority ||
+			(entry.msg.Priority == best.msg.Priority && entry.seq < best.seq) {
+			best = entry
+		}
+	}
+	if best == nil {
+		return nil, ErrEmpty
+	}
+
+	best.msg.Deliveries++
+	best.token = newToken()
+	best.visibleAt = now.Add(visibility)
+	return &Lease{Message: best.msg, Token: best.token, VisibleAt: best.visibleAt}, nil
+}
+
+// Ack removes a leased message
+func (q *MemoryQueue) Ack(ctx context.Context, lease *Lease) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, err := q.held(lease); err != nil {
+		return err
+	}
+	delete(q.entries, lease.ID)
+	return nil
+}
+
+// Release returns a leased message to the queue with the lease's payload,
+// visible after delay
+func (q *MemoryQueue) Release(ctx context.Context, lease *Lease, delay time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, err := q.held(lease)
+	if err != nil {
+		return err
+	}
+	entry.msg.Payload = append([]byte(nil), lease.Payload...)
+	entry.token = ""
+	entry.visibleAt = q.now().Add(delay)
+	return nil
+}
+
+// Extend pushes a lease's visibility deadline out by visibility
+func (q *MemoryQueue) Extend(ctx context.Context, lease *Lease, visibility time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, err := q.held(lease)
+	if err != nil {
+		return err
+	}
+	entry.visibleAt = q.now().Add(visibility)
+	lease.VisibleAt = entry.visibleAt
+	return nil
+}
+
+// Recover makes every leased message visible again
+func (q *MemoryQueue) Recover(ctx context.Context) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	recovered := 0
+	for _, entry := range q.entries {
+		if entry.token != "" {
+			entry.token = ""
+			entry.visibleAt = now
+			recovered++
+		}
+	}
+	return recovered, nil
+}
+
+// SetPartitions restricts Dequeue to messages in the given partitions
+func (q *MemoryQueue) SetPartitions(partitions []int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if partitions == nil {
+		q.partitions = nil
+		return
+	}
+	q.partitions = make(map[int]bool, len(partitions))
+	for _, p := range partitions {
+		q.partitions[p] = true
+	}
+}
+
+// Len returns the number of queued messages
+func (q *MemoryQueue) Len(ctx context.Context) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries), nil
+}
+
+// Capacity returns the most messages the queue holds; zero means unbounded
+func (q *MemoryQueue) Capacity() int {
+	if q.capacity < 0 {
+		return 0
+	}
+	return q.capacity
+}
+
+// held returns the entry for a lease that is still current. Callers must
+// hold q.mu.
+func (q *MemoryQueue) held(lease *Lease) (*memoryEntry, error) {
+	entry, exists := q.entries[lease.ID]
+	if !exists || entry.token != lease.Token {
+		return nil, ErrLeaseLost
+	}
+	return entry, nil
+}
+
+// newToken returns a random lease token
+func newToken() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic("failed to generate lease token: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,154 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testQueue returns a memory queue with a controllable clock
+func testQueue(capacity int, now *time.Time) *MemoryQueue {
+	q := NewMemoryQueue(capacity)
+	q.now = func() time.Time { return *now }
+	return q
+}
+
+func TestMemoryQueuePriorityOrder(t *testing.T) {
+	now := time.Now()
+	q := testQueue(0, &now)
+	ctx := context.Background()
+
+	require.NoError(t, q.Enqueue(ctx, &Message{ID: "batch", Priority: 5}, 0))
+	require.NoError(t, q.Enqueue(ctx, &Message{ID: "exec", Priority: 1}, 0))
+	require.NoError(t, q.Enqueue(ctx, &Message{ID: "batch-2", Priority: 5}, 0))
+
+	var order []string
+	for i := 0; i < 3; i++ {
+		lease, err := q.Dequeue(ctx, time.Minute)
+		require.NoError(t, err)
+		order = append(order, lease.ID)
+	}
+	assert.Equal(t, []string{"exec", "batch", "batch-2"}, order)
+
+	_, err := q.Dequeue(ctx, time.Minute)
+	assert.ErrorIs(t, err, ErrEmpty)
+}
+
+func TestMemoryQueueCapacityAndDuplicates(t *testing.T) {
+	now := time.Now()
+	q := testQueue(2, &now)
+	ctx := context.Background()
+
+	require.NoError(t, q.Enqueue(ctx, &Message{ID: "a"}, 0))
+	assert.ErrorIs(t, q.Enqueue(ctx, &Message{ID: "a"}, 0), ErrDuplicate)
+	require.NoError(t, q.Enqueue(ctx, &Message{ID: "b"}, 0))
+	assert.ErrorIs(t, q.Enqueue(ctx, &Message{ID: "c"}, 0), ErrFull)
+
+	n, err := q.Len(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func TestMemoryQueueVisibilityTimeout(t *testing.T) {
+	now := time.Now()
+	q := testQueue(0, &now)
+	ctx := context.Background()
+
+	require.NoError(t, q.Enqueue(ctx, &Message{ID: "job"}, 0))
+	first, err := q.Dequeue(ctx, 30*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 1, first.Deliveries)
+
+	// Invisible while leased
+	_, err = q.Dequeue(ctx, 30*time.Second)
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	// Redelivered once the lease expires; the old lease can no longer ack
+	now = now.Add(31 * time.Second)
+	second, err := q.Dequeue(ctx, 30*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 2, second.Deliveries)
+	assert.ErrorIs(t, q.Ack(ctx, first), ErrLeaseLost)
+	assert.NoError(t, q.Ack(ctx, second))
+
+	n, _ := q.Len(ctx)
+	assert.Zero(t, n)
+}
+
+func TestMemoryQueueExtendAndRelease(t *testing.T) {
+	now := time.Now()
+	q := testQueue(0, &now)
+	ctx := context.Background()
+
+	require.NoError(t, q.Enqueue(ctx, &Message{ID: "job"}, 0))
+	lease, err := q.Dequeue(ctx, 30*time.Second)
+	require.NoError(t, err)
+
+	now = now.Add(20 * time.Second)
+	require.NoError(t, q.Extend(ctx, lease, 30*time.Second))
+	now = now.Add(20 * time.Second)
+	_, err = q.Dequeue(ctx, 30*time.Second)
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	lease.Payload = []byte("attempt 1 failed")
+	require.NoError(t, q.Release(ctx, lease, 5*time.Second))
+	_, err = q.Dequeue(ctx, 30*time.Second)
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	now = now.Add(5 * time.Second)
+	again, err := q.Dequeue(ctx, 30*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("attempt 1 failed"), again.Payload)
+}
+
+func TestMemoryQueueRecover(t *testing.T) {
+	now := time.Now()
+	q := testQueue(0, &now)
+	ctx := context.Background()
+
+	require.NoError(t, q.Enqueue(ctx, &Message{ID: "in-flight"}, 0))
+	require.NoError(t, q.Enqueue(ctx, &Message{ID: "waiting"}, time.Hour))
+	_, err := q.Dequeue(ctx, time.Hour)
+	require.NoError(t, err)
+
+	recovered, err := q.Recover(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, recovered)
+
+	lease, err := q.Dequeue(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, "in-flight", lease.ID)
+}
+
+func TestMemoryQueuePartitions(t *testing.T) {
+	now := time.Now()
+	q := testQueue(0, &now)
+	ctx := context.Background()
+
+	require.NoError(t, q.Enqueue(ctx, &Message{ID: "job-1"}, 0))
+	partition := PartitionOf("job-1")
+	assert.Equal(t, partition, PartitionOf("job-1"))
+	assert.Less(t, partition, Partitions)
+
+	// Another replica's share is left alone
+	q.SetPartitions([]int{(partition + 1) % Partitions})
+	_, err := q.Dequeue(ctx, time.Minute)
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	q.SetPartitions([]int{})
+	_, err = q.Dequeue(ctx, time.Minute)
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	q.SetPartitions([]int{partition})
+	lease, err := q.Dequeue(ctx, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "job-1", lease.ID)
+
+	require.NoError(t, q.Release(ctx, lease, 0))
+	q.SetPartitions(nil)
+	_, err = q.Dequeue(ctx, time.Minute)
+	assert.NoError(t, err)
+}
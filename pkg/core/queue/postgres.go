@@ -0,0 +1,253 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PostgresQueue is a durable Queue stored in the conductor's PostgreSQL
+// database. Consumers claim rows with FOR UPDATE SKIP LOCKED, so several
+// dequeuers never receive the same visible message. Replicas sharing the
+// table can each be limited to their own partitions to avoid contending
+// for the same rows.
+type PostgresQueue struct {
+	db *sql.DB
+
+	// owner identifies this conductor instance on leased rows so Recover
+	// only reclaims its own in-flight work
+	owner string
+
+	// capacity bounds the number of queued rows; zero means unbounded
+	capacity int
+
+	mu sync.Mutex
+	// partitions limits Dequeue to these partitions when non-nil
+	partitions pq.Int64Array
+}
+
+// NewPostgresQueue creates a queue backed by db. owner must be stable
+// across restarts of the same instance (for example the host name).
+func NewPostgresQueue(db *sql.DB, owner string, capacity int) *PostgresQueue {
+	return &PostgresQueue{db: db, owner: owner, capacity: capacity}
+}
+
+// EnsureSchema creates the queue table and its delivery index
+func (q *PostgresQueue) EnsureSchema(ctx context.Context) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS job_queue (
+			id          TEXT PRIMARY KEY,
+			payload     BYTEA NOT NULL,
+			priority    INTEGER NOT NULL DEFAULT 0,
+			deliveries  INTEGER NOT NULL DEFAULT 0,
+			visible_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+			lease_token TEXT,
+			lease_owner TEXT,
+			enqueued_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`,
+		`CREATE INDEX IF NOT EXISTS job_queue_delivery_idx
+			ON job_queue (priority, enqueued_at) INCLUDE (visible_at)`,
+		`ALTER TABLE job_queue ADD COLUMN IF NOT EXISTS partition_id INTEGER NOT NULL DEFAULT 0`,
+		`CREATE INDEX IF NOT EXISTS job_queue_partition_idx
+			ON job_queue (partition_id, priority, enqueued_at) INCLUDE (visible_at)`,
+		`CREATE INDEX IF NOT EXISTS job_queue_owner_idx
+			ON job_queue (lease_owner) WHERE lease_owner IS NOT NULL`,
+		// A single row bounded enqueuers lock while they check the depth
+		`CREATE TABLE IF NOT EXISTS job_queue_capacity (id INTEGER PRIMARY KEY)`,
+		`INSERT INTO job_queue_capacity (id) VALUES (1) ON CONFLICT DO NOTHING`,
+	}
+	for _, stmt := range statements {
+		if _, err := q.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create job queue schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// Enqueue inserts a message that becomes visible after delay
+func (q *PostgresQueue) Enqueue(ctx context.Context, msg *Message, delay time.Duration) error {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue message %s: %w", msg.ID, err)
+	}
+	defer tx.Rollback()
+
+	if q.capacity > 0 {
+		// Enqueuers take the capacity row in turn, so two of them cannot
+		// both see room for the last slot
+		if _, err := tx.ExecContext(ctx, `SELECT 1 FROM job_queue_capacity WHERE id = 1 FOR UPDATE`); err != nil {
+			return fmt.Errorf("failed to lock queue capacity: %w", err)
+		}
+		var depth int
+		if err := tx.QueryRowContext(ctx, `SELECT count(*) FROM job_queue`).Scan(&depth); err != nil {
+			return fmt.Errorf("failed to count queued messages: %w", err)
+		}
+		if depth >= q.capacity {
+			return ErrFull
+		}
+	}
+
+	enqueuedAt := msg.EnqueuedAt
+	if enqueuedAt.IsZero() {
+		enqueuedAt = time.Now()
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO job_queue (id, payload, priority, deliveries, visible_at, enqueued_at, partition_id)
+		 VALUES ($1, $2, $3, $4, now() + $5 * interval '1 millisecond', $6, $7)
+		 ON CONFLICT (id) DO NOTHING`,
+		msg.ID, msg.Payload, msg.Priority, msg.Deliveries, delay.Milliseconds(), enqueuedAt, PartitionOf(msg.ID))
+	if err != nil {
+		return fmt.Errorf("failed to enqueue message %s: %w", msg.ID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrDuplicate
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to enqueue message %s: %w", msg.ID, err)
+	}
+	return nil
+}
+
+// Dequeue leases the most urgent visible message. Messages whose lease
+// expired are visible again, which is how work held by a crashed consumer
+// is redelivered.
+func (q *PostgresQueue) Dequeue(ctx context.Context, visibility time.Duration) (*Lease, error) {
+	q.mu.Lock()
+	partitions := q.partitions
+	q.mu.Unlock()
+
+	lease := &Lease{Token: newToken()}
+	err := q.db.QueryRowContext(ctx,
+		`UPDATE job_queue
+		    SET deliveries  = deliveries + 1,
+		        visible_at  = now() + $1 * interval '1 millisecond',
+		        lease_token = $2,
+		        lease_owner = $3
+		  WHERE id = (
+		        SELECT id FROM job_queue
+		         WHERE visible_at <= now()
+		           AND ($4::integer[] IS NULL OR partition_id = ANY($4))
+		         ORDER BY priority, enqueued_at
+		         FOR UPDATE SKIP LOCKED
+		         LIMIT 1)
+		 RETURNING id, payload, priority, deliveries, enqueued_at, visible_at`,
+		visibility.Milliseconds(), lease.Token, q.owner, partitions,
+	).Scan(&lease.ID, &lease.Payload, &lease.Priority, &lease.Deliveries, &lease.EnqueuedAt, &lease.VisibleAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEmpty
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to dequeue message: %w", err)
+	}
+	return lease, nil
+}
+
+// Ack deletes a leased message
+func (q *PostgresQueue) Ack(ctx context.Context, lease *Lease) error {
+	res, err := q.db.ExecContext(ctx,
+		`DELETE FROM job_queue WHERE id = $1 AND lease_token = $2`, lease.ID, lease.Token)
+	if err != nil {
+		return fmt.Errorf("failed to ack message %s: %w", lease.ID, err)
+	}
+	return requireLease(res)
+}
+
+// Release returns a leased message to the queue with the lease's payload,
+// visible after delay
+func (q *PostgresQueue) Release(ctx context.Context, lease *Lease, delay time.Duration) error {
+	res, err := q.db.ExecContext(ctx,
+		`UPDATE job_queue
+		    SET payload = $4, visible_at = now() + $3 * interval '1 millisecond',
+		        lease_token = NULL, lease_owner = NULL
+		  WHERE id = $1 AND lease_token = $2`,
+		lease.ID, lease.Token, delay.Milliseconds(), lease.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to release message %s: %w", lease.ID, err)
+	}
+	return requireLease(res)
+}
+
+// Extend pushes a lease's visibility deadline out by visibility
+func (q *PostgresQueue) Extend(ctx context.Context, lease *Lease, visibility time.Duration) error {
+	err := q.db.QueryRowContext(ctx,
+		`UPDATE job_queue SET visible_at = now() + $3 * interval '1 millisecond'
+		  WHERE id = $1 AND lease_token = $2
+		 RETURNING visible_at`,
+		lease.ID, lease.Token, visibility.Milliseconds()).Scan(&lease.VisibleAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrLeaseLost
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extend lease on message %s: %w", lease.ID, err)
+	}
+	return nil
+}
+
+// Recover makes every message leased by this owner visible again
+func (q *PostgresQueue) Recover(ctx context.Context) (int, error) {
+	return q.RecoverOwner(ctx, q.owner)
+}
+
+// RecoverOwner makes every message leased by owner visible again. It is
+// used to take over the in-flight work of a replica that died, without
+// waiting for each lease's visibility timeout.
+func (q *PostgresQueue) RecoverOwner(ctx context.Context, owner string) (int, error) {
+	res, err := q.db.ExecContext(ctx,
+		`UPDATE job_queue SET visible_at = now(), lease_token = NULL, lease_owner = NULL
+		  WHERE lease_owner = $1`, owner)
+	if err != nil {
+		return 0, fmt.Errorf("failed to recover in-flight messages of %s: %w", owner, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovered messages: %w", err)
+	}
+	return int(n), nil
+}
+
+// SetPartitions restricts Dequeue to messages in the given partitions
+func (q *PostgresQueue) SetPartitions(partitions []int) {
+	var restricted pq.Int64Array
+	if partitions != nil {
+		restricted = make(pq.Int64Array, len(partitions))
+		for i, p := range partitions {
+			restricted[i] = int64(p)
+		}
+	}
+
+	q.mu.Lock()
+	q.partitions = restricted
+	q.mu.Unlock()
+}
+
+// Len returns the number of queued rows
+func (q *PostgresQueue) Len(ctx context.Context) (int, error) {
+	var n int
+	if err := q.db.QueryRowContext(ctx, `SELECT count(*) FROM job_queue`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count queued messages: %w", err)
+	}
+	return n, nil
+}
+
+// Capacity returns the most rows the queue holds; zero means unbounded
+func (q *PostgresQueue) Capacity() int {
+	return q.capacity
+}
+
+// requireLease maps a statement that matched no row to ErrLeaseLost
+func requireLease(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Package queue provides the job queue behind the conductor, with an
+// in-memory implementation for tests and a durable PostgreSQL one.
+//
+// Both implementations give at-least-once delivery: a dequeued message is
+// leased for a visibility timeout and becomes visible again unless it is
+// acknowledged or its lease is extended before the timeout expires.
+package queue
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"time"
+)
+
+// Partitions is how many partitions messages are spread over. Replicas
+// sharing a queue split the partitions between them so each mostly
+// dequeues its own share.
+const Partitions = 64
+
+var (
+	// ErrEmpty is returned by Dequeue when no message is visible
+	ErrEmpty = errors.New("queue is empty")
+
+	// ErrFull is returned by Enqueue when the queue is at capacity
+	ErrFull = errors.New("queue is full")
+
+	// ErrDuplicate is returned by Enqueue when the message ID is already queued
+	ErrDuplicate = errors.New("message already queued")
+
+	// ErrLeaseLost is returned when a lease expired and the message was
+	// redelivered or removed before the holder acknowledged it
+	ErrLeaseLost = errors.New("lease no longer held")
+)
+
+// Message is a queued unit of work
+type Message struct {
+	// ID identifies the message; it must be unique among queued messages
+	ID string
+
+	// Payload is the encoded job
+	Payload []byte
+
+	// Priority orders delivery; lower values are delivered first
+	Priority int32
+
+	// Deliveries counts how many times the message has been dequeued
+	Deliveries int
+
+	// EnqueuedAt is when the message was first queued
+	EnqueuedAt time.Time
+}
+
+// Lease is a message checked out by a consumer
+type Lease struct {
+	Message
+
+	// Token identifies this delivery; acknowledging with a stale token
+	// fails with ErrLeaseLost
+	Token string
+
+	// VisibleAt is when the message becomes deliverable again unless the
+	// lease is acknowledged or extended
+	VisibleAt time.Time
+}
+
+// Queue is a priority queue with visibility-timeout leases
+type Queue interface {
+	// Enqueue adds a message that becomes visible after delay
+	Enqueue(ctx context.Context, msg *Message, delay time.Duration) error
+
+	// Dequeue leases the most urgent visible message for visibility
+	Dequeue(ctx context.Context, visibility time.Duration) (*Lease, error)
+
+	// Ack removes a leased message for good
+	Ack(ctx context.Context, lease *Lease) error
+
+	// Release returns a leased message to the queue, visible after delay.
+	// The lease's Payload replaces the queued one, so a consumer can record
+	// progress (such as attempt history) when it gives a message back.
+	Release(ctx context.Context, lease *Lease, delay time.Duration) error
+
+	// Extend pushes a lease's visibility deadline out by visibility
+	Extend(ctx context.Context, lease *Lease, visibility time.Duration) error
+
+	// Recover makes every message leased by this queue instance visible
+	// again, returning how many were requeued. It is called at startup to
+	// reclaim work that was in flight when the process died.
+	Recover(ctx context.Context) (int, error)
+
+	// Len returns the number of queued messages, leased or not
+	Len(ctx context.Context) (int, error)
+
+	// Capacity returns the most messages the queue holds; zero means
+	// unbounded
+	Capacity() int
+}
+
+// Partitioned is implemented by queues whose consumers can be restricted to
+// a subset of partitions
+type Partitioned interface {
+	// SetPartitions restricts Dequeue to messages in the given partitions.
+	// Nil lifts the restriction; an empty slice dequeues nothing.
+	SetPartitions(partitions []int)
+}
+
+// PartitionOf returns the partition a message ID hashes to
+func PartitionOf(id string) int {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return int(h.Sum32() % Partitions)
+}
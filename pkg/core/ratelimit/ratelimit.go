@@ -0,0 +1,208 @@
+// Package ratelimit holds token buckets for the external services cyborgs
+// depend on (SALESFORCE, NOTION, SLACK, ...). Vendors enforce quotas across
+// all cyborgs combined, so each dependency has one shared bucket, and a
+// cyborg may additionally be held to its own share of it.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit is a token-bucket rate: Tokens per Period, with up to Burst tokens
+// saved up
+type Limit struct {
+	Tokens float64
+	Period time.Duration
+	Burst  int
+}
+
+// perSecond returns the refill rate
+func (l Limit) perSecond() float64 {
+	return l.Tokens / l.Period.Seconds()
+}
+
+// Config lists the configured limits
+type Config struct {
+	// Dependencies maps a dependency to the limit shared by all cyborgs
+	Dependencies map[string]Limit
+	// Cyborgs maps a cyborg ID to its own limits per dependency
+	Cyborgs map[string]map[string]Limit
+}
+
+// ParseConfig reads limits from a comma-separated list of
+// DEPENDENCY=TOKENS/PERIOD[:BURST] entries. Prefixing the dependency with a
+// cyborg ID and a dot sets that cyborg's sub-limit, for example
+// "SALESFORCE=100/1m:20,SALE0001.SALESFORCE=30/1m,NOTION=3/1s".
+func ParseConfig(spec string) (Config, error) {
+	cfg := Config{Dependencies: map[string]Limit{}, Cyborgs: map[string]map[string]Limit{}}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, rate, ok := strings.Cut(entry, "=")
+		if !ok {
+			return Config{}, fmt.Errorf("rate limit %q: want NAME=TOKENS/PERIOD[:BURST]", entry)
+		}
+		limit, err := parseLimit(rate)
+		if err != nil {
+			return Config{}, fmt.Errorf("rate limit %q: %w", entry, err)
+		}
+
+		name = strings.TrimSpace(name)
+		if cyborgID, dependency, scoped := strings.Cut(name, "."); scoped {
+			if cfg.Cyborgs[cyborgID] == nil {
+				cfg.Cyborgs[cyborgID] = map[string]Limit{}
+			}
+			cfg.Cyborgs[cyborgID][dependency] = limit
+		} else {
+			cfg.Dependencies[name] = limit
+		}
+	}
+	return cfg, nil
+}
+
+// parseLimit reads TOKENS/PERIOD[:BURST]
+func parseLimit(s string) (Limit, error) {
+	rate, burst, hasBurst := strings.Cut(strings.TrimSpace(s), ":")
+	tokens, period, ok := strings.Cut(rate, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("missing /PERIOD")
+	}
+
+	var limit Limit
+	var err error
+	if limit.Tokens, err = strconv.ParseFloat(tokens, 64); err != nil || limit.Tokens <= 0 {
+		return Limit{}, fmt.Errorf("tokens must be a positive number")
+	}
+	if limit.Period, err = time.ParseDuration(period); err != nil || limit.Period <= 0 {
+		return Limit{}, fmt.Errorf("period must be a positive duration such as 1s or 1m")
+	}
+	limit.Burst = int(math.Ceil(limit.Tokens))
+	if hasBurst {
+		if limit.Burst, err = strconv.Atoi(burst); err != nil || limit.Burst <= 0 {
+			return Limit{}, fmt.Errorf("burst must be a positive integer")
+		}
+	}
+	return limit, nil
+}
+
+// bucket is a token bucket
+type bucket struct {
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+// newBucket creates a full bucket
+func newBucket(limit Limit) *bucket {
+	return &bucket{limit: limit, tokens: float64(limit.Burst)}
+}
+
+// refill adds the tokens earned since the last refill
+func (b *bucket) refill(now time.Time) {
+	if b.last.IsZero() {
+		b.last = now
+		return
+	}
+	if now.After(b.last) {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*b.limit.perSecond())
+		b.last = now
+	}
+}
+
+// wait returns how long until a token is available
+func (b *bucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.limit.perSecond() * float64(time.Second))
+}
+
+// Limiter applies a Config. It is safe for concurrent use, and a nil
+// *Limiter allows everything.
+type Limiter struct {
+	mu           sync.Mutex
+	dependencies map[string]*bucket
+	cyborgs      map[string]map[string]*bucket
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// NewLimiter creates a limiter with full buckets
+func NewLimiter(cfg Config) *Limiter {
+	l := &Limiter{
+		dependencies: make(map[string]*bucket),
+		cyborgs:      make(map[string]map[string]*bucket),
+		now:          time.Now,
+	}
+	for dependency, limit := range cfg.Dependencies {
+		l.dependencies[dependency] = newBucket(limit)
+	}
+	for cyborgID, limits := range cfg.Cyborgs {
+		l.cyborgs[cyborgID] = make(map[string]*bucket)
+		for dependency, limit := range limits {
+			l.cyborgs[cyborgID][dependency] = newBucket(limit)
+		}
+	}
+	return l
+}
+
+// Take spends one token from every bucket that applies to a job on
+// cyborgID using dependencies. Either all are spent or none: when any
+// bucket is empty, Take returns false, how long to wait, and the
+// dependency that is holding the job.
+func (l *Limiter) Take(cyborgID string, dependencies []string) (bool, time.Duration, string) {
+	if l == nil || len(dependencies) == 0 {
+		return true, 0, ""
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var buckets []*bucket
+	var wait time.Duration
+	blockedBy := ""
+	for _, dependency := range sortedUnique(dependencies) {
+		for _, b := range []*bucket{l.dependencies[dependency], l.cyborgs[cyborgID][dependency]} {
+			if b == nil {
+				continue
+			}
+			b.refill(now)
+			if w := b.wait(); w > wait {
+				wait, blockedBy = w, dependency
+			}
+			buckets = append(buckets, b)
+		}
+	}
+	if wait > 0 {
+		return false, wait, blockedBy
+	}
+	for _, b := range buckets {
+		b.tokens--
+	}
+	return true, 0, ""
+}
+
+// sortedUnique returns the distinct names in a stable order, so a job that
+// lists a dependency twice is charged once
+func sortedUnique(names []string) []string {
+	unique := append([]string(nil), names...)
+	sort.Strings(unique)
+	n := 0
+	for i, name := range unique {
+		if i == 0 || name != unique[n-1] {
+			unique[n] = name
+			n++
+		}
+	}
+	return unique[:n]
+}
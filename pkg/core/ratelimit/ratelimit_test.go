@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig("SALESFORCE=100/1m:20, SALE0001.SALESFORCE=30/1m ,NOTION=3/1s")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Tokens: 100, Period: time.Minute, Burst: 20}, cfg.Dependencies["SALESFORCE"])
+	assert.Equal(t, Limit{Tokens: 3, Period: time.Second, Burst: 3}, cfg.Dependencies["NOTION"])
+	assert.Equal(t, Limit{Tokens: 30, Period: time.Minute, Burst: 30}, cfg.Cyborgs["SALE0001"]["SALESFORCE"])
+
+	empty, err := ParseConfig("")
+	require.NoError(t, err)
+	assert.Empty(t, empty.Dependencies)
+
+	for _, bad := range []string{"SLACK", "SLACK=10", "SLACK=x/1s", "SLACK=10/forever", "SLACK=10/1s:0", "SLACK=-1/1s"} {
+		_, err := ParseConfig(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestLimiterSharedBucket(t *testing.T) {
+	now := time.Now()
+	l := NewLimiter(Config{Dependencies: map[string]Limit{"NOTION": {Tokens: 2, Period: time.Second, Burst: 2}}})
+	l.now = func() time.Time { return now }
+
+	// The quota is shared by every cyborg
+	ok, _, _ := l.Take("PROD0001", []string{"NOTION"})
+	assert.True(t, ok)
+	ok, _, _ = l.Take("ENGR0001", []string{"NOTION", "NOTION"})
+	assert.True(t, ok)
+	ok, wait, blockedBy := l.Take("PROD0001", []string{"NOTION"})
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+	assert.Equal(t, "NOTION", blockedBy)
+
+	// Dependencies without a limit are free
+	ok, _, _ = l.Take("PROD0001", []string{"FIGMA"})
+	assert.True(t, ok)
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _, _ = l.Take("PROD0001", []string{"NOTION"})
+	assert.True(t, ok)
+}
+
+func TestLimiterCyborgSubLimitAllOrNothing(t *testing.T) {
+	now := time.Now()
+	l := NewLimiter(Config{
+		Dependencies: map[string]Limit{"SALESFORCE": {Tokens: 10, Period: time.Second, Burst: 10}},
+		Cyborgs:      map[string]map[string]Limit{"SALE0001": {"SALESFORCE": {Tokens: 1, Period: time.Second, Burst: 1}}},
+	})
+	l.now = func() time.Time { return now }
+
+	ok, _, _ := l.Take("SALE0001", []string{"SALESFORCE"})
+	assert.True(t, ok)
+	ok, wait, _ := l.Take("SALE0001", []string{"SALESFORCE", "SLACK"})
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, wait)
+
+	// The held job did not spend from the shared bucket
+	for i := 0; i < 9; i++ {
+		ok, _, _ = l.Take("SALE0002", []string{"SALESFORCE"})
+		assert.True(t, ok)
+	}
+	ok, _, _ = l.Take("SALE0002", []string{"SALESFORCE"})
+	assert.False(t, ok)
+
+	var unset *Limiter
+	ok, _, _ = unset.Take("SALE0001", []string{"SALESFORCE"})
+	assert.True(t, ok)
+}
@@ -0,0 +1,481 @@
+// Package remote hands conductor jobs to cyborg worker processes that pull
+// them over the network, and collects their results.
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/budget"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/output"
+)
+
+var (
+	// ErrUnknownDelivery is returned to a worker that acks or reports on a
+	// delivery it no longer holds, because the job was redelivered,
+	// cancelled or already finished
+	ErrUnknownDelivery = errors.New("unknown or expired delivery")
+	// ErrWorkerLost is returned when a job was delivered MaxDeliveries
+	// times without any worker finishing it
+	ErrWorkerLost = errors.New("no worker completed the job")
+	// ErrNoWorker is returned when no worker is connected for the cyborg
+	ErrNoWorker = errors.New("no worker connected")
+	// ErrDuplicateJob is returned when a job ID is already assigned
+	ErrDuplicateJob = errors.New("job already assigned")
+)
+
+// Result codes, matching envelope.v1.ResultStatus.StatusCode
+const (
+	CodeSuccess          = "SUCCESS"
+	CodeFailure          = "FAILURE"
+	CodeTimeout          = "TIMEOUT"
+	CodeRejected         = "REJECTED"
+	CodeRetryableFailure = "RETRYABLE_FAILURE"
+)
+
+// Job is the work handed to a remote worker
+type Job struct {
+	ID          string
+	CyborgID    string
+	Payload     []byte
+	SubmittedAt time.Time
+	// Deadline for completion; zero means none
+	Deadline time.Time
+}
+
+// Delivery is one hand-off of a job to one worker
+type Delivery struct {
+	ID          string
+	Job         Job
+	Attempt     int
+	AckDeadline time.Time
+}
+
+// Result is a worker's final report on a job
+type Result struct {
+	Code         string
+	Message      string
+	ErrorDetails string
+	Payload      []byte
+	// Usage is what an LLM job spent, whether or not it succeeded
+	Usage budget.Usage
+}
+
+// Progress is a worker's report on a running job
+type Progress struct {
+	JobID    string
+	CyborgID string
+	WorkerID string
+	Percent  float32
+	Message  string
+	At       time.Time
+}
+
+// Config controls delivery deadlines
+type Config struct {
+	// AckTimeout is how long a worker has to ack a delivery before the job
+	// is offered to another worker
+	AckTimeout time.Duration
+	// LeaseTimeout is how long an acked job may go without progress
+	// before it is redelivered
+	LeaseTimeout time.Duration
+	// MaxDeliveries is how many times a job is delivered before it fails
+	// with ErrWorkerLost
+	MaxDeliveries int
+	// WorkerTTL is how long after its last contact a worker still counts
+	// as connected
+	WorkerTTL time.Duration
+	// OnProgress, if set, is called with every progress report
+	OnProgress func(Progress)
+	// OnOutput, if set, is called with the output chunks workers report,
+	// labelled with their job
+	OnOutput func([]output.Chunk)
+}
+
+// DefaultConfig returns the delivery settings used for unset fields
+func DefaultConfig() Config {
+	return Config{
+		AckTimeout:    30 * time.Second,
+		LeaseTimeout:  2 * time.Minute,
+		MaxDeliveries: 3,
+		WorkerTTL:     time.Minute,
+	}
+}
+
+// Assignment states
+const (
+	statePending = iota
+	stateDelivered
+	stateRunning
+)
+
+// assignment tracks a job from submission until a worker finishes it
+type assignment struct {
+	job        Job
+	state      int
+	workerID   string
+	deliveryID string
+	attempts   int
+	// deadline is the ack deadline while delivered and the lease deadline
+	// while running
+	deadline time.Time
+	done     chan outcome
+}
+
+// outcome ends an assignment
+type outcome struct {
+	result *Result
+	err    error
+}
+
+// Broker queues jobs per cyborg until a worker for that cyborg pulls them
+type Broker struct {
+	cfg Config
+
+	mu sync.Mutex
+	// pending holds undelivered assignments per cyborg, oldest first
+	pending map[string][]*assignment
+	// jobs holds every unfinished assignment by job ID
+	jobs map[string]*assignment
+	// deliveries maps live delivery IDs to their assignment
+	deliveries map[string]*assignment
+	// seen is each cyborg's workers by their last contact
+	seen map[string]map[string]time.Time
+	// polling counts the workers waiting for work per cyborg
+	polling map[string]int
+	// wake is closed when work is added for a cyborg
+	wake map[string]chan struct{}
+}
+
+// NewBroker creates a broker; zero fields in cfg take their defaults
+func NewBroker(cfg Config) *Broker {
+	defaults := DefaultConfig()
+	if cfg.AckTimeout <= 0 {
+		cfg.AckTimeout = defaults.AckTimeout
+	}
+	if cfg.LeaseTimeout <= 0 {
+		cfg.LeaseTimeout = defaults.LeaseTimeout
+	}
+	if cfg.MaxDeliveries <= 0 {
+		cfg.MaxDeliveries = defaults.MaxDeliveries
+	}
+	if cfg.WorkerTTL <= 0 {
+		cfg.WorkerTTL = defaults.WorkerTTL
+	}
+	return &Broker{
+		cfg:        cfg,
+		pending:    make(map[string][]*assignment),
+		jobs:       make(map[string]*assignment),
+		deliveries: make(map[string]*assignment),
+		seen:       make(map[string]map[string]time.Time),
+		polling:    make(map[string]int),
+		wake:       make(map[string]chan struct{}),
+	}
+}
+
+// Run redelivers jobs whose ack or lease deadline passed until ctx is done
+func (b *Broker) Run(ctx context.Context) {
+	interval := b.cfg.AckTimeout
+	if b.cfg.LeaseTimeout < interval {
+		interval = b.cfg.LeaseTimeout
+	}
+	ticker := time.NewTicker(interval / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			b.Reap(now)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Execute assigns a job to its cyborg's workers and waits for the result.
+// Cancelling ctx withdraws the job; the worker holding it gets
+// ErrUnknownDelivery on its next report.
+func (b *Broker) Execute(ctx context.Context, job Job) (*Result, error) {
+	a := &assignment{job: job, state: statePending, done: make(chan outcome, 1)}
+
+	b.mu.Lock()
+	if _, exists := b.jobs[job.ID]; exists {
+		b.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrDuplicateJob, job.ID)
+	}
+	b.jobs[job.ID] = a
+	b.enqueue(a, false)
+	b.mu.Unlock()
+
+	select {
+	case o := <-a.done:
+		return o.result, o.err
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.remove(a)
+		b.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// Poll waits up to wait for a job for the worker's cyborg and delivers it
+// to the worker. It returns nil when none was assigned in time.
+func (b *Broker) Poll(ctx context.Context, cyborgID, workerID string, wait time.Duration) (*Delivery, error) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	b.mu.Lock()
+	b.polling[cyborgID]++
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		b.polling[cyborgID]--
+		b.touch(cyborgID, workerID, time.Now())
+		b.mu.Unlock()
+	}()
+
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.touch(cyborgID, workerID, now)
+		if queue := b.pending[cyborgID]; len(queue) > 0 {
+			b.pending[cyborgID] = queue[1:]
+			delivery := b.deliver(queue[0], workerID, now)
+			b.mu.Unlock()
+			return delivery, nil
+		}
+		wake := b.wakeChan(cyborgID)
+		b.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-timer.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Ack marks a delivered job as started and returns its lease deadline
+func (b *Broker) Ack(workerID, deliveryID string) (time.Time, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	a, err := b.held(workerID, deliveryID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	a.state = stateRunning
+	a.deadline = time.Now().Add(b.cfg.LeaseTimeout)
+	return a.deadline, nil
+}
+
+// ReportProgress records progress on a job, acking it if needed, and
+// returns the extended lease deadline
+func (b *Broker) ReportProgress(workerID, deliveryID string, percent float32, message string) (time.Time, error) {
+	b.mu.Lock()
+	a, err := b.held(workerID, deliveryID)
+	if err != nil {
+		b.mu.Unlock()
+		return time.Time{}, err
+	}
+	now := time.Now()
+	a.state = stateRunning
+	a.deadline = now.Add(b.cfg.LeaseTimeout)
+	deadline := a.deadline
+	progress := Progress{
+		JobID:    a.job.ID,
+		CyborgID: a.job.CyborgID,
+		WorkerID: workerID,
+		Percent:  percent,
+		Message:  message,
+		At:       now,
+	}
+	b.mu.Unlock()
+
+	if b.cfg.OnProgress != nil {
+		b.cfg.OnProgress(progress)
+	}
+	return deadline, nil
+}
+
+// ReportOutput relays output a worker read from a running job, acking it
+// if needed, and returns the extended lease deadline
+func (b *Broker) ReportOutput(workerID, deliveryID string, chunks []output.Chunk) (time.Time, error) {
+	b.mu.Lock()
+	a, err := b.held(workerID, deliveryID)
+	if err != nil {
+		b.mu.Unlock()
+		return time.Time{}, err
+	}
+	a.state = stateRunning
+	a.deadline = time.Now().Add(b.cfg.LeaseTimeout)
+	deadline := a.deadline
+	jobID := a.job.ID
+	b.mu.Unlock()
+
+	if b.cfg.OnOutput != nil && len(chunks) > 0 {
+		labelled := make([]output.Chunk, len(chunks))
+		for i, chunk := range chunks {
+			chunk.JobID = jobID
+			labelled[i] = chunk
+		}
+		b.cfg.OnOutput(labelled)
+	}
+	return deadline, nil
+}
+
+// Complete records a worker's final result for a job
+func (b *Broker) Complete(workerID, deliveryID string, result Result) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	a, err := b.held(workerID, deliveryID)
+	if err != nil {
+		return err
+	}
+	b.remove(a)
+	a.done <- outcome{result: &result}
+	return nil
+}
+
+// Disconnect redelivers every job a worker holds, for example when its
+// stream closes
+func (b *Broker) Disconnect(cyborgID, workerID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.seen[cyborgID], workerID)
+	for _, a := range b.jobs {
+		if a.state != statePending && a.workerID == workerID {
+			b.redeliver(a)
+		}
+	}
+}
+
+// Reap redelivers jobs whose ack or lease deadline has passed and forgets
+// workers not heard from within the worker TTL
+func (b *Broker) Reap(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, a := range b.jobs {
+		if a.state != statePending && now.After(a.deadline) {
+			b.redeliver(a)
+		}
+	}
+	for cyborgID, workers := range b.seen {
+		for workerID, last := range workers {
+			if now.Sub(last) > b.cfg.WorkerTTL {
+				delete(workers, workerID)
+			}
+		}
+		if len(workers) == 0 {
+			delete(b.seen, cyborgID)
+		}
+	}
+}
+
+// HasWorkers reports whether any worker for a cyborg is connected
+func (b *Broker) HasWorkers(cyborgID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.polling[cyborgID] > 0 {
+		return true
+	}
+	now := time.Now()
+	for _, last := range b.seen[cyborgID] {
+		if now.Sub(last) <= b.cfg.WorkerTTL {
+			return true
+		}
+	}
+	return false
+}
+
+// touch records contact from a worker; callers hold mu
+func (b *Broker) touch(cyborgID, workerID string, now time.Time) {
+	workers, exists := b.seen[cyborgID]
+	if !exists {
+		workers = make(map[string]time.Time)
+		b.seen[cyborgID] = workers
+	}
+	workers[workerID] = now
+}
+
+// wakeChan returns the channel closed when work arrives for a cyborg;
+// callers hold mu
+func (b *Broker) wakeChan(cyborgID string) chan struct{} {
+	ch, exists := b.wake[cyborgID]
+	if !exists {
+		ch = make(chan struct{})
+		b.wake[cyborgID] = ch
+	}
+	return ch
+}
+
+// enqueue makes an assignment available to its cyborg's workers, at the
+// front when it is being redelivered; callers hold mu
+func (b *Broker) enqueue(a *assignment, front bool) {
+	cyborgID := a.job.CyborgID
+	if front {
+		b.pending[cyborgID] = append([]*assignment{a}, b.pending[cyborgID]...)
+	} else {
+		b.pending[cyborgID] = append(b.pending[cyborgID], a)
+	}
+	if ch, exists := b.wake[cyborgID]; exists {
+		close(ch)
+		delete(b.wake, cyborgID)
+	}
+}
+
+// deliver hands an assignment to a worker; callers hold mu
+func (b *Broker) deliver(a *assignment, workerID string, now time.Time) *Delivery {
+	a.attempts++
+	a.state = stateDelivered
+	a.workerID = workerID
+	a.deliveryID = fmt.Sprintf("%s#%d", a.job.ID, a.attempts)
+	a.deadline = now.Add(b.cfg.AckTimeout)
+	b.deliveries[a.deliveryID] = a
+	return &Delivery{ID: a.deliveryID, Job: a.job, Attempt: a.attempts, AckDeadline: a.deadline}
+}
+
+// held returns the assignment behind a live delivery owned by workerID;
+// callers hold mu
+func (b *Broker) held(workerID, deliveryID string) (*assignment, error) {
+	a, exists := b.deliveries[deliveryID]
+	if !exists || a.workerID != workerID {
+		return nil, ErrUnknownDelivery
+	}
+	return a, nil
+}
+
+// redeliver takes a job back from its worker and offers it again, or fails
+// it once it has used up its deliveries; callers hold mu
+func (b *Broker) redeliver(a *assignment) {
+	delete(b.deliveries, a.deliveryID)
+	a.workerID, a.deliveryID = "", ""
+	if a.attempts >= b.cfg.MaxDeliveries {
+		b.remove(a)
+		a.done <- outcome{err: fmt.Errorf("%w after %d deliveries", ErrWorkerLost, a.attempts)}
+		return
+	}
+	a.state = statePending
+	b.enqueue(a, true)
+}
+
+// remove forgets an assignment wherever it is; callers hold mu
+func (b *Broker) remove(a *assignment) {
+	delete(b.jobs, a.job.ID)
+	delete(b.deliveries, a.deliveryID)
+	queue := b.pending[a.job.CyborgID]
+	for i, pending := range queue {
+		if pending == a {
+			b.pending[a.job.CyborgID] = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toxicoder/cyborg-conductor-core/internal/runner"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/budget"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/conductor"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/executor"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/output"
+)
+
+// execute runs Execute in the background and returns its outcome channel
+func execute(b *Broker, job Job) <-chan outcome {
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := b.Execute(context.Background(), job)
+		done <- outcome{result: result, err: err}
+	}()
+	return done
+}
+
+// poll fetches the next delivery for a worker or fails the test
+func poll(t *testing.T, b *Broker, cyborgID, workerID string) *Delivery {
+	t.Helper()
+	delivery, err := b.Poll(context.Background(), cyborgID, workerID, time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, delivery, "no job delivered to %s", workerID)
+	return delivery
+}
+
+// TestBrokerDeliversAndCollectsResult tests the worker round trip of poll,
+// ack, progress, output and result
+func TestBrokerDeliversAndCollectsResult(t *testing.T) {
+	var reports []Progress
+	var chunks []output.Chunk
+	b := NewBroker(Config{
+		OnProgress: func(p Progress) { reports = append(reports, p) },
+		OnOutput:   func(c []output.Chunk) { chunks = append(chunks, c...) },
+	})
+	done := execute(b, Job{ID: "forecast", CyborgID: "FINC0001", Payload: []byte("q3")})
+
+	delivery := poll(t, b, "FINC0001", "worker-a")
+	assert.Equal(t, "forecast", delivery.Job.ID)
+	assert.Equal(t, []byte("q3"), delivery.Job.Payload)
+	assert.Equal(t, 1, delivery.Attempt)
+	assert.True(t, b.HasWorkers("FINC0001"))
+	assert.False(t, b.HasWorkers("SALE0001"))
+
+	_, err := b.Ack("worker-a", delivery.ID)
+	require.NoError(t, err)
+	_, err = b.ReportProgress("worker-a", delivery.ID, 50, "halfway")
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, "halfway", reports[0].Message)
+	_, err = b.ReportOutput("worker-a", delivery.ID, []output.Chunk{{Stream: output.Stdout, Data: []byte("rows: 12\n")}})
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "forecast", chunks[0].JobID)
+
+	_, err = b.Ack("worker-b", delivery.ID)
+	assert.ErrorIs(t, err, ErrUnknownDelivery, "only the holder may report")
+
+	require.NoError(t, b.Complete("worker-a", delivery.ID, Result{Code: CodeSuccess, Payload: []byte("ok")}))
+	o := <-done
+	require.NoError(t, o.err)
+	assert.Equal(t, []byte("ok"), o.result.Payload)
+
+	assert.ErrorIs(t, b.Complete("worker-a", delivery.ID, Result{Code: CodeSuccess}), ErrUnknownDelivery)
+}
+
+// TestBrokerRedeliversUnackedJob tests that a job not acked in time goes to
+// another worker and the first worker's delivery stops being accepted
+func TestBrokerRedeliversUnackedJob(t *testing.T) {
+	b := NewBroker(Config{AckTimeout: 10 * time.Millisecond})
+	done := execute(b, Job{ID: "forecast", CyborgID: "FINC0001"})
+
+	first := poll(t, b, "FINC0001", "worker-a")
+	b.Reap(time.Now().Add(20 * time.Millisecond))
+
+	second := poll(t, b, "FINC0001", "worker-b")
+	assert.Equal(t, 2, second.Attempt)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	_, err := b.Ack("worker-a", first.ID)
+	assert.ErrorIs(t, err, ErrUnknownDelivery)
+
+	require.NoError(t, b.Complete("worker-b", second.ID, Result{Code: CodeSuccess}))
+	assert.NoError(t, (<-done).err)
+}
+
+// TestBrokerDisconnectRedeliversUntilExhausted tests that a worker that
+// disconnects gives its job back and that a job is failed after its last
+// delivery
+func TestBrokerDisconnectRedeliversUntilExhausted(t *testing.T) {
+	b := NewBroker(Config{MaxDeliveries: 2})
+	done := execute(b, Job{ID: "forecast", CyborgID: "FINC0001"})
+
+	delivery := poll(t, b, "FINC0001", "worker-a")
+	_, err := b.Ack("worker-a", delivery.ID)
+	require.NoError(t, err)
+	b.Disconnect("FINC0001", "worker-a")
+
+	delivery = poll(t, b, "FINC0001", "worker-b")
+	assert.Equal(t, 2, delivery.Attempt)
+	b.Disconnect("FINC0001", "worker-b")
+
+	o := <-done
+	assert.ErrorIs(t, o.err, ErrWorkerLost)
+}
+
+// TestBrokerCancelWithdrawsJob tests that a cancelled job is withdrawn from
+// its worker
+func TestBrokerCancelWithdrawsJob(t *testing.T) {
+	b := NewBroker(Config{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := b.Execute(ctx, Job{ID: "forecast", CyborgID: "FINC0001"})
+		done <- err
+	}()
+
+	delivery := poll(t, b, "FINC0001", "worker-a")
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	_, err := b.ReportProgress("worker-a", delivery.ID, 10, "")
+	assert.ErrorIs(t, err, ErrUnknownDelivery)
+}
+
+// TestBrokerHandler tests the conductor handler's mapping of worker
+// results and its fallback when no worker is connected
+func TestBrokerHandler(t *testing.T) {
+	b := NewBroker(Config{})
+
+	err := b.Handler(nil)(context.Background(), &conductor.Job{ID: "j1", CyborgID: "FINC0001"})
+	var execErr *conductor.ExecError
+	require.ErrorAs(t, err, &execErr)
+	assert.ErrorIs(t, err, ErrNoWorker)
+
+	fellBack := false
+	fallback := func(ctx context.Context, job *conductor.Job) error {
+		fellBack = true
+		return nil
+	}
+	require.NoError(t, b.Handler(fallback)(context.Background(), &conductor.Job{ID: "j2", CyborgID: "FINC0001"}))
+	assert.True(t, fellBack)
+
+	// A worker long-polls, takes the job and reports a failure
+	go func() {
+		delivery, err := b.Poll(context.Background(), "FINC0001", "worker-a", time.Second)
+		if err != nil || delivery == nil {
+			return
+		}
+		_ = b.Complete("worker-a", delivery.ID, Result{
+			Code: CodeRejected, Message: "out of scope", ErrorDetails: "trace",
+			Usage: budget.Usage{Tokens: 420, Cost: 0.02},
+		})
+	}()
+	require.Eventually(t, func() bool { return b.HasWorkers("FINC0001") }, time.Second, time.Millisecond)
+
+	job := &conductor.Job{ID: "j3", CyborgID: "FINC0001"}
+	err = b.Handler(nil)(context.Background(), job)
+	require.ErrorAs(t, err, &execErr)
+	assert.Equal(t, "trace", execErr.Stderr)
+	assert.False(t, errors.Is(err, ErrNoWorker))
+	assert.ErrorIs(t, err, conductor.ErrRejected)
+	// Tokens spent on a failed job are still charged
+	assert.Equal(t, &budget.Usage{Tokens: 420, Cost: 0.02}, job.Usage)
+}
+
+// TestBrokerExecutor tests the executor backend's mapping of worker results
+func TestBrokerExecutor(t *testing.T) {
+	b := NewBroker(Config{})
+	exec := b.Executor()
+
+	_, err := exec.Execute(context.Background(), &executor.Request{JobID: "j1", CyborgID: "FINC0001"})
+	assert.ErrorIs(t, err, ErrNoWorker)
+
+	go func() {
+		delivery, err := b.Poll(context.Background(), "FINC0001", "worker-a", time.Second)
+		if err != nil || delivery == nil {
+			return
+		}
+		_ = b.Complete("worker-a", delivery.ID, Result{
+			Code: CodeRejected, Message: "out of scope", ErrorDetails: "trace",
+			Usage: budget.Usage{Tokens: 420, Cost: 0.02},
+		})
+	}()
+	require.Eventually(t, func() bool { return b.HasWorkers("FINC0001") }, time.Second, time.Millisecond)
+
+	result, err := exec.Execute(context.Background(), &executor.Request{JobID: "j2", CyborgID: "FINC0001", Payload: []byte("q3")})
+	assert.ErrorIs(t, err, executor.ErrRejected)
+	require.NotNil(t, result)
+	assert.Equal(t, runner.StatusRejected, result.Status.Code)
+	assert.Equal(t, "trace", string(result.Stderr))
+	assert.Equal(t, budget.Usage{Tokens: 420, Cost: 0.02}, result.Usage)
+}
@@ -0,0 +1,50 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/internal/runner"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/executor"
+)
+
+// Executor returns an executor backend that runs jobs on remote workers.
+// Jobs for cyborgs without a connected worker fail with ErrNoWorker.
+func (b *Broker) Executor() executor.Executor {
+	return executor.Func(func(ctx context.Context, req *executor.Request) (*executor.Result, error) {
+		if !b.HasWorkers(req.CyborgID) {
+			return nil, fmt.Errorf("%w for cyborg %s", ErrNoWorker, req.CyborgID)
+		}
+
+		job := Job{
+			ID:          req.JobID,
+			CyborgID:    req.CyborgID,
+			Payload:     req.Payload,
+			SubmittedAt: time.Now(),
+		}
+		job.Deadline, _ = ctx.Deadline()
+
+		result, err := b.Execute(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+		status := &runner.ResultStatus{Message: result.Message, ErrorDetails: result.ErrorDetails}
+		status.Code, _ = runner.ParseStatusCode(result.Code)
+		out := &executor.Result{
+			Stderr:  []byte(result.ErrorDetails),
+			Payload: result.Payload,
+			Status:  status,
+			Usage:   result.Usage,
+		}
+		switch result.Code {
+		case CodeSuccess:
+			return out, nil
+		case CodeRejected:
+			// The cyborg declined the job rather than failed it
+			return out, fmt.Errorf("%w: %s", executor.ErrRejected, result.Message)
+		default:
+			return out, fmt.Errorf("worker reported %s: %s", result.Code, result.Message)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/conductor"
+)
+
+// Handler returns a conductor job handler that runs jobs on remote workers.
+// Jobs for cyborgs without a connected worker go to fallback, or fail with
+// ErrNoWorker when fallback is nil so the conductor retries them later.
+func (b *Broker) Handler(fallback conductor.JobHandler) conductor.JobHandler {
+	return func(ctx context.Context, job *conductor.Job) error {
+		if !b.HasWorkers(job.CyborgID) {
+			if fallback != nil {
+				return fallback(ctx, job)
+			}
+			return &conductor.ExecError{Err: fmt.Errorf("%w for cyborg %s", ErrNoWorker, job.CyborgID)}
+		}
+
+		remoteJob := Job{
+			ID:          job.ID,
+			CyborgID:    job.CyborgID,
+			Payload:     job.Payload,
+			SubmittedAt: time.Now(),
+		}
+		if job.TimeoutMs > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(job.TimeoutMs)*time.Millisecond)
+			defer cancel()
+			remoteJob.Deadline, _ = ctx.Deadline()
+		}
+
+		result, err := b.Execute(ctx, remoteJob)
+		if err != nil {
+			return err
+		}
+		if !result.Usage.IsZero() {
+			usage := result.Usage
+			job.Usage = &usage
+		}
+		if result.Code == CodeRejected {
+			// The cyborg declined the job rather than failed it; the
+			// conductor may escalate it
+			return &conductor.ExecError{
+				Err:    fmt.Errorf("%w: %s", conductor.ErrRejected, result.Message),
+				Stderr: result.ErrorDetails,
+			}
+		}
+		if result.Code != CodeSuccess {
+			return &conductor.ExecError{
+				Err:    fmt.Errorf("worker reported %s: %s", result.Code, result.Message),
+				Stderr: result.ErrorDetails,
+			}
+		}
+		job.Result = result.Payload
+		return nil
+	}
+}
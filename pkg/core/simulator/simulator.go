@@ -0,0 +1,316 @@
+// Package simulator replays job traces against registry snapshots on a
+// virtual clock, to see how the scheduler would place them without
+// running anything. Cyborgs are picked through the same scheduling
+// policies the scheduler uses.
+package simulator
+
+import (
+	"container/heap"
+	"sort"
+	"time"
+
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/policy"
+)
+
+// Rejection reasons
+const (
+	// RejectNoCyborg means no cyborg in the snapshot has the capabilities
+	RejectNoCyborg = "no_cyborg"
+	// RejectQueueFull means the task queue was at capacity on arrival
+	RejectQueueFull = "queue_full"
+)
+
+// Cyborg is a cyborg in the registry snapshot a simulation runs against
+type Cyborg struct {
+	ID           string   `json:"id"`
+	Capabilities []string `json:"capabilities"`
+	// Slots is how many tasks the cyborg runs at once; defaults to 1
+	Slots int `json:"slots,omitempty"`
+	// Speed scales modelled execution times, so 2 runs tasks in half the
+	// time; defaults to 1
+	Speed float64 `json:"speed,omitempty"`
+}
+
+// Task is one task in a trace
+type Task struct {
+	ID           string   `json:"id"`
+	Capabilities []string `json:"capabilities"`
+	// ArrivalMs is when the task is submitted, from the start of the trace
+	ArrivalMs int64 `json:"arrival_ms"`
+	// DurationMs is the modelled execution time on a cyborg of speed 1
+	DurationMs int64 `json:"duration_ms"`
+	// SLAMs is the latency budget from submission to completion; zero
+	// means none
+	SLAMs int64 `json:"sla_ms,omitempty"`
+}
+
+// Config controls a simulation run
+type Config struct {
+	// QueueCapacity caps the tasks waiting for a slot; further arrivals are
+	// rejected. Defaults to the scheduler's task queue size.
+	QueueCapacity int `json:"queue_capacity,omitempty"`
+
+	// LoadAware defers placement until a capable cyborg has a free slot.
+	// Without it a task is bound to its cyborg on arrival, as the
+	// orchestrator's Scheduler.SubmitTask does, and waits for that cyborg.
+	LoadAware bool `json:"load_aware,omitempty"`
+
+	// Policy picks among the capable cyborgs; nil means first-fit. Each
+	// cyborg is described by its slots, its running and bound tasks, and
+	// its speed as latency.
+	Policy policy.SchedulingPolicy `json:"-"`
+}
+
+// Report summarizes a simulation run
+type Report struct {
+	Tasks     int `json:"tasks"`
+	Completed int `json:"completed"`
+	// Rejected counts rejected tasks by reason
+	Rejected  map[string]int `json:"rejected"`
+	SLAMisses int            `json:"sla_misses"`
+	// Wait is the time completed tasks spent queued before starting
+	Wait       WaitStats               `json:"wait"`
+	MakespanMs int64                   `json:"makespan_ms"`
+	Cyborgs    map[string]*CyborgStats `json:"cyborgs"`
+}
+
+// WaitStats are queue wait percentiles in milliseconds
+type WaitStats struct {
+	P50Ms int64 `json:"p50_ms"`
+	P90Ms int64 `json:"p90_ms"`
+	P99Ms int64 `json:"p99_ms"`
+	MaxMs int64 `json:"max_ms"`
+}
+
+// CyborgStats is one cyborg's share of a simulation run
+type CyborgStats struct {
+	Tasks  int   `json:"tasks"`
+	BusyMs int64 `json:"busy_ms"`
+	// Utilization is busy time over slots times the makespan
+	Utilization float64 `json:"utilization"`
+}
+
+// queuedTask is a task waiting in the simulated queue
+type queuedTask struct {
+	Task
+	// cyborg is set once the task is bound to a cyborg
+	cyborg *Cyborg
+}
+
+// completion is a running task finishing at a point on the virtual clock
+type completion struct {
+	at       time.Duration
+	cyborgID string
+}
+
+// completions orders running tasks by finish time
+type completions []completion
+
+func (c completions) Len() int            { return len(c) }
+func (c completions) Less(i, j int) bool  { return c[i].at < c[j].at }
+func (c completions) Swap(i, j int)       { c[i], c[j] = c[j], c[i] }
+func (c *completions) Push(x interface{}) { *c = append(*c, x.(completion)) }
+func (c *completions) Pop() interface{} {
+	old := *c
+	last := old[len(old)-1]
+	*c = old[:len(old)-1]
+	return last
+}
+
+// Run replays a trace against a registry snapshot on a virtual clock and
+// reports how the scheduler would have placed it. Cyborgs are chosen by
+// the configured policy as the scheduler's are; execution is modelled from
+// the trace, so nothing is run.
+func Run(snapshot []Cyborg, trace []Task, cfg Config) *Report {
+	if cfg.QueueCapacity <= 0 {
+		cfg.QueueCapacity = 100 // Scheduler task queue size
+	}
+	if cfg.Policy == nil {
+		cfg.Policy, _ = policy.New(policy.FirstFit, policy.DefaultWeights)
+	}
+
+	cyborgs := make([]*Cyborg, 0, len(snapshot))
+	report := &Report{
+		Tasks:    len(trace),
+		Rejected: make(map[string]int),
+		Cyborgs:  make(map[string]*CyborgStats, len(snapshot)),
+	}
+	for _, c := range snapshot {
+		if c.Slots <= 0 {
+			c.Slots = 1
+		}
+		if c.Speed <= 0 {
+			c.Speed = 1
+		}
+		cyborg := c
+		cyborgs = append(cyborgs, &cyborg)
+		report.Cyborgs[c.ID] = &CyborgStats{}
+	}
+	sort.Slice(cyborgs, func(i, j int) bool { return cyborgs[i].ID < cyborgs[j].ID })
+
+	arrivals := append([]Task(nil), trace...)
+	sort.SliceStable(arrivals, func(i, j int) bool { return arrivals[i].ArrivalMs < arrivals[j].ArrivalMs })
+
+	// busy counts running tasks per cyborg and bound counts queued tasks
+	// bound to it
+	busy := make(map[string]int)
+	bound := make(map[string]int)
+	describe := func(cyborg *Cyborg) policy.Candidate {
+		return policy.Candidate{
+			ID:       cyborg.ID,
+			Load:     busy[cyborg.ID] + bound[cyborg.ID],
+			Capacity: cyborg.Slots,
+			Latency:  time.Duration(float64(time.Second) / cyborg.Speed),
+		}
+	}
+	free := func(i int) (policy.Candidate, bool) {
+		return describe(cyborgs[i]), busy[cyborgs[i].ID] < cyborgs[i].Slots
+	}
+	anyCyborg := func(i int) (policy.Candidate, bool) {
+		return describe(cyborgs[i]), true
+	}
+	capabilities := make([][]string, len(cyborgs))
+	for i, cyborg := range cyborgs {
+		capabilities[i] = cyborg.Capabilities
+	}
+	// place picks a cyborg for the task the way the orchestrator's
+	// scheduler does; the simulation has no breakers, so every pick is
+	// allowed
+	place := func(task *queuedTask, describe func(i int) (policy.Candidate, bool)) *Cyborg {
+		i := policy.Place(cfg.Policy, policy.Request{JobID: task.ID}, task.Capabilities, capabilities, describe, nil)
+		if i < 0 {
+			return nil
+		}
+		return cyborgs[i]
+	}
+	capable := func(required []string) bool {
+		for _, have := range capabilities {
+			if policy.HasCapabilities(have, required) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var (
+		now     time.Duration
+		queue   []*queuedTask
+		running completions
+		waits   []time.Duration
+	)
+
+	// dispatch starts every queued task whose cyborg has a free slot, in
+	// arrival order
+	dispatch := func() {
+		waiting := queue[:0]
+		for _, task := range queue {
+			cyborg := task.cyborg
+			if cyborg == nil {
+				cyborg = place(task, free)
+			} else if busy[cyborg.ID] >= cyborg.Slots {
+				cyborg = nil
+			}
+			if cyborg == nil {
+				waiting = append(waiting, task)
+				continue
+			}
+			if task.cyborg != nil {
+				bound[cyborg.ID]--
+			}
+
+			duration := time.Duration(float64(task.DurationMs)*float64(time.Millisecond)/cyborg.Speed + 0.5)
+			finish := now + duration
+			busy[cyborg.ID]++
+			heap.Push(&running, completion{at: finish, cyborgID: cyborg.ID})
+
+			arrival := time.Duration(task.ArrivalMs) * time.Millisecond
+			waits = append(waits, now-arrival)
+			stats := report.Cyborgs[cyborg.ID]
+			stats.Tasks++
+			stats.BusyMs += duration.Milliseconds()
+			report.Completed++
+			if task.SLAMs > 0 && finish-arrival > time.Duration(task.SLAMs)*time.Millisecond {
+				report.SLAMisses++
+			}
+			if finish.Milliseconds() > report.MakespanMs {
+				report.MakespanMs = finish.Milliseconds()
+			}
+		}
+		queue = waiting
+	}
+
+	for len(arrivals) > 0 || len(running) > 0 {
+		// Advance the clock to the next arrival or completion
+		next := time.Duration(-1)
+		if len(arrivals) > 0 {
+			next = time.Duration(arrivals[0].ArrivalMs) * time.Millisecond
+		}
+		if len(running) > 0 && (next < 0 || running[0].at < next) {
+			next = running[0].at
+		}
+		now = next
+
+		for len(running) > 0 && running[0].at <= now {
+			done := heap.Pop(&running).(completion)
+			busy[done.cyborgID]--
+		}
+		for len(arrivals) > 0 && time.Duration(arrivals[0].ArrivalMs)*time.Millisecond <= now {
+			task := &queuedTask{Task: arrivals[0]}
+			arrivals = arrivals[1:]
+
+			switch {
+			case !capable(task.Capabilities):
+				report.Rejected[RejectNoCyborg]++
+			case len(queue) >= cfg.QueueCapacity:
+				report.Rejected[RejectQueueFull]++
+			default:
+				if !cfg.LoadAware {
+					// The scheduler picks the cyborg before queueing
+					task.cyborg = place(task, anyCyborg)
+					if task.cyborg == nil {
+						report.Rejected[RejectNoCyborg]++
+						break
+					}
+					bound[task.cyborg.ID]++
+				}
+				queue = append(queue, task)
+			}
+			// A task that can start right away never holds a queue place
+			dispatch()
+		}
+		dispatch()
+	}
+
+	report.Wait = waitStats(waits)
+	for _, cyborg := range cyborgs {
+		if report.MakespanMs > 0 {
+			stats := report.Cyborgs[cyborg.ID]
+			stats.Utilization = float64(stats.BusyMs) / float64(int64(cyborg.Slots)*report.MakespanMs)
+		}
+	}
+	return report
+}
+
+// waitStats computes nearest-rank wait percentiles
+func waitStats(waits []time.Duration) WaitStats {
+	if len(waits) == 0 {
+		return WaitStats{}
+	}
+	sort.Slice(waits, func(i, j int) bool { return waits[i] < waits[j] })
+	rank := func(p float64) int64 {
+		idx := int(p*float64(len(waits))+0.5) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= len(waits) {
+			idx = len(waits) - 1
+		}
+		return waits[idx].Milliseconds()
+	}
+	return WaitStats{
+		P50Ms: rank(0.50),
+		P90Ms: rank(0.90),
+		P99Ms: rank(0.99),
+		MaxMs: waits[len(waits)-1].Milliseconds(),
+	}
+}
@@ -0,0 +1,77 @@
+package simulator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toxicoder/cyborg-conductor-core/pkg/core/policy"
+)
+
+// salesSnapshot has two interchangeable single-slot sales cyborgs
+var salesSnapshot = []Cyborg{
+	{ID: "SALE0001", Capabilities: []string{"pipeline"}},
+	{ID: "SALE0002", Capabilities: []string{"pipeline"}},
+}
+
+// burst submits three identical tasks at once
+var burst = []Task{
+	{ID: "t1", Capabilities: []string{"pipeline"}, DurationMs: 100, SLAMs: 150},
+	{ID: "t2", Capabilities: []string{"pipeline"}, DurationMs: 100, SLAMs: 150},
+	{ID: "t3", Capabilities: []string{"pipeline"}, DurationMs: 100, SLAMs: 150},
+}
+
+// TestRunBindsOnArrival tests that the default simulation places work
+// the way the scheduler does, on the first capable cyborg
+func TestRunBindsOnArrival(t *testing.T) {
+	report := Run(salesSnapshot, burst, Config{})
+
+	assert.Equal(t, 3, report.Completed)
+	assert.Equal(t, int64(300), report.MakespanMs)
+	assert.Equal(t, 3, report.Cyborgs["SALE0001"].Tasks)
+	assert.Equal(t, 0, report.Cyborgs["SALE0002"].Tasks)
+	assert.Equal(t, 1.0, report.Cyborgs["SALE0001"].Utilization)
+	assert.Equal(t, WaitStats{P50Ms: 100, P90Ms: 200, P99Ms: 200, MaxMs: 200}, report.Wait)
+	assert.Equal(t, 2, report.SLAMisses)
+}
+
+// TestRunLoadAware tests that load-aware placement spreads the same
+// trace over both cyborgs
+func TestRunLoadAware(t *testing.T) {
+	report := Run(salesSnapshot, burst, Config{LoadAware: true})
+
+	assert.Equal(t, int64(200), report.MakespanMs)
+	assert.Equal(t, 2, report.Cyborgs["SALE0001"].Tasks)
+	assert.Equal(t, 1, report.Cyborgs["SALE0002"].Tasks)
+	assert.Equal(t, int64(100), report.Wait.MaxMs)
+	assert.Equal(t, 1, report.SLAMisses)
+}
+
+// TestRunRejections tests that tasks no cyborg can run and tasks that
+// overflow the queue are rejected
+func TestRunRejections(t *testing.T) {
+	trace := append([]Task{{ID: "payroll", Capabilities: []string{"payroll"}, DurationMs: 10}}, burst...)
+	snapshot := []Cyborg{{ID: "SALE0001", Capabilities: []string{"pipeline"}, Speed: 2}}
+
+	report := Run(snapshot, trace, Config{QueueCapacity: 1})
+
+	assert.Equal(t, 4, report.Tasks)
+	assert.Equal(t, 1, report.Rejected[RejectNoCyborg])
+	assert.Equal(t, 1, report.Rejected[RejectQueueFull])
+	assert.Equal(t, 2, report.Completed)
+	assert.Equal(t, int64(100), report.MakespanMs, "speed 2 halves each task")
+}
+
+// TestRunPolicy tests that a scheduling policy spreads tasks bound on
+// arrival over both cyborgs
+func TestRunPolicy(t *testing.T) {
+	leastLoaded, err := policy.New(policy.LeastLoaded, policy.DefaultWeights)
+	require.NoError(t, err)
+
+	report := Run(salesSnapshot, burst, Config{Policy: leastLoaded})
+
+	assert.Equal(t, int64(200), report.MakespanMs)
+	assert.Equal(t, 2, report.Cyborgs["SALE0001"].Tasks)
+	assert.Equal(t, 1, report.Cyborgs["SALE0002"].Tasks)
+	assert.Equal(t, 1, report.SLAMisses)
+}
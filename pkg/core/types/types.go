@@ -9,8 +9,20 @@ type CyborgDescriptor struct {
 	DeploymentSpec          []byte
 	Capabilities            []CapabilitySpec
 	ConfigBlob              []byte
+	ReliabilityTier         string          `json:"reliability_tier"`
+	Dependencies            []string        `json:"dependencies"`
+	// MaxConcurrentJobs caps the jobs placed on the cyborg at once; zero
+	// means no limit
+	MaxConcurrentJobs       int32           `json:"max_concurrent_jobs"`
+	// JobType is how the cyborg's jobs run, such as "LLM"; it may pick the
+	// executor backend they run on
+	JobType                 string          `json:"job_type"`
 }
 
+// ReliabilityTierFiveNines is the highest reliability tier, whose jobs the
+// conductor may hedge across a second cyborg
+const ReliabilityTierFiveNines = "RELIABILITY_TIER_FIVE_NINES"
+
 // CapabilitySpec represents a capability specification
 type CapabilitySpec struct {
 	Name        string `json:"name"`